@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"github.com/koderover/zadig/pkg/cli/upgradeassistant/internal/upgradepath"
+	"github.com/koderover/zadig/pkg/shared/encryption"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+func init() {
+	upgradepath.RegisterHandler("1.12.0", "1.13.0", V1120ToV1130)
+	upgradepath.RegisterHandler("1.13.0", "1.12.0", V1130ToV1120)
+}
+
+// V1120ToV1130 encrypts the credentials stored in plain text with the data keys,
+// the master aes key must be mounted at /etc/encryption/aes as it is for aslan
+func V1120ToV1130() error {
+	updated, err := encryption.EncryptSecrets()
+	if err != nil {
+		log.Errorf("encryptSecrets err:%s", err)
+		return err
+	}
+	log.Infof("credentials in %d documents are encrypted", updated)
+
+	return nil
+}
+
+func V1130ToV1120() error {
+	updated, err := encryption.DecryptSecrets()
+	if err != nil {
+		log.Errorf("decryptSecrets err:%s", err)
+		return err
+	}
+	log.Infof("credentials in %d documents are decrypted", updated)
+
+	return nil
+}
@@ -110,6 +110,18 @@ const (
 	StatusPrepare    Status = "prepare"
 )
 
+// ConcurrencyQuotaType is the dimension a concurrency quota is applied on
+type ConcurrencyQuotaType string
+
+const (
+	// ProjectQuota limits the running tasks of a project
+	ProjectQuota ConcurrencyQuotaType = "project"
+	// ClusterQuota limits the running tasks which build, test or deploy in a cluster
+	ClusterQuota ConcurrencyQuotaType = "cluster"
+	// BuildTypeQuota limits the running tasks which contain a stage of the given task type, e.g. buildv2, jenkins_build
+	BuildTypeQuota ConcurrencyQuotaType = "build_type"
+)
+
 type TaskStatus string
 
 const (
@@ -143,6 +155,7 @@ const (
 	TaskTrigger         TaskType = "trigger"
 	TaskExtension       TaskType = "extension"
 	TaskArtifactPackage TaskType = "artifact_package"
+	TaskApproval        TaskType = "approval"
 )
 
 type DistributeType string
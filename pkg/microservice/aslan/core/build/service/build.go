@@ -19,6 +19,7 @@ package service
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -115,6 +116,12 @@ func CreateBuild(username string, build *commonmodels.Build, log *zap.SugaredLog
 	if err := commonutil.CheckDefineResourceParam(build.PreBuild.ResReq, build.PreBuild.ResReqSpec); err != nil {
 		return e.ErrCreateBuildModule.AddDesc(err.Error())
 	}
+	if err := checkDockerBuild(build); err != nil {
+		return e.ErrCreateBuildModule.AddDesc(err.Error())
+	}
+	if err := build.PreBuild.Scheduling.ValidateTemplate(); err != nil {
+		return e.ErrCreateBuildModule.AddDesc(err.Error())
+	}
 
 	build.UpdateBy = username
 	correctFields(build)
@@ -134,6 +141,12 @@ func UpdateBuild(username string, build *commonmodels.Build, log *zap.SugaredLog
 	if err := commonutil.CheckDefineResourceParam(build.PreBuild.ResReq, build.PreBuild.ResReqSpec); err != nil {
 		return e.ErrUpdateBuildModule.AddDesc(err.Error())
 	}
+	if err := checkDockerBuild(build); err != nil {
+		return e.ErrUpdateBuildModule.AddDesc(err.Error())
+	}
+	if err := build.PreBuild.Scheduling.ValidateTemplate(); err != nil {
+		return e.ErrUpdateBuildModule.AddDesc(err.Error())
+	}
 
 	existed, err := commonrepo.NewBuildColl().Find(&commonrepo.BuildFindOption{Name: build.Name, ProductName: build.ProductName})
 	if err == nil && existed.PreBuild != nil && build.PreBuild != nil {
@@ -336,6 +349,38 @@ func correctFields(build *commonmodels.Build) {
 	}
 }
 
+var platformRegExp = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9]+(/[a-z0-9]+)?$`)
+
+func checkDockerBuild(build *commonmodels.Build) error {
+	if build.PostBuild == nil || build.PostBuild.DockerBuild == nil {
+		return nil
+	}
+	dockerBuild := build.PostBuild.DockerBuild
+
+	switch dockerBuild.Builder {
+	case "", setting.ImageBuilderDocker, setting.ImageBuilderBuildKit, setting.ImageBuilderKaniko:
+	default:
+		return fmt.Errorf("unsupported image builder: %s", dockerBuild.Builder)
+	}
+
+	for _, platform := range dockerBuild.Platforms {
+		if !platformRegExp.MatchString(platform) {
+			return fmt.Errorf("invalid platform: %s, it should be like linux/amd64", platform)
+		}
+	}
+	if sets.NewString(dockerBuild.Platforms...).Len() != len(dockerBuild.Platforms) {
+		return errors.New("duplicate platform found")
+	}
+	switch {
+	case dockerBuild.Builder == setting.ImageBuilderKaniko && len(dockerBuild.Platforms) > 1:
+		return errors.New("kaniko can only build the image for one platform")
+	case (dockerBuild.Builder == "" || dockerBuild.Builder == setting.ImageBuilderDocker) && len(dockerBuild.Platforms) > 0:
+		// dind has neither buildx nor QEMU and the platform of its node is unknown here
+		return errors.New("the docker builder only builds the image for the platform of the docker daemon, use buildkit to build for other platforms")
+	}
+	return dockerBuild.Provenance.Validate()
+}
+
 func verifyBuildTargets(name, productName string, targets []*commonmodels.ServiceModuleTarget, log *zap.SugaredLogger) error {
 	if hasDuplicateTargets(targets) {
 		return errors.New("duplicate target found")
@@ -0,0 +1,34 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ApprovalDecision is the approve or reject operation of an approver on the approval stage of a workflow task,
+// warpdrive polls the decisions and records them into the task document
+type ApprovalDecision struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"          json:"id,omitempty"`
+	PipelineName string             `bson:"pipeline_name"          json:"pipeline_name"`
+	TaskID       int64              `bson:"task_id"                json:"task_id"`
+	UserName     string             `bson:"user_name"              json:"user_name"`
+	Approved     bool               `bson:"approved"               json:"approved"`
+	Comment      string             `bson:"comment,omitempty"      json:"comment,omitempty"`
+	CreateTime   int64              `bson:"create_time"            json:"create_time"`
+}
+
+func (ApprovalDecision) TableName() string {
+	return "approval_decision"
+}
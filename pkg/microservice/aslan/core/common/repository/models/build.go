@@ -78,6 +78,8 @@ type PreBuild struct {
 	// UploadPkg uploads package to s3
 	UploadPkg bool   `bson:"upload_pkg"                      json:"upload_pkg"`
 	ClusterID string `bson:"cluster_id"                      json:"cluster_id"`
+	// Scheduling controls where the build job runs, it takes precedence over the defaults of the cluster
+	Scheduling *types.JobScheduling `bson:"scheduling,omitempty"   json:"scheduling,omitempty"`
 
 	// TODO: Deprecated.
 	Namespace string `bson:"namespace"                       json:"namespace"`
@@ -124,6 +126,12 @@ type DockerBuild struct {
 	TemplateID string `bson:"template_id"            json:"template_id"`
 	// TemplateName is the name of the template dockerfile
 	TemplateName string `bson:"template_name"        json:"template_name"`
+	// Builder is the image builder used by reaper, docker is used if it is empty
+	Builder string `bson:"builder,omitempty"          json:"builder,omitempty"`
+	// Platforms are the target platforms of the image, e.g. linux/amd64, an image index is pushed if it is not empty
+	Platforms []string `bson:"platforms,omitempty"      json:"platforms,omitempty"`
+	// Provenance are the options of the SBOM and the signature generated for the pushed image
+	Provenance *types.ProvenanceOptions `bson:"provenance,omitempty" json:"provenance,omitempty"`
 }
 
 type JenkinsBuild struct {
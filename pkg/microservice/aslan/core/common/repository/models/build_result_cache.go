@@ -0,0 +1,46 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// BuildResultCache is the image of a successful build, a later build with the same content key reuses the image
+// instead of building it again
+type BuildResultCache struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"          json:"id,omitempty"`
+	Key           string             `bson:"key"                    json:"key"`
+	ProductName   string             `bson:"product_name"           json:"product_name"`
+	ServiceName   string             `bson:"service_name"           json:"service_name"`
+	ServiceModule string             `bson:"service_module"         json:"service_module"`
+	Image         string             `bson:"image"                  json:"image"`
+	PipelineName  string             `bson:"pipeline_name"          json:"pipeline_name"`
+	TaskID        int64              `bson:"task_id"                json:"task_id"`
+	CreateTime    int64              `bson:"create_time"            json:"create_time"`
+	// UsedAt is when the result is saved or reused last time, the result expires if it is not used for a while
+	UsedAt time.Time `bson:"used_at" json:"used_at"`
+	// Provenance is the SBOM and the signature of the image, it is reused along with the image
+	Provenance *types.ImageProvenance `bson:"provenance,omitempty" json:"provenance,omitempty"`
+}
+
+func (BuildResultCache) TableName() string {
+	return "build_result_cache"
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+)
+
+// ConcurrencyQuota limits the number of running workflow tasks of a project, a cluster or a build type,
+// it works together with the global WorkflowConcurrency in SystemSetting
+type ConcurrencyQuota struct {
+	ID         primitive.ObjectID          `bson:"_id,omitempty"          json:"id,omitempty"`
+	Type       config.ConcurrencyQuotaType `bson:"type"                   json:"type"`
+	Target     string                      `bson:"target"                 json:"target"`
+	Limit      int64                       `bson:"limit"                  json:"limit"`
+	UpdateTime int64                       `bson:"update_time"            json:"update_time"`
+	UpdateBy   string                      `bson:"update_by"              json:"update_by"`
+}
+
+func (ConcurrencyQuota) TableName() string {
+	return "concurrency_quota"
+}
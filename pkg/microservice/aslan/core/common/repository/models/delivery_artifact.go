@@ -18,26 +18,29 @@ package models
 
 import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/types"
 )
 
 type DeliveryArtifact struct {
-	ID                  primitive.ObjectID `bson:"_id,omitempty"                   json:"id"`
-	Name                string             `bson:"name"                            json:"name"`
-	Type                string             `bson:"type"                            json:"type"`
-	Source              string             `bson:"source"                          json:"source"`
-	Image               string             `bson:"image,omitempty"                 json:"image,omitempty"`
-	ImageHash           string             `bson:"image_hash,omitempty"            json:"image_hash,omitempty"`
-	ImageTag            string             `bson:"image_tag"                       json:"image_tag"`
-	ImageDigest         string             `bson:"image_digest,omitempty"          json:"image_digest,omitempty"`
-	ImageSize           int64              `bson:"image_size,omitempty"            json:"image_size,omitempty"`
-	Architecture        string             `bson:"architecture,omitempty"          json:"architecture,omitempty"`
-	Os                  string             `bson:"os,omitempty"                    json:"os,omitempty"`
-	DockerFile          string             `bson:"docker_file,omitempty"           json:"docker_file,omitempty"`
-	Layers              []Descriptor       `bson:"layers,omitempty"                json:"layers,omitempty"`
-	PackageFileLocation string             `bson:"package_file_location,omitempty" json:"package_file_location,omitempty"`
-	PackageStorageURI   string             `bson:"package_storage_uri,omitempty"   json:"package_storage_uri,omitempty"`
-	CreatedBy           string             `bson:"created_by"                      json:"created_by"`
-	CreatedTime         int64              `bson:"created_time"                    json:"created_time"`
+	ID                  primitive.ObjectID     `bson:"_id,omitempty"                   json:"id"`
+	Name                string                 `bson:"name"                            json:"name"`
+	Type                string                 `bson:"type"                            json:"type"`
+	Source              string                 `bson:"source"                          json:"source"`
+	Image               string                 `bson:"image,omitempty"                 json:"image,omitempty"`
+	ImageHash           string                 `bson:"image_hash,omitempty"            json:"image_hash,omitempty"`
+	ImageTag            string                 `bson:"image_tag"                       json:"image_tag"`
+	ImageDigest         string                 `bson:"image_digest,omitempty"          json:"image_digest,omitempty"`
+	ImageSize           int64                  `bson:"image_size,omitempty"            json:"image_size,omitempty"`
+	Architecture        string                 `bson:"architecture,omitempty"          json:"architecture,omitempty"`
+	Os                  string                 `bson:"os,omitempty"                    json:"os,omitempty"`
+	DockerFile          string                 `bson:"docker_file,omitempty"           json:"docker_file,omitempty"`
+	Layers              []Descriptor           `bson:"layers,omitempty"                json:"layers,omitempty"`
+	Provenance          *types.ImageProvenance `bson:"provenance,omitempty"            json:"provenance,omitempty"`
+	PackageFileLocation string                 `bson:"package_file_location,omitempty" json:"package_file_location,omitempty"`
+	PackageStorageURI   string                 `bson:"package_storage_uri,omitempty"   json:"package_storage_uri,omitempty"`
+	CreatedBy           string                 `bson:"created_by"                      json:"created_by"`
+	CreatedTime         int64                  `bson:"created_time"                    json:"created_time"`
 }
 
 type Descriptor struct {
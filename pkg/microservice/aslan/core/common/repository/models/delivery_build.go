@@ -23,18 +23,19 @@ import (
 )
 
 type DeliveryBuild struct {
-	ID          primitive.ObjectID  `bson:"_id,omitempty"                json:"id,omitempty"`
-	ReleaseID   primitive.ObjectID  `bson:"release_id"           json:"releaseId"`
-	ServiceName string              `bson:"service_name"         json:"serviceName"`
-	ImageInfo   *DeliveryImage      `bson:"image_info"           json:"imageInfo"`
-	ImageName   string              `bson:"image_name"           json:"imageName"`
-	PackageInfo *DeliveryPackage    `bson:"package_info"         json:"packageInfo"`
-	Issues      []*JiraIssue        `bson:"issues"               json:"issues"`
-	Commits     []*types.Repository `bson:"commits"              json:"commits"`
-	StartTime   int64               `bson:"start_time"           json:"start_time,omitempty"`
-	EndTime     int64               `bson:"end_time"             json:"end_time,omitempty"`
-	CreatedAt   int64               `bson:"created_at"           json:"created_at"`
-	DeletedAt   int64               `bson:"deleted_at"           json:"deleted_at"`
+	ID          primitive.ObjectID     `bson:"_id,omitempty"                json:"id,omitempty"`
+	ReleaseID   primitive.ObjectID     `bson:"release_id"           json:"releaseId"`
+	ServiceName string                 `bson:"service_name"         json:"serviceName"`
+	ImageInfo   *DeliveryImage         `bson:"image_info"           json:"imageInfo"`
+	ImageName   string                 `bson:"image_name"           json:"imageName"`
+	PackageInfo *DeliveryPackage       `bson:"package_info"         json:"packageInfo"`
+	Issues      []*JiraIssue           `bson:"issues"               json:"issues"`
+	Commits     []*types.Repository    `bson:"commits"              json:"commits"`
+	Provenance  *types.ImageProvenance `bson:"provenance,omitempty" json:"provenance,omitempty"`
+	StartTime   int64                  `bson:"start_time"           json:"start_time,omitempty"`
+	EndTime     int64                  `bson:"end_time"             json:"end_time,omitempty"`
+	CreatedAt   int64                  `bson:"created_at"           json:"created_at"`
+	DeletedAt   int64                  `bson:"deleted_at"           json:"deleted_at"`
 }
 
 // JiraIssue ...
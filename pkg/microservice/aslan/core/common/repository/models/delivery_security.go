@@ -28,6 +28,7 @@ type DeliverySecurity struct {
 	Vulnerability Vulnerability      `bson:"vulnerability"         json:"vulnerability"`
 	Feature       Feature            `bson:"feature"               json:"feature"`
 	Severity      string             `bson:"severity"              json:"severity"`
+	Scanner       string             `bson:"scanner,omitempty"     json:"scanner,omitempty"`
 	CreatedAt     int64              `bson:"created_at"            json:"created_at"`
 	DeletedAt     int64              `bson:"deleted_at"            json:"deleted_at"`
 }
@@ -0,0 +1,32 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+// DeployStrategy rolls out the new image with a canary or blue-green deploy instead of a rolling update,
+// only deployments are supported, statefulsets are still updated in place
+type DeployStrategy struct {
+	// ServiceName is the service the strategy applies to, empty means all services of the workflow
+	ServiceName string `bson:"service_name,omitempty"        json:"service_name,omitempty"`
+	// Type is canary or blue_green
+	Type string `bson:"type"                          json:"type"`
+	// CanaryReplicas is the replicas of the canary, the traffic is split by the replica ratio if TrafficWeight is 0
+	CanaryReplicas int `bson:"canary_replicas,omitempty"     json:"canary_replicas,omitempty"`
+	// TrafficWeight is the percentage of traffic istio routes to the canary
+	TrafficWeight int `bson:"traffic_weight,omitempty"      json:"traffic_weight,omitempty"`
+	// VerifySeconds is how long the new version must stay healthy before it is promoted
+	VerifySeconds int `bson:"verify_seconds,omitempty"      json:"verify_seconds,omitempty"`
+}
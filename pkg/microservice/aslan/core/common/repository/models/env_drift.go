@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/tool/kube/diff"
+)
+
+// EnvDrift is the latest drift detection result of an environment, the expected manifests of every service
+// are compared with the live objects in the cluster
+type EnvDrift struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"          json:"id,omitempty"`
+	ProductName string             `bson:"product_name"           json:"product_name"`
+	EnvName     string             `bson:"env_name"               json:"env_name"`
+	Drifted     bool               `bson:"drifted"                json:"drifted"`
+	Services    []*ServiceDrift    `bson:"services"               json:"services"`
+	DetectTime  int64              `bson:"detect_time"            json:"detect_time"`
+}
+
+type ServiceDrift struct {
+	ServiceName string         `bson:"service_name"           json:"service_name"`
+	ReleaseName string         `bson:"release_name,omitempty" json:"release_name,omitempty"`
+	Objects     []*ObjectDrift `bson:"objects"                json:"objects"`
+	// Error is set if the expected manifests or the live objects can not be fetched
+	Error string `bson:"error,omitempty"        json:"error,omitempty"`
+}
+
+type ObjectDrift struct {
+	Kind    string             `bson:"kind"                   json:"kind"`
+	Name    string             `bson:"name"                   json:"name"`
+	Missing bool               `bson:"missing"                json:"missing"`
+	Fields  []*diff.FieldDrift `bson:"fields"                 json:"fields"`
+}
+
+func (EnvDrift) TableName() string {
+	return "env_drift"
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type EnvTTLAction string
+
+const (
+	EnvTTLActionDelete EnvTTLAction = "delete"
+	EnvTTLActionSleep  EnvTTLAction = "sleep"
+)
+
+type EnvSleepReason string
+
+const (
+	EnvSleepReasonManual   EnvSleepReason = "manual"
+	EnvSleepReasonSchedule EnvSleepReason = "schedule"
+	EnvSleepReasonTTL      EnvSleepReason = "ttl"
+)
+
+// DefaultEnvTTLWarnHours 环境回收前默认提前多少小时通知
+const DefaultEnvTTLWarnHours = 24
+
+// EnvLifecycle is the lifecycle policy and state of an environment, the TTL of the environment is Product.RecycleDay
+type EnvLifecycle struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"              json:"id,omitempty"`
+	ProductName string             `bson:"product_name"               json:"product_name"`
+	EnvName     string             `bson:"env_name"                   json:"env_name"`
+	// TTLAction is taken when the environment is inactive for RecycleDay days
+	TTLAction EnvTTLAction `bson:"ttl_action"                 json:"ttl_action"`
+	// WarnHours is how many hours before the TTL action the owners are warned
+	WarnHours     int               `bson:"warn_hours"                 json:"warn_hours"`
+	NotifyCtl     *NotifyCtl        `bson:"notify_ctl,omitempty"       json:"notify_ctl,omitempty"`
+	SleepSchedule *EnvSleepSchedule `bson:"sleep_schedule,omitempty"   json:"sleep_schedule,omitempty"`
+	// AutoWake wakes the sleeping environment when it is accessed or a workflow runs against it
+	AutoWake bool `bson:"auto_wake"                  json:"auto_wake"`
+
+	// LastActiveTime is the last time the environment is accessed or a workflow runs against it,
+	// deploys are recorded in Product.UpdateTime
+	LastActiveTime int64 `bson:"last_active_time"           json:"last_active_time"`
+	// WarnTime is the time the owners are warned of the TTL action, it is reset by any activity
+	WarnTime    int64          `bson:"warn_time"                  json:"warn_time"`
+	Sleeping    bool           `bson:"sleeping"                   json:"sleeping"`
+	SleepReason EnvSleepReason `bson:"sleep_reason,omitempty"     json:"sleep_reason,omitempty"`
+	SleepTime   int64          `bson:"sleep_time"                 json:"sleep_time"`
+	// WakeTime is the last time the environment is woken up, the sleep schedule doesn't put it
+	// back to sleep until the next sleep window
+	WakeTime int64               `bson:"wake_time"                  json:"wake_time"`
+	Replicas []*WorkloadReplicas `bson:"replicas,omitempty"         json:"replicas,omitempty"`
+}
+
+// EnvSleepSchedule keeps the environment awake during work hours, it sleeps at nights and on weekends
+type EnvSleepSchedule struct {
+	Enabled bool `bson:"enabled"                    json:"enabled"`
+	// Days are the days of week on which the environment is awake, 0 is Sunday
+	Days []int `bson:"days"                       json:"days"`
+	// StartTime and EndTime are the awake hours of the days in the form of 15:04
+	StartTime string `bson:"start_time"                 json:"start_time"`
+	EndTime   string `bson:"end_time"                   json:"end_time"`
+	// TimeZone is the IANA time zone of the awake hours, e.g. Asia/Shanghai, it is UTC if empty
+	TimeZone string `bson:"time_zone,omitempty"        json:"time_zone,omitempty"`
+}
+
+// WorkloadReplicas are the replicas of a workload before the environment sleeps
+type WorkloadReplicas struct {
+	Kind     string `bson:"kind"                       json:"kind"`
+	Name     string `bson:"name"                       json:"name"`
+	Replicas int32  `bson:"replicas"                   json:"replicas"`
+}
+
+func (EnvLifecycle) TableName() string {
+	return "env_lifecycle"
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnvSnapshot is a point-in-time copy of an environment, it can be restored into the same environment
+// or into a new one
+type EnvSnapshot struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty"           json:"id,omitempty"`
+	ProductName string              `bson:"product_name"            json:"product_name"`
+	EnvName     string              `bson:"env_name"                json:"env_name"`
+	Description string              `bson:"description"             json:"description"`
+	ClusterID   string              `bson:"cluster_id"              json:"cluster_id"`
+	Namespace   string              `bson:"namespace"               json:"namespace"`
+	Source      string              `bson:"source"                  json:"source"`
+	RegistryID  string              `bson:"registry_id"             json:"registry_id"`
+	Revision    int64               `bson:"revision"                json:"revision"`
+	Services    [][]*ProductService `bson:"services"                json:"services"`
+	RenderSet   *RenderSet          `bson:"render_set"              json:"render_set"`
+	// HelmReleases are the deployed releases of helm environments
+	HelmReleases []*HelmReleaseSnapshot `bson:"helm_releases,omitempty" json:"helm_releases,omitempty"`
+	// EnvConfigYamls are the ConfigMaps, Ingresses and PersistentVolumeClaims of the environment
+	EnvConfigYamls []string `bson:"env_config_yamls"        json:"env_config_yamls"`
+	// SecretYamls are the Secrets of the environment, they are encrypted at rest and never returned by the api
+	SecretYamls string `bson:"secret_yamls,omitempty"  json:"-"`
+	CreatedBy   string `bson:"created_by"              json:"created_by"`
+	CreateTime  int64  `bson:"create_time"             json:"create_time"`
+}
+
+type HelmReleaseSnapshot struct {
+	ReleaseName  string `bson:"release_name"            json:"release_name"`
+	ServiceName  string `bson:"service_name"            json:"service_name"`
+	Revision     int    `bson:"revision"                json:"revision"`
+	ChartVersion string `bson:"chart_version"           json:"chart_version"`
+	// Values are the values supplied by zadig when the release is installed or upgraded
+	Values string `bson:"values"                  json:"values"`
+}
+
+func (EnvSnapshot) TableName() string {
+	return "env_snapshot"
+}
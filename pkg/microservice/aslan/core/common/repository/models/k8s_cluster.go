@@ -62,6 +62,8 @@ type AdvancedConfig struct {
 	Strategy     string                     `json:"strategy,omitempty"       bson:"strategy,omitempty"`
 	NodeLabels   []*NodeSelectorRequirement `json:"node_labels,omitempty"    bson:"node_labels,omitempty"`
 	ProjectNames []string                   `json:"-"                        bson:"-"`
+	// Scheduling is the default scheduling of the build and test jobs running in the cluster
+	Scheduling *types.JobScheduling `json:"scheduling,omitempty"     bson:"scheduling,omitempty"`
 }
 
 type NodeSelectorRequirement struct {
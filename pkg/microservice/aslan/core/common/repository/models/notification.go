@@ -24,6 +24,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/setting"
 )
 
 type Notification struct {
@@ -174,6 +175,8 @@ func getEnvRecyclePolicy(policy string) string {
 		return "工作流成功之后销毁"
 	case config.EnvRecyclePolicyNever:
 		return "每次保留"
+	case setting.EnvRecyclePolicyPRClosed:
+		return "PR 关闭或合并之后销毁"
 	default:
 		return "每次保留"
 	}
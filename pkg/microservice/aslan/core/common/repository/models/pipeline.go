@@ -69,6 +69,11 @@ type Stage struct {
 	Desc        string                            `bson:"desc,omitempty"     json:"desc,omitempty"`
 	SubTasks    map[string]map[string]interface{} `bson:"sub_tasks"          json:"sub_tasks"`
 	AfterAll    bool                              `bson:"after_all"          json:"after_all"`
+	// Name and DependsOn are only set for custom workflows which run their stages as a DAG
+	Name      string   `bson:"name,omitempty"       json:"name,omitempty"`
+	DependsOn []string `bson:"depends_on,omitempty" json:"depends_on,omitempty"`
+	// Policy is copied from the workflow when the task starts
+	Policy *StagePolicy `bson:"policy,omitempty"     json:"policy,omitempty"`
 }
 
 type Hook struct {
@@ -71,6 +71,10 @@ type Queue struct {
 	Features                []string                     `bson:"features"                                   json:"features"`
 	IsRestart               bool                         `bson:"is_restart"                                 json:"is_restart"`
 	StorageEndpoint         string                       `bson:"storage_endpoint"                           json:"storage_endpoint"`
+	Priority                int                          `bson:"priority"                                   json:"priority"`
+	BlockedReason           string                       `bson:"blocked_reason,omitempty"                   json:"blocked_reason,omitempty"`
+	DAG                     []*DAGNode                   `bson:"dag,omitempty"                              json:"dag,omitempty"`
+	StagePolicies           []*StagePolicy               `bson:"stage_policies,omitempty"                   json:"stage_policies,omitempty"`
 }
 
 type TriggerBy struct {
@@ -95,6 +99,8 @@ type ServiceTaskArgs struct {
 	Namespace          string   `bson:"namespace"               json:"namespace"`
 	K8sNamespace       string   `bson:"k8s_namespace"           json:"k8s_namespace"`
 	Updatable          bool     `bson:"updatable"               json:"updatable"`
+	// Priority 队列优先级, 同一项目中数值越大越先被调度
+	Priority int `bson:"priority,omitempty"      json:"priority,omitempty"`
 }
 
 type ImageData struct {
@@ -116,6 +122,8 @@ type ArtifactPackageTaskArgs struct {
 	Images           []*ImagesByService `bson:"images"                  json:"images"`
 	SourceRegistries []string           `bson:"source_registries"       json:"source_registries"`
 	TargetRegistries []string           `bson:"target_registries"       json:"target_registries"`
+	// Priority 队列优先级, 同一项目中数值越大越先被调度
+	Priority int `bson:"priority,omitempty"      json:"priority,omitempty"`
 }
 
 type ConfigPayload struct {
@@ -154,6 +162,9 @@ type ConfigPayload struct {
 
 	// build concurrency settings
 	BuildConcurrency int64 `json:"build_concurrency"`
+
+	// VerifyImageSignature refuses to deploy unsigned images, the signing key itself is passed to the jobs in a secret
+	VerifyImageSignature bool `json:"verify_image_signature"`
 }
 
 type AslanConfig struct {
@@ -22,7 +22,20 @@ type SystemSetting struct {
 	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	WorkflowConcurrency int64              `bson:"workflow_concurrency" json:"workflow_concurrency"`
 	BuildConcurrency    int64              `bson:"build_concurrency" json:"build_concurrency"`
-	UpdateTime          int64              `bson:"update_time" json:"update_time"`
+	// ProjectQueueWeights is the weight of each project when the queue shares warpdrive slots among projects,
+	// projects not listed here have a weight of 1
+	ProjectQueueWeights map[string]int64 `bson:"project_queue_weights" json:"project_queue_weights"`
+	ImageSigning        *ImageSigning    `bson:"image_signing,omitempty" json:"image_signing,omitempty"`
+	UpdateTime          int64            `bson:"update_time" json:"update_time"`
+}
+
+// ImageSigning is the cosign key pair used to sign the built and released images
+type ImageSigning struct {
+	PrivateKey string `bson:"private_key" json:"private_key"`
+	Password   string `bson:"password"    json:"password"`
+	PublicKey  string `bson:"public_key"  json:"public_key"`
+	// VerifyOnDeploy rejects deploying images which are not signed by the key pair
+	VerifyOnDeploy bool `bson:"verify_on_deploy" json:"verify_on_deploy"`
 }
 
 func (SystemSetting) TableName() string {
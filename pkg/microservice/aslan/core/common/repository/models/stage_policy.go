@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+)
+
+// StagePolicy controls when a stage runs and how its failure affects the task,
+// it applies to the stage with the same Name, or to the stages of TaskType if Name is empty
+type StagePolicy struct {
+	TaskType config.TaskType `bson:"type,omitempty"            json:"type,omitempty"`
+	Name     string          `bson:"name,omitempty"            json:"name,omitempty"`
+	When     *StageCondition `bson:"when,omitempty"            json:"when,omitempty"`
+	// ContinueOnError keeps running the following stages and does not fail the task if the stage fails
+	ContinueOnError bool `bson:"continue_on_error"         json:"continue_on_error"`
+	// AlwaysRun runs the stage even if earlier stages failed, it is used for cleanup and notification
+	AlwaysRun bool `bson:"always_run"                json:"always_run"`
+	// Retry reruns the failed sub tasks of the stage
+	Retry *RetryPolicy `bson:"retry,omitempty"           json:"retry,omitempty"`
+}
+
+// RetryPolicy reruns a failed sub task after a backoff, the backoff grows by Factor after each attempt
+type RetryPolicy struct {
+	// MaxAttempts includes the first run
+	MaxAttempts int `bson:"max_attempts"              json:"max_attempts"`
+	// Backoff is the seconds to wait before the first retry
+	Backoff    int     `bson:"backoff"                   json:"backoff"`
+	Factor     float64 `bson:"factor,omitempty"          json:"factor,omitempty"`
+	MaxBackoff int     `bson:"max_backoff,omitempty"     json:"max_backoff,omitempty"`
+	// RetryOn are the failure classes to retry: image_pull, evicted, registry and timeout, empty means any failure
+	RetryOn []string `bson:"retry_on,omitempty"        json:"retry_on,omitempty"`
+}
+
+// StageCondition is met only if all its non-empty parts are met
+type StageCondition struct {
+	// Branches are glob patterns, one of the branches of the task must match one of them
+	Branches []string `bson:"branches,omitempty"        json:"branches,omitempty"`
+	// Sources are the trigger sources: manual, webhook or timer
+	Sources     []string                `bson:"sources,omitempty"         json:"sources,omitempty"`
+	Parameters  []*ParameterCondition   `bson:"parameters,omitempty"      json:"parameters,omitempty"`
+	StageStatus []*StageStatusCondition `bson:"stage_status,omitempty"    json:"stage_status,omitempty"`
+}
+
+type ParameterCondition struct {
+	Key   string `bson:"key"                       json:"key"`
+	Value string `bson:"value"                     json:"value"`
+}
+
+// StageStatusCondition requires an earlier stage, identified by its name or task type, to end with one of the statuses
+type StageStatusCondition struct {
+	Stage    string          `bson:"stage"                     json:"stage"`
+	Statuses []config.Status `bson:"statuses"                  json:"statuses"`
+}
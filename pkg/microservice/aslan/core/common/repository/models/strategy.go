@@ -24,8 +24,13 @@ type CapacityTarget string
 const (
 	// 工作流任务的留存
 	WorkflowTaskRetention CapacityTarget = "WorkflowTaskRetention"
+	// 环境快照的留存
+	EnvSnapshotRetention CapacityTarget = "EnvSnapshotRetention"
 )
 
+// DefaultEnvSnapshotMaxItems 每个环境默认最多保留的快照数
+const DefaultEnvSnapshotMaxItems = 20
+
 // RetentionConfig 资源留存相关的配置
 type RetentionConfig struct {
 	MaxDays  int `bson:"max_days"      json:"max_days"`  // 最多几天
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/setting"
 )
 
@@ -54,6 +55,22 @@ type Deploy struct {
 	IsRestart        bool                         `bson:"is_restart"                    json:"is_restart"`
 	ResetImage       bool                         `bson:"reset_image"                   json:"reset_image"`
 	ResetImagePolicy setting.ResetImagePolicyType `bson:"reset_image_policy"            json:"reset_image_policy"`
+	// Strategy is nil for a rolling update
+	Strategy *models.DeployStrategy `bson:"strategy,omitempty"            json:"strategy,omitempty"`
+	Rollout  *RolloutStatus         `bson:"rollout,omitempty"             json:"rollout,omitempty"`
+	// RollbackOnFailure restores the images recorded in ReplaceResources, or the previous helm revision,
+	// if the deploy fails, RolledBack is set when it happens
+	RollbackOnFailure bool `bson:"rollback_on_failure,omitempty" json:"rollback_on_failure,omitempty"`
+	RolledBack        bool `bson:"rolled_back,omitempty"         json:"rolled_back,omitempty"`
+}
+
+// RolloutStatus records the progress of a canary or blue-green deploy
+type RolloutStatus struct {
+	Strategy string `bson:"strategy"                json:"strategy"`
+	Phase    string `bson:"phase"                   json:"phase"`
+	// Workload is the canary or green copy of the deployment
+	Workload string `bson:"workload"                json:"workload"`
+	Message  string `bson:"message,omitempty"       json:"message,omitempty"`
 }
 
 // SetNamespace ...
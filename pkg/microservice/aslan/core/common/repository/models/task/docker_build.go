@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/types"
 )
 
 type DockerBuild struct {
@@ -54,13 +55,17 @@ func (db *DockerBuild) ToSubTask() (map[string]interface{}, error) {
 // DockerFile: dockerfile名称, 默认为Dockerfile
 // ImageBuild: build image镜像全称, e.g. xxx.com/release-candidates/image:tag
 type DockerBuildCtx struct {
-	Source                string `yaml:"source" bson:"source" json:"source"`
-	WorkDir               string `yaml:"work_dir" bson:"work_dir" json:"work_dir"`
-	DockerFile            string `yaml:"docker_file" bson:"docker_file" json:"docker_file"`
-	ImageName             string `yaml:"image_name" bson:"image_name" json:"image_name"`
-	BuildArgs             string `yaml:"build_args" bson:"build_args" json:"build_args"`
-	ImageReleaseTag       string `yaml:"image_release_tag,omitempty" bson:"image_release_tag,omitempty" json:"image_release_tag"`
-	DockerTemplateContent string `yaml:"docker_template_content" bson:"docker_template_content" json:"docker_template_content"`
+	Source                string   `yaml:"source" bson:"source" json:"source"`
+	WorkDir               string   `yaml:"work_dir" bson:"work_dir" json:"work_dir"`
+	DockerFile            string   `yaml:"docker_file" bson:"docker_file" json:"docker_file"`
+	ImageName             string   `yaml:"image_name" bson:"image_name" json:"image_name"`
+	BuildArgs             string   `yaml:"build_args" bson:"build_args" json:"build_args"`
+	ImageReleaseTag       string   `yaml:"image_release_tag,omitempty" bson:"image_release_tag,omitempty" json:"image_release_tag"`
+	DockerTemplateContent string   `yaml:"docker_template_content" bson:"docker_template_content" json:"docker_template_content"`
+	Builder               string   `yaml:"builder,omitempty" bson:"builder,omitempty" json:"builder,omitempty"`
+	Platforms             []string `yaml:"platforms,omitempty" bson:"platforms,omitempty" json:"platforms,omitempty"`
+	// Provenance are the options of the SBOM and the signature generated for the pushed image
+	Provenance *types.ProvenanceOptions `yaml:"provenance,omitempty" bson:"provenance,omitempty" json:"provenance,omitempty"`
 }
 
 type FileArchiveCtx struct {
@@ -84,6 +84,14 @@ type Task struct {
 	Features         []string                     `bson:"features"               json:"features"`
 	IsRestart        bool                         `bson:"is_restart"             json:"is_restart"`
 	StorageEndpoint  string                       `bson:"storage_endpoint"       json:"storage_endpoint"`
+	// Priority 队列优先级, 同一项目中数值越大越先被调度
+	Priority int `bson:"priority"               json:"priority"`
+	// BlockedReason 任务在队列中被阻塞的原因, 例如超出并发配额
+	BlockedReason string `bson:"blocked_reason,omitempty" json:"blocked_reason,omitempty"`
+	// DAG 自定义工作流中子任务之间的依赖关系, 为空时按顺序执行
+	DAG []*models.DAGNode `bson:"dag,omitempty"            json:"dag,omitempty"`
+	// StagePolicies 控制各个stage的执行条件和失败策略
+	StagePolicies []*models.StagePolicy `bson:"stage_policies,omitempty" json:"stage_policies,omitempty"`
 }
 
 func (Task) TableName() string {
@@ -34,6 +34,10 @@ type Security struct {
 	EndTime    int64           `bson:"end_time,omitempty"            json:"end_time,omitempty"`
 	LogFile    string          `bson:"log_file"                      json:"log_file"`
 	Summary    map[string]int  `bson:"summary"                       json:"summary"`
+	// Scanner is the backend to scan the image with: clair or trivy, clair is used if it is empty
+	Scanner string `bson:"scanner,omitempty"             json:"scanner,omitempty"`
+	// SeverityThreshold fails the task if the image has a vulnerability at or above the severity
+	SeverityThreshold string `bson:"severity_threshold,omitempty"  json:"severity_threshold,omitempty"`
 }
 
 func (s *Security) SetImageName(imageName string) {
@@ -56,6 +56,14 @@ type Testing struct {
 	CacheEnable  bool               `bson:"cache_enable"        json:"cache_enable"`
 	CacheDirType types.CacheDirType `bson:"cache_dir_type"      json:"cache_dir_type"`
 	CacheUserDir string             `bson:"cache_user_dir"      json:"cache_user_dir"`
+
+	// Scheduling is the scheduling of the test template, it is merged with the defaults of the cluster
+	Scheduling *types.JobScheduling `bson:"scheduling,omitempty" json:"scheduling,omitempty"`
+
+	// Coverage is the coverage collected by the test, nil if the test does not collect coverage
+	Coverage *types.CoverageReport `bson:"coverage,omitempty" json:"coverage,omitempty"`
+	// QuarantinedFailures are the failed test cases which are in quarantine
+	QuarantinedFailures []string `bson:"quarantined_failures,omitempty" json:"quarantined_failures,omitempty"`
 }
 
 func (t *Testing) ToSubTask() (map[string]interface{}, error) {
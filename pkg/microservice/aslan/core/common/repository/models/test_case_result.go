@@ -0,0 +1,47 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TestCaseStatus string
+
+const (
+	TestCasePassed  TestCaseStatus = "passed"
+	TestCaseFailed  TestCaseStatus = "failed"
+	TestCaseSkipped TestCaseStatus = "skipped"
+)
+
+// TestCaseResult is the result of a test case in a workflow task, test cases which both pass and fail
+// on the same commit are flaky
+type TestCaseResult struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"          json:"id,omitempty"`
+	ProductName  string             `bson:"product_name"           json:"product_name"`
+	TestName     string             `bson:"test_name"              json:"test_name"`
+	CaseName     string             `bson:"case_name"              json:"case_name"`
+	PipelineName string             `bson:"pipeline_name"          json:"pipeline_name"`
+	TaskID       int64              `bson:"task_id"                json:"task_id"`
+	CommitID     string             `bson:"commit_id,omitempty"    json:"commit_id,omitempty"`
+	Status       TestCaseStatus     `bson:"status"                 json:"status"`
+	CreateTime   int64              `bson:"create_time"            json:"create_time"`
+}
+
+func (TestCaseResult) TableName() string {
+	return "test_case_result"
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// TestCoverage is the coverage collected by a test in a workflow task, it is the source of
+// the coverage trend and the baseline to find coverage regressions of pull requests
+type TestCoverage struct {
+	ID           primitive.ObjectID    `bson:"_id,omitempty"          json:"id,omitempty"`
+	ProductName  string                `bson:"product_name"           json:"product_name"`
+	PipelineName string                `bson:"pipeline_name"          json:"pipeline_name"`
+	TaskID       int64                 `bson:"task_id"                json:"task_id"`
+	TestName     string                `bson:"test_name"              json:"test_name"`
+	RepoName     string                `bson:"repo_name,omitempty"    json:"repo_name,omitempty"`
+	Branch       string                `bson:"branch,omitempty"       json:"branch,omitempty"`
+	PR           int                   `bson:"pr,omitempty"           json:"pr,omitempty"`
+	Coverage     *types.CoverageReport `bson:"coverage"               json:"coverage"`
+	CreateTime   int64                 `bson:"create_time"            json:"create_time"`
+}
+
+func (TestCoverage) TableName() string {
+	return "test_coverage"
+}
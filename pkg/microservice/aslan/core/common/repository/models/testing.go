@@ -37,10 +37,17 @@ type Testing struct {
 	UpdateBy    string              `bson:"update_by"                json:"update_by"`
 	// Junit 测试报告
 	TestResultPath string `bson:"test_result_path"         json:"test_result_path"`
+	// TestReportFormat is the format of the files matching TestResultGlob in TestResultPath
+	TestReportFormat string `bson:"test_report_format,omitempty" json:"test_report_format,omitempty"`
+	TestResultGlob   string `bson:"test_result_glob,omitempty"   json:"test_result_glob,omitempty"`
 	// html 测试报告
 	TestReportPath string `bson:"test_report_path"         json:"test_report_path"`
 	Threshold      int    `bson:"threshold"                json:"threshold"`
 	TestType       string `bson:"test_type"                json:"test_type"`
+	// Coverage collects the coverage files of the test, nil means no coverage is collected
+	Coverage *types.CoverageConfig `bson:"coverage,omitempty"       json:"coverage,omitempty"`
+	// QuarantinedCases are the test cases whose failures are reported but do not fail the test
+	QuarantinedCases []string `bson:"quarantined_cases,omitempty" json:"quarantined_cases,omitempty"`
 
 	// TODO: Deprecated.
 	Caches []string `bson:"caches"                   json:"caches"`
@@ -97,6 +104,8 @@ type PreTest struct {
 	// EnableProxy
 	EnableProxy bool   `bson:"enable_proxy"           json:"enable_proxy"`
 	ClusterID   string `bson:"cluster_id"             json:"cluster_id"`
+	// Scheduling controls where the test job runs, it takes precedence over the defaults of the cluster
+	Scheduling *types.JobScheduling `bson:"scheduling,omitempty"   json:"scheduling,omitempty"`
 
 	// TODO: Deprecated.
 	Namespace string `bson:"namespace"              json:"namespace"`
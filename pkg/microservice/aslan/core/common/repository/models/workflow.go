@@ -48,6 +48,8 @@ type Workflow struct {
 	SecurityStage   *SecurityStage     `bson:"security_stage"               json:"security_stage"`
 	DistributeStage *DistributeStage   `bson:"distribute_stage"             json:"distribute_stage"`
 	ExtensionStage  *ExtensionStage    `bson:"extension_stage"              json:"extension_stage"`
+	ApprovalStage   *ApprovalStage     `bson:"approval_stage,omitempty"     json:"approval_stage,omitempty"`
+	StagePolicies   []*StagePolicy     `bson:"stage_policies,omitempty"     json:"stage_policies,omitempty"`
 	// TODO: Deprecated.
 	NotifyCtl       *NotifyCtl         `bson:"notify_ctl,omitempty"         json:"notify_ctl,omitempty"`
 	// New since V1.12.0.
@@ -60,6 +62,27 @@ type Workflow struct {
 	ResetImagePolicy setting.ResetImagePolicyType `bson:"reset_image_policy,omitempty" json:"reset_image_policy,omitempty"`
 	// IsParallel 控制单一工作流的任务是否支持并行处理
 	IsParallel bool `json:"is_parallel" bson:"is_parallel"`
+	// ReuseBuildResult skips the build of a service whose sources, build template, variables and base image
+	// are the same as a previous successful build, the image of that build is reused
+	ReuseBuildResult bool `json:"reuse_build_result" bson:"reuse_build_result"`
+	// DeployStrategies are the canary or blue-green strategies of the deploy sub tasks
+	DeployStrategies []*DeployStrategy `json:"deploy_strategies,omitempty" bson:"deploy_strategies,omitempty"`
+	// RollbackOnFailure restores the previous images of the services if their deploy fails
+	RollbackOnFailure bool `json:"rollback_on_failure" bson:"rollback_on_failure"`
+}
+
+// DeployStrategyOf returns the strategy to deploy the service, or nil for a rolling update
+func (w *Workflow) DeployStrategyOf(serviceName string) *DeployStrategy {
+	var strategy *DeployStrategy
+	for _, s := range w.DeployStrategies {
+		if s.ServiceName == serviceName {
+			return s
+		}
+		if s.ServiceName == "" {
+			strategy = s
+		}
+	}
+	return strategy
 }
 
 type WorkflowHookCtrl struct {
@@ -136,6 +159,8 @@ type TaskArgs struct {
 	IsQiNiu        bool                `bson:"is_qiniu"                json:"is_qiniu"`
 	NotificationID string              `bson:"notification_id"         json:"notification_id"`
 	CodeHostID     int                 `bson:"codehost_id"             json:"codehost_id"`
+	// Priority 队列优先级, 同一项目中数值越大越先被调度
+	Priority int `bson:"priority,omitempty"      json:"priority,omitempty"`
 }
 
 type CallbackArgs struct {
@@ -166,6 +191,8 @@ type WorkflowTaskArgs struct {
 	IgnoreCache bool `json:"ignore_cache" bson:"ignore_cache"`
 	// Ignore workspace cache and reset volume
 	ResetCache bool `json:"reset_cache" bson:"reset_cache"`
+	// Build again even if a previous build result could be reused
+	ForceRebuild bool `json:"force_rebuild" bson:"force_rebuild"`
 
 	// NotificationID is the id of scmnotify.Notification
 	NotificationID string `bson:"notification_id" json:"notification_id"`
@@ -187,6 +214,8 @@ type WorkflowTaskArgs struct {
 
 	Callback      *CallbackArgs   `bson:"callback"                    json:"callback"`
 	ReleaseImages []*ReleaseImage `bson:"release_images,omitempty"    json:"release_images,omitempty"`
+	// Priority 队列优先级, 同一项目中数值越大越先被调度
+	Priority int `bson:"priority,omitempty"          json:"priority,omitempty"`
 }
 
 type ReleaseImage struct {
@@ -208,6 +237,8 @@ type TestTaskArgs struct {
 	CodehostID     int    `bson:"codehost_id"      json:"codehost_id"`
 	RepoOwner      string `bson:"repo_owner"       json:"repo_owner"`
 	RepoName       string `bson:"repo_name"        json:"repo_name"`
+	// Priority 队列优先级, 同一项目中数值越大越先被调度
+	Priority int `bson:"priority,omitempty" json:"priority,omitempty"`
 }
 
 type Slack struct {
@@ -254,6 +285,10 @@ type TestExecArgs struct {
 
 type SecurityStage struct {
 	Enabled bool `bson:"enabled"                    json:"enabled"`
+	// Scanner is clair or trivy, clair is used if it is empty
+	Scanner string `bson:"scanner,omitempty"          json:"scanner,omitempty"`
+	// SeverityThreshold fails the stage if an image has a vulnerability at or above the severity
+	SeverityThreshold string `bson:"severity_threshold,omitempty" json:"severity_threshold,omitempty"`
 }
 
 type DistributeStage struct {
@@ -265,6 +300,8 @@ type DistributeStage struct {
 
 	// repos to release images
 	Releases []RepoImage `bson:"releases" json:"releases"`
+	// Provenance are the options of the SBOM and the signature generated for the released images
+	Provenance *types.ProvenanceOptions `bson:"provenance,omitempty" json:"provenance,omitempty"`
 }
 
 type ExtensionStage struct {
@@ -276,6 +313,16 @@ type ExtensionStage struct {
 	Headers    []*KeyVal `bson:"headers"              json:"headers"`
 }
 
+// ApprovalStage 人工审批阶段，在部署和分发之前暂停任务，等待审批人处理
+type ApprovalStage struct {
+	Enabled         bool     `bson:"enabled"               json:"enabled"`
+	Approvers       []string `bson:"approvers"             json:"approvers"`
+	NeededApprovers int      `bson:"needed_approvers"      json:"needed_approvers"`
+	Description     string   `bson:"description,omitempty" json:"description,omitempty"`
+	// Timeout 单位为分钟
+	Timeout int `bson:"timeout"               json:"timeout"`
+}
+
 type RepoImage struct {
 	RepoID        string `json:"repo_id" bson:"repo_id"`
 	Name          string `json:"name" bson:"name" yaml:"name"`
@@ -404,6 +451,7 @@ type BuildModuleArgs struct {
 	FileName     string
 	URL          string
 	TaskType     string
+	ReuseResult  bool
 }
 
 func (Workflow) TableName() string {
@@ -32,6 +32,17 @@ type WorkflowV3 struct {
 	CreateTime  int64                    `bson:"create_time"    json:"create_time"`
 	UpdatedBy   string                   `bson:"updated_by"     json:"updated_by"`
 	UpdateTime  int64                    `bson:"update_time"    json:"update_time"`
+	// DAG declares the dependencies between sub tasks, DAG[i] describes SubTasks[i],
+	// sub tasks are run one by one in the order of SubTasks if it is empty
+	DAG []*DAGNode `bson:"dag"            json:"dag"`
+	// StagePolicies control when the sub tasks run and how their failures affect the task
+	StagePolicies []*StagePolicy `bson:"stage_policies" json:"stage_policies"`
+}
+
+// DAGNode names a sub task of a custom workflow and lists the sub tasks it depends on
+type DAGNode struct {
+	Name      string   `bson:"name"                 json:"name"`
+	DependsOn []string `bson:"depends_on,omitempty" json:"depends_on,omitempty"`
 }
 
 type ParameterSettingType string
@@ -83,6 +94,8 @@ type WorkflowV3Args struct {
 	Name        string              `bson:"name"                    json:"name"`
 	Builds      []*types.Repository `bson:"builds"                  json:"builds"`
 	BuildArgs   []*KeyVal           `bson:"build_args"              json:"build_args"`
+	// Priority 队列优先级, 同一项目中数值越大越先被调度
+	Priority int `bson:"priority,omitempty"      json:"priority,omitempty"`
 }
 
 func (WorkflowV3) TableName() string {
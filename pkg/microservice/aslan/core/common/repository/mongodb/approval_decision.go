@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type ApprovalDecisionColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewApprovalDecisionColl() *ApprovalDecisionColl {
+	name := models.ApprovalDecision{}.TableName()
+	return &ApprovalDecisionColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ApprovalDecisionColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ApprovalDecisionColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "pipeline_name", Value: 1},
+			bson.E{Key: "task_id", Value: 1},
+			bson.E{Key: "user_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+func (c *ApprovalDecisionColl) List(pipelineName string, taskID int64) ([]*models.ApprovalDecision, error) {
+	query := bson.M{"pipeline_name": pipelineName, "task_id": taskID}
+	resp := make([]*models.ApprovalDecision, 0)
+	ctx := context.Background()
+	opts := options.Find().SetSort(bson.D{{"create_time", 1}})
+
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, err
+}
+
+// Create fails with a duplicate key error if the user has already made a decision on the task
+func (c *ApprovalDecisionColl) Create(args *models.ApprovalDecision) error {
+	if args == nil {
+		return errors.New("nil approval decision")
+	}
+
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+// Delete removes all decisions of the task, it is used when the approval stage starts over
+func (c *ApprovalDecisionColl) Delete(pipelineName string, taskID int64) error {
+	query := bson.M{"pipeline_name": pipelineName, "task_id": taskID}
+	_, err := c.DeleteMany(context.TODO(), query)
+	return err
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type BuildResultCacheColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewBuildResultCacheColl() *BuildResultCacheColl {
+	name := models.BuildResultCache{}.TableName()
+	return &BuildResultCacheColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *BuildResultCacheColl) GetCollectionName() string {
+	return c.coll
+}
+
+// buildResultCacheTTL is how long a build result is kept after it is saved or reused last time
+const buildResultCacheTTL = 30 * 24 * time.Hour
+
+func (c *BuildResultCacheColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"key": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.M{"used_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(int32(buildResultCacheTTL.Seconds())),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+func (c *BuildResultCacheColl) Find(key string) (*models.BuildResultCache, error) {
+	res := &models.BuildResultCache{}
+	err := c.FindOne(context.TODO(), bson.M{"key": key}).Decode(res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Upsert keeps the latest image built with the key
+func (c *BuildResultCacheColl) Upsert(args *models.BuildResultCache) error {
+	if args == nil {
+		return errors.New("nil build result cache")
+	}
+
+	now := time.Now()
+	args.CreateTime = now.Unix()
+	args.UsedAt = now
+	query := bson.M{"key": args.Key}
+	change := bson.M{"$set": bson.M{
+		"product_name":   args.ProductName,
+		"service_name":   args.ServiceName,
+		"service_module": args.ServiceModule,
+		"image":          args.Image,
+		"pipeline_name":  args.PipelineName,
+		"task_id":        args.TaskID,
+		"create_time":    args.CreateTime,
+		"used_at":        args.UsedAt,
+		"provenance":     args.Provenance,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+// Touch delays the expiration of the build result since it is reused
+func (c *BuildResultCacheColl) Touch(key string) error {
+	_, err := c.UpdateOne(context.TODO(), bson.M{"key": key}, bson.M{"$set": bson.M{"used_at": time.Now()}})
+	return err
+}
+
+func (c *BuildResultCacheColl) Delete(key string) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"key": key})
+	return err
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type ConcurrencyQuotaColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewConcurrencyQuotaColl() *ConcurrencyQuotaColl {
+	name := models.ConcurrencyQuota{}.TableName()
+	return &ConcurrencyQuotaColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ConcurrencyQuotaColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ConcurrencyQuotaColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "type", Value: 1},
+			bson.E{Key: "target", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+func (c *ConcurrencyQuotaColl) List() ([]*models.ConcurrencyQuota, error) {
+	query := bson.M{}
+	resp := make([]*models.ConcurrencyQuota, 0)
+	ctx := context.Background()
+
+	cursor, err := c.Collection.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, err
+}
+
+// Upsert creates or updates the quota identified by type and target
+func (c *ConcurrencyQuotaColl) Upsert(args *models.ConcurrencyQuota) error {
+	if args == nil {
+		return errors.New("nil concurrency quota")
+	}
+
+	args.UpdateTime = time.Now().Unix()
+	query := bson.M{"type": args.Type, "target": args.Target}
+	change := bson.M{"$set": bson.M{
+		"type":        args.Type,
+		"target":      args.Target,
+		"limit":       args.Limit,
+		"update_time": args.UpdateTime,
+		"update_by":   args.UpdateBy,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *ConcurrencyQuotaColl) Delete(quotaType config.ConcurrencyQuotaType, target string) error {
+	query := bson.M{"type": quotaType, "target": target}
+	_, err := c.DeleteOne(context.TODO(), query)
+	return err
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type EnvLifecycleColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvLifecycleColl() *EnvLifecycleColl {
+	name := models.EnvLifecycle{}.TableName()
+	return &EnvLifecycleColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvLifecycleColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvLifecycleColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "product_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+func (c *EnvLifecycleColl) Find(productName, envName string) (*models.EnvLifecycle, error) {
+	query := bson.M{"product_name": productName, "env_name": envName}
+	resp := new(models.EnvLifecycle)
+	err := c.FindOne(context.TODO(), query).Decode(resp)
+	return resp, err
+}
+
+func (c *EnvLifecycleColl) List() ([]*models.EnvLifecycle, error) {
+	resp := make([]*models.EnvLifecycle, 0)
+	cursor, err := c.Collection.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+// UpdatePolicy updates the lifecycle policy of the environment, the lifecycle state is kept
+func (c *EnvLifecycleColl) UpdatePolicy(args *models.EnvLifecycle) error {
+	query := bson.M{"product_name": args.ProductName, "env_name": args.EnvName}
+	change := bson.M{"$set": bson.M{
+		"ttl_action":     args.TTLAction,
+		"warn_hours":     args.WarnHours,
+		"notify_ctl":     args.NotifyCtl,
+		"sleep_schedule": args.SleepSchedule,
+		"auto_wake":      args.AutoWake,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+// UpdateActiveTime records an activity of the environment, the TTL warning is reset
+func (c *EnvLifecycleColl) UpdateActiveTime(productName, envName string, activeTime int64) error {
+	query := bson.M{"product_name": productName, "env_name": envName}
+	change := bson.M{"$set": bson.M{
+		"last_active_time": activeTime,
+		"warn_time":        0,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *EnvLifecycleColl) UpdateWarnTime(productName, envName string, warnTime int64) error {
+	query := bson.M{"product_name": productName, "env_name": envName}
+	change := bson.M{"$set": bson.M{"warn_time": warnTime}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+// UpdateSleepState updates whether the environment sleeps and the replicas to restore when it wakes up
+func (c *EnvLifecycleColl) UpdateSleepState(args *models.EnvLifecycle) error {
+	query := bson.M{"product_name": args.ProductName, "env_name": args.EnvName}
+	change := bson.M{"$set": bson.M{
+		"sleeping":     args.Sleeping,
+		"sleep_reason": args.SleepReason,
+		"sleep_time":   args.SleepTime,
+		"wake_time":    args.WakeTime,
+		"replicas":     args.Replicas,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *EnvLifecycleColl) Delete(productName, envName string) error {
+	query := bson.M{"product_name": productName, "env_name": envName}
+	_, err := c.DeleteOne(context.TODO(), query)
+	return err
+}
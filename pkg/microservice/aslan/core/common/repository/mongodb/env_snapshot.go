@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/shared/encryption"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type EnvSnapshotColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvSnapshotColl() *EnvSnapshotColl {
+	name := models.EnvSnapshot{}.TableName()
+	return &EnvSnapshotColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvSnapshotColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvSnapshotColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "product_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "create_time", Value: -1},
+		},
+		Options: options.Index().SetUnique(false),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+func (c *EnvSnapshotColl) Create(args *models.EnvSnapshot) error {
+	// the caller keeps the plain secrets, only the stored document is encrypted
+	doc := *args
+	encrypted, err := encryption.Encrypt(args.SecretYamls)
+	if err != nil {
+		return err
+	}
+	doc.SecretYamls = encrypted
+	doc.CreateTime = time.Now().Unix()
+
+	res, err := c.InsertOne(context.TODO(), &doc)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		args.ID = oid
+	}
+	args.CreateTime = doc.CreateTime
+	return nil
+}
+
+func (c *EnvSnapshotColl) Find(id string) (*models.EnvSnapshot, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.EnvSnapshot)
+	if err := c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp); err != nil {
+		return nil, err
+	}
+	resp.SecretYamls, err = encryption.Decrypt(resp.SecretYamls)
+	return resp, err
+}
+
+// List lists the snapshots of the environment from the latest one, the secrets are not loaded
+func (c *EnvSnapshotColl) List(productName, envName string) ([]*models.EnvSnapshot, error) {
+	query := bson.M{"product_name": productName, "env_name": envName}
+	opts := options.Find().
+		SetSort(bson.D{{"create_time", -1}}).
+		SetProjection(bson.M{"secret_yamls": 0})
+
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.EnvSnapshot, 0)
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+func (c *EnvSnapshotColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return err
+}
+
+// Prune deletes the snapshots of the environment which are beyond the retention,
+// a non-positive maxItems or maxDays means no limit on it
+func (c *EnvSnapshotColl) Prune(productName, envName string, maxItems, maxDays int) error {
+	query := bson.M{"product_name": productName, "env_name": envName}
+	if maxDays > 0 {
+		expired := bson.M{
+			"product_name": productName,
+			"env_name":     envName,
+			"create_time":  bson.M{"$lt": time.Now().AddDate(0, 0, -maxDays).Unix()},
+		}
+		if _, err := c.DeleteMany(context.TODO(), expired); err != nil {
+			return err
+		}
+	}
+	if maxItems <= 0 {
+		return nil
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{"create_time", -1}}).
+		SetSkip(int64(maxItems)).
+		SetProjection(bson.M{"_id": 1})
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return err
+	}
+	var docs []bson.M
+	if err := cursor.All(context.TODO(), &docs); err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc["_id"])
+	}
+	_, err = c.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+// DeleteBefore deletes the snapshots of all environments which are created before the time
+func (c *EnvSnapshotColl) DeleteBefore(createTime int64) error {
+	_, err := c.DeleteMany(context.TODO(), bson.M{"create_time": bson.M{"$lt": createTime}})
+	return err
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
 )
 
@@ -59,7 +60,14 @@ func (c *JenkinsIntegrationColl) Create(args *models.JenkinsIntegration) error {
 
 	args.UpdatedAt = time.Now().Unix()
 
-	_, err := c.InsertOne(context.TODO(), args)
+	doc := *args
+	password, err := encryption.Encrypt(args.Password)
+	if err != nil {
+		return err
+	}
+	doc.Password = password
+
+	_, err = c.InsertOne(context.TODO(), &doc)
 	return err
 }
 
@@ -69,11 +77,16 @@ func (c *JenkinsIntegrationColl) Update(ID string, args *models.JenkinsIntegrati
 		return err
 	}
 
+	password, err := encryption.Encrypt(args.Password)
+	if err != nil {
+		return err
+	}
+
 	query := bson.M{"_id": oldID}
 	change := bson.M{"$set": bson.M{
 		"url":        args.URL,
 		"username":   args.Username,
-		"password":   args.Password,
+		"password":   password,
 		"update_by":  args.UpdateBy,
 		"updated_at": time.Now().Unix(),
 	}}
@@ -82,6 +95,21 @@ func (c *JenkinsIntegrationColl) Update(ID string, args *models.JenkinsIntegrati
 	return err
 }
 
+func (c *JenkinsIntegrationColl) Get(ID string) (*models.JenkinsIntegration, error) {
+	oid, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.JenkinsIntegration{}
+	if err := c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp); err != nil {
+		return nil, err
+	}
+
+	resp.Password, err = encryption.Decrypt(resp.Password)
+	return resp, err
+}
+
 func (c *JenkinsIntegrationColl) Delete(ID string) error {
 	oldID, err := primitive.ObjectIDFromHex(ID)
 	if err != nil {
@@ -111,5 +139,10 @@ func (c *JenkinsIntegrationColl) List() ([]*models.JenkinsIntegration, error) {
 		return nil, err
 	}
 
+	for _, jenkins := range resp {
+		if jenkins.Password, err = encryption.Decrypt(jenkins.Password); err != nil {
+			return nil, err
+		}
+	}
 	return resp, nil
 }
@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type PreviewEnvFindOption struct {
+	WorkflowName string
+	CodehostID   int
+	RepoOwner    string
+	RepoName     string
+	PrID         int
+}
+
+type PreviewEnvListOption struct {
+	CodehostIDs []int
+	RepoOwner   string
+	RepoName    string
+	PrID        int
+}
+
+type PreviewEnvColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewPreviewEnvColl() *PreviewEnvColl {
+	name := models.PreviewEnv{}.TableName()
+	return &PreviewEnvColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *PreviewEnvColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *PreviewEnvColl) EnsureIndex(ctx context.Context) error {
+	mods := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "workflow_name", Value: 1},
+				bson.E{Key: "codehost_id", Value: 1},
+				bson.E{Key: "repo_owner", Value: 1},
+				bson.E{Key: "repo_name", Value: 1},
+				bson.E{Key: "pr_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "product_name", Value: 1},
+				bson.E{Key: "env_name", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mods)
+	return err
+}
+
+func (c *PreviewEnvColl) Find(opt *PreviewEnvFindOption) (*models.PreviewEnv, error) {
+	query := bson.M{
+		"workflow_name": opt.WorkflowName,
+		"codehost_id":   opt.CodehostID,
+		"repo_owner":    opt.RepoOwner,
+		"repo_name":     opt.RepoName,
+		"pr_id":         opt.PrID,
+	}
+	resp := new(models.PreviewEnv)
+	err := c.FindOne(context.TODO(), query).Decode(resp)
+	return resp, err
+}
+
+// List lists the preview environments of a pull request in all workflows
+func (c *PreviewEnvColl) List(opt *PreviewEnvListOption) ([]*models.PreviewEnv, error) {
+	query := bson.M{
+		"codehost_id": bson.M{"$in": opt.CodehostIDs},
+		"repo_owner":  opt.RepoOwner,
+		"repo_name":   opt.RepoName,
+		"pr_id":       opt.PrID,
+	}
+	resp := make([]*models.PreviewEnv, 0)
+	cursor, err := c.Collection.Find(context.TODO(), query)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+func (c *PreviewEnvColl) Create(args *models.PreviewEnv) error {
+	args.ID = primitive.NewObjectID()
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+func (c *PreviewEnvColl) UpdateCommit(id, commitID string, updateTime int64) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	change := bson.M{"$set": bson.M{"commit_id": commitID, "update_time": updateTime}}
+	_, err = c.UpdateOne(context.TODO(), bson.M{"_id": oid}, change)
+	return err
+}
+
+func (c *PreviewEnvColl) UpdateCommentID(id, commentID string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = c.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.M{"$set": bson.M{"comment_id": commentID}})
+	return err
+}
+
+func (c *PreviewEnvColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return err
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
 )
 
@@ -83,6 +84,11 @@ func (c *PrivateKeyColl) Find(option FindPrivateKeyOption) (*models.PrivateKey,
 	}
 
 	err := c.FindOne(context.TODO(), query).Decode(privateKey)
+	if err != nil {
+		return privateKey, err
+	}
+
+	privateKey.PrivateKey, err = encryption.Decrypt(privateKey.PrivateKey)
 	return privateKey, err
 }
 
@@ -104,7 +110,7 @@ func (c *PrivateKeyColl) List(args *PrivateKeyArgs) ([]*models.PrivateKey, error
 		return nil, err
 	}
 
-	return resp, err
+	return resp, decryptPrivateKeys(resp)
 }
 
 func (c *PrivateKeyColl) Create(args *models.PrivateKey) error {
@@ -115,7 +121,15 @@ func (c *PrivateKeyColl) Create(args *models.PrivateKey) error {
 	args.CreateTime = time.Now().Unix()
 	args.UpdateTime = time.Now().Unix()
 
-	_, err := c.InsertOne(context.TODO(), args)
+	// the caller keeps the plain key, only the stored document is encrypted
+	doc := *args
+	encrypted, err := encryption.Encrypt(args.PrivateKey)
+	if err != nil {
+		return err
+	}
+	doc.PrivateKey = encrypted
+
+	_, err = c.InsertOne(context.TODO(), &doc)
 
 	return err
 }
@@ -137,6 +151,10 @@ func (c *PrivateKeyColl) Update(id string, args *models.PrivateKey) error {
 			"status": args.Status,
 		}}
 	} else {
+		encrypted, err := encryption.Encrypt(args.PrivateKey)
+		if err != nil {
+			return err
+		}
 		change = bson.M{"$set": bson.M{
 			"name":        args.Name,
 			"user_name":   args.UserName,
@@ -144,7 +162,7 @@ func (c *PrivateKeyColl) Update(id string, args *models.PrivateKey) error {
 			"port":        args.Port,
 			"label":       args.Label,
 			"is_prod":     args.IsProd,
-			"private_key": args.PrivateKey,
+			"private_key": encrypted,
 			"provider":    args.Provider,
 			"update_by":   args.UpdateBy,
 			"update_time": time.Now().Unix(),
@@ -220,7 +238,7 @@ func (c *PrivateKeyColl) ListHostIPByArgs(args *ListHostIPArgs) ([]*models.Priva
 		return nil, err
 	}
 
-	return resp, err
+	return resp, decryptPrivateKeys(resp)
 }
 
 // DistinctLabels returns distinct label
@@ -241,3 +259,14 @@ func (c *PrivateKeyColl) DistinctLabels() ([]string, error) {
 
 	return resp, err
 }
+
+func decryptPrivateKeys(keys []*models.PrivateKey) error {
+	for _, key := range keys {
+		decrypted, err := encryption.Decrypt(key.PrivateKey)
+		if err != nil {
+			return err
+		}
+		key.PrivateKey = decrypted
+	}
+	return nil
+}
@@ -118,10 +118,11 @@ func (c *QueueColl) Update(args *models.Queue) error {
 
 	query := bson.M{"task_id": args.TaskID, "pipeline_name": args.PipelineName, "create_time": args.CreateTime}
 	change := bson.M{"$set": bson.M{
-		"status":     args.Status,
-		"start_time": args.StartTime,
-		"end_time":   args.EndTime,
-		"sub_tasks":  args.SubTasks,
+		"status":         args.Status,
+		"start_time":     args.StartTime,
+		"end_time":       args.EndTime,
+		"sub_tasks":      args.SubTasks,
+		"blocked_reason": args.BlockedReason,
 	}}
 
 	_, err := c.UpdateOne(context.TODO(), query, change)
@@ -137,3 +138,13 @@ func (c *QueueColl) UpdateAgent(taskID int64, pipelineName string, createTime in
 	_, err := c.UpdateOne(context.TODO(), query, change)
 	return err
 }
+
+func (c *QueueColl) UpdatePriority(taskID int64, pipelineName string, createTime int64, priority int) error {
+	query := bson.M{"task_id": taskID, "pipeline_name": pipelineName, "create_time": createTime}
+	change := bson.M{"$set": bson.M{
+		"priority": priority,
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
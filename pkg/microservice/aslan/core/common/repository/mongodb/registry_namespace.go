@@ -28,6 +28,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
 )
 
@@ -78,7 +79,11 @@ func (r *RegistryNamespaceColl) Create(args *models.RegistryNamespace) error {
 
 	args.UpdateTime = time.Now().Unix()
 
-	_, err := r.InsertOne(context.TODO(), args)
+	doc, err := encryptRegistry(args)
+	if err != nil {
+		return err
+	}
+	_, err = r.InsertOne(context.TODO(), doc)
 	return err
 }
 
@@ -119,8 +124,11 @@ func (r *RegistryNamespaceColl) Find(opt *FindRegOps) (*models.RegistryNamespace
 
 	res := &models.RegistryNamespace{}
 	err := r.FindOne(context.TODO(), query).Decode(res)
+	if err != nil {
+		return res, err
+	}
 
-	return res, err
+	return res, decryptRegistry(res)
 }
 
 func (r *RegistryNamespaceColl) FindAll(opt *FindRegOps) ([]*models.RegistryNamespace, error) {
@@ -140,7 +148,12 @@ func (r *RegistryNamespaceColl) FindAll(opt *FindRegOps) ([]*models.RegistryName
 		return nil, err
 	}
 
-	return resp, err
+	for _, reg := range resp {
+		if err := decryptRegistry(reg); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
 }
 
 func (r *RegistryNamespaceColl) Update(id string, args *models.RegistryNamespace) error {
@@ -157,7 +170,11 @@ func (r *RegistryNamespaceColl) Update(id string, args *models.RegistryNamespace
 	args.ID = oid
 	args.UpdateTime = time.Now().Unix()
 
-	change := bson.M{"$set": args}
+	doc, err := encryptRegistry(args)
+	if err != nil {
+		return err
+	}
+	change := bson.M{"$set": doc}
 	_, err = r.UpdateOne(context.TODO(), query, change)
 	return err
 }
@@ -173,3 +190,25 @@ func (r *RegistryNamespaceColl) Delete(id string) error {
 
 	return err
 }
+
+// encryptRegistry returns a copy of the registry to be stored, whose credentials are encrypted
+func encryptRegistry(reg *models.RegistryNamespace) (*models.RegistryNamespace, error) {
+	doc := *reg
+	var err error
+	if doc.AccessKey, err = encryption.Encrypt(reg.AccessKey); err != nil {
+		return nil, err
+	}
+	if doc.SecretKey, err = encryption.Encrypt(reg.SecretKey); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func decryptRegistry(reg *models.RegistryNamespace) error {
+	var err error
+	if reg.AccessKey, err = encryption.Decrypt(reg.AccessKey); err != nil {
+		return err
+	}
+	reg.SecretKey, err = encryption.Decrypt(reg.SecretKey)
+	return err
+}
@@ -28,7 +28,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
-	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	"github.com/koderover/zadig/pkg/tool/log"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
 )
@@ -63,11 +63,9 @@ func (c *S3StorageColl) FindDefault() (*models.S3Storage, error) {
 		return nil, err
 	}
 
-	decryptedKey, err := crypto.AesDecrypt(storage.EncryptedSk)
-	if err != nil {
+	if err := decryptS3Storage(storage); err != nil {
 		return nil, err
 	}
-	storage.Sk = decryptedKey
 
 	return storage, nil
 }
@@ -84,11 +82,9 @@ func (c *S3StorageColl) Find(id string) (*models.S3Storage, error) {
 		return nil, err
 	}
 
-	decryptedKey, err := crypto.AesDecrypt(storage.EncryptedSk)
-	if err != nil {
+	if err := decryptS3Storage(storage); err != nil {
 		return nil, err
 	}
-	storage.Sk = decryptedKey
 
 	return storage, nil
 }
@@ -105,11 +101,9 @@ func (c *S3StorageColl) GetS3Storage() (*models.S3Storage, error) {
 		return nil, err
 	}
 
-	decryptedKey, err := crypto.AesDecrypt(storage.EncryptedSk)
-	if err != nil {
+	if err := decryptS3Storage(storage); err != nil {
 		return nil, err
 	}
-	storage.Sk = decryptedKey
 
 	return storage, nil
 }
@@ -134,11 +128,10 @@ func (c *S3StorageColl) Update(id string, args *models.S3Storage) error {
 	query := bson.M{"_id": args.ID}
 	args.UpdateTime = time.Now().Unix()
 
-	encryptedKey, err := crypto.AesEncrypt(args.Sk)
+	doc, err := encryptS3Storage(args)
 	if err != nil {
 		return err
 	}
-	args.EncryptedSk = encryptedKey
 
 	if args.IsDefault {
 		if err := c.unsetDefault(); err != nil {
@@ -146,7 +139,7 @@ func (c *S3StorageColl) Update(id string, args *models.S3Storage) error {
 		}
 	}
 
-	change := bson.M{"$set": args}
+	change := bson.M{"$set": doc}
 	_, err = c.UpdateOne(context.TODO(), query, change)
 	return err
 }
@@ -165,11 +158,10 @@ func (c *S3StorageColl) Delete(id string) error {
 // Create if the crated storage is default, all other default storage will be set as not default
 func (c *S3StorageColl) Create(args *models.S3Storage) error {
 	args.UpdateTime = time.Now().Unix()
-	encryptedKey, err := crypto.AesEncrypt(args.Sk)
+	doc, err := encryptS3Storage(args)
 	if err != nil {
 		return err
 	}
-	args.EncryptedSk = encryptedKey
 
 	if args.IsDefault {
 		if err := c.unsetDefault(); err != nil {
@@ -177,7 +169,7 @@ func (c *S3StorageColl) Create(args *models.S3Storage) error {
 		}
 	}
 
-	_, err = c.InsertOne(context.TODO(), args)
+	_, err = c.InsertOne(context.TODO(), doc)
 	return err
 }
 
@@ -195,11 +187,9 @@ func (c *S3StorageColl) FindAll() ([]*models.S3Storage, error) {
 	}
 
 	for _, s := range storages {
-		decryptedKey, err := crypto.AesDecrypt(s.EncryptedSk)
-		if err != nil {
+		if err := decryptS3Storage(s); err != nil {
 			return nil, err
 		}
-		s.Sk = decryptedKey
 	}
 
 	return storages, nil
@@ -247,3 +237,27 @@ func (c *S3StorageColl) InitData() error {
 
 	return c.Create(&minioStorage)
 }
+
+// encryptS3Storage returns a copy of the storage to be stored, whose credentials are encrypted
+func encryptS3Storage(storage *models.S3Storage) (*models.S3Storage, error) {
+	doc := *storage
+	var err error
+	if doc.Ak, err = encryption.Encrypt(storage.Ak); err != nil {
+		return nil, err
+	}
+	if doc.EncryptedSk, err = encryption.Encrypt(storage.Sk); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// decryptS3Storage decrypts the credentials, the secret key may still be encrypted by the master key only
+// if it is stored before envelope encryption
+func decryptS3Storage(storage *models.S3Storage) error {
+	var err error
+	if storage.Ak, err = encryption.Decrypt(storage.Ak); err != nil {
+		return err
+	}
+	storage.Sk, err = encryption.DecryptLegacy(storage.EncryptedSk)
+	return err
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
 )
 
@@ -55,7 +56,15 @@ func (c *SystemSettingColl) Get() (*models.SystemSetting, error) {
 	resp := &models.SystemSetting{}
 
 	err := c.FindOne(context.TODO(), query).Decode(resp)
-	return resp, err
+	if err != nil || resp.ImageSigning == nil {
+		return resp, err
+	}
+	for _, secret := range []*string{&resp.ImageSigning.PrivateKey, &resp.ImageSigning.Password} {
+		if *secret, err = encryption.Decrypt(*secret); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
 }
 
 func (c *SystemSettingColl) UpdateConcurrencySetting(workflowConcurrency, buildConcurrency int64) error {
@@ -69,6 +78,35 @@ func (c *SystemSettingColl) UpdateConcurrencySetting(workflowConcurrency, buildC
 	return err
 }
 
+func (c *SystemSettingColl) UpdateProjectQueueWeights(weights map[string]int64) error {
+	id, _ := primitive.ObjectIDFromHex(setting.LocalClusterID)
+	change := bson.M{"$set": bson.M{
+		"project_queue_weights": weights,
+	}}
+	query := bson.M{"_id": id}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *SystemSettingColl) UpdateImageSigning(imageSigning *models.ImageSigning) error {
+	doc := *imageSigning
+	for _, secret := range []*string{&doc.PrivateKey, &doc.Password} {
+		encrypted, err := encryption.Encrypt(*secret)
+		if err != nil {
+			return err
+		}
+		*secret = encrypted
+	}
+
+	id, _ := primitive.ObjectIDFromHex(setting.LocalClusterID)
+	change := bson.M{"$set": bson.M{
+		"image_signing": &doc,
+	}}
+	query := bson.M{"_id": id}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
 func (c *SystemSettingColl) InitSystemSettings() error {
 	_, err := c.Get()
 	// if we didn't find anything
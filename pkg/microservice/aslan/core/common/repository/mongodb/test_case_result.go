@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type TestCaseResultListOption struct {
+	ProductName string
+	TestName    string
+	StartTime   int64
+}
+
+type TestCaseResultColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewTestCaseResultColl() *TestCaseResultColl {
+	name := models.TestCaseResult{}.TableName()
+	return &TestCaseResultColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *TestCaseResultColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *TestCaseResultColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "pipeline_name", Value: 1},
+				bson.E{Key: "task_id", Value: 1},
+				bson.E{Key: "test_name", Value: 1},
+				bson.E{Key: "case_name", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "product_name", Value: 1},
+				bson.E{Key: "test_name", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+// UpsertMany saves the results of the test cases in a task, a restarted task overrides the results of the last run
+func (c *TestCaseResultColl) UpsertMany(results []*models.TestCaseResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	var ms []mongo.WriteModel
+	for _, result := range results {
+		ms = append(ms,
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"pipeline_name": result.PipelineName, "task_id": result.TaskID, "test_name": result.TestName, "case_name": result.CaseName}).
+				SetUpdate(bson.M{"$set": result}).
+				SetUpsert(true),
+		)
+	}
+	_, err := c.BulkWrite(context.TODO(), ms)
+	return err
+}
+
+func (c *TestCaseResultColl) List(opt *TestCaseResultListOption) ([]*models.TestCaseResult, error) {
+	query := bson.M{}
+	if opt.ProductName != "" {
+		query["product_name"] = opt.ProductName
+	}
+	if opt.TestName != "" {
+		query["test_name"] = opt.TestName
+	}
+	if opt.StartTime > 0 {
+		query["create_time"] = bson.M{"$gte": opt.StartTime}
+	}
+
+	resp := make([]*models.TestCaseResult, 0)
+	ctx := context.Background()
+	cursor, err := c.Collection.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type TestCoverageListOption struct {
+	ProductNames []string
+	TestName     string
+	StartTime    int64
+	EndTime      int64
+}
+
+type TestCoverageColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewTestCoverageColl() *TestCoverageColl {
+	name := models.TestCoverage{}.TableName()
+	return &TestCoverageColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *TestCoverageColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *TestCoverageColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "pipeline_name", Value: 1},
+				bson.E{Key: "task_id", Value: 1},
+				bson.E{Key: "test_name", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "test_name", Value: 1},
+				bson.E{Key: "branch", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "product_name", Value: 1},
+				bson.E{Key: "create_time", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+// Upsert saves the coverage of a test in a task, a restarted task overrides the coverage of the last run
+func (c *TestCoverageColl) Upsert(args *models.TestCoverage) error {
+	if args == nil {
+		return errors.New("nil test coverage")
+	}
+
+	query := bson.M{"pipeline_name": args.PipelineName, "task_id": args.TaskID, "test_name": args.TestName}
+	change := bson.M{"$set": args}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+// FindLatest returns the latest coverage of the test on the branch which is not collected for a pull request
+func (c *TestCoverageColl) FindLatest(testName, branch string) (*models.TestCoverage, error) {
+	query := bson.M{"test_name": testName, "pr": bson.M{"$in": []interface{}{0, nil}}}
+	if branch != "" {
+		query["branch"] = branch
+	}
+
+	resp := new(models.TestCoverage)
+	opts := options.FindOne().SetSort(bson.D{{"create_time", -1}})
+	if err := c.FindOne(context.TODO(), query, opts).Decode(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *TestCoverageColl) List(opt *TestCoverageListOption) ([]*models.TestCoverage, error) {
+	query := bson.M{}
+	if len(opt.ProductNames) > 0 {
+		query["product_name"] = bson.M{"$in": opt.ProductNames}
+	}
+	if opt.TestName != "" {
+		query["test_name"] = opt.TestName
+	}
+	if opt.StartTime > 0 || opt.EndTime > 0 {
+		timeRange := bson.M{}
+		if opt.StartTime > 0 {
+			timeRange["$gte"] = opt.StartTime
+		}
+		if opt.EndTime > 0 {
+			timeRange["$lte"] = opt.EndTime
+		}
+		query["create_time"] = timeRange
+	}
+
+	resp := make([]*models.TestCoverage, 0)
+	ctx := context.Background()
+	opts := options.Find().SetSort(bson.D{{"create_time", 1}})
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
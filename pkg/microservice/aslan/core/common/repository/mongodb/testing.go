@@ -177,3 +177,15 @@ func (c *TestingColl) Update(testing *models.Testing) error {
 	_, err := c.UpdateOne(context.TODO(), query, change)
 	return err
 }
+
+// UpdateQuarantinedCases replaces the quarantined test cases of the testing module
+func (c *TestingColl) UpdateQuarantinedCases(name, productName string, cases []string) error {
+	query := bson.M{"name": name, "product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"quarantined_cases": cases,
+		"update_time":       time.Now().Unix(),
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
@@ -139,3 +139,11 @@ func ToExtensionTask(sb map[string]interface{}) (*task.Extension, error) {
 	}
 	return extension, nil
 }
+
+func ToApprovalTask(sb map[string]interface{}) (*task.Approval, error) {
+	var approval *task.Approval
+	if err := task.IToi(sb, &approval); err != nil {
+		return nil, fmt.Errorf("convert interface to approvalTask error: %s", err)
+	}
+	return approval, nil
+}
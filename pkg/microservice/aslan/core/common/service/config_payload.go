@@ -91,6 +91,9 @@ func GetConfigPayload(codeHostID int) *models.ConfigPayload {
 	concurrencySettings, _ := mongodb.NewSystemSettingColl().Get()
 	if concurrencySettings != nil {
 		payload.BuildConcurrency = concurrencySettings.BuildConcurrency
+		if imageSigning := concurrencySettings.ImageSigning; imageSigning != nil {
+			payload.VerifyImageSignature = imageSigning.VerifyOnDeploy
+		}
 	}
 
 	privateKeys, _ := mongodb.NewPrivateKeyColl().List(&mongodb.PrivateKeyArgs{})
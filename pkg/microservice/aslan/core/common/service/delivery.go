@@ -157,6 +157,7 @@ func GetSubTaskContent(deliveryVersion *commonmodels.DeliveryVersion, pipelineTa
 				imageName := buildInfo.JobCtx.Image
 				deliveryBuild.ImageName = imageName
 				deliveryBuild.Commits = buildInfo.JobCtx.Builds
+				deliveryBuild.Provenance = buildInfo.Provenance
 				//packageInfo
 				if buildInfo.JobCtx.FileArchiveCtx != nil {
 					deliveryPackage := new(commonmodels.DeliveryPackage)
@@ -315,6 +316,7 @@ func GetSubTaskContent(deliveryVersion *commonmodels.DeliveryVersion, pipelineTa
 				deliveryDistribute.Namespace = releaseImageInfo.ImageRepo
 				deliveryDistribute.StartTime = releaseImageInfo.StartTime
 				deliveryDistribute.EndTime = releaseImageInfo.EndTime
+				deliveryDistribute.Provenances = releaseImageInfo.Provenances
 				deliveryDistribute.CreatedAt = time.Now().Unix()
 				deliveryDistribute.DeletedAt = 0
 
@@ -0,0 +1,212 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+)
+
+// TouchEnv records an activity of the environment, the sleeping environment is woken up if auto wake is enabled
+func TouchEnv(productName, envName string, log *zap.SugaredLogger) {
+	if err := commonrepo.NewEnvLifecycleColl().UpdateActiveTime(productName, envName, time.Now().Unix()); err != nil {
+		log.Warnf("failed to record activity of env %s/%s, err: %s", productName, envName, err)
+		return
+	}
+
+	lifecycle, err := commonrepo.NewEnvLifecycleColl().Find(productName, envName)
+	if err != nil || !lifecycle.Sleeping || !lifecycle.AutoWake {
+		return
+	}
+	go func() {
+		if err := WakeEnv(productName, envName, log); err != nil {
+			log.Errorf("failed to wake env %s/%s, err: %s", productName, envName, err)
+		}
+	}()
+}
+
+// SleepEnv scales the workloads of the services of the environment to zero, the replicas are recorded to be restored when it wakes up
+func SleepEnv(prod *commonmodels.Product, reason commonmodels.EnvSleepReason, log *zap.SugaredLogger) error {
+	lifecycle, err := commonrepo.NewEnvLifecycleColl().Find(prod.ProductName, prod.EnvName)
+	if err != nil && !commonrepo.IsErrNoDocuments(err) {
+		return err
+	}
+	if err == nil && lifecycle.Sleeping {
+		return nil
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	deployments, statefulSets, err := listEnvWorkloads(prod, kubeClient)
+	if err != nil {
+		return err
+	}
+
+	state := &commonmodels.EnvLifecycle{
+		ProductName: prod.ProductName,
+		EnvName:     prod.EnvName,
+		Sleeping:    true,
+		SleepReason: reason,
+		SleepTime:   time.Now().Unix(),
+	}
+	if lifecycle != nil {
+		state.WakeTime = lifecycle.WakeTime
+	}
+
+	errList := new(multierror.Error)
+	scale := func(kind, name string, replicas *int32, scaleFunc func(ns, name string, replicas int, cl client.Client) error) {
+		// a nil replicas means the default replicas 1
+		r := int32(1)
+		if replicas != nil {
+			r = *replicas
+		}
+		if r == 0 {
+			return
+		}
+		if err := scaleFunc(prod.Namespace, name, 0, kubeClient); err != nil {
+			errList = multierror.Append(errList, fmt.Errorf("failed to scale %s %s to zero: %s", kind, name, err))
+			return
+		}
+		state.Replicas = append(state.Replicas, &commonmodels.WorkloadReplicas{Kind: kind, Name: name, Replicas: r})
+	}
+	for _, d := range deployments {
+		scale(setting.Deployment, d.Name, d.Spec.Replicas, updater.ScaleDeployment)
+	}
+	for _, s := range statefulSets {
+		scale(setting.StatefulSet, s.Name, s.Spec.Replicas, updater.ScaleStatefulSet)
+	}
+
+	// the workloads which are scaled to zero are recorded even if some of them fail
+	if err := commonrepo.NewEnvLifecycleColl().UpdateSleepState(state); err != nil {
+		errList = multierror.Append(errList, err)
+	}
+	return errList.ErrorOrNil()
+}
+
+// listEnvWorkloads lists the deployments and statefulsets of the services of the environment,
+// the other workloads in the namespace, e.g. those of a shared namespace, are left alone
+func listEnvWorkloads(prod *commonmodels.Product, kubeClient client.Client) ([]*appsv1.Deployment, []*appsv1.StatefulSet, error) {
+	selector := labels.Set{setting.ProductLabel: prod.ProductName}.AsSelector()
+	owned := func(annotations map[string]string) bool { return true }
+	if prod.Source == setting.SourceFromHelm {
+		// the workloads of helm charts are not labelled by zadig, they are found by their releases instead
+		releaseNameMap, err := GetReleaseNameToServiceNameMap(prod)
+		if err != nil {
+			return nil, nil, err
+		}
+		selector = labels.Everything()
+		owned = func(annotations map[string]string) bool {
+			_, ok := releaseNameMap[annotations[setting.HelmReleaseNameAnnotation]]
+			return ok
+		}
+	}
+
+	allDeployments, err := getter.ListDeployments(prod.Namespace, selector, kubeClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	allStatefulSets, err := getter.ListStatefulSets(prod.Namespace, selector, kubeClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var deployments []*appsv1.Deployment
+	for _, d := range allDeployments {
+		if owned(d.Annotations) {
+			deployments = append(deployments, d)
+		}
+	}
+	var statefulSets []*appsv1.StatefulSet
+	for _, s := range allStatefulSets {
+		if owned(s.Annotations) {
+			statefulSets = append(statefulSets, s)
+		}
+	}
+	return deployments, statefulSets, nil
+}
+
+// WakeEnv restores the replicas of the workloads of the sleeping environment
+func WakeEnv(productName, envName string, log *zap.SugaredLogger) error {
+	lifecycle, err := commonrepo.NewEnvLifecycleColl().Find(productName, envName)
+	if err != nil {
+		if commonrepo.IsErrNoDocuments(err) {
+			return nil
+		}
+		return err
+	}
+	if !lifecycle.Sleeping {
+		return nil
+	}
+
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return err
+	}
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	errList := new(multierror.Error)
+	var failed []*commonmodels.WorkloadReplicas
+	for _, r := range lifecycle.Replicas {
+		var err error
+		switch r.Kind {
+		case setting.Deployment:
+			err = updater.ScaleDeployment(prod.Namespace, r.Name, int(r.Replicas), kubeClient)
+		case setting.StatefulSet:
+			err = updater.ScaleStatefulSet(prod.Namespace, r.Name, int(r.Replicas), kubeClient)
+		}
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Warnf("%s %s of env %s/%s is deleted during sleep", r.Kind, r.Name, productName, envName)
+				continue
+			}
+			errList = multierror.Append(errList, fmt.Errorf("failed to scale %s %s to %d: %s", r.Kind, r.Name, r.Replicas, err))
+			failed = append(failed, r)
+		}
+	}
+
+	// the workloads which fail to be restored are kept so that they are retried next time
+	lifecycle.Replicas = failed
+	if len(failed) == 0 {
+		lifecycle.Sleeping = false
+		lifecycle.SleepReason = ""
+		lifecycle.WakeTime = time.Now().Unix()
+	}
+	if err := commonrepo.NewEnvLifecycleColl().UpdateSleepState(lifecycle); err != nil {
+		errList = multierror.Append(errList, err)
+	}
+	return errList.ErrorOrNil()
+}
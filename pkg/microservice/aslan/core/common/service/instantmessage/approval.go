@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package instantmessage
+
+import (
+	"fmt"
+	"strings"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+// SendApprovalMessage asks the approvers to handle the approval stage of a workflow task
+// through all enabled IM channels of the workflow
+func (w *Service) SendApprovalMessage(pipelineTask *task.Task, approval *task.Approval) error {
+	resp, err := w.workflowColl.Find(pipelineTask.PipelineName)
+	if err != nil {
+		log.Errorf("failed to find Workflow,err: %s", err)
+		return err
+	}
+
+	title := "工作流待审批"
+	url := fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/multi/%s/%d", configbase.SystemAddress(), pipelineTask.ProductName, pipelineTask.PipelineName, pipelineTask.TaskID)
+	lines := []string{
+		fmt.Sprintf("工作流 %s #%d 等待审批", pipelineTask.PipelineName, pipelineTask.TaskID),
+		fmt.Sprintf("审批人：%s", strings.Join(approval.Approvers, ",")),
+	}
+	if approval.Description != "" {
+		lines = append(lines, fmt.Sprintf("审批说明：%s", approval.Description))
+	}
+	lines = append(lines, fmt.Sprintf("任务详情：%s", url))
+	content := strings.Join(lines, "\n")
+
+	for _, notifyCtl := range resp.NotifyCtls {
+		if notifyCtl == nil || !notifyCtl.Enabled {
+			continue
+		}
+
+		switch notifyCtl.WebHookType {
+		case dingDingType:
+			err = w.sendDingDingMessage(notifyCtl.DingDingWebHook, title, strings.Join(lines, "\n\n"), notifyCtl.AtMobiles)
+		case feiShuType:
+			err = w.sendFeishuMessageOfSingleType(title, notifyCtl.FeiShuWebHook, content)
+		default:
+			err = w.SendWeChatWorkMessage(weChatTextTypeText, notifyCtl.WeChatWebHook, content)
+		}
+		if err != nil {
+			log.Errorf("send %s approval message err: %s", notifyCtl.WebHookType, err)
+			continue
+		}
+	}
+	return nil
+}
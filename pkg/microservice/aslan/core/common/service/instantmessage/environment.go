@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"strings"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+// SendEnvLifecycleMessage tells the owners of an environment what the lifecycle controller did or is going to do
+func (w *Service) SendEnvLifecycleMessage(notifyCtl *models.NotifyCtl, title string, lines []string) error {
+	if notifyCtl == nil || !notifyCtl.Enabled {
+		return nil
+	}
+
+	switch notifyCtl.WebHookType {
+	case dingDingType:
+		return w.sendDingDingMessage(notifyCtl.DingDingWebHook, title, strings.Join(lines, "\n\n"), notifyCtl.AtMobiles)
+	case feiShuType:
+		return w.sendFeishuMessageOfSingleType(title, notifyCtl.FeiShuWebHook, strings.Join(lines, "\n"))
+	default:
+		return w.SendWeChatWorkMessage(weChatTextTypeText, notifyCtl.WeChatWebHook, strings.Join(lines, "\n"))
+	}
+}
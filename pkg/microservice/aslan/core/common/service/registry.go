@@ -28,6 +28,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/docker/distribution/reference"
 	"go.uber.org/zap"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -35,7 +36,6 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
-	"github.com/koderover/zadig/pkg/tool/crypto"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/util"
 )
@@ -98,30 +98,13 @@ func FindDefaultRegistry(getRealCredential bool, log *zap.SugaredLogger) (reg *m
 	return findRegisty(&mongodb.FindRegOps{IsDefault: true}, getRealCredential, log)
 }
 
-func ListRegistryNamespaces(encryptedKey string, getRealCredential bool, log *zap.SugaredLogger) ([]*models.RegistryNamespace, error) {
+func ListRegistryNamespaces(getRealCredential bool, log *zap.SugaredLogger) ([]*models.RegistryNamespace, error) {
 	resp, err := mongodb.NewRegistryNamespaceColl().FindAll(&mongodb.FindRegOps{})
 	if err != nil {
 		log.Errorf("RegistryNamespace.List error: %s", err)
 		return resp, fmt.Errorf("RegistryNamespace.List error: %s", err)
 	}
-	var aesKey *GetAesKeyFromEncryptedKeyResp
-	if len(encryptedKey) > 0 {
-		aesKey, err = GetAesKeyFromEncryptedKey(encryptedKey, log)
-		if err != nil {
-			log.Errorf("RegistryNamespace.List GetAesKeyFromEncryptedKey error: %s", err)
-			return nil, err
-		}
-	}
 	if !getRealCredential {
-		if len(encryptedKey) > 0 {
-			for _, reg := range resp {
-				reg.SecretKey, err = crypto.AesEncryptByKey(reg.SecretKey, aesKey.PlainText)
-				if err != nil {
-					log.Errorf("RegistryNamespace.List AesEncryptByKey error: %s", err)
-					return nil, err
-				}
-			}
-		}
 		return resp, nil
 	}
 
@@ -139,16 +122,43 @@ func ListRegistryNamespaces(encryptedKey string, getRealCredential bool, log *za
 			reg.AccessKey = realAK
 			reg.SecretKey = realSK
 		}
-		if len(encryptedKey) == 0 {
+	}
+	return resp, nil
+}
+
+// FindImageRegistry returns the integrated registry the image is pushed to
+func FindImageRegistry(image string, log *zap.SugaredLogger) (reference.Named, *models.RegistryNamespace, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, nil, err
+	}
+	regs, err := ListRegistryNamespaces(true, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	reg := findImageRegistry(reference.Domain(named), reference.Path(named), regs)
+	if reg == nil {
+		return nil, nil, fmt.Errorf("registry of image %s is not integrated", image)
+	}
+	return named, reg, nil
+}
+
+// findImageRegistry returns the registry the image is pushed to, registries with a matched namespace are preferred
+func findImageRegistry(domain, path string, regs []*models.RegistryNamespace) *models.RegistryNamespace {
+	var matched *models.RegistryNamespace
+	for _, reg := range regs {
+		host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(reg.RegAddr, "https://"), "http://"), "/")
+		if host != domain {
 			continue
 		}
-		reg.SecretKey, err = crypto.AesEncryptByKey(reg.SecretKey, aesKey.PlainText)
-		if err != nil {
-			log.Errorf("RegistryNamespace.List AesEncryptByKey error: %s", err)
-			return nil, err
+		if reg.Namespace != "" && strings.HasPrefix(path, reg.Namespace+"/") {
+			return reg
+		}
+		if matched == nil {
+			matched = reg
 		}
 	}
-	return resp, nil
+	return matched
 }
 
 func EnsureDefaultRegistrySecret(namespace string, registryId string, kubeClient client.Client, log *zap.SugaredLogger) error {
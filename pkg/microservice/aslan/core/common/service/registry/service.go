@@ -35,6 +35,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/distribution/registry/client"
@@ -180,6 +181,16 @@ func (c *authClient) getRepository(repoName string) (repo distribution.Repositor
 		return
 	}
 
+	repo, err = client.NewRepository(c.ctx, repoNameRef, c.endpointURL.String(), c.repositoryTransport(repoName))
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// repositoryTransport returns a transport which is authorized to pull from the repository
+func (c *authClient) repositoryTransport(repoName string) http.RoundTripper {
 	creds := registry.NewStaticCredentialStore(&types.AuthConfig{
 		Username:      c.endpoint.Ak,
 		Password:      c.endpoint.Sk,
@@ -202,14 +213,7 @@ func (c *authClient) getRepository(repoName string) (repo distribution.Repositor
 
 	tokenHandler := auth.NewTokenHandlerWithOptions(tokenHandlerOptions)
 	modifier := auth.NewAuthorizer(c.cm, tokenHandler, basicHandler)
-	tr := transport.NewTransport(c.tr, modifier)
-
-	repo, err = client.NewRepository(c.ctx, repoNameRef, c.endpointURL.String(), tr)
-	if err != nil {
-		return
-	}
-
-	return
+	return transport.NewTransport(c.tr, modifier)
 }
 
 func (c *authClient) listTags(repoName string) (tags []string, err error) {
@@ -253,6 +257,18 @@ func (c *authClient) getImageInfo(repoName, tag string) (ci *containerInfo, err
 		return
 	}
 
+	// the info of a multi-arch image is read from one of its platforms, the digest is still the one of the list
+	if list, ok := m.(*manifestlist.DeserializedManifestList); ok {
+		if len(list.Manifests) == 0 {
+			err = errors.New("empty manifest list")
+			return
+		}
+		m, err = manifestService.Get(c.ctx, defaultPlatformManifest(list.Manifests).Digest)
+		if err != nil {
+			return
+		}
+	}
+
 	// 只支持schema2
 	v2, ok := m.(*schema2.DeserializedManifest)
 	if !ok {
@@ -286,6 +302,16 @@ func (c *authClient) getImageInfo(repoName, tag string) (ci *containerInfo, err
 	return
 }
 
+// defaultPlatformManifest returns the manifest of linux/amd64, or the first one if there is no such platform
+func defaultPlatformManifest(manifests []manifestlist.ManifestDescriptor) manifestlist.ManifestDescriptor {
+	for _, m := range manifests {
+		if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+			return m
+		}
+	}
+	return manifests[0]
+}
+
 func (s *v2RegistryService) GetImageInfo(option GetRepoImageDetailOption, log *zap.SugaredLogger) (di *commonmodels.DeliveryImage, err error) {
 	cli, err := s.createClient(option.Endpoint, log)
 	if err != nil {
@@ -0,0 +1,183 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/tool/cosign"
+)
+
+const (
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexMediaType    = "application/vnd.oci.image.index.v1+json"
+)
+
+type signatureManifest struct {
+	Layers []struct {
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+// GetImageSignatures resolves the digest of the image and reads the cosign signatures attached to it,
+// the registry is accessed by the docker registry v2 api, so the endpoint must hold docker login credentials
+func GetImageSignatures(option GetRepoImageDetailOption, tlsEnabled bool, tlsCert string, log *zap.SugaredLogger) (string, []*cosign.Signature, error) {
+	s := &v2RegistryService{EnableHTTPS: tlsEnabled, CustomCert: tlsCert}
+	cli, err := s.createClient(option.Endpoint, log)
+	if err != nil {
+		return "", nil, err
+	}
+
+	repoName := option.Image
+	if option.Namespace != "" {
+		repoName = strings.Join([]string{option.Namespace, option.Image}, "/")
+	}
+	httpClient := &http.Client{Transport: cli.repositoryTransport(repoName)}
+
+	dgst, _, err := cli.getManifest(httpClient, repoName, option.Tag)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to resolve the digest of %s:%s: %s", repoName, option.Tag, err)
+	}
+
+	_, body, err := cli.getManifest(httpClient, repoName, cosign.SignatureTag(dgst))
+	if err == errManifestNotFound {
+		return dgst, nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get the signatures of %s@%s: %s", repoName, dgst, err)
+	}
+
+	manifest := &signatureManifest{}
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return "", nil, err
+	}
+
+	var signatures []*cosign.Signature
+	for _, layer := range manifest.Layers {
+		sig, ok := layer.Annotations[cosign.SignatureAnnotation]
+		if !ok {
+			continue
+		}
+		payload, err := cli.getBlob(httpClient, repoName, layer.Digest)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get the signature payload %s: %s", layer.Digest, err)
+		}
+		signatures = append(signatures, &cosign.Signature{Payload: payload, Signature: sig})
+	}
+
+	return dgst, signatures, nil
+}
+
+// ImageExists checks whether the manifest of the image is still in the registry
+func ImageExists(option GetRepoImageDetailOption, tlsEnabled bool, tlsCert string, log *zap.SugaredLogger) (bool, error) {
+	s := &v2RegistryService{EnableHTTPS: tlsEnabled, CustomCert: tlsCert}
+	cli, err := s.createClient(option.Endpoint, log)
+	if err != nil {
+		return false, err
+	}
+
+	repoName := option.Image
+	if option.Namespace != "" {
+		repoName = strings.Join([]string{option.Namespace, option.Image}, "/")
+	}
+	httpClient := &http.Client{Transport: cli.repositoryTransport(repoName)}
+
+	req, err := http.NewRequest(http.MethodHead, fmt.Sprintf("%s/v2/%s/manifests/%s", strings.TrimSuffix(cli.endpointURL.String(), "/"), repoName, option.Tag), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", strings.Join([]string{
+		schema2.MediaTypeManifest, manifestlist.MediaTypeManifestList, ociManifestMediaType, ociIndexMediaType,
+	}, ", "))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+var errManifestNotFound = errors.New("manifest not found")
+
+// getManifest returns the digest and the raw content of the manifest, OCI manifests are accepted as well
+// since signatures are always stored as OCI manifests
+func (c *authClient) getManifest(httpClient *http.Client, repoName, reference string) (string, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v2/%s/manifests/%s", strings.TrimSuffix(c.endpointURL.String(), "/"), repoName, reference), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Accept", strings.Join([]string{
+		schema2.MediaTypeManifest, manifestlist.MediaTypeManifestList, ociManifestMediaType, ociIndexMediaType,
+	}, ", "))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil, errManifestNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dgst := resp.Header.Get("Docker-Content-Digest")
+	if dgst == "" {
+		dgst = digest.FromBytes(body).String()
+	}
+	return dgst, body, nil
+}
+
+func (c *authClient) getBlob(httpClient *http.Client, repoName, dgst string) ([]byte, error) {
+	resp, err := httpClient.Get(fmt.Sprintf("%s/v2/%s/blobs/%s", strings.TrimSuffix(c.endpointURL.String(), "/"), repoName, dgst))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
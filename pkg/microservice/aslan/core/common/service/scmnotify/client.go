@@ -17,6 +17,7 @@ limitations under the License.
 package scmnotify
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -27,9 +28,12 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	githubservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/github"
+	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
 	"github.com/koderover/zadig/pkg/tool/gerrit"
 	gitlabtool "github.com/koderover/zadig/pkg/tool/git/gitlab"
+	"github.com/koderover/zadig/pkg/tool/gitee"
 	"github.com/koderover/zadig/pkg/tool/log"
 )
 
@@ -160,3 +164,68 @@ func (c *Client) Comment(notify *models.Notification) error {
 
 	return nil
 }
+
+// CommentPreviewEnv creates or updates the comment of the preview environment in its pull request,
+// the comment id is set in preview after it is created
+func (c *Client) CommentPreviewEnv(preview *models.PreviewEnv, comment string) error {
+	codeHostDetail, err := systemconfig.New().GetCodeHost(preview.CodehostID)
+	if err != nil {
+		return errors.Wrapf(err, "codehost %d not found to comment", preview.CodehostID)
+	}
+
+	switch strings.ToLower(codeHostDetail.Type) {
+	case setting.SourceFromGitlab:
+		cli, err := gitlabtool.NewClient(codeHostDetail.Address, codeHostDetail.AccessToken, config.ProxyHTTPSAddr(), codeHostDetail.EnableProxy)
+		if err != nil {
+			return fmt.Errorf("create gitlab client failed err: %v", err)
+		}
+		projectID := strings.TrimLeft(preview.RepoOwner+"/"+preview.RepoName, "/")
+		if preview.CommentID == "" {
+			note, _, err := cli.Notes.CreateMergeRequestNote(projectID, preview.PrID, &gitlab.CreateMergeRequestNoteOptions{Body: &comment})
+			if err != nil {
+				return fmt.Errorf("failed to comment gitlab due to %s/%d %v", projectID, preview.PrID, err)
+			}
+			preview.CommentID = strconv.Itoa(note.ID)
+			return nil
+		}
+		noteID, _ := strconv.Atoi(preview.CommentID)
+		if _, _, err = cli.Notes.UpdateMergeRequestNote(projectID, preview.PrID, noteID, &gitlab.UpdateMergeRequestNoteOptions{Body: &comment}); err != nil {
+			return fmt.Errorf("failed to comment gitlab due to %s/%d %v", projectID, preview.PrID, err)
+		}
+
+	case setting.SourceFromGithub:
+		cli := githubservice.NewClient(codeHostDetail.AccessToken, config.ProxyHTTPSAddr(), codeHostDetail.EnableProxy)
+		if preview.CommentID == "" {
+			note, err := cli.CreatePullRequestComment(context.TODO(), preview.RepoOwner, preview.RepoName, preview.PrID, comment)
+			if err != nil {
+				return fmt.Errorf("failed to comment github due to %s/%s/%d %v", preview.RepoOwner, preview.RepoName, preview.PrID, err)
+			}
+			preview.CommentID = strconv.FormatInt(note.GetID(), 10)
+			return nil
+		}
+		commentID, _ := strconv.ParseInt(preview.CommentID, 10, 64)
+		if err = cli.EditPullRequestComment(context.TODO(), preview.RepoOwner, preview.RepoName, commentID, comment); err != nil {
+			return fmt.Errorf("failed to comment github due to %s/%s/%d %v", preview.RepoOwner, preview.RepoName, preview.PrID, err)
+		}
+
+	case setting.SourceFromGitee:
+		cli := gitee.NewClient(codeHostDetail.ID, codeHostDetail.AccessToken, config.ProxyHTTPSAddr(), codeHostDetail.EnableProxy)
+		if preview.CommentID == "" {
+			note, err := cli.CreatePullRequestComment(context.TODO(), preview.RepoOwner, preview.RepoName, preview.PrID, comment)
+			if err != nil {
+				return fmt.Errorf("failed to comment gitee due to %s/%s/%d %v", preview.RepoOwner, preview.RepoName, preview.PrID, err)
+			}
+			preview.CommentID = strconv.Itoa(int(note.Id))
+			return nil
+		}
+		commentID, _ := strconv.Atoi(preview.CommentID)
+		if err = cli.UpdatePullRequestComment(context.TODO(), preview.RepoOwner, preview.RepoName, commentID, comment); err != nil {
+			return fmt.Errorf("failed to comment gitee due to %s/%s/%d %v", preview.RepoOwner, preview.RepoName, preview.PrID, err)
+		}
+
+	default:
+		return fmt.Errorf("%s source not supported to comment", codeHostDetail.Type)
+	}
+
+	return nil
+}
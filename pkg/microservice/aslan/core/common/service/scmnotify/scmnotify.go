@@ -514,3 +514,21 @@ func (s *Service) UpdateEnvAndTaskWebhookComment(workflowArgs *models.WorkflowTa
 
 	return nil
 }
+
+// UpdatePreviewEnvComment shows how to access the preview environment in its pull request, all updates of the
+// preview environment are shown in the same comment
+func (s *Service) UpdatePreviewEnvComment(preview *models.PreviewEnv, comment string, logger *zap.SugaredLogger) error {
+	commentID := preview.CommentID
+	if err := s.Client.CommentPreviewEnv(preview, comment); err != nil {
+		logger.Errorf("UpdatePreviewEnvComment failed to comment env %s in %s/%s#%d, %v", preview.EnvName, preview.RepoOwner, preview.RepoName, preview.PrID, err)
+		return err
+	}
+
+	if preview.CommentID != commentID && !preview.ID.IsZero() {
+		if err := mongodb.NewPreviewEnvColl().UpdateCommentID(preview.ID.Hex(), preview.CommentID); err != nil {
+			logger.Errorf("UpdatePreviewEnvComment failed to save comment id of env %s, %v", preview.EnvName, err)
+			return err
+		}
+	}
+	return nil
+}
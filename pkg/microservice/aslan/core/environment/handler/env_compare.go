@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// CompareEnvs compares env :name with env :targetName, the target env is in the same project
+// unless targetProjectName is given. The policy only covers the base env, the permission of the
+// target env is checked by the service.
+func CompareEnvs(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := &service.CompareEnvsArgs{
+		BaseProjectName:   c.Query("projectName"),
+		BaseEnvName:       c.Param("name"),
+		TargetProjectName: c.Query("targetProjectName"),
+		TargetEnvName:     c.Param("targetName"),
+		UserID:            ctx.UserID,
+	}
+	if args.BaseProjectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+	if args.TargetProjectName == "" {
+		args.TargetProjectName = args.BaseProjectName
+	}
+
+	ctx.Resp, ctx.Err = service.CompareEnvs(args, ctx.Logger)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+func ListEnvSnapshots(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.ListEnvSnapshots(c.Param("name"), projectName, ctx.Logger)
+}
+
+func CreateEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	envName := c.Param("name")
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	args := new(service.CreateEnvSnapshotArgs)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "新增", "环境-快照", fmt.Sprintf("环境名称:%s", envName), "", ctx.Logger)
+	ctx.Resp, ctx.Err = service.CreateEnvSnapshot(envName, projectName, ctx.UserName, args, ctx.Logger)
+}
+
+func GetEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.GetEnvSnapshot(c.Param("name"), projectName, c.Param("id"), ctx.Logger)
+}
+
+func DeleteEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	envName := c.Param("name")
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "删除", "环境-快照", fmt.Sprintf("环境名称:%s,快照:%s", envName, c.Param("id")), "", ctx.Logger)
+	ctx.Err = service.DeleteEnvSnapshot(envName, projectName, c.Param("id"), ctx.Logger)
+}
+
+func RestoreEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	envName := c.Param("name")
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "恢复", "环境-快照", fmt.Sprintf("环境名称:%s,快照:%s", envName, c.Param("id")), "", ctx.Logger)
+	ctx.Err = service.RestoreEnvSnapshot(envName, projectName, c.Param("id"), ctx.UserName, ctx.RequestID, ctx.Logger)
+}
+
+// CopyEnvSnapshot restores the snapshot of env :name into a new env, which is authorized as creating an env
+func CopyEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	envName := c.Param("name")
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	args := new(service.RestoreEnvSnapshotArgs)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "复制", "环境-快照", fmt.Sprintf("环境名称:%s,快照:%s,目标环境:%s", envName, c.Param("id"), args.EnvName), "", ctx.Logger)
+	ctx.Err = service.CopyEnvSnapshot(envName, projectName, c.Param("id"), ctx.UserID, ctx.UserName, ctx.RequestID, args, ctx.Logger)
+}
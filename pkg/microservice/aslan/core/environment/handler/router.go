@@ -61,6 +61,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	cron := router.Group("cron")
 	{
 		cron.GET("/cleanproduct", CleanProductCronJob)
+		cron.GET("/drift", DetectEnvDriftCronJob)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -151,6 +152,25 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		environments.GET("/:name/check/sharenv/:op/ready", CheckShareEnvReady)
 
 		environments.GET("/:name/services/:serviceName/pmexec", ConnectSshPmExec)
+
+		environments.GET("/:name/drift", GetEnvDrift)
+		environments.POST("/:name/drift/detect", DetectEnvDrift)
+		environments.POST("/:name/drift/reconcile", gin2.UpdateOperationLogStatus, ReconcileEnvDrift)
+
+		environments.GET("/:name/compare/:targetName", CompareEnvs)
+
+		environments.GET("/:name/snapshots", ListEnvSnapshots)
+		environments.POST("/:name/snapshots", gin2.UpdateOperationLogStatus, CreateEnvSnapshot)
+		environments.GET("/:name/snapshots/:id", GetEnvSnapshot)
+		environments.DELETE("/:name/snapshots/:id", gin2.UpdateOperationLogStatus, DeleteEnvSnapshot)
+		environments.POST("/:name/snapshots/:id/restore", gin2.UpdateOperationLogStatus, RestoreEnvSnapshot)
+		environments.POST("/:name/snapshots/:id/copy", gin2.UpdateOperationLogStatus, CopyEnvSnapshot)
+
+		environments.GET("/:name/lifecycle", GetEnvLifecycle)
+		environments.PUT("/:name/lifecycle", gin2.UpdateOperationLogStatus, UpdateEnvLifecycle)
+		environments.POST("/:name/sleep", gin2.UpdateOperationLogStatus, SleepEnv)
+		environments.POST("/:name/wake", gin2.UpdateOperationLogStatus, WakeEnv)
+		environments.POST("/:name/access", AccessEnv)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -19,11 +19,14 @@ package service
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
@@ -459,3 +462,130 @@ func checkExistInList(target string, arrList []string) bool {
 	}
 	return false
 }
+
+const secretTypeHelmRelease = "helm.sh/release.v1"
+
+// envConfigFields are the fields which make up the content of the common env configs of each kind
+var envConfigFields = map[string][]string{
+	setting.ConfigMap:             {"data", "binaryData"},
+	setting.Secret:                {"type", "data"},
+	setting.Ingress:               {"spec"},
+	setting.PersistentVolumeClaim: {"spec"},
+}
+
+// listEnvConfigObjects lists the common env configs in the namespace of the environment, only the name, labels,
+// annotations and content of each object are kept so that they can be applied to any environment
+func listEnvConfigObjects(prod *models.Product, log *zap.SugaredLogger) ([]*unstructured.Unstructured, error) {
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	cliSet, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	version, err := cliSet.Discovery().ServerVersion()
+	if err != nil {
+		log.Errorf("Failed to get server version info for cluster: %s, the error is: %s", prod.ClusterID, err)
+		return nil, err
+	}
+
+	var resp []*unstructured.Unstructured
+	add := func(apiVersion, kind string, obj client.Object) error {
+		u, err := newEnvConfigObject(apiVersion, kind, obj)
+		if err != nil {
+			return err
+		}
+		resp = append(resp, u)
+		return nil
+	}
+
+	cms, err := getter.ListConfigMaps(prod.Namespace, labels.Set{setting.ProductLabel: prod.ProductName}.AsSelector(), kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range cms {
+		if cm.GetLabels()[setting.ConfigBackupLabel] == setting.LabelValueTrue {
+			continue
+		}
+		if err := add("v1", setting.ConfigMap, cm); err != nil {
+			return nil, err
+		}
+	}
+
+	secrets, err := getter.ListSecrets(prod.Namespace, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets {
+		if secret.Type == corev1.SecretTypeServiceAccountToken || secret.Type == secretTypeHelmRelease {
+			continue
+		}
+		if err := add("v1", setting.Secret, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	ingresses, err := getter.ListIngresses(prod.Namespace, kubeClient, kubeclient.VersionLessThan122(version))
+	if err != nil {
+		return nil, err
+	}
+	for i := range ingresses.Items {
+		if err := add(ingresses.Items[i].GetAPIVersion(), setting.Ingress, &ingresses.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	pvcs, err := getter.ListPvcs(prod.Namespace, nil, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, pvc := range pvcs {
+		// the bound volume belongs to the original environment
+		pvc.Spec.VolumeName = ""
+		if err := add("v1", setting.PersistentVolumeClaim, pvc); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+func newEnvConfigObject(apiVersion, kind string, obj client.Object) (*unstructured.Unstructured, error) {
+	var content map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = u.Object
+	} else {
+		var err error
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &unstructured.Unstructured{Object: make(map[string]interface{})}
+	resp.SetAPIVersion(apiVersion)
+	resp.SetKind(kind)
+	resp.SetName(obj.GetName())
+	resp.SetLabels(obj.GetLabels())
+
+	annotations := make(map[string]string)
+	for k, v := range obj.GetAnnotations() {
+		// annotations set by kubectl and the volume controllers are not part of the config
+		if k == corev1.LastAppliedConfigAnnotation || strings.HasPrefix(k, "pv.kubernetes.io/") ||
+			strings.HasPrefix(k, "volume.beta.kubernetes.io/") || strings.HasPrefix(k, "volume.kubernetes.io/") {
+			continue
+		}
+		annotations[k] = v
+	}
+	if len(annotations) > 0 {
+		resp.SetAnnotations(annotations)
+	}
+
+	for _, field := range envConfigFields[kind] {
+		if v, ok := content[field]; ok {
+			resp.Object[field] = v
+		}
+	}
+	return resp, nil
+}
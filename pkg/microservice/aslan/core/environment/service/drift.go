@@ -0,0 +1,450 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	versionedclient "istio.io/client-go/pkg/clientset/versioned"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/pkg/tool/helmclient"
+	"github.com/koderover/zadig/pkg/tool/kube/diff"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/informer"
+	"github.com/koderover/zadig/pkg/tool/kube/serializer"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	"github.com/koderover/zadig/pkg/tool/kube/util"
+)
+
+// serviceManifests are the objects which are expected to be running for a service
+type serviceManifests struct {
+	serviceName string
+	releaseName string
+	objects     []*unstructured.Unstructured
+	err         error
+}
+
+type ReconcileEnvDriftArgs struct {
+	ServiceNames []string `json:"service_names"`
+}
+
+// GetEnvDrift returns the latest drift detection result of the environment
+func GetEnvDrift(envName, productName string, log *zap.SugaredLogger) (*commonmodels.EnvDrift, error) {
+	resp, err := commonrepo.NewEnvDriftColl().Find(productName, envName)
+	if err != nil {
+		if commonrepo.IsErrNoDocuments(err) {
+			return &commonmodels.EnvDrift{ProductName: productName, EnvName: envName, Services: []*commonmodels.ServiceDrift{}}, nil
+		}
+		log.Errorf("[%s][%s] failed to find env drift: %s", envName, productName, err)
+		return nil, e.ErrDetectEnvDrift.AddErr(err)
+	}
+	return resp, nil
+}
+
+// DetectEnvDrift compares the expected manifests of every service with the live objects in the cluster,
+// and saves the result as the latest drift of the environment
+func DetectEnvDrift(envName, productName string, log *zap.SugaredLogger) (*commonmodels.EnvDrift, error) {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		log.Errorf("[%s][%s] failed to find env: %s", envName, productName, err)
+		return nil, e.ErrDetectEnvDrift.AddErr(err)
+	}
+	if prod.Source == setting.SourceFromExternal || prod.Source == setting.SourceFromPM {
+		return nil, e.ErrDetectEnvDrift.AddDesc("drift detection is only supported for k8s and helm environments")
+	}
+	sleeping, err := envSleeping(productName, envName)
+	if err != nil {
+		log.Errorf("[%s][%s] failed to find env lifecycle: %s", envName, productName, err)
+		return nil, e.ErrDetectEnvDrift.AddErr(err)
+	}
+	if sleeping {
+		return nil, e.ErrDetectEnvDrift.AddDesc(envSleepingMsg)
+	}
+
+	resp, err := detectEnvDrift(prod, log)
+	if err != nil {
+		log.Errorf("[%s][%s] failed to detect env drift: %s", envName, productName, err)
+		return nil, e.ErrDetectEnvDrift.AddErr(err)
+	}
+	if err := commonrepo.NewEnvDriftColl().Upsert(resp); err != nil {
+		log.Errorf("[%s][%s] failed to save env drift: %s", envName, productName, err)
+		return nil, e.ErrDetectEnvDrift.AddErr(err)
+	}
+	return resp, nil
+}
+
+// DetectEnvDriftCronJob detects the drift of all k8s and helm environments
+func DetectEnvDriftCronJob(log *zap.SugaredLogger) {
+	log.Info("[DetectEnvDriftCronJob] started ...")
+	defer log.Info("[DetectEnvDriftCronJob] end")
+
+	products, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{})
+	if err != nil {
+		log.Errorf("[Product.List] error: %v", err)
+		return
+	}
+	lifecycles, err := listEnvLifecycles()
+	if err != nil {
+		log.Errorf("[EnvLifecycle.List] error: %v", err)
+		return
+	}
+
+	for _, prod := range products {
+		if prod.Source == setting.SourceFromExternal || prod.Source == setting.SourceFromPM {
+			continue
+		}
+		if lifecycle, ok := lifecycles[envLifecycleKey(prod.ProductName, prod.EnvName)]; ok && lifecycle.Sleeping {
+			continue
+		}
+		switch prod.Status {
+		case setting.ProductStatusCreating, setting.ProductStatusUpdating, setting.ProductStatusDeleting:
+			continue
+		}
+
+		if _, err := DetectEnvDrift(prod.EnvName, prod.ProductName, log); err != nil {
+			log.Warnf("[%s][%s] failed to detect env drift: %s", prod.EnvName, prod.ProductName, err)
+		}
+	}
+}
+
+// ReconcileEnvDrift applies the expected manifests of the given services again to revert the drift,
+// all drifted services are reconciled if serviceNames is empty
+func ReconcileEnvDrift(envName, productName string, serviceNames []string, log *zap.SugaredLogger) (*commonmodels.EnvDrift, error) {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		log.Errorf("[%s][%s] failed to find env: %s", envName, productName, err)
+		return nil, e.ErrReconcileEnvDrift.AddErr(err)
+	}
+	switch prod.Status {
+	case setting.ProductStatusCreating, setting.ProductStatusUpdating, setting.ProductStatusDeleting:
+		return nil, e.ErrReconcileEnvDrift.AddDesc(e.EnvCantUpdatedMsg)
+	}
+	sleeping, err := envSleeping(productName, envName)
+	if err != nil {
+		log.Errorf("[%s][%s] failed to find env lifecycle: %s", envName, productName, err)
+		return nil, e.ErrReconcileEnvDrift.AddErr(err)
+	}
+	if sleeping {
+		return nil, e.ErrReconcileEnvDrift.AddDesc(envSleepingMsg)
+	}
+
+	names := sets.NewString(serviceNames...)
+	if names.Len() == 0 {
+		drift, err := GetEnvDrift(envName, productName, log)
+		if err != nil {
+			return nil, err
+		}
+		for _, svc := range drift.Services {
+			if len(svc.Objects) > 0 {
+				names.Insert(svc.ServiceName)
+			}
+		}
+	}
+
+	if names.Len() > 0 {
+		if prod.Source == setting.SourceFromHelm {
+			err = reconcileHelmServices(prod, names, log)
+		} else {
+			err = reconcileK8sServices(prod, names, log)
+		}
+		if err != nil {
+			log.Errorf("[%s][%s] failed to reconcile services %v: %s", envName, productName, names.List(), err)
+			return nil, e.ErrReconcileEnvDrift.AddErr(err)
+		}
+	}
+
+	return DetectEnvDrift(envName, productName, log)
+}
+
+// envSleepingMsg is returned for sleeping environments, their workloads are scaled to zero on purpose
+// which must not be reported or reverted as drift
+const envSleepingMsg = "the environment is sleeping, wake it up first"
+
+func envSleeping(productName, envName string) (bool, error) {
+	lifecycle, err := findEnvLifecycle(productName, envName)
+	if err != nil {
+		return false, err
+	}
+	return lifecycle.Sleeping, nil
+}
+
+func detectEnvDrift(prod *commonmodels.Product, log *zap.SugaredLogger) (*commonmodels.EnvDrift, error) {
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests, err := expectedManifests(prod, log)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &commonmodels.EnvDrift{
+		ProductName: prod.ProductName,
+		EnvName:     prod.EnvName,
+		Services:    make([]*commonmodels.ServiceDrift, 0, len(manifests)),
+		DetectTime:  time.Now().Unix(),
+	}
+	for _, m := range manifests {
+		svcDrift := &commonmodels.ServiceDrift{
+			ServiceName: m.serviceName,
+			ReleaseName: m.releaseName,
+			Objects:     make([]*commonmodels.ObjectDrift, 0),
+		}
+		resp.Services = append(resp.Services, svcDrift)
+		if m.err != nil {
+			svcDrift.Error = m.err.Error()
+			continue
+		}
+
+		for _, u := range m.objects {
+			objDrift, err := detectObjectDrift(u, prod.Namespace, kubeClient)
+			if err != nil {
+				svcDrift.Error = fmt.Sprintf("failed to get %s %s: %s", u.GetKind(), u.GetName(), err)
+				break
+			}
+			if objDrift.Missing || len(objDrift.Fields) > 0 {
+				svcDrift.Objects = append(svcDrift.Objects, objDrift)
+			}
+		}
+		if len(svcDrift.Objects) > 0 {
+			resp.Drifted = true
+		}
+	}
+	return resp, nil
+}
+
+func detectObjectDrift(expected *unstructured.Unstructured, namespace string, kubeClient client.Client) (*commonmodels.ObjectDrift, error) {
+	resp := &commonmodels.ObjectDrift{Kind: expected.GetKind(), Name: expected.GetName()}
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(expected.GroupVersionKind())
+	found, err := getter.GetResourceInCache(namespace, expected.GetName(), live, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		resp.Missing = true
+		return resp, nil
+	}
+
+	// the last applied configuration tells whether a field is changed out of band,
+	// objects deployed by helm do not have it
+	var lastApplied map[string]interface{}
+	if original, err := util.GetOriginalConfiguration(live); err == nil && len(original) > 0 {
+		if err := json.Unmarshal(original, &lastApplied); err != nil {
+			lastApplied = nil
+		}
+	}
+
+	resp.Fields = diff.Drift(expected.Object, lastApplied, live.Object)
+	return resp, nil
+}
+
+// expectedManifests renders the manifests of every service in the environment,
+// the service template with the render set for k8s services and the release manifest for helm services
+func expectedManifests(prod *commonmodels.Product, log *zap.SugaredLogger) ([]*serviceManifests, error) {
+	if prod.Source == setting.SourceFromHelm {
+		return expectedHelmManifests(prod)
+	}
+	return expectedK8sManifests(prod, log)
+}
+
+func expectedK8sManifests(prod *commonmodels.Product, log *zap.SugaredLogger) ([]*serviceManifests, error) {
+	renderSet := &commonmodels.RenderSet{}
+	if prod.Render != nil {
+		var err error
+		renderSet, err = commonservice.GetRenderSet(prod.Render.Name, prod.Render.Revision, log)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var resp []*serviceManifests
+	for _, group := range prod.Services {
+		for _, svc := range group {
+			if svc.Type != setting.K8SDeployType {
+				continue
+			}
+			m := &serviceManifests{serviceName: svc.ServiceName}
+			resp = append(resp, m)
+
+			parsedYaml, err := renderService(prod, renderSet, svc)
+			if err != nil {
+				m.err = fmt.Errorf("failed to render service: %s", err)
+				continue
+			}
+			m.objects, m.err = decodeManifests(*parsedYaml)
+		}
+	}
+	return resp, nil
+}
+
+func expectedHelmManifests(prod *commonmodels.Product) ([]*serviceManifests, error) {
+	restConfig, err := kube.GetRESTConfig(prod.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	helmClient, err := helmtool.NewClientFromRestConf(restConfig, prod.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := helmClient.ListDeployedReleases()
+	if err != nil {
+		return nil, err
+	}
+	manifestMap := make(map[string]string, len(releases))
+	for _, release := range releases {
+		manifestMap[release.Name] = release.Manifest
+	}
+
+	releaseNameMap, err := commonservice.GetReleaseNameToServiceNameMap(prod)
+	if err != nil {
+		return nil, err
+	}
+	serviceMap := prod.GetServiceMap()
+	releaseNames := make([]string, 0, len(releaseNameMap))
+	for releaseName, serviceName := range releaseNameMap {
+		if _, ok := serviceMap[serviceName]; ok {
+			releaseNames = append(releaseNames, releaseName)
+		}
+	}
+	sort.Strings(releaseNames)
+
+	var resp []*serviceManifests
+	for _, releaseName := range releaseNames {
+		m := &serviceManifests{serviceName: releaseNameMap[releaseName], releaseName: releaseName}
+		resp = append(resp, m)
+
+		manifest, ok := manifestMap[releaseName]
+		if !ok {
+			m.err = fmt.Errorf("release %s is not deployed", releaseName)
+			continue
+		}
+		m.objects, m.err = decodeManifests(manifest)
+	}
+	return resp, nil
+}
+
+func decodeManifests(manifest string) ([]*unstructured.Unstructured, error) {
+	manifests := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(manifests))
+	for k := range manifests {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var resp []*unstructured.Unstructured
+	for _, k := range keys {
+		u, err := serializer.NewDecoder().YamlToUnstructured([]byte(manifests[k]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode manifest: %s", err)
+		}
+		resp = append(resp, u)
+	}
+	return resp, nil
+}
+
+func reconcileK8sServices(prod *commonmodels.Product, serviceNames sets.String, log *zap.SugaredLogger) error {
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	restConfig, err := kubeclient.GetRESTConfig(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	istioClient, err := versionedclient.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	cls, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	inf, err := informer.NewInformer(prod.ClusterID, prod.Namespace, cls)
+	if err != nil {
+		return err
+	}
+
+	renderSet := &commonmodels.RenderSet{}
+	if prod.Render != nil {
+		renderSet, err = commonservice.GetRenderSet(prod.Render.Name, prod.Render.Revision, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	serviceMap := prod.GetServiceMap()
+	for _, serviceName := range serviceNames.List() {
+		svc, ok := serviceMap[serviceName]
+		if !ok || svc.Type != setting.K8SDeployType {
+			return fmt.Errorf("service %s is not a k8s service in the environment", serviceName)
+		}
+		if _, err := upsertService(true, prod, svc, nil, renderSet, inf, kubeClient, istioClient, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileHelmServices patches the objects in the release manifests back, the release itself is not upgraded
+func reconcileHelmServices(prod *commonmodels.Product, serviceNames sets.String, log *zap.SugaredLogger) error {
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	manifests, err := expectedHelmManifests(prod)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range manifests {
+		if !serviceNames.Has(m.serviceName) {
+			continue
+		}
+		if m.err != nil {
+			return fmt.Errorf("service %s: %s", m.serviceName, m.err)
+		}
+		for _, u := range m.objects {
+			if u.GetNamespace() == "" {
+				u.SetNamespace(prod.Namespace)
+			}
+			if err := updater.CreateOrPatchUnstructured(u, kubeClient); err != nil {
+				log.Errorf("failed to reconcile %s %s of release %s: %s", u.GetKind(), u.GetName(), m.releaseName, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,486 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/label/config"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/policy"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/pkg/tool/helmclient"
+	"github.com/koderover/zadig/pkg/tool/kube/diff"
+)
+
+type EnvCompareStatus string
+
+const (
+	EnvCompareIdentical    EnvCompareStatus = "identical"
+	EnvCompareChanged      EnvCompareStatus = "changed"
+	EnvCompareOnlyInBase   EnvCompareStatus = "only_in_base"
+	EnvCompareOnlyInTarget EnvCompareStatus = "only_in_target"
+)
+
+const (
+	redactedValue = "******"
+	// getEnvironmentVerb is the policy verb of viewing an environment
+	getEnvironmentVerb = "get_environment"
+)
+
+// sensitiveKeyRegexp matches the keys of variables and helm values which are likely to hold secrets,
+// there is no secret flag for them so the values are redacted by their names
+var sensitiveKeyRegexp = regexp.MustCompile(`(?i)(password|passwd|pwd|secret|token|credential|private_?key|access_?key|api_?key)`)
+
+type CompareEnvsArgs struct {
+	BaseProjectName   string
+	BaseEnvName       string
+	TargetProjectName string
+	TargetEnvName     string
+	// UserID is the user who requests the comparison, who must be able to view the target env
+	UserID string
+}
+
+type EnvCompareEnv struct {
+	ProductName string `json:"product_name"`
+	EnvName     string `json:"env_name"`
+	ClusterID   string `json:"cluster_id"`
+	Namespace   string `json:"namespace"`
+	Source      string `json:"source"`
+}
+
+type EnvCompareSummary struct {
+	Identical            bool     `json:"identical"`
+	ServicesOnlyInBase   []string `json:"services_only_in_base"`
+	ServicesOnlyInTarget []string `json:"services_only_in_target"`
+	ServicesChanged      []string `json:"services_changed"`
+	VariablesChanged     int      `json:"variables_changed"`
+	ConfigsChanged       int      `json:"configs_changed"`
+}
+
+type ServiceCompareResult struct {
+	ServiceName    string           `json:"service_name"`
+	Status         EnvCompareStatus `json:"status"`
+	BaseRevision   int64            `json:"base_revision"`
+	TargetRevision int64            `json:"target_revision"`
+	// Images are the changes of container images, paths are the container names
+	Images []*diff.Change `json:"images"`
+	// Values are the changes of the chart version and the merged values of helm services
+	Values []*diff.Change `json:"values"`
+}
+
+type ConfigCompareResult struct {
+	Kind    string           `json:"kind"`
+	Name    string           `json:"name"`
+	Status  EnvCompareStatus `json:"status"`
+	Changes []*diff.Change   `json:"changes"`
+}
+
+type EnvCompareResult struct {
+	Base     *EnvCompareEnv          `json:"base"`
+	Target   *EnvCompareEnv          `json:"target"`
+	Summary  *EnvCompareSummary      `json:"summary"`
+	Services []*ServiceCompareResult `json:"services"`
+	// Variables are the changes of the render set variables of k8s envs or the default values of helm envs
+	Variables []*diff.Change         `json:"variables"`
+	Configs   []*ConfigCompareResult `json:"configs"`
+}
+
+// envCompareData is everything of an environment which takes part in the comparison
+type envCompareData struct {
+	prod      *commonmodels.Product
+	renderSet *commonmodels.RenderSet
+	// configs are the content of the common env configs keyed by "kind/name"
+	configs map[string]map[string]interface{}
+}
+
+// CompareEnvs compares two environments, which can be in different projects or clusters
+func CompareEnvs(args *CompareEnvsArgs, log *zap.SugaredLogger) (*EnvCompareResult, error) {
+	if err := authorizeCompareTarget(args, log); err != nil {
+		return nil, err
+	}
+
+	base, err := loadEnvCompareData(args.BaseProjectName, args.BaseEnvName, log)
+	if err != nil {
+		log.Errorf("failed to load env %s/%s: %s", args.BaseProjectName, args.BaseEnvName, err)
+		return nil, e.ErrCompareEnvs.AddErr(err)
+	}
+	target, err := loadEnvCompareData(args.TargetProjectName, args.TargetEnvName, log)
+	if err != nil {
+		log.Errorf("failed to load env %s/%s: %s", args.TargetProjectName, args.TargetEnvName, err)
+		return nil, e.ErrCompareEnvs.AddErr(err)
+	}
+
+	services, err := compareEnvServices(base, target)
+	if err != nil {
+		return nil, e.ErrCompareEnvs.AddErr(err)
+	}
+	variables, err := compareEnvVariables(base.renderSet, target.renderSet)
+	if err != nil {
+		return nil, e.ErrCompareEnvs.AddErr(err)
+	}
+
+	resp := &EnvCompareResult{
+		Base:      newEnvCompareEnv(base.prod),
+		Target:    newEnvCompareEnv(target.prod),
+		Services:  services,
+		Variables: variables,
+		Configs:   compareEnvConfigs(base.configs, target.configs),
+	}
+	resp.Summary = summarizeEnvCompare(resp)
+	return resp, nil
+}
+
+// authorizeCompareTarget checks that the user can view the target env, the base env is already
+// authorized by the policy of the api
+func authorizeCompareTarget(args *CompareEnvsArgs, log *zap.SugaredLogger) error {
+	if args.TargetProjectName == args.BaseProjectName && args.TargetEnvName == args.BaseEnvName {
+		return nil
+	}
+
+	allowed, err := hasEnvPermission(args.UserID, args.TargetProjectName, args.TargetEnvName, getEnvironmentVerb)
+	if err != nil {
+		log.Errorf("failed to get the permission of env %s/%s: %s", args.TargetProjectName, args.TargetEnvName, err)
+		return e.ErrCompareEnvs.AddErr(err)
+	}
+	if !allowed {
+		return e.ErrForbidden.AddDesc(fmt.Sprintf("no permission to view env %s/%s", args.TargetProjectName, args.TargetEnvName))
+	}
+	return nil
+}
+
+// hasEnvPermission tells whether the user has the verb on the env, it is used by the apis which touch
+// another env besides the one authorized by their policies
+func hasEnvPermission(uid, projectName, envName, verb string) (bool, error) {
+	perms, err := policy.NewDefault().GetResourcePermission(&policy.ResourcePermissionReq{
+		ProjectName:  projectName,
+		Uid:          uid,
+		ResourceType: string(config.ResourceTypeProduct),
+		Resources:    []string{envName},
+	})
+	if err != nil {
+		return false, err
+	}
+	return sets.NewString(perms[envName]...).HasAny("*", verb), nil
+}
+
+func newEnvCompareEnv(prod *commonmodels.Product) *EnvCompareEnv {
+	return &EnvCompareEnv{
+		ProductName: prod.ProductName,
+		EnvName:     prod.EnvName,
+		ClusterID:   prod.ClusterID,
+		Namespace:   prod.Namespace,
+		Source:      prod.Source,
+	}
+}
+
+func loadEnvCompareData(productName, envName string, log *zap.SugaredLogger) (*envCompareData, error) {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &envCompareData{prod: prod, renderSet: &commonmodels.RenderSet{}}
+	if prod.Render != nil {
+		resp.renderSet, err = commonservice.GetRenderSet(prod.Render.Name, prod.Render.Revision, log)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if prod.Source == setting.SourceFromPM {
+		return resp, nil
+	}
+	resp.configs, err = listEnvConfigs(prod, log)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// listEnvConfigs lists the common env configs of the environment, only the content of each object is kept
+// since the metadata like namespace, uid and labels always differ between environments
+func listEnvConfigs(prod *commonmodels.Product, log *zap.SugaredLogger) (map[string]map[string]interface{}, error) {
+	objs, err := listEnvConfigObjects(prod, log)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make(map[string]map[string]interface{}, len(objs))
+	for _, obj := range objs {
+		content := make(map[string]interface{})
+		for _, field := range envConfigFields[obj.GetKind()] {
+			if v, ok := obj.Object[field]; ok {
+				content[field] = v
+			}
+		}
+		resp[obj.GetKind()+"/"+obj.GetName()] = content
+	}
+	return resp, nil
+}
+
+func compareEnvServices(base, target *envCompareData) ([]*ServiceCompareResult, error) {
+	baseServices := base.prod.GetServiceMap()
+	targetServices := target.prod.GetServiceMap()
+	serviceNames := sets.NewString()
+	for name := range baseServices {
+		serviceNames.Insert(name)
+	}
+	for name := range targetServices {
+		serviceNames.Insert(name)
+	}
+
+	resp := make([]*ServiceCompareResult, 0, serviceNames.Len())
+	for _, name := range serviceNames.List() {
+		baseSvc, inBase := baseServices[name]
+		targetSvc, inTarget := targetServices[name]
+		result := &ServiceCompareResult{ServiceName: name}
+		resp = append(resp, result)
+
+		switch {
+		case !inTarget:
+			result.Status = EnvCompareOnlyInBase
+			result.BaseRevision = baseSvc.Revision
+			continue
+		case !inBase:
+			result.Status = EnvCompareOnlyInTarget
+			result.TargetRevision = targetSvc.Revision
+			continue
+		}
+
+		result.BaseRevision = baseSvc.Revision
+		result.TargetRevision = targetSvc.Revision
+		result.Images = diff.Compare(containerImages(baseSvc), containerImages(targetSvc))
+
+		baseValues, err := helmServiceValues(base.renderSet, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge values of service %s in env %s: %s", name, base.prod.EnvName, err)
+		}
+		targetValues, err := helmServiceValues(target.renderSet, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge values of service %s in env %s: %s", name, target.prod.EnvName, err)
+		}
+		result.Values = diff.Compare(baseValues, targetValues)
+		redactSensitiveChanges(result.Values)
+
+		result.Status = EnvCompareIdentical
+		if result.BaseRevision != result.TargetRevision || len(result.Images) > 0 || len(result.Values) > 0 {
+			result.Status = EnvCompareChanged
+		}
+	}
+	return resp, nil
+}
+
+func containerImages(svc *commonmodels.ProductService) map[string]interface{} {
+	resp := make(map[string]interface{}, len(svc.Containers))
+	for _, container := range svc.Containers {
+		resp[container.Name] = container.Image
+	}
+	return resp
+}
+
+// helmServiceValues returns the chart version and the values which are finally used to install the chart,
+// it returns nil for k8s services which have no chart in the render set
+func helmServiceValues(renderSet *commonmodels.RenderSet, serviceName string) (map[string]interface{}, error) {
+	for _, chart := range renderSet.ChartInfos {
+		if chart.ServiceName != serviceName {
+			continue
+		}
+		mergedValues, err := helmtool.MergeOverrideValues(chart.ValuesYaml, renderSet.DefaultValues, chart.GetOverrideYaml(), chart.OverrideValues)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]interface{})
+		if err := yaml.Unmarshal([]byte(mergedValues), &values); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"chart_version": chart.ChartVersion, "values": values}, nil
+	}
+	return nil, nil
+}
+
+func compareEnvVariables(base, target *commonmodels.RenderSet) ([]*diff.Change, error) {
+	baseVars, err := renderSetVariables(base)
+	if err != nil {
+		return nil, err
+	}
+	targetVars, err := renderSetVariables(target)
+	if err != nil {
+		return nil, err
+	}
+	changes := diff.Compare(baseVars, targetVars)
+	redactSensitiveChanges(changes)
+	return changes, nil
+}
+
+// renderSetVariables returns the key values of k8s envs and the default values of helm envs
+func renderSetVariables(renderSet *commonmodels.RenderSet) (map[string]interface{}, error) {
+	resp := make(map[string]interface{})
+	for key, value := range renderSet.GetKeyValueMap() {
+		resp[key] = value
+	}
+	if renderSet.DefaultValues != "" {
+		defaultValues := make(map[string]interface{})
+		if err := yaml.Unmarshal([]byte(renderSet.DefaultValues), &defaultValues); err != nil {
+			return nil, err
+		}
+		resp["default_values"] = defaultValues
+	}
+	return resp, nil
+}
+
+func compareEnvConfigs(base, target map[string]map[string]interface{}) []*ConfigCompareResult {
+	keys := sets.NewString()
+	for key := range base {
+		keys.Insert(key)
+	}
+	for key := range target {
+		keys.Insert(key)
+	}
+
+	resp := make([]*ConfigCompareResult, 0, keys.Len())
+	for _, key := range keys.List() {
+		kindName := strings.SplitN(key, "/", 2)
+		kind, name := kindName[0], kindName[1]
+
+		baseObj, inBase := base[key]
+		targetObj, inTarget := target[key]
+		result := &ConfigCompareResult{Kind: kind, Name: name, Status: EnvCompareIdentical}
+		switch {
+		case !inTarget:
+			result.Status = EnvCompareOnlyInBase
+		case !inBase:
+			result.Status = EnvCompareOnlyInTarget
+		default:
+			result.Changes = diff.Compare(baseObj, targetObj)
+			if len(result.Changes) > 0 {
+				result.Status = EnvCompareChanged
+			}
+		}
+		if kind == setting.Secret {
+			redactSecretChanges(result.Changes)
+		}
+		resp = append(resp, result)
+	}
+	return resp
+}
+
+// redactSecretChanges hides the values of secrets, only the fact that a value differs is reported
+func redactSecretChanges(changes []*diff.Change) {
+	for _, change := range changes {
+		if change.Path == "type" {
+			continue
+		}
+		if change.From != nil {
+			change.From = redactedValue
+		}
+		if change.To != nil {
+			change.To = redactedValue
+		}
+	}
+}
+
+// redactSensitiveChanges hides the values of the fields whose keys look like secrets, in the changed
+// field itself or in the added and removed subtrees
+func redactSensitiveChanges(changes []*diff.Change) {
+	for _, change := range changes {
+		if isSensitivePath(change.Path) {
+			if change.From != nil {
+				change.From = redactedValue
+			}
+			if change.To != nil {
+				change.To = redactedValue
+			}
+			continue
+		}
+		change.From = redactSensitiveValue(change.From)
+		change.To = redactSensitiveValue(change.To)
+	}
+}
+
+func isSensitivePath(path string) bool {
+	for _, key := range strings.Split(path, ".") {
+		if i := strings.Index(key, "["); i >= 0 {
+			key = key[:i]
+		}
+		if sensitiveKeyRegexp.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSensitiveValue returns a copy of the value in which the values of sensitive keys are redacted
+func redactSensitiveValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		resp := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			if sensitiveKeyRegexp.MatchString(key) {
+				resp[key] = redactedValue
+				continue
+			}
+			resp[key] = redactSensitiveValue(val)
+		}
+		return resp
+	case []interface{}:
+		resp := make([]interface{}, 0, len(v))
+		for _, val := range v {
+			resp = append(resp, redactSensitiveValue(val))
+		}
+		return resp
+	default:
+		return value
+	}
+}
+
+func summarizeEnvCompare(result *EnvCompareResult) *EnvCompareSummary {
+	resp := &EnvCompareSummary{
+		ServicesOnlyInBase:   make([]string, 0),
+		ServicesOnlyInTarget: make([]string, 0),
+		ServicesChanged:      make([]string, 0),
+		VariablesChanged:     len(result.Variables),
+	}
+	for _, svc := range result.Services {
+		switch svc.Status {
+		case EnvCompareOnlyInBase:
+			resp.ServicesOnlyInBase = append(resp.ServicesOnlyInBase, svc.ServiceName)
+		case EnvCompareOnlyInTarget:
+			resp.ServicesOnlyInTarget = append(resp.ServicesOnlyInTarget, svc.ServiceName)
+		case EnvCompareChanged:
+			resp.ServicesChanged = append(resp.ServicesChanged, svc.ServiceName)
+		}
+	}
+	for _, cfg := range result.Configs {
+		if cfg.Status != EnvCompareIdentical {
+			resp.ConfigsChanged++
+		}
+	}
+
+	resp.Identical = len(resp.ServicesOnlyInBase) == 0 && len(resp.ServicesOnlyInTarget) == 0 && len(resp.ServicesChanged) == 0 &&
+		resp.VariablesChanged == 0 && resp.ConfigsChanged == 0
+	return resp
+}
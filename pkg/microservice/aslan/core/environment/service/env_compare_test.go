@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+	"github.com/koderover/zadig/pkg/tool/kube/diff"
+)
+
+var _ = Describe("Testing env compare", func() {
+
+	newEnv := func(envName string, services ...*commonmodels.ProductService) *envCompareData {
+		return &envCompareData{
+			prod:      &commonmodels.Product{EnvName: envName, Services: [][]*commonmodels.ProductService{services}},
+			renderSet: &commonmodels.RenderSet{},
+		}
+	}
+	newService := func(name string, revision int64, image string) *commonmodels.ProductService {
+		return &commonmodels.ProductService{
+			ServiceName: name,
+			Revision:    revision,
+			Containers:  []*commonmodels.Container{{Name: name, Image: image}},
+		}
+	}
+
+	It("should compare service membership, revisions and images", func() {
+		base := newEnv("staging", newService("a", 1, "a:v1"), newService("b", 2, "b:v1"), newService("c", 1, "c:v1"))
+		target := newEnv("prod", newService("a", 1, "a:v1"), newService("b", 2, "b:v2"), newService("d", 1, "d:v1"))
+
+		services, err := compareEnvServices(base, target)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(services).To(HaveLen(4))
+		Expect(services[0].Status).To(Equal(EnvCompareIdentical))
+		Expect(services[1].Status).To(Equal(EnvCompareChanged))
+		Expect(services[1].Images).To(Equal([]*diff.Change{{Path: "b", Type: diff.ChangeModified, From: "b:v1", To: "b:v2"}}))
+		Expect(services[2].Status).To(Equal(EnvCompareOnlyInBase))
+		Expect(services[3].Status).To(Equal(EnvCompareOnlyInTarget))
+
+		summary := summarizeEnvCompare(&EnvCompareResult{Services: services})
+		Expect(summary.Identical).To(BeFalse())
+		Expect(summary.ServicesChanged).To(Equal([]string{"b"}))
+		Expect(summary.ServicesOnlyInBase).To(Equal([]string{"c"}))
+		Expect(summary.ServicesOnlyInTarget).To(Equal([]string{"d"}))
+	})
+
+	It("should compare render set variables", func() {
+		base := &commonmodels.RenderSet{KVs: []*templatemodels.RenderKV{{Key: "replicas", Value: "1"}}}
+		target := &commonmodels.RenderSet{KVs: []*templatemodels.RenderKV{{Key: "replicas", Value: "3"}}}
+
+		variables, err := compareEnvVariables(base, target)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(Equal([]*diff.Change{{Path: "replicas", Type: diff.ChangeModified, From: "1", To: "3"}}))
+	})
+
+	It("should redact the values of secrets", func() {
+		base := map[string]map[string]interface{}{
+			"Secret/db":    {"type": "Opaque", "data": map[string]interface{}{"password": "MTIz"}},
+			"ConfigMap/cm": {"data": map[string]interface{}{"mode": "debug"}},
+		}
+		target := map[string]map[string]interface{}{
+			"Secret/db":    {"type": "Opaque", "data": map[string]interface{}{"password": "NDU2"}},
+			"ConfigMap/cm": {"data": map[string]interface{}{"mode": "release"}},
+		}
+
+		configs := compareEnvConfigs(base, target)
+		Expect(configs).To(HaveLen(2))
+		Expect(configs[0].Kind).To(Equal("ConfigMap"))
+		Expect(configs[0].Changes).To(Equal([]*diff.Change{{Path: "data.mode", Type: diff.ChangeModified, From: "debug", To: "release"}}))
+		Expect(configs[1].Kind).To(Equal("Secret"))
+		Expect(configs[1].Name).To(Equal("db"))
+		Expect(configs[1].Status).To(Equal(EnvCompareChanged))
+		Expect(configs[1].Changes).To(Equal([]*diff.Change{{Path: "data.password", Type: diff.ChangeModified, From: redactedValue, To: redactedValue}}))
+	})
+
+	It("should redact variables and values with sensitive keys", func() {
+		base := &commonmodels.RenderSet{KVs: []*templatemodels.RenderKV{{Key: "DB_PASSWORD", Value: "123"}}}
+		target := &commonmodels.RenderSet{
+			KVs:           []*templatemodels.RenderKV{{Key: "DB_PASSWORD", Value: "456"}},
+			DefaultValues: "redis:\n  host: redis\n  auth:\n    token: abc\n",
+		}
+
+		variables, err := compareEnvVariables(base, target)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(ConsistOf(
+			&diff.Change{Path: "DB_PASSWORD", Type: diff.ChangeModified, From: redactedValue, To: redactedValue},
+			&diff.Change{Path: "default_values", Type: diff.ChangeAdded, To: map[string]interface{}{
+				"redis": map[string]interface{}{"host": "redis", "auth": map[string]interface{}{"token": redactedValue}},
+			}},
+		))
+	})
+})
@@ -0,0 +1,434 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"time"
+	// the time zones of the sleep schedules are loaded even if the image has no tzdata
+	_ "time/tzdata"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+const awakeHourLayout = "15:04"
+
+type envTTLStage int
+
+const (
+	envTTLNone envTTLStage = iota
+	// envTTLWarn means the owners should be warned of the TTL action
+	envTTLWarn
+	// envTTLAct means the TTL action should be taken
+	envTTLAct
+)
+
+type EnvLifecycleResp struct {
+	*commonmodels.EnvLifecycle
+	RecycleDay int `json:"recycle_day"`
+	// ActionTime is when the TTL action is taken if the environment stays inactive, 0 means never
+	ActionTime int64 `json:"action_time"`
+}
+
+type UpdateEnvLifecycleArgs struct {
+	TTLAction     commonmodels.EnvTTLAction      `json:"ttl_action"`
+	WarnHours     int                            `json:"warn_hours"`
+	NotifyCtl     *commonmodels.NotifyCtl        `json:"notify_ctl"`
+	SleepSchedule *commonmodels.EnvSleepSchedule `json:"sleep_schedule"`
+	AutoWake      bool                           `json:"auto_wake"`
+}
+
+func GetEnvLifecycle(envName, productName string, log *zap.SugaredLogger) (*EnvLifecycleResp, error) {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		log.Errorf("failed to find env %s of product %s, err: %s", envName, productName, err)
+		return nil, e.ErrGetEnvLifecycle.AddDesc(e.EnvNotFoundErrMsg)
+	}
+	lifecycle, err := findEnvLifecycle(productName, envName)
+	if err != nil {
+		log.Errorf("failed to find lifecycle of env %s, err: %s", envName, err)
+		return nil, e.ErrGetEnvLifecycle.AddErr(err)
+	}
+
+	return &EnvLifecycleResp{
+		EnvLifecycle: lifecycle,
+		RecycleDay:   prod.RecycleDay,
+		ActionTime:   envTTLActionTime(prod, lifecycle),
+	}, nil
+}
+
+func UpdateEnvLifecycle(envName, productName string, args *UpdateEnvLifecycleArgs, log *zap.SugaredLogger) error {
+	if _, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName}); err != nil {
+		log.Errorf("failed to find env %s of product %s, err: %s", envName, productName, err)
+		return e.ErrUpdateEnvLifecycle.AddDesc(e.EnvNotFoundErrMsg)
+	}
+	if err := validateEnvLifecycle(args); err != nil {
+		return e.ErrUpdateEnvLifecycle.AddDesc(err.Error())
+	}
+
+	err := commonrepo.NewEnvLifecycleColl().UpdatePolicy(&commonmodels.EnvLifecycle{
+		ProductName:   productName,
+		EnvName:       envName,
+		TTLAction:     args.TTLAction,
+		WarnHours:     args.WarnHours,
+		NotifyCtl:     args.NotifyCtl,
+		SleepSchedule: args.SleepSchedule,
+		AutoWake:      args.AutoWake,
+	})
+	if err != nil {
+		log.Errorf("failed to update lifecycle of env %s, err: %s", envName, err)
+		return e.ErrUpdateEnvLifecycle.AddErr(err)
+	}
+	return nil
+}
+
+// SleepEnvironment scales the workloads of the environment to zero until it is woken up
+func SleepEnvironment(envName, productName string, log *zap.SugaredLogger) error {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		log.Errorf("failed to find env %s of product %s, err: %s", envName, productName, err)
+		return e.ErrSleepEnv.AddDesc(e.EnvNotFoundErrMsg)
+	}
+	if !sleepSupported(prod) {
+		return e.ErrSleepEnv.AddDesc("only k8s and helm environments can sleep")
+	}
+
+	if err := commonservice.SleepEnv(prod, commonmodels.EnvSleepReasonManual, log); err != nil {
+		log.Errorf("failed to sleep env %s, err: %s", envName, err)
+		return e.ErrSleepEnv.AddErr(err)
+	}
+	return nil
+}
+
+// WakeEnvironment restores the workloads of the sleeping environment, it counts as an activity of the environment
+func WakeEnvironment(envName, productName string, log *zap.SugaredLogger) error {
+	if err := commonrepo.NewEnvLifecycleColl().UpdateActiveTime(productName, envName, time.Now().Unix()); err != nil {
+		log.Errorf("failed to record activity of env %s, err: %s", envName, err)
+		return e.ErrWakeEnv.AddErr(err)
+	}
+	if err := commonservice.WakeEnv(productName, envName, log); err != nil {
+		log.Errorf("failed to wake env %s, err: %s", envName, err)
+		return e.ErrWakeEnv.AddErr(err)
+	}
+	return nil
+}
+
+// AccessEnvironment records that the environment is accessed by a user, the sleeping environment is woken up
+// if auto wake is enabled
+func AccessEnvironment(envName, productName string, log *zap.SugaredLogger) error {
+	if _, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName}); err != nil {
+		log.Errorf("failed to find env %s of product %s, err: %s", envName, productName, err)
+		return e.ErrGetEnv.AddDesc(e.EnvNotFoundErrMsg)
+	}
+	commonservice.TouchEnv(productName, envName, log)
+	return nil
+}
+
+// listEnvLifecycles returns the lifecycles of all environments keyed by project and env name
+func listEnvLifecycles() (map[string]*commonmodels.EnvLifecycle, error) {
+	lifecycles, err := commonrepo.NewEnvLifecycleColl().List()
+	if err != nil {
+		return nil, err
+	}
+	resp := make(map[string]*commonmodels.EnvLifecycle, len(lifecycles))
+	for _, lifecycle := range lifecycles {
+		resp[envLifecycleKey(lifecycle.ProductName, lifecycle.EnvName)] = lifecycle
+	}
+	return resp, nil
+}
+
+func envLifecycleKey(productName, envName string) string {
+	return productName + "/" + envName
+}
+
+func findEnvLifecycle(productName, envName string) (*commonmodels.EnvLifecycle, error) {
+	lifecycle, err := commonrepo.NewEnvLifecycleColl().Find(productName, envName)
+	if err != nil {
+		if commonrepo.IsErrNoDocuments(err) {
+			return defaultEnvLifecycle(productName, envName), nil
+		}
+		return nil, err
+	}
+	return lifecycle, nil
+}
+
+// defaultEnvLifecycle is used for the environments whose lifecycle is never configured or recorded
+func defaultEnvLifecycle(productName, envName string) *commonmodels.EnvLifecycle {
+	return &commonmodels.EnvLifecycle{
+		ProductName: productName,
+		EnvName:     envName,
+		TTLAction:   commonmodels.EnvTTLActionDelete,
+		WarnHours:   commonmodels.DefaultEnvTTLWarnHours,
+	}
+}
+
+func sleepSupported(prod *commonmodels.Product) bool {
+	return prod.Source != setting.SourceFromExternal && prod.Source != setting.SourceFromPM
+}
+
+func validateEnvLifecycle(args *UpdateEnvLifecycleArgs) error {
+	switch args.TTLAction {
+	case "", commonmodels.EnvTTLActionDelete, commonmodels.EnvTTLActionSleep:
+	default:
+		return fmt.Errorf("invalid ttl action: %s", args.TTLAction)
+	}
+	if args.WarnHours < 0 {
+		return fmt.Errorf("warn hours can not be negative")
+	}
+
+	schedule := args.SleepSchedule
+	if schedule == nil || !schedule.Enabled {
+		return nil
+	}
+	if len(schedule.Days) == 0 {
+		return fmt.Errorf("at least one awake day is required")
+	}
+	for _, day := range schedule.Days {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid day of week: %d", day)
+		}
+	}
+	if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %s: %s", schedule.TimeZone, err)
+	}
+	start, end, err := parseAwakeHours(schedule)
+	if err != nil {
+		return err
+	}
+	if start >= end {
+		return fmt.Errorf("start time %s must be earlier than end time %s", schedule.StartTime, schedule.EndTime)
+	}
+	return nil
+}
+
+// parseAwakeHours returns the awake hours of the schedule in minutes of the day
+func parseAwakeHours(schedule *commonmodels.EnvSleepSchedule) (int, int, error) {
+	start, err := time.Parse(awakeHourLayout, schedule.StartTime)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start time %s: %s", schedule.StartTime, err)
+	}
+	end, err := time.Parse(awakeHourLayout, schedule.EndTime)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end time %s: %s", schedule.EndTime, err)
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
+
+func isAwakeDay(schedule *commonmodels.EnvSleepSchedule, day time.Weekday) bool {
+	for _, d := range schedule.Days {
+		if d == int(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// inSleepWindow reports whether the environment should sleep at the time according to the schedule
+func inSleepWindow(schedule *commonmodels.EnvSleepSchedule, now time.Time) bool {
+	if schedule == nil || !schedule.Enabled {
+		return false
+	}
+	start, end, err := parseAwakeHours(schedule)
+	if err != nil {
+		return false
+	}
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return false
+	}
+	now = now.In(loc)
+	if !isAwakeDay(schedule, now.Weekday()) {
+		return true
+	}
+	minutes := now.Hour()*60 + now.Minute()
+	return minutes < start || minutes >= end
+}
+
+// sleepWindowStart returns when the current sleep window starts, which is the end of the awake hours
+// of the last awake day
+func sleepWindowStart(schedule *commonmodels.EnvSleepSchedule, now time.Time) time.Time {
+	_, end, err := parseAwakeHours(schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return time.Time{}
+	}
+	now = now.In(loc)
+	for i := 0; i <= 7; i++ {
+		day := now.AddDate(0, 0, -i)
+		if !isAwakeDay(schedule, day.Weekday()) {
+			continue
+		}
+		t := time.Date(day.Year(), day.Month(), day.Day(), end/60, end%60, 0, 0, now.Location())
+		if !t.After(now) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// envTTLActionTime returns when the TTL action is taken if the environment stays inactive
+func envTTLActionTime(prod *commonmodels.Product, lifecycle *commonmodels.EnvLifecycle) int64 {
+	if prod.RecycleDay <= 0 {
+		return 0
+	}
+	lastActive := prod.UpdateTime
+	if lifecycle.LastActiveTime > lastActive {
+		lastActive = lifecycle.LastActiveTime
+	}
+	return lastActive + int64(prod.RecycleDay)*24*60*60
+}
+
+// getEnvTTLStage decides whether to warn the owners or take the TTL action, the owners are always warned
+// WarnHours before the action is taken
+func getEnvTTLStage(prod *commonmodels.Product, lifecycle *commonmodels.EnvLifecycle, now int64) envTTLStage {
+	deadline := envTTLActionTime(prod, lifecycle)
+	if deadline == 0 {
+		return envTTLNone
+	}
+	warnSeconds := int64(lifecycle.WarnHours) * 60 * 60
+	if warnSeconds == 0 {
+		if now >= deadline {
+			return envTTLAct
+		}
+		return envTTLNone
+	}
+
+	// the warning is outdated if there is any activity after it
+	warned := lifecycle.WarnTime > 0 && lifecycle.WarnTime >= deadline-int64(prod.RecycleDay)*24*60*60
+	if !warned {
+		if now >= deadline-warnSeconds {
+			return envTTLWarn
+		}
+		return envTTLNone
+	}
+	if now >= deadline && now >= lifecycle.WarnTime+warnSeconds {
+		return envTTLAct
+	}
+	return envTTLNone
+}
+
+// handleEnvSleepSchedule puts the environment to sleep in the sleep window of its schedule, and wakes it up
+// after the window if it is put to sleep by the schedule
+func handleEnvSleepSchedule(prod *commonmodels.Product, lifecycle *commonmodels.EnvLifecycle, now time.Time, log *zap.SugaredLogger) {
+	if lifecycle.SleepSchedule == nil || !lifecycle.SleepSchedule.Enabled || !sleepSupported(prod) {
+		return
+	}
+
+	if inSleepWindow(lifecycle.SleepSchedule, now) {
+		// the environment which is woken up in the window keeps awake until the next window
+		if lifecycle.Sleeping || lifecycle.WakeTime >= sleepWindowStart(lifecycle.SleepSchedule, now).Unix() {
+			return
+		}
+		log.Infof("[%s][P:%s] env sleeps by schedule", prod.EnvName, prod.ProductName)
+		if err := commonservice.SleepEnv(prod, commonmodels.EnvSleepReasonSchedule, log); err != nil {
+			log.Errorf("[%s][P:%s] failed to sleep env: %s", prod.EnvName, prod.ProductName, err)
+		}
+		return
+	}
+
+	if lifecycle.Sleeping && lifecycle.SleepReason == commonmodels.EnvSleepReasonSchedule {
+		log.Infof("[%s][P:%s] env wakes up by schedule", prod.EnvName, prod.ProductName)
+		if err := commonservice.WakeEnv(prod.ProductName, prod.EnvName, log); err != nil {
+			log.Errorf("[%s][P:%s] failed to wake env: %s", prod.EnvName, prod.ProductName, err)
+		}
+	}
+}
+
+// handleEnvTTL warns the owners of the inactive environment, then deletes it or puts it to sleep
+func handleEnvTTL(prod *commonmodels.Product, lifecycle *commonmodels.EnvLifecycle, now int64, requestID string, log *zap.SugaredLogger) {
+	action := lifecycle.TTLAction
+	if action == commonmodels.EnvTTLActionSleep && !sleepSupported(prod) {
+		action = commonmodels.EnvTTLActionDelete
+	}
+
+	switch getEnvTTLStage(prod, lifecycle, now) {
+	case envTTLWarn:
+		if action == commonmodels.EnvTTLActionSleep && lifecycle.Sleeping {
+			return
+		}
+		actionTime := time.Unix(envTTLActionTime(prod, lifecycle), 0)
+		if actionTime.Unix() < now+int64(lifecycle.WarnHours)*60*60 {
+			actionTime = time.Unix(now+int64(lifecycle.WarnHours)*60*60, 0)
+		}
+		notifyEnvOwners(prod, lifecycle, "环境即将被回收", []string{
+			fmt.Sprintf("环境 [%s] 已经连续 %d 天没有使用", prod.EnvName, prod.RecycleDay),
+			fmt.Sprintf("系统将于 %s %s该环境, 如需继续使用请访问或部署该环境", actionTime.Format("2006-01-02 15:04"), envTTLActionText(action)),
+		}, requestID, log)
+		if err := commonrepo.NewEnvLifecycleColl().UpdateWarnTime(prod.ProductName, prod.EnvName, now); err != nil {
+			log.Errorf("[%s][P:%s] failed to update warn time: %s", prod.EnvName, prod.ProductName, err)
+		}
+
+	case envTTLAct:
+		if action == commonmodels.EnvTTLActionSleep {
+			if lifecycle.Sleeping {
+				return
+			}
+			if err := commonservice.SleepEnv(prod, commonmodels.EnvSleepReasonTTL, log); err != nil {
+				log.Errorf("[%s][P:%s] failed to sleep env: %s", prod.EnvName, prod.ProductName, err)
+				return
+			}
+		} else {
+			if err := DeleteProduct("robot", prod.EnvName, prod.ProductName, requestID, log); err != nil {
+				log.Errorf("[%s][P:%s] delete product error: %v", prod.EnvName, prod.ProductName, err)
+
+				// 如果有错误，重试删除
+				if err := DeleteProduct("robot", prod.EnvName, prod.ProductName, requestID, log); err != nil {
+					log.Errorf("[%s][P:%s] retry delete product error: %v", prod.EnvName, prod.ProductName, err)
+					return
+				}
+			}
+			log.Warnf("[%s] product %s deleted", prod.EnvName, prod.ProductName)
+		}
+
+		notifyEnvOwners(prod, lifecycle, "环境已被回收", []string{
+			fmt.Sprintf("环境 [%s] 已经连续 %d 天没有使用, 系统已自动%s该环境", prod.EnvName, prod.RecycleDay, envTTLActionText(action)),
+		}, requestID, log)
+	}
+}
+
+func envTTLActionText(action commonmodels.EnvTTLAction) string {
+	if action == commonmodels.EnvTTLActionSleep {
+		return "休眠"
+	}
+	return "删除"
+}
+
+// notifyEnvOwners sends the message to the notification center of the last updater of the environment,
+// and to the IM channel of the environment if configured
+func notifyEnvOwners(prod *commonmodels.Product, lifecycle *commonmodels.EnvLifecycle, title string, lines []string, requestID string, log *zap.SugaredLogger) {
+	title = fmt.Sprintf("[%s] %s", prod.ProductName, title)
+	if prod.UpdateBy != "" {
+		content := ""
+		for _, line := range lines {
+			content += line + "\n"
+		}
+		commonservice.SendMessage(prod.UpdateBy, title, content, requestID, log)
+	}
+	if err := instantmessage.NewWeChatClient().SendEnvLifecycleMessage(lifecycle.NotifyCtl, title, lines); err != nil {
+		log.Errorf("[%s][P:%s] failed to send lifecycle message: %s", prod.EnvName, prod.ProductName, err)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+var _ = Describe("Testing env lifecycle", func() {
+
+	const day = int64(24 * 60 * 60)
+
+	Context("getEnvTTLStage", func() {
+		prod := &commonmodels.Product{RecycleDay: 7, UpdateTime: 1000}
+
+		It("should do nothing when the TTL is disabled", func() {
+			lc := &commonmodels.EnvLifecycle{WarnHours: 24}
+			Expect(getEnvTTLStage(&commonmodels.Product{UpdateTime: 1000}, lc, 1000+30*day)).To(Equal(envTTLNone))
+		})
+
+		It("should warn before the deadline and act after the warning period", func() {
+			lc := &commonmodels.EnvLifecycle{WarnHours: 24}
+			Expect(getEnvTTLStage(prod, lc, 1000+5*day)).To(Equal(envTTLNone))
+			Expect(getEnvTTLStage(prod, lc, 1000+6*day)).To(Equal(envTTLWarn))
+
+			lc.WarnTime = 1000 + 6*day
+			Expect(getEnvTTLStage(prod, lc, 1000+6*day+day/2)).To(Equal(envTTLNone))
+			Expect(getEnvTTLStage(prod, lc, 1000+7*day)).To(Equal(envTTLAct))
+		})
+
+		It("should always warn for the whole warning period even if the deadline is passed", func() {
+			lc := &commonmodels.EnvLifecycle{WarnHours: 24}
+			Expect(getEnvTTLStage(prod, lc, 1000+10*day)).To(Equal(envTTLWarn))
+
+			lc.WarnTime = 1000 + 10*day
+			Expect(getEnvTTLStage(prod, lc, 1000+10*day+day/2)).To(Equal(envTTLNone))
+			Expect(getEnvTTLStage(prod, lc, 1000+11*day)).To(Equal(envTTLAct))
+		})
+
+		It("should restart the TTL after any activity", func() {
+			lc := &commonmodels.EnvLifecycle{WarnHours: 24, WarnTime: 1000 + 6*day, LastActiveTime: 1000 + 6*day + 1}
+			Expect(getEnvTTLStage(prod, lc, 1000+7*day)).To(Equal(envTTLNone))
+			Expect(getEnvTTLStage(prod, lc, 1000+12*day+1)).To(Equal(envTTLWarn))
+		})
+
+		It("should act at the deadline without warning", func() {
+			lc := &commonmodels.EnvLifecycle{}
+			Expect(getEnvTTLStage(prod, lc, 1000+7*day-1)).To(Equal(envTTLNone))
+			Expect(getEnvTTLStage(prod, lc, 1000+7*day)).To(Equal(envTTLAct))
+		})
+	})
+
+	Context("sleep schedule", func() {
+		// awake from 09:00 to 21:00 on weekdays
+		schedule := &commonmodels.EnvSleepSchedule{Enabled: true, Days: []int{1, 2, 3, 4, 5}, StartTime: "09:00", EndTime: "21:00"}
+		// 2021-11-01 is a Monday
+		at := func(d, h, m int) time.Time {
+			return time.Date(2021, 11, d, h, m, 0, 0, time.UTC)
+		}
+
+		It("should sleep out of the awake hours", func() {
+			Expect(inSleepWindow(schedule, at(1, 8, 59))).To(BeTrue())
+			Expect(inSleepWindow(schedule, at(1, 9, 0))).To(BeFalse())
+			Expect(inSleepWindow(schedule, at(1, 20, 59))).To(BeFalse())
+			Expect(inSleepWindow(schedule, at(1, 21, 0))).To(BeTrue())
+			Expect(inSleepWindow(schedule, at(6, 12, 0))).To(BeTrue())
+			Expect(inSleepWindow(&commonmodels.EnvSleepSchedule{}, at(6, 12, 0))).To(BeFalse())
+		})
+
+		It("should find the start of the current sleep window", func() {
+			Expect(sleepWindowStart(schedule, at(1, 22, 0))).To(BeTemporally("==", at(1, 21, 0)))
+			Expect(sleepWindowStart(schedule, at(2, 8, 0))).To(BeTemporally("==", at(1, 21, 0)))
+			Expect(sleepWindowStart(schedule, at(7, 12, 0))).To(BeTemporally("==", at(5, 21, 0)))
+			Expect(sleepWindowStart(schedule, at(8, 8, 0))).To(BeTemporally("==", at(5, 21, 0)))
+		})
+
+		It("should evaluate the awake hours in the time zone of the schedule", func() {
+			zoned := *schedule
+			zoned.TimeZone = "Asia/Shanghai"
+			// 09:00 in Shanghai is 01:00 UTC
+			Expect(inSleepWindow(&zoned, at(1, 0, 59))).To(BeTrue())
+			Expect(inSleepWindow(&zoned, at(1, 1, 0))).To(BeFalse())
+			Expect(inSleepWindow(&zoned, at(1, 12, 59))).To(BeFalse())
+			Expect(inSleepWindow(&zoned, at(1, 13, 0))).To(BeTrue())
+			Expect(sleepWindowStart(&zoned, at(1, 14, 0))).To(BeTemporally("==", at(1, 13, 0)))
+			Expect(inSleepWindow(&commonmodels.EnvSleepSchedule{Enabled: true, Days: []int{1}, StartTime: "09:00", EndTime: "21:00", TimeZone: "Mars/Olympus"}, at(1, 0, 0))).To(BeFalse())
+		})
+	})
+
+	Context("validateEnvLifecycle", func() {
+		It("should reject invalid policies", func() {
+			Expect(validateEnvLifecycle(&UpdateEnvLifecycleArgs{TTLAction: "stop"})).To(HaveOccurred())
+			Expect(validateEnvLifecycle(&UpdateEnvLifecycleArgs{WarnHours: -1})).To(HaveOccurred())
+			Expect(validateEnvLifecycle(&UpdateEnvLifecycleArgs{SleepSchedule: &commonmodels.EnvSleepSchedule{Enabled: true, StartTime: "09:00", EndTime: "21:00"}})).To(HaveOccurred())
+			Expect(validateEnvLifecycle(&UpdateEnvLifecycleArgs{SleepSchedule: &commonmodels.EnvSleepSchedule{Enabled: true, Days: []int{7}, StartTime: "09:00", EndTime: "21:00"}})).To(HaveOccurred())
+			Expect(validateEnvLifecycle(&UpdateEnvLifecycleArgs{SleepSchedule: &commonmodels.EnvSleepSchedule{Enabled: true, Days: []int{1}, StartTime: "21:00", EndTime: "09:00"}})).To(HaveOccurred())
+			Expect(validateEnvLifecycle(&UpdateEnvLifecycleArgs{SleepSchedule: &commonmodels.EnvSleepSchedule{Enabled: true, Days: []int{1}, StartTime: "09:00", EndTime: "21:00", TimeZone: "Mars/Olympus"}})).To(HaveOccurred())
+		})
+
+		It("should accept valid policies", func() {
+			Expect(validateEnvLifecycle(&UpdateEnvLifecycleArgs{})).NotTo(HaveOccurred())
+			Expect(validateEnvLifecycle(&UpdateEnvLifecycleArgs{
+				TTLAction:     commonmodels.EnvTTLActionSleep,
+				WarnHours:     12,
+				SleepSchedule: &commonmodels.EnvSleepSchedule{Enabled: true, Days: []int{0, 6}, StartTime: "09:00", EndTime: "21:00", TimeZone: "Asia/Shanghai"},
+			})).NotTo(HaveOccurred())
+		})
+	})
+})
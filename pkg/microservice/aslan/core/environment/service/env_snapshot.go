@@ -0,0 +1,638 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	versionedclient "istio.io/client-go/pkg/clientset/versioned"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/pkg/tool/helmclient"
+	"github.com/koderover/zadig/pkg/tool/kube/informer"
+)
+
+const envSnapshotYamlSeparator = "\n---\n"
+
+type CreateEnvSnapshotArgs struct {
+	Description string `json:"description"`
+}
+
+type RestoreEnvSnapshotArgs struct {
+	// EnvName is the new environment to restore into, the snapshot is restored into the environment
+	// it is taken from by RestoreEnvSnapshot
+	EnvName   string `json:"env_name"`
+	ClusterID string `json:"cluster_id"`
+	Namespace string `json:"namespace"`
+}
+
+// CreateEnvSnapshot captures the services, render set, helm releases and common env configs of the environment
+func CreateEnvSnapshot(envName, productName, userName string, args *CreateEnvSnapshotArgs, log *zap.SugaredLogger) (*commonmodels.EnvSnapshot, error) {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		log.Errorf("failed to find env %s of product %s, err: %s", envName, productName, err)
+		return nil, e.ErrCreateEnvSnapshot.AddDesc(e.EnvNotFoundErrMsg)
+	}
+	if !snapshotSupported(prod) {
+		return nil, e.ErrCreateEnvSnapshot.AddDesc("only k8s and helm environments support snapshots")
+	}
+
+	snapshot := &commonmodels.EnvSnapshot{
+		ProductName: prod.ProductName,
+		EnvName:     prod.EnvName,
+		Description: args.Description,
+		ClusterID:   prod.ClusterID,
+		Namespace:   prod.Namespace,
+		Source:      prod.Source,
+		RegistryID:  prod.RegistryID,
+		Revision:    prod.Revision,
+		Services:    prod.Services,
+		CreatedBy:   userName,
+	}
+
+	if prod.Render != nil {
+		snapshot.RenderSet, err = commonservice.GetRenderSet(prod.Render.Name, prod.Render.Revision, log)
+		if err != nil {
+			return nil, e.ErrCreateEnvSnapshot.AddErr(err)
+		}
+	}
+
+	if prod.Source == setting.SourceFromHelm {
+		snapshot.HelmReleases, err = snapshotHelmReleases(prod)
+		if err != nil {
+			log.Errorf("failed to list helm releases of env %s, err: %s", envName, err)
+			return nil, e.ErrCreateEnvSnapshot.AddErr(err)
+		}
+	}
+
+	snapshot.EnvConfigYamls, snapshot.SecretYamls, err = snapshotEnvConfigs(prod, log)
+	if err != nil {
+		log.Errorf("failed to list env configs of env %s, err: %s", envName, err)
+		return nil, e.ErrCreateEnvSnapshot.AddErr(err)
+	}
+
+	if err := commonrepo.NewEnvSnapshotColl().Create(snapshot); err != nil {
+		log.Errorf("failed to save snapshot of env %s, err: %s", envName, err)
+		return nil, e.ErrCreateEnvSnapshot.AddErr(err)
+	}
+
+	maxItems, maxDays := envSnapshotRetention()
+	if err := commonrepo.NewEnvSnapshotColl().Prune(productName, envName, maxItems, maxDays); err != nil {
+		// the snapshot is created, old snapshots will be pruned next time
+		log.Warnf("failed to prune snapshots of env %s, err: %s", envName, err)
+	}
+	return snapshot, nil
+}
+
+func ListEnvSnapshots(envName, productName string, log *zap.SugaredLogger) ([]*commonmodels.EnvSnapshot, error) {
+	snapshots, err := commonrepo.NewEnvSnapshotColl().List(productName, envName)
+	if err != nil {
+		log.Errorf("failed to list snapshots of env %s, err: %s", envName, err)
+		return nil, e.ErrListEnvSnapshots.AddErr(err)
+	}
+	return snapshots, nil
+}
+
+func GetEnvSnapshot(envName, productName, id string, log *zap.SugaredLogger) (*commonmodels.EnvSnapshot, error) {
+	snapshot, err := findEnvSnapshot(envName, productName, id)
+	if err != nil {
+		log.Errorf("failed to find snapshot %s of env %s, err: %s", id, envName, err)
+		return nil, e.ErrGetEnvSnapshot.AddErr(err)
+	}
+	return snapshot, nil
+}
+
+func DeleteEnvSnapshot(envName, productName, id string, log *zap.SugaredLogger) error {
+	if _, err := findEnvSnapshot(envName, productName, id); err != nil {
+		log.Errorf("failed to find snapshot %s of env %s, err: %s", id, envName, err)
+		return e.ErrDeleteEnvSnapshot.AddErr(err)
+	}
+	if err := commonrepo.NewEnvSnapshotColl().Delete(id); err != nil {
+		log.Errorf("failed to delete snapshot %s of env %s, err: %s", id, envName, err)
+		return e.ErrDeleteEnvSnapshot.AddErr(err)
+	}
+	return nil
+}
+
+// RestoreEnvSnapshot restores the snapshot into the environment it is taken from, or into a new environment
+// on any registered cluster
+func RestoreEnvSnapshot(envName, productName, id, userName, requestID string, log *zap.SugaredLogger) error {
+	snapshot, err := findEnvSnapshot(envName, productName, id)
+	if err != nil {
+		log.Errorf("failed to find snapshot %s of env %s, err: %s", id, envName, err)
+		return e.ErrRestoreEnvSnapshot.AddErr(err)
+	}
+
+	if err := restoreEnvSnapshotInPlace(snapshot, userName, requestID, log); err != nil {
+		log.Errorf("failed to restore snapshot %s of env %s, err: %s", id, envName, err)
+		return e.ErrRestoreEnvSnapshot.AddErr(err)
+	}
+	return nil
+}
+
+// CopyEnvSnapshot restores the snapshot into a new environment, the api is authorized by the permission of
+// creating environments, so the user must also be able to view the env which the snapshot is taken from
+func CopyEnvSnapshot(envName, productName, id, userID, userName, requestID string, args *RestoreEnvSnapshotArgs, log *zap.SugaredLogger) error {
+	if args.EnvName == "" || args.EnvName == envName {
+		return e.ErrInvalidParam.AddDesc("env_name must be a new environment")
+	}
+
+	allowed, err := hasEnvPermission(userID, productName, envName, getEnvironmentVerb)
+	if err != nil {
+		log.Errorf("failed to get the permission of env %s/%s, err: %s", productName, envName, err)
+		return e.ErrRestoreEnvSnapshot.AddErr(err)
+	}
+	if !allowed {
+		return e.ErrForbidden.AddDesc(fmt.Sprintf("no permission to view env %s/%s", productName, envName))
+	}
+
+	snapshot, err := findEnvSnapshot(envName, productName, id)
+	if err != nil {
+		log.Errorf("failed to find snapshot %s of env %s, err: %s", id, envName, err)
+		return e.ErrRestoreEnvSnapshot.AddErr(err)
+	}
+	if err := restoreEnvSnapshotToNewEnv(snapshot, userName, requestID, args, log); err != nil {
+		log.Errorf("failed to restore snapshot %s of env %s into env %s, err: %s", id, envName, args.EnvName, err)
+		return e.ErrRestoreEnvSnapshot.AddErr(err)
+	}
+	return nil
+}
+
+func findEnvSnapshot(envName, productName, id string) (*commonmodels.EnvSnapshot, error) {
+	snapshot, err := commonrepo.NewEnvSnapshotColl().Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.ProductName != productName || snapshot.EnvName != envName {
+		return nil, fmt.Errorf("snapshot %s is not taken from env %s of project %s", id, envName, productName)
+	}
+	return snapshot, nil
+}
+
+// checkProjectCluster checks that the cluster is allowed to be used by the project
+func checkProjectCluster(productName, clusterID string) error {
+	relations, err := commonrepo.NewProjectClusterRelationColl().List(&commonrepo.ProjectClusterRelationOption{
+		ProjectName: productName,
+		ClusterID:   clusterID,
+	})
+	if err != nil {
+		return err
+	}
+	if len(relations) == 0 {
+		return fmt.Errorf("cluster %s is not available to project %s", clusterID, productName)
+	}
+	return nil
+}
+
+func snapshotSupported(prod *commonmodels.Product) bool {
+	switch prod.Source {
+	case setting.SourceFromExternal, setting.SourceFromPM:
+		return false
+	default:
+		return true
+	}
+}
+
+func envSnapshotRetention() (int, int) {
+	strategy, err := commonrepo.NewStrategyColl().GetByTarget(commonmodels.EnvSnapshotRetention)
+	if err != nil || strategy.Retention == nil {
+		return commonmodels.DefaultEnvSnapshotMaxItems, 0
+	}
+	return strategy.Retention.MaxItems, strategy.Retention.MaxDays
+}
+
+func snapshotHelmReleases(prod *commonmodels.Product) ([]*commonmodels.HelmReleaseSnapshot, error) {
+	restConfig, err := kube.GetRESTConfig(prod.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	helmClient, err := helmtool.NewClientFromRestConf(restConfig, prod.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := helmClient.ListDeployedReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	// only releases deployed by zadig are kept
+	releaseNameMap, err := commonservice.GetReleaseNameToServiceNameMap(prod)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []*commonmodels.HelmReleaseSnapshot
+	for _, release := range releases {
+		serviceName, ok := releaseNameMap[release.Name]
+		if !ok {
+			continue
+		}
+		values, err := yaml.Marshal(release.Config)
+		if err != nil {
+			return nil, err
+		}
+		rs := &commonmodels.HelmReleaseSnapshot{
+			ReleaseName: release.Name,
+			ServiceName: serviceName,
+			Revision:    release.Version,
+			Values:      string(values),
+		}
+		if release.Chart != nil && release.Chart.Metadata != nil {
+			rs.ChartVersion = release.Chart.Metadata.Version
+		}
+		resp = append(resp, rs)
+	}
+	return resp, nil
+}
+
+// snapshotEnvConfigs returns the yamls of the common env configs, secrets are returned separately
+// so that they can be encrypted
+func snapshotEnvConfigs(prod *commonmodels.Product, log *zap.SugaredLogger) ([]string, string, error) {
+	objs, err := listEnvConfigObjects(prod, log)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var configYamls, secretYamls []string
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, "", err
+		}
+		if obj.GetKind() == setting.Secret {
+			secretYamls = append(secretYamls, string(data))
+			continue
+		}
+		configYamls = append(configYamls, string(data))
+	}
+	return configYamls, strings.Join(secretYamls, envSnapshotYamlSeparator), nil
+}
+
+func envSnapshotConfigYamls(snapshot *commonmodels.EnvSnapshot) []string {
+	yamls := make([]string, 0, len(snapshot.EnvConfigYamls)+1)
+	yamls = append(yamls, snapshot.EnvConfigYamls...)
+	if snapshot.SecretYamls != "" {
+		yamls = append(yamls, snapshot.SecretYamls)
+	}
+	return yamls
+}
+
+// restoreEnvSnapshotToNewEnv creates a new environment from the snapshot the same way as an environment is copied
+func restoreEnvSnapshotToNewEnv(snapshot *commonmodels.EnvSnapshot, userName, requestID string, args *RestoreEnvSnapshotArgs, log *zap.SugaredLogger) error {
+	clusterID := args.ClusterID
+	if clusterID == "" {
+		clusterID = snapshot.ClusterID
+	}
+	if err := checkProjectCluster(snapshot.ProductName, clusterID); err != nil {
+		return err
+	}
+	namespace := args.Namespace
+	if namespace == "" {
+		namespace = snapshot.ProductName + "-env-" + args.EnvName
+	}
+
+	prod := &commonmodels.Product{
+		ProductName: snapshot.ProductName,
+		ClusterID:   clusterID,
+		RegistryID:  snapshot.RegistryID,
+		Source:      snapshot.Source,
+		Revision:    snapshot.Revision,
+		Services:    snapshot.Services,
+		UpdateBy:    userName,
+		// secrets are applied after the environment is created, so they are never stored in the environment
+		EnvConfigYamls: snapshot.EnvConfigYamls,
+	}
+
+	var err error
+	switch snapshot.Source {
+	case setting.SourceFromHelm:
+		prod.EnvName = args.EnvName
+		prod.Namespace = namespace
+		var defaultValues string
+		var yamlData *templatemodels.CustomYaml
+		if snapshot.RenderSet != nil {
+			prod.ChartInfos = snapshotChartInfos(snapshot)
+			defaultValues, yamlData = snapshot.RenderSet.DefaultValues, snapshot.RenderSet.YamlData
+		}
+		err = createHelmProductCopy(prod, defaultValues, yamlData, userName, requestID, log)
+	default:
+		var vars []*templatemodels.RenderKV
+		if snapshot.RenderSet != nil {
+			vars = snapshot.RenderSet.KVs
+		}
+		prod.Render = &commonmodels.RenderInfo{ProductTmpl: snapshot.ProductName}
+		err = copyYamlProduct(prod, args.EnvName, namespace, "", vars, userName, requestID, log)
+	}
+	if err != nil {
+		return err
+	}
+
+	if snapshot.SecretYamls == "" {
+		return nil
+	}
+	// the environment is created asynchronously, its namespace is ready only after the creation is done
+	go func() {
+		err := restoreEnvSnapshotSecrets(snapshot, args.EnvName, clusterID, namespace, userName)
+		if err != nil {
+			log.Errorf("[%s][P:%s] failed to restore secrets of snapshot %s, err: %s", args.EnvName, snapshot.ProductName, snapshot.ID.Hex(), err)
+			title := fmt.Sprintf("恢复 [%s] 的 [%s] 环境快照的密钥失败", snapshot.ProductName, args.EnvName)
+			commonservice.SendErrorMessage(userName, title, requestID, err, log)
+		}
+	}()
+	return nil
+}
+
+// restoreEnvSnapshotSecrets applies the secrets of the snapshot once the environment restored from it is created
+func restoreEnvSnapshotSecrets(snapshot *commonmodels.EnvSnapshot, envName, clusterID, namespace, userName string) error {
+	// services are started group by group when the environment is created
+	timeout := time.Duration(config.ServiceStartTimeout()*(len(snapshot.Services)+1)) * time.Second
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: snapshot.ProductName, EnvName: envName})
+		if err != nil {
+			return false, err
+		}
+		switch env.Status {
+		case setting.ProductStatusCreating:
+			return false, nil
+		case setting.ProductStatusFailed:
+			return false, fmt.Errorf("env %s failed to be created: %s", envName, env.Error)
+		default:
+			return true, nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), clusterID)
+	if err != nil {
+		return err
+	}
+	cls, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), clusterID)
+	if err != nil {
+		return err
+	}
+	inf, err := informer.NewInformer(clusterID, namespace, cls)
+	if err != nil {
+		return err
+	}
+	return initEnvConfigSetAction(envName, snapshot.ProductName, namespace, userName, []string{snapshot.SecretYamls}, inf, kubeClient)
+}
+
+// snapshotChartInfos returns the chart infos of the snapshot, the values of a snapshotted helm release override the
+// values of its chart so that the release is restored with the values it was deployed with
+func snapshotChartInfos(snapshot *commonmodels.EnvSnapshot) []*templatemodels.RenderChart {
+	releaseValues := make(map[string]string)
+	for _, release := range snapshot.HelmReleases {
+		releaseValues[release.ServiceName] = release.Values
+	}
+
+	charts := make([]*templatemodels.RenderChart, 0, len(snapshot.RenderSet.ChartInfos))
+	for _, chart := range snapshot.RenderSet.ChartInfos {
+		rc := *chart
+		if values, ok := releaseValues[rc.ServiceName]; ok {
+			rc.OverrideYaml = &templatemodels.CustomYaml{YamlContent: values}
+			rc.OverrideValues = ""
+		}
+		charts = append(charts, &rc)
+	}
+	return charts
+}
+
+// restoreEnvSnapshotInPlace rolls the environment back to the snapshot, services which are added after the snapshot
+// is taken are deleted
+func restoreEnvSnapshotInPlace(snapshot *commonmodels.EnvSnapshot, userName, requestID string, log *zap.SugaredLogger) error {
+	envName, productName := snapshot.EnvName, snapshot.ProductName
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return fmt.Errorf("env %s not found, err: %s", envName, err)
+	}
+	if prod.ClusterID != snapshot.ClusterID || prod.Namespace != snapshot.Namespace {
+		return fmt.Errorf("env %s is recreated since the snapshot is taken, please restore it into a new env", envName)
+	}
+
+	switch prod.Status {
+	case setting.ProductStatusCreating, setting.ProductStatusUpdating, setting.ProductStatusDeleting:
+		return e.ErrUpdateEnv.AddDesc(e.EnvCantUpdatedMsg)
+	}
+	if err := commonrepo.NewProductColl().UpdateStatus(envName, productName, setting.ProductStatusUpdating); err != nil {
+		log.Errorf("[%s][P:%s] Product.UpdateStatus error: %v", envName, productName, err)
+		return e.ErrUpdateEnv.AddDesc(e.UpdateEnvStatusErrMsg)
+	}
+
+	go func() {
+		status, errMsg := setting.ProductStatusSuccess, ""
+		if err := restoreEnvSnapshot(prod, snapshot, userName, log); err != nil {
+			log.Errorf("[%s][P:%s] failed to restore snapshot %s, err: %s", envName, productName, snapshot.ID.Hex(), err)
+			title := fmt.Sprintf("恢复 [%s] 的 [%s] 环境快照失败", productName, envName)
+			commonservice.SendErrorMessage(userName, title, requestID, err, log)
+			status, errMsg = setting.ProductStatusFailed, err.Error()
+		}
+
+		if err := commonrepo.NewProductColl().UpdateStatus(envName, productName, status); err != nil {
+			log.Errorf("[%s][P:%s] Product.UpdateStatus error: %v", envName, productName, err)
+			return
+		}
+		if err := commonrepo.NewProductColl().UpdateErrors(envName, productName, errMsg); err != nil {
+			log.Errorf("[%s][P:%s] Product.UpdateErrors error: %v", envName, productName, err)
+		}
+	}()
+	return nil
+}
+
+func restoreEnvSnapshot(prod *commonmodels.Product, snapshot *commonmodels.EnvSnapshot, userName string, log *zap.SugaredLogger) error {
+	snapshotServices := sets.NewString()
+	for _, group := range snapshot.Services {
+		for _, svc := range group {
+			snapshotServices.Insert(svc.ServiceName)
+		}
+	}
+	removedServices := make(map[string]int64)
+	for serviceName, svc := range prod.GetServiceMap() {
+		if !snapshotServices.Has(serviceName) {
+			removedServices[serviceName] = svc.Revision
+		}
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	cls, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	inf, err := informer.NewInformer(prod.ClusterID, prod.Namespace, cls)
+	if err != nil {
+		return err
+	}
+
+	if prod.Source != setting.SourceFromHelm && len(removedServices) > 0 {
+		removed := make([]string, 0, len(removedServices))
+		for serviceName := range removedServices {
+			removed = append(removed, serviceName)
+		}
+		if err := deleteK8sProductServices(prod, removed, log); err != nil {
+			return err
+		}
+	}
+
+	renderSet, err := restoreEnvSnapshotRenderSet(prod, snapshot, userName, log)
+	if err != nil {
+		return err
+	}
+
+	existedServices := prod.GetServiceMap()
+	prod.Render = &commonmodels.RenderInfo{
+		Name:        renderSet.Name,
+		Revision:    renderSet.Revision,
+		ProductTmpl: renderSet.ProductTmpl,
+		Description: renderSet.Description,
+	}
+	prod.Services = snapshot.Services
+	prod.Revision = snapshot.Revision
+	setServiceRender(prod)
+
+	if prod.Source == setting.SourceFromHelm {
+		err = restoreHelmServices(prod, renderSet, removedServices)
+	} else {
+		err = restoreK8sServices(prod, existedServices, renderSet, inf, kubeClient, log)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := initEnvConfigSetAction(prod.EnvName, prod.ProductName, prod.Namespace, userName, envSnapshotConfigYamls(snapshot), inf, kubeClient); err != nil {
+		return err
+	}
+
+	return commonrepo.NewProductColl().Update(prod)
+}
+
+// restoreEnvSnapshotRenderSet saves the render set of the snapshot as a new revision of the render set of the environment
+func restoreEnvSnapshotRenderSet(prod *commonmodels.Product, snapshot *commonmodels.EnvSnapshot, userName string, log *zap.SugaredLogger) (*commonmodels.RenderSet, error) {
+	renderSetName := prod.Namespace
+	if prod.Render != nil && prod.Render.Name != "" {
+		renderSetName = prod.Render.Name
+	}
+
+	if snapshot.RenderSet != nil {
+		args := &commonmodels.RenderSet{
+			Name:        renderSetName,
+			EnvName:     prod.EnvName,
+			ProductTmpl: prod.ProductName,
+			UpdateBy:    userName,
+		}
+		var err error
+		if prod.Source == setting.SourceFromHelm {
+			args.DefaultValues = snapshot.RenderSet.DefaultValues
+			args.YamlData = snapshot.RenderSet.YamlData
+			args.ChartInfos = snapshotChartInfos(snapshot)
+			err = commonservice.CreateHelmRenderSet(args, log)
+		} else {
+			args.KVs = snapshot.RenderSet.KVs
+			err = commonservice.CreateRenderSet(args, log)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the render set is not created if it is the same as the latest one
+	return commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: renderSetName, ProductTmpl: prod.ProductName})
+}
+
+func restoreK8sServices(prod *commonmodels.Product, existedServices map[string]*commonmodels.ProductService, renderSet *commonmodels.RenderSet,
+	inf informers.SharedInformerFactory, kubeClient client.Client, log *zap.SugaredLogger) error {
+	restConfig, err := kubeclient.GetRESTConfig(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	istioClient, err := versionedclient.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range prod.Services {
+		for _, svc := range group {
+			prevSvc := existedServices[svc.ServiceName]
+			if _, err := upsertService(prevSvc != nil, prod, svc, prevSvc, renderSet, inf, kubeClient, istioClient, log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func restoreHelmServices(prod *commonmodels.Product, renderSet *commonmodels.RenderSet, removedServices map[string]int64) error {
+	helmClient, err := helmtool.NewClientFromNamespace(prod.ClusterID, prod.Namespace)
+	if err != nil {
+		return err
+	}
+
+	for serviceName, revision := range removedServices {
+		if err := UninstallServiceByName(helmClient, prod.ProductName, prod.Namespace, prod.EnvName, serviceName, revision, true); err != nil {
+			return err
+		}
+	}
+
+	renderChartMap := make(map[string]*templatemodels.RenderChart)
+	for _, chart := range renderSet.ChartInfos {
+		renderChartMap[chart.ServiceName] = chart
+	}
+	for _, group := range prod.Services {
+		for _, svc := range group {
+			renderChart, ok := renderChartMap[svc.ServiceName]
+			if !ok {
+				continue
+			}
+			serviceObj, err := commonrepo.NewServiceColl().Find(&commonrepo.ServiceFindOption{
+				ServiceName: svc.ServiceName,
+				Type:        svc.Type,
+				Revision:    svc.Revision,
+				ProductName: svc.ProductName,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to find service %s of revision %d, err: %s", svc.ServiceName, svc.Revision, err)
+			}
+			param, err := buildInstallParam(prod.Namespace, prod.EnvName, renderSet.DefaultValues, renderChart, serviceObj)
+			if err != nil {
+				return err
+			}
+			if err := InstallService(helmClient, param); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+var _ = Describe("Testing env snapshot", func() {
+
+	It("should only keep the name, labels, annotations and content of env configs", func() {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "cfg",
+				Namespace:       "demo-env-dev",
+				UID:             "uid",
+				ResourceVersion: "10",
+				Labels:          map[string]string{"app": "demo"},
+				Annotations: map[string]string{
+					corev1.LastAppliedConfigAnnotation: "{}",
+					"owner":                            "qa",
+				},
+			},
+			Data: map[string]string{"a": "1"},
+		}
+
+		obj, err := newEnvConfigObject("v1", setting.ConfigMap, cm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(obj.GetKind()).To(Equal(setting.ConfigMap))
+		Expect(obj.GetName()).To(Equal("cfg"))
+		Expect(obj.GetNamespace()).To(BeEmpty())
+		Expect(obj.GetUID()).To(BeEmpty())
+		Expect(obj.GetResourceVersion()).To(BeEmpty())
+		Expect(obj.GetLabels()).To(Equal(map[string]string{"app": "demo"}))
+		Expect(obj.GetAnnotations()).To(Equal(map[string]string{"owner": "qa"}))
+		Expect(obj.Object["data"]).To(Equal(map[string]interface{}{"a": "1"}))
+	})
+
+	It("should restore secrets together with other env configs", func() {
+		snapshot := &commonmodels.EnvSnapshot{EnvConfigYamls: []string{"cm", "ingress"}}
+		Expect(envSnapshotConfigYamls(snapshot)).To(Equal([]string{"cm", "ingress"}))
+
+		snapshot.SecretYamls = "secret"
+		Expect(envSnapshotConfigYamls(snapshot)).To(Equal([]string{"cm", "ingress", "secret"}))
+	})
+
+	It("should restore helm releases with their snapshotted values", func() {
+		chart := &templatemodels.RenderChart{ServiceName: "a", ValuesYaml: "image: a:1", OverrideValues: `[{"key":"replicas","value":"2"}]`}
+		snapshot := &commonmodels.EnvSnapshot{
+			RenderSet: &commonmodels.RenderSet{ChartInfos: []*templatemodels.RenderChart{
+				chart,
+				{ServiceName: "b", ValuesYaml: "image: b:1"},
+			}},
+			HelmReleases: []*commonmodels.HelmReleaseSnapshot{{ServiceName: "a", Values: "image: a:2\nreplicas: 3\n"}},
+		}
+
+		charts := snapshotChartInfos(snapshot)
+		Expect(charts).To(HaveLen(2))
+		Expect(charts[0].GetOverrideYaml()).To(Equal("image: a:2\nreplicas: 3\n"))
+		Expect(charts[0].OverrideValues).To(BeEmpty())
+		Expect(charts[1].GetOverrideYaml()).To(BeEmpty())
+		Expect(charts[1].ValuesYaml).To(Equal("image: b:1"))
+		// the chart infos of the snapshot are kept as they are
+		Expect(chart.OverrideYaml).To(BeNil())
+	})
+})
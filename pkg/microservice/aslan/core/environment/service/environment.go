@@ -1098,14 +1098,7 @@ func BulkCopyYamlProduct(projectName, user, requestID string, arg CopyYamlProduc
 
 	for _, item := range arg.Items {
 		if product, ok := productMap[item.OldName]; ok {
-			product.EnvName = item.NewName
-			product.Vars = item.Vars
-			product.Namespace = projectName + "-env-" + product.EnvName
-			product.Render.Name = product.Namespace
-			util.Clear(&product.ID)
-			product.Render.Revision = 0
-			product.BaseName = item.BaseName
-			err = CreateProduct(user, requestID, product, log)
+			err = copyYamlProduct(product, item.NewName, projectName+"-env-"+item.NewName, item.BaseName, item.Vars, user, requestID, log)
 			if err != nil {
 				return err
 			}
@@ -1116,6 +1109,18 @@ func BulkCopyYamlProduct(projectName, user, requestID string, arg CopyYamlProduc
 	return nil
 }
 
+// copyYamlProduct creates a new environment from the product of another environment
+func copyYamlProduct(product *commonmodels.Product, envName, namespace, baseName string, vars []*templatemodels.RenderKV, user, requestID string, log *zap.SugaredLogger) error {
+	product.EnvName = envName
+	product.Vars = vars
+	product.Namespace = namespace
+	product.Render.Name = product.Namespace
+	util.Clear(&product.ID)
+	product.Render.Revision = 0
+	product.BaseName = baseName
+	return CreateProduct(user, requestID, product, log)
+}
+
 // CreateProduct create a new product with its dependent stacks
 func CreateProduct(user, requestID string, args *commonmodels.Product, log *zap.SugaredLogger) (err error) {
 	log.Infof("[%s][P:%s] CreateProduct", args.EnvName, args.ProductName)
@@ -1199,7 +1204,11 @@ func copySingleHelmProduct(templateProduct *templatemodels.Product, productName,
 	if err != nil {
 		return err
 	}
+	return createHelmProductCopy(productInfo, arg.DefaultValues, geneYamlData(arg.ValuesData), userName, requestID, log)
+}
 
+// createHelmProductCopy saves the chart infos of the copied helm environment as its render set and creates it
+func createHelmProductCopy(productInfo *commonmodels.Product, defaultValues string, yamlData *templatemodels.CustomYaml, userName, requestID string, log *zap.SugaredLogger) error {
 	// clear render info
 	productInfo.Render = nil
 	setServiceRender(productInfo)
@@ -1207,18 +1216,18 @@ func copySingleHelmProduct(templateProduct *templatemodels.Product, productName,
 	// insert renderset info into db
 	if len(productInfo.ChartInfos) > 0 {
 		err := commonservice.CreateHelmRenderSet(&commonmodels.RenderSet{
-			Name:          commonservice.GetProductEnvNamespace(arg.EnvName, arg.ProductName, arg.Namespace),
-			EnvName:       arg.EnvName,
-			ProductTmpl:   arg.ProductName,
+			Name:          productInfo.Namespace,
+			EnvName:       productInfo.EnvName,
+			ProductTmpl:   productInfo.ProductName,
 			UpdateBy:      userName,
 			IsDefault:     false,
-			DefaultValues: arg.DefaultValues,
-			YamlData:      geneYamlData(arg.ValuesData),
+			DefaultValues: defaultValues,
+			YamlData:      yamlData,
 			ChartInfos:    productInfo.ChartInfos,
 		}, log)
 		if err != nil {
-			log.Errorf("rennderset create fail when copy creating helm product, productName: %s,envname:%s,err:%s", arg.ProductName, arg.EnvName, err)
-			return e.ErrCreateEnv.AddDesc(fmt.Sprintf("failed to save chart values, productName: %s,envname:%s,err:%s", arg.ProductName, arg.EnvName, err))
+			log.Errorf("rennderset create fail when copy creating helm product, productName: %s,envname:%s,err:%s", productInfo.ProductName, productInfo.EnvName, err)
+			return e.ErrCreateEnv.AddDesc(fmt.Sprintf("failed to save chart values, productName: %s,envname:%s,err:%s", productInfo.ProductName, productInfo.EnvName, err))
 		}
 	}
 	return CreateProduct(userName, requestID, productInfo, log)
@@ -1970,6 +1979,13 @@ func DeleteProduct(username, envName, productName, requestID string, log *zap.Su
 	log.Infof("[%s] delete product %s", username, productInfo.Namespace)
 	commonservice.LogProductStats(username, setting.DeleteProductEvent, productName, requestID, eventStart, log)
 
+	if err := commonrepo.NewEnvDriftColl().Delete(productName, envName); err != nil {
+		log.Warnf("failed to delete env drift of %s/%s: %s", productName, envName, err)
+	}
+	if err := commonrepo.NewEnvLifecycleColl().Delete(productName, envName); err != nil {
+		log.Warnf("failed to delete env lifecycle of %s/%s: %s", productName, envName, err)
+	}
+
 	switch productInfo.Source {
 	case setting.SourceFromHelm:
 		err = commonrepo.NewProductColl().Delete(envName, productName)
@@ -2399,7 +2415,7 @@ func createGroups(envName, user, requestID string, args *commonmodels.Product, e
 		}
 	}()
 
-	err = envHandleFunc(getProjectType(args.ProductName), log).initEnvConfigSet(envName, args.ProductName, user, args.EnvConfigYamls, informer, kubeClient)
+	err = envHandleFunc(getProjectType(args.ProductName), log).initEnvConfigSet(envName, args.ProductName, args.Namespace, user, args.EnvConfigYamls, informer, kubeClient)
 	if err != nil {
 		args.Status = setting.ProductStatusFailed
 		log.Errorf("initEnvConfigSet error :%s", err)
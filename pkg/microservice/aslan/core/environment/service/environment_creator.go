@@ -226,7 +226,7 @@ func (creator *HelmProductCreator) Create(user, requestID string, args *models.P
 		log.Errorf("failed to create informer from clientset for clusterID: %s, the error is: %s", clusterID, err)
 		return nil
 	}
-	err = helmInitEnvConfigSet(args.EnvName, args.ProductName, user, args.EnvConfigYamls, inf, kubeClient)
+	err = helmInitEnvConfigSet(args.EnvName, args.ProductName, args.Namespace, user, args.EnvConfigYamls, inf, kubeClient)
 	if err != nil {
 		log.Errorf("failed to helmInitEnvConfigSet [%s][P:%s]: %s, the error is: %s", args.EnvName, args.ProductName, err)
 		if err := commonrepo.NewProductColl().UpdateStatus(args.EnvName, args.ProductName, setting.ProductStatusFailed); err != nil {
@@ -391,11 +391,11 @@ func GetImageInfos(productName, envName, serviceNames string, log *zap.SugaredLo
 	return ret, nil
 }
 
-func helmInitEnvConfigSet(envName, productName, userName string, envConfigYamls []string, inf informers.SharedInformerFactory, kubeClient client.Client) error {
-	return initEnvConfigSetAction(envName, productName, userName, envConfigYamls, inf, kubeClient)
+func helmInitEnvConfigSet(envName, productName, namespace, userName string, envConfigYamls []string, inf informers.SharedInformerFactory, kubeClient client.Client) error {
+	return initEnvConfigSetAction(envName, productName, namespace, userName, envConfigYamls, inf, kubeClient)
 }
 
-func initEnvConfigSetAction(envName, productName, userName string, envConfigYamls []string, inf informers.SharedInformerFactory, kubeClient client.Client) error {
+func initEnvConfigSetAction(envName, productName, namespace, userName string, envConfigYamls []string, inf informers.SharedInformerFactory, kubeClient client.Client) error {
 	errList := &multierror.Error{
 		ErrorFormat: func(es []error) string {
 			format := "创建环境配置"
@@ -428,7 +428,7 @@ func initEnvConfigSetAction(envName, productName, userName string, envConfigYaml
 			switch u.GetKind() {
 			case setting.ConfigMap, setting.Ingress, setting.Secret, setting.PersistentVolumeClaim:
 				ls := kube.MergeLabels(clusterLabels, u.GetLabels())
-				u.SetNamespace(productName + "-env-" + envName)
+				u.SetNamespace(namespace)
 				u.SetLabels(ls)
 
 				err := updater.CreateOrPatchUnstructuredNeverAnnotation(u, kubeClient)
@@ -292,6 +292,6 @@ func (k *K8sService) createGroup(envName, productName, username string, group []
 	return nil
 }
 
-func (k *K8sService) initEnvConfigSet(envName, productName, userName string, envConfigYamls []string, inf informers.SharedInformerFactory, kubeClient client.Client) error {
-	return initEnvConfigSetAction(envName, productName, userName, envConfigYamls, inf, kubeClient)
+func (k *K8sService) initEnvConfigSet(envName, productName, namespace, userName string, envConfigYamls []string, inf informers.SharedInformerFactory, kubeClient client.Client) error {
+	return initEnvConfigSetAction(envName, productName, namespace, userName, envConfigYamls, inf, kubeClient)
 }
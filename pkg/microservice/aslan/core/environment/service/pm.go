@@ -272,6 +272,6 @@ func (p *PMService) createGroup(envName, productName, username string, group []*
 	return nil
 }
 
-func (p *PMService) initEnvConfigSet(envName, productName, username string, envConfigYamls []string, inf informers.SharedInformerFactory, kubeClient client.Client) error {
+func (p *PMService) initEnvConfigSet(envName, productName, namespace, username string, envConfigYamls []string, inf informers.SharedInformerFactory, kubeClient client.Client) error {
 	return nil
 }
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+)
+
+// PreviewEnvAccess is how to access the preview environment of a pull request
+type PreviewEnvAccess struct {
+	Hosts []string
+	// Header is set if the environment is a sub env of a shared environment, the requests to the hosts of
+	// the base env are routed to the sub env only if they carry the header
+	Header string
+}
+
+func GetPreviewEnvAccess(envName, productName string, log *zap.SugaredLogger) (*PreviewEnvAccess, error) {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &PreviewEnvAccess{}
+	hostEnv := envName
+	if prod.ShareEnv.Enable && !prod.ShareEnv.IsBase {
+		hostEnv = prod.ShareEnv.BaseEnv
+		resp.Header = fmt.Sprintf("%s: %s", zadigMatchXEnv, envName)
+	}
+
+	ingresses, err := ListIngresses(hostEnv, productName, log)
+	if err != nil {
+		return nil, err
+	}
+	for _, ingress := range ingresses {
+		for _, host := range strings.Split(ingress.HostInfo, ",") {
+			if host != "" {
+				resp.Hosts = append(resp.Hosts, host)
+			}
+		}
+	}
+	return resp, nil
+}
@@ -55,9 +55,22 @@ func CleanProductCronJob(requestID string, log *zap.SugaredLogger) {
 	if err != nil {
 		return
 	}
+	lifecycles, err := listEnvLifecycles()
+	if err != nil {
+		log.Errorf("failed to list env lifecycles, err: %s", err)
+		return
+	}
+
+	now := time.Now()
 	wl := sets.NewString(DefaultCleanWhiteList...)
 	wl.Insert(config.CleanSkippedList()...)
 	for _, product := range products {
+		lifecycle, ok := lifecycles[envLifecycleKey(product.ProductName, product.EnvName)]
+		if !ok {
+			lifecycle = defaultEnvLifecycle(product.ProductName, product.EnvName)
+		}
+		handleEnvSleepSchedule(product, lifecycle, now, log)
+
 		if wl.Has(product.EnvName) {
 			continue
 		}
@@ -68,22 +81,7 @@ func CleanProductCronJob(requestID string, log *zap.SugaredLogger) {
 		if _, ok := envCMMap[collaboration.BuildEnvCMMapKey(product.ProductName, product.EnvName)]; ok {
 			continue
 		}
-		if time.Now().Unix()-product.UpdateTime > int64(60*60*24*product.RecycleDay) {
-			//title := "系统清理产品信息"
-			//content := fmt.Sprintf("环境 [%s] 已经连续%d天没有使用, 系统已自动删除该环境, 如有需要请重新创建。", product.EnvName, product.RecycleDay)
-
-			if err := DeleteProduct("robot", product.EnvName, product.ProductName, requestID, log); err != nil {
-				log.Errorf("[%s][P:%s] delete product error: %v", product.EnvName, product.ProductName, err)
-
-				// 如果有错误，重试删除
-				if err := DeleteProduct("robot", product.EnvName, product.ProductName, requestID, log); err != nil {
-					//content = fmt.Sprintf("系统自动清理环境 [%s] 失败，请手动删除环境。", product.ProductName)
-					log.Errorf("[%s][P:%s] retry delete product error: %v", product.EnvName, product.ProductName, err)
-				}
-			}
-
-			log.Warnf("[%s] product %s deleted", product.EnvName, product.ProductName)
-		}
+		handleEnvTTL(product, lifecycle, now.Unix(), requestID, log)
 	}
 }
 
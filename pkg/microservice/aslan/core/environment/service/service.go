@@ -143,7 +143,7 @@ func RestartScale(args *RestartScaleArgs, _ *zap.SugaredLogger) error {
 	}
 
 	// aws secrets needs to be refreshed
-	regs, err := commonservice.ListRegistryNamespaces("", true, log.SugaredLogger())
+	regs, err := commonservice.ListRegistryNamespaces(true, log.SugaredLogger())
 	if err != nil {
 		log.Errorf("Failed to get registries to restart container, the error is: %s", err)
 		return err
@@ -404,7 +404,7 @@ func RestartService(envName string, args *SvcOptArgs, log *zap.SugaredLogger) (e
 	}
 
 	// aws secrets needs to be refreshed
-	regs, err := commonservice.ListRegistryNamespaces("", true, log)
+	regs, err := commonservice.ListRegistryNamespaces(true, log)
 	if err != nil {
 		log.Errorf("Failed to get registries to restart container, the error is: %s", err)
 		return err
@@ -55,8 +55,13 @@ func GetWorkflowBuildJobContainerLogs(c *gin.Context) {
 		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
 		return
 	}
+	attempt, err := getAttempt(c)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid attempt")
+		return
+	}
 	// Use all lowercase job names to avoid subdomain errors
-	ctx.Resp, ctx.Err = logservice.GetWorkflowBuildJobContainerLogs(strings.ToLower(c.Param("pipelineName")), c.Param("serviceName"), c.Query("type"), taskID, ctx.Logger)
+	ctx.Resp, ctx.Err = logservice.GetWorkflowBuildJobContainerLogs(strings.ToLower(c.Param("pipelineName")), c.Param("serviceName"), c.Query("type"), c.Query("step"), taskID, attempt, ctx.Logger)
 }
 
 func GetTestJobContainerLogs(c *gin.Context) {
@@ -83,8 +88,13 @@ func GetWorkflowTestJobContainerLogs(c *gin.Context) {
 		return
 	}
 
+	attempt, err := getAttempt(c)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid attempt")
+		return
+	}
 	// Use all lowercase job names to avoid subdomain errors
-	ctx.Resp, ctx.Err = logservice.GetWorkflowTestJobContainerLogs(strings.ToLower(c.Param("pipelineName")), c.Param("serviceName"), c.Query("workflowType"), taskID, ctx.Logger)
+	ctx.Resp, ctx.Err = logservice.GetWorkflowTestJobContainerLogs(strings.ToLower(c.Param("pipelineName")), c.Param("serviceName"), c.Query("workflowType"), taskID, attempt, ctx.Logger)
 }
 
 func GetContainerLogs(c *gin.Context) {
@@ -126,5 +136,19 @@ func GetWorkflowBuildV3JobContainerLogs(c *gin.Context) {
 		return
 	}
 	// Use all lowercase job names to avoid subdomain errors
-	ctx.Resp, ctx.Err = logservice.GetWorkflowBuildV3JobContainerLogs(strings.ToLower(c.Param("workflowName")), c.Query("type"), taskID, ctx.Logger)
+	attempt, err := getAttempt(c)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid attempt")
+		return
+	}
+
+	ctx.Resp, ctx.Err = logservice.GetWorkflowBuildV3JobContainerLogs(strings.ToLower(c.Param("workflowName")), c.Query("type"), c.Query("dagNode"), taskID, attempt, ctx.Logger)
+}
+
+// getAttempt returns the attempt of a retried sub task in the query, 0 means the last attempt
+func getAttempt(c *gin.Context) (int, error) {
+	if c.Query("attempt") == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(c.Query("attempt"))
 }
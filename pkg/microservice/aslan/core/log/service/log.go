@@ -30,8 +30,10 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
 	s3service "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
 	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/tool/kube/containerlog"
 	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/types"
 	"github.com/koderover/zadig/pkg/util"
 )
 
@@ -45,13 +47,22 @@ func GetBuildJobContainerLogs(pipelineName, serviceName string, taskID int64, lo
 	return buildLog, nil
 }
 
-func GetWorkflowBuildJobContainerLogs(pipelineName, serviceName, buildType string, taskID int64, log *zap.SugaredLogger) (string, error) {
-	buildJobNamePrefix := fmt.Sprintf("%s-%s-%d-%s-%s", config.WorkflowType, pipelineName, taskID, buildType, serviceName)
+// GetWorkflowBuildJobContainerLogs returns the log of the build job, or only the log of the given step if step is not empty
+func GetWorkflowBuildJobContainerLogs(pipelineName, serviceName, buildType, step string, taskID int64, attempt int, log *zap.SugaredLogger) (string, error) {
+	buildJobNamePrefix := attemptLogPrefix(fmt.Sprintf("%s-%s-%d-%s-%s", config.WorkflowType, pipelineName, taskID, buildType, serviceName), attempt)
 	buildLog, err := getContainerLogFromS3(pipelineName, buildJobNamePrefix, taskID, log)
 	if err != nil {
 		return "", err
 	}
 
+	if step != "" {
+		stepLog, found := types.BuildStepLog(buildLog, step)
+		if !found {
+			return "", e.ErrNotFound.AddDesc(fmt.Sprintf("step %s is not found in the build log", step))
+		}
+		return stepLog, nil
+	}
+
 	return buildLog, nil
 }
 
@@ -60,16 +71,24 @@ func GetTestJobContainerLogs(pipelineName, serviceName string, taskID int64, log
 	return getContainerLogFromS3(pipelineName, taskName, taskID, log)
 }
 
-func GetWorkflowTestJobContainerLogs(pipelineName, serviceName, pipelineType string, taskID int64, log *zap.SugaredLogger) (string, error) {
+func GetWorkflowTestJobContainerLogs(pipelineName, serviceName, pipelineType string, taskID int64, attempt int, log *zap.SugaredLogger) (string, error) {
 	workflowTypeString := config.WorkflowType
 	if pipelineType == string(config.TestType) {
 		workflowTypeString = config.TestType
 	}
 
-	taskName := fmt.Sprintf("%s-%s-%d-%s-%s", workflowTypeString, pipelineName, taskID, config.TaskTestingV2, serviceName)
+	taskName := attemptLogPrefix(fmt.Sprintf("%s-%s-%d-%s-%s", workflowTypeString, pipelineName, taskID, config.TaskTestingV2, serviceName), attempt)
 	return getContainerLogFromS3(pipelineName, taskName, taskID, log)
 }
 
+// attemptLogPrefix returns the log of an attempt of a sub task which was retried, attempt 0 means the last attempt
+func attemptLogPrefix(prefix string, attempt int) string {
+	if attempt <= 0 {
+		return prefix
+	}
+	return fmt.Sprintf("%s-attempt-%d", prefix, attempt)
+}
+
 func getContainerLogFromS3(pipelineName, filenamePrefix string, taskID int64, log *zap.SugaredLogger) (string, error) {
 	fileName := strings.Replace(strings.ToLower(filenamePrefix), "_", "-", -1)
 	fileName += ".log"
@@ -138,8 +157,13 @@ func GetCurrentContainerLogs(podName, containerName, envName, productName string
 	return buf.String(), nil
 }
 
-func GetWorkflowBuildV3JobContainerLogs(workflowName, buildType string, taskID int64, log *zap.SugaredLogger) (string, error) {
-	buildJobNamePrefix := fmt.Sprintf("%s-%s-%d-%s-%s", config.WorkflowTypeV3, workflowName, taskID, buildType, fmt.Sprintf("%s-job", workflowName))
+// GetWorkflowBuildV3JobContainerLogs returns the log of a sub task, dagNode is required if the workflow runs as a DAG
+func GetWorkflowBuildV3JobContainerLogs(workflowName, buildType, dagNode string, taskID int64, attempt int, log *zap.SugaredLogger) (string, error) {
+	jobName := fmt.Sprintf("%s-job", workflowName)
+	if dagNode != "" {
+		jobName = strings.Replace(strings.ToLower(dagNode), "_", "-", -1)
+	}
+	buildJobNamePrefix := attemptLogPrefix(fmt.Sprintf("%s-%s-%d-%s-%s", config.WorkflowTypeV3, workflowName, taskID, buildType, jobName), attempt)
 	buildLog, err := getContainerLogFromS3(workflowName, buildJobNamePrefix, taskID, log)
 	if err != nil {
 		return "", err
@@ -60,9 +60,10 @@ type K8SCluster struct {
 }
 
 type AdvancedConfig struct {
-	Strategy     string   `json:"strategy,omitempty"        bson:"strategy,omitempty"`
-	NodeLabels   []string `json:"node_labels,omitempty"     bson:"node_labels,omitempty"`
-	ProjectNames []string `json:"project_names"             bson:"project_names"`
+	Strategy     string               `json:"strategy,omitempty"        bson:"strategy,omitempty"`
+	NodeLabels   []string             `json:"node_labels,omitempty"     bson:"node_labels,omitempty"`
+	ProjectNames []string             `json:"project_names"             bson:"project_names"`
+	Scheduling   *types.JobScheduling `json:"scheduling,omitempty"      bson:"scheduling,omitempty"`
 }
 
 func (k *K8SCluster) Clean() error {
@@ -112,6 +113,7 @@ func ListClusters(ids []string, projectName string, logger *zap.SugaredLogger) (
 				Strategy:     c.AdvancedConfig.Strategy,
 				NodeLabels:   convertToNodeLabels(c.AdvancedConfig.NodeLabels),
 				ProjectNames: getProjectNames(c.ID.Hex(), logger),
+				Scheduling:   c.AdvancedConfig.Scheduling,
 			}
 		}
 		res = append(res, &K8SCluster{
@@ -186,10 +188,14 @@ func CreateCluster(args *K8SCluster, logger *zap.SugaredLogger) (*commonmodels.K
 	s, _ := kube.NewService("")
 	var advancedConfig *commonmodels.AdvancedConfig
 	if args.AdvancedConfig != nil {
+		if err := args.AdvancedConfig.Scheduling.Validate(); err != nil {
+			return nil, e.ErrCreateCluster.AddDesc(err.Error())
+		}
 		advancedConfig = &commonmodels.AdvancedConfig{
 			Strategy:     args.AdvancedConfig.Strategy,
 			NodeLabels:   convertToNodeSelectorRequirements(args.AdvancedConfig.NodeLabels),
 			ProjectNames: args.AdvancedConfig.ProjectNames,
+			Scheduling:   args.AdvancedConfig.Scheduling,
 		}
 	}
 
@@ -218,8 +224,12 @@ func UpdateCluster(id string, args *K8SCluster, logger *zap.SugaredLogger) (*com
 	s, _ := kube.NewService("")
 	advancedConfig := new(commonmodels.AdvancedConfig)
 	if args.AdvancedConfig != nil {
+		if err := args.AdvancedConfig.Scheduling.Validate(); err != nil {
+			return nil, e.ErrUpdateCluster.AddDesc(err.Error())
+		}
 		advancedConfig.Strategy = args.AdvancedConfig.Strategy
 		advancedConfig.NodeLabels = convertToNodeSelectorRequirements(args.AdvancedConfig.NodeLabels)
+		advancedConfig.Scheduling = args.AdvancedConfig.Scheduling
 		// Delete all projects associated with clusterID
 		err := commonrepo.NewProjectClusterRelationColl().Delete(&commonrepo.ProjectClusterRelationOption{ClusterID: id})
 		if err != nil {
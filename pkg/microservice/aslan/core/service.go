@@ -46,6 +46,7 @@ import (
 	testinghandler "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/testing/handler"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/policy"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
 	"github.com/koderover/zadig/pkg/tool/log"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
@@ -261,6 +262,15 @@ func initDatabase() {
 		commonrepo.NewSecretColl(),
 		commonrepo.NewPvcColl(),
 		commonrepo.NewEnvSvcDependColl(),
+		commonrepo.NewConcurrencyQuotaColl(),
+		commonrepo.NewTestCoverageColl(),
+		commonrepo.NewTestCaseResultColl(),
+		commonrepo.NewApprovalDecisionColl(),
+		commonrepo.NewBuildResultCacheColl(),
+		commonrepo.NewEnvDriftColl(),
+		commonrepo.NewEnvSnapshotColl(),
+		commonrepo.NewEnvLifecycleColl(),
+		commonrepo.NewPreviewEnvColl(),
 
 		systemrepo.NewAnnouncementColl(),
 		systemrepo.NewOperationLogColl(),
@@ -268,6 +278,7 @@ func initDatabase() {
 		labelMongodb.NewLabelBindingColl(),
 		modeMongodb.NewCollaborationModeColl(),
 		modeMongodb.NewCollaborationInstanceColl(),
+		encryption.NewDataKeyColl(),
 	} {
 		wg.Add(1)
 		go func(r indexer) {
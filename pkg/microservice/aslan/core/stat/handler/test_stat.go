@@ -90,3 +90,15 @@ func GetTestTrendMeasure(c *gin.Context) {
 	}
 	ctx.Resp, ctx.Err = service.GetTestTrendMeasure(args.StartDate, args.EndDate, args.ProductNames, ctx.Logger)
 }
+
+func GetCoverageTrendMeasure(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	//params validate
+	args := new(getStatReq)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	ctx.Resp, ctx.Err = service.GetCoverageTrendMeasure(args.StartDate, args.EndDate, args.ProductNames, ctx.Logger)
+}
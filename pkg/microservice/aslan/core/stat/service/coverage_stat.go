@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonmongodb "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+type coverageTrend struct {
+	ProductName string               `json:"productName"`
+	Daily       []*coverageDailyStat `json:"daily"`
+}
+
+type coverageDailyStat struct {
+	Date       string  `json:"date"`
+	LineRate   float64 `json:"lineRate"`
+	BranchRate float64 `json:"branchRate"`
+	// Tests is the line coverage of every test on that day
+	Tests map[string]float64 `json:"tests"`
+}
+
+// GetCoverageTrendMeasure returns the daily coverage of the projects, the coverage of a project on a day
+// is calculated from the last coverage of all its tests on that day
+func GetCoverageTrendMeasure(startDate, endDate int64, productNames []string, log *zap.SugaredLogger) ([]*coverageTrend, error) {
+	coverages, err := commonmongodb.NewTestCoverageColl().List(&commonmongodb.TestCoverageListOption{
+		ProductNames: productNames,
+		StartTime:    startDate,
+		EndTime:      endDate,
+	})
+	if err != nil {
+		log.Errorf("ListTestCoverage err:%v", err)
+		return nil, fmt.Errorf("ListTestCoverage err:%v", err)
+	}
+
+	return summarizeCoverageTrend(coverages), nil
+}
+
+// summarizeCoverageTrend groups the coverages sorted by create time by project and date
+func summarizeCoverageTrend(coverages []*commonmodels.TestCoverage) []*coverageTrend {
+	// product name => date => test name => the last coverage of the test on that day
+	latest := make(map[string]map[string]map[string]*commonmodels.TestCoverage)
+	for _, coverage := range coverages {
+		if coverage.Coverage == nil {
+			continue
+		}
+		date := time.Unix(coverage.CreateTime, 0).Format(config.Date)
+		if _, ok := latest[coverage.ProductName]; !ok {
+			latest[coverage.ProductName] = make(map[string]map[string]*commonmodels.TestCoverage)
+		}
+		if _, ok := latest[coverage.ProductName][date]; !ok {
+			latest[coverage.ProductName][date] = make(map[string]*commonmodels.TestCoverage)
+		}
+		latest[coverage.ProductName][date][coverage.TestName] = coverage
+	}
+
+	trends := make([]*coverageTrend, 0, len(latest))
+	for productName, dates := range latest {
+		trend := &coverageTrend{ProductName: productName, Daily: make([]*coverageDailyStat, 0, len(dates))}
+		for date, tests := range dates {
+			summary := types.CoverageSummary{}
+			daily := &coverageDailyStat{Date: date, Tests: make(map[string]float64, len(tests))}
+			for testName, coverage := range tests {
+				summary.Add(coverage.Coverage.CoverageSummary)
+				daily.Tests[testName] = coverage.Coverage.LineRate
+			}
+			daily.LineRate = summary.LineRate
+			daily.BranchRate = summary.BranchRate
+			trend.Daily = append(trend.Daily, daily)
+		}
+		sort.Slice(trend.Daily, func(i, j int) bool {
+			return trend.Daily[i].Date < trend.Daily[j].Date
+		})
+		trends = append(trends, trend)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		return trends[i].ProductName < trends[j].ProductName
+	})
+
+	return trends
+}
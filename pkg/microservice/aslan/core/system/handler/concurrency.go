@@ -18,9 +18,12 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/service"
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
 )
@@ -50,3 +53,59 @@ func UpdateWorkflowConcurrency(c *gin.Context) {
 
 	ctx.Err = service.UpdateWorkflowConcurrency(args.WorkflowConcurrency, args.BuildConcurrency, ctx.Logger)
 }
+
+func GetProjectQueueWeights(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetProjectQueueWeights()
+}
+
+func UpdateProjectQueueWeights(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.ProjectQueueWeightSettings)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = err
+		return
+	}
+
+	// args validation
+	for project, weight := range args.ProjectQueueWeights {
+		if weight <= 0 {
+			ctx.Err = fmt.Errorf("queue weight of project %s cannot be less than 1", project)
+			return
+		}
+	}
+
+	ctx.Err = service.UpdateProjectQueueWeights(args.ProjectQueueWeights, ctx.Logger)
+}
+
+func ListConcurrencyQuotas(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.ListConcurrencyQuotas()
+}
+
+func UpsertConcurrencyQuota(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.ConcurrencyQuota)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = err
+		return
+	}
+	args.UpdateBy = ctx.UserName
+
+	ctx.Err = service.UpsertConcurrencyQuota(args, ctx.Logger)
+}
+
+func DeleteConcurrencyQuota(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Err = service.DeleteConcurrencyQuota(config.ConcurrencyQuotaType(c.Param("type")), c.Param("target"), ctx.Logger)
+}
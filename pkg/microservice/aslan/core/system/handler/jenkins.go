@@ -35,7 +35,7 @@ func CheckJenkinsIntegration(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
 
-	resp, err := service.ListJenkinsIntegration("", ctx.Logger)
+	resp, err := service.ListJenkinsIntegration(false, ctx.Logger)
 	if err != nil || len(resp) == 0 {
 		ctx.Resp = &CheckJenkinsIntegrationResp{Exists: false}
 		return
@@ -64,12 +64,7 @@ func ListJenkinsIntegration(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
 
-	encryptedKey := c.Query("encryptedKey")
-	if len(encryptedKey) == 0 {
-		ctx.Err = e.ErrInvalidParam
-		return
-	}
-	ctx.Resp, ctx.Err = service.ListJenkinsIntegration(encryptedKey, ctx.Logger)
+	ctx.Resp, ctx.Err = service.ListJenkinsIntegration(false, ctx.Logger)
 }
 
 func UpdateJenkinsIntegration(c *gin.Context) {
@@ -43,12 +43,7 @@ func ListPrivateKeys(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
 
-	encryptedKey := c.Query("encryptedKey")
-	if len(encryptedKey) == 0 {
-		ctx.Err = e.ErrInvalidParam
-		return
-	}
-	ctx.Resp, ctx.Err = service.ListPrivateKeys(encryptedKey, ctx.Logger)
+	ctx.Resp, ctx.Err = service.ListPrivateKeys(ctx.Logger)
 }
 
 func GetPrivateKey(c *gin.Context) {
@@ -58,6 +53,13 @@ func GetPrivateKey(c *gin.Context) {
 	ctx.Resp, ctx.Err = service.GetPrivateKey(c.Param("id"), ctx.Logger)
 }
 
+func GetPrivateKeyInternal(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetPrivateKeyInternal(c.Param("id"), ctx.Logger)
+}
+
 func CreatePrivateKey(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -93,12 +93,7 @@ func ListRegistryNamespaces(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
 
-	encryptedKey := c.Query("encryptedKey")
-	if len(encryptedKey) == 0 {
-		ctx.Err = e.ErrInvalidParam
-		return
-	}
-	ctx.Resp, ctx.Err = commonservice.ListRegistryNamespaces(encryptedKey, false, ctx.Logger)
+	ctx.Resp, ctx.Err = service.ListRegistryNamespaces(ctx.Logger)
 }
 
 func CreateRegistryNamespace(c *gin.Context) {
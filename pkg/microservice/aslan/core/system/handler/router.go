@@ -130,6 +130,27 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	{
 		concurrency.GET("/workflow", GetWorkflowConcurrency)
 		concurrency.POST("/workflow", UpdateWorkflowConcurrency)
+		concurrency.GET("/queue", GetProjectQueueWeights)
+		concurrency.POST("/queue", UpdateProjectQueueWeights)
+		concurrency.GET("/quotas", ListConcurrencyQuotas)
+		concurrency.POST("/quotas", gin2.UpdateOperationLogStatus, UpsertConcurrencyQuota)
+		concurrency.DELETE("/quotas/:type/:target", gin2.UpdateOperationLogStatus, DeleteConcurrencyQuota)
+	}
+
+	// image signing settings
+	imageSigning := router.Group("imageSigning")
+	{
+		imageSigning.GET("", GetImageSigning)
+		imageSigning.PUT("", gin2.UpdateOperationLogStatus, UpdateImageSigning)
+		imageSigning.POST("/internal/sign", SignImageInternal)
+		imageSigning.POST("/verify", VerifyImageSignature)
+	}
+
+	// data keys used to encrypt the stored credentials
+	encryption := router.Group("encryption")
+	{
+		encryption.GET("/keys", ListEncryptionKeys)
+		encryption.POST("/rotate", gin2.UpdateOperationLogStatus, RotateEncryptionKey)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -163,6 +184,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	{
 		privateKey.GET("", ListPrivateKeys)
 		privateKey.GET("/internal", ListPrivateKeysInternal)
+		privateKey.GET("/internal/:id", GetPrivateKeyInternal)
 		privateKey.GET("/:id", GetPrivateKey)
 		privateKey.GET("/labels", ListLabels)
 		privateKey.POST("", gin2.UpdateOperationLogStatus, CreatePrivateKey)
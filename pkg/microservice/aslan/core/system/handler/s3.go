@@ -36,12 +36,7 @@ func ListS3Storage(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
 
-	encryptedKey := c.Query("encryptedKey")
-	if len(encryptedKey) == 0 {
-		ctx.Err = e.ErrInvalidParam
-		return
-	}
-	ctx.Resp, ctx.Err = service.ListS3Storage(encryptedKey, ctx.Logger)
+	ctx.Resp, ctx.Err = service.ListS3Storage(ctx.Logger)
 }
 
 func CreateS3Storage(c *gin.Context) {
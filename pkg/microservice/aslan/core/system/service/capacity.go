@@ -44,6 +44,13 @@ var defaultWorkflowTaskRetention = &commonmodels.CapacityStrategy{
 	},
 }
 
+var defaultEnvSnapshotRetention = &commonmodels.CapacityStrategy{
+	Target: commonmodels.EnvSnapshotRetention,
+	Retention: &commonmodels.RetentionConfig{
+		MaxItems: commonmodels.DefaultEnvSnapshotMaxItems,
+	},
+}
+
 func UpdateSysCapStrategy(strategy *commonmodels.CapacityStrategy) error {
 	if err := validateStrategy(strategy); err != nil {
 		return err
@@ -55,7 +62,20 @@ func UpdateSysCapStrategy(strategy *commonmodels.CapacityStrategy) error {
 	}
 
 	// 更新成功后，立即按照新的配置清理数据
-	go handleWorkflowTaskRetentionCenter(strategy, false)
+	switch strategy.Target {
+	case commonmodels.WorkflowTaskRetention:
+		go handleWorkflowTaskRetentionCenter(strategy, false)
+	case commonmodels.EnvSnapshotRetention:
+		// the max items limit is applied per environment when a new snapshot is created
+		if strategy.Retention.MaxDays > 0 {
+			go func() {
+				retentionTime := time.Now().AddDate(0, 0, -strategy.Retention.MaxDays).Unix()
+				if err := commonrepo.NewEnvSnapshotColl().DeleteBefore(retentionTime); err != nil {
+					log.Errorf("failed to clean env snapshots, err: %s", err)
+				}
+			}()
+		}
+	}
 
 	return nil
 }
@@ -65,6 +85,9 @@ func GetCapacityStrategy(target commonmodels.CapacityTarget) (*commonmodels.Capa
 	if err != nil && target == commonmodels.WorkflowTaskRetention {
 		return defaultWorkflowTaskRetention, nil // Return default setup
 	}
+	if err != nil && target == commonmodels.EnvSnapshotRetention {
+		return defaultEnvSnapshotRetention, nil
+	}
 	return result, err
 }
 
@@ -336,6 +359,16 @@ func validateStrategy(strategy *commonmodels.CapacityStrategy) error {
 				"can only set one positive value at a time. days: %v, items: %v",
 				retention.MaxDays, retention.MaxItems)
 		}
+	} else if strategy.Target == commonmodels.EnvSnapshotRetention {
+		retention := strategy.Retention
+		if retention == nil {
+			return errors.New("SysCap strategy: nil retention config for EnvSnapshotRetention")
+		}
+		if retention.MaxDays < 0 || retention.MaxItems < 0 || retention.MaxDays+retention.MaxItems == 0 {
+			return fmt.Errorf("SysCap strategy: max days or items value invalid, "+
+				"at least one positive value is required. days: %v, items: %v",
+				retention.MaxDays, retention.MaxItems)
+		}
 	} else {
 		// Note: currently doesn't support other strategies yet.
 		return fmt.Errorf("SysCap strategy target is invalid - passed in value: %v", strategy.Target)
@@ -18,11 +18,13 @@ package service
 
 import (
 	"errors"
+	"fmt"
 
 	"go.uber.org/zap"
 
 	configbase "github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
 	"github.com/koderover/zadig/pkg/setting"
@@ -61,3 +63,53 @@ func UpdateWorkflowConcurrency(workflowConcurrency, buildConcurrency int64, log
 	}
 	return updater.ScaleDeployment(config.Namespace(), configbase.WarpDriveServiceName(), int(workflowConcurrency), kubeClient)
 }
+
+func GetProjectQueueWeights() (*ProjectQueueWeightSettings, error) {
+	configuration, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		return nil, err
+	}
+	return &ProjectQueueWeightSettings{
+		ProjectQueueWeights: configuration.ProjectQueueWeights,
+	}, nil
+}
+
+func UpdateProjectQueueWeights(weights map[string]int64, log *zap.SugaredLogger) error {
+	err := commonrepo.NewSystemSettingColl().UpdateProjectQueueWeights(weights)
+	if err != nil {
+		log.Errorf("Failed to update project queue weights, the error is: %s", err)
+	}
+	return err
+}
+
+func ListConcurrencyQuotas() ([]*commonmodels.ConcurrencyQuota, error) {
+	return commonrepo.NewConcurrencyQuotaColl().List()
+}
+
+func UpsertConcurrencyQuota(args *commonmodels.ConcurrencyQuota, log *zap.SugaredLogger) error {
+	switch args.Type {
+	case config.ProjectQuota, config.ClusterQuota, config.BuildTypeQuota:
+	default:
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("invalid quota type: %s", args.Type))
+	}
+	if args.Target == "" {
+		return e.ErrInvalidParam.AddDesc("quota target cannot be empty")
+	}
+	if args.Limit <= 0 {
+		return e.ErrInvalidParam.AddDesc("quota limit cannot be less than 1")
+	}
+
+	if err := commonrepo.NewConcurrencyQuotaColl().Upsert(args); err != nil {
+		log.Errorf("Failed to upsert concurrency quota %s/%s, the error is: %s", args.Type, args.Target, err)
+		return err
+	}
+	return nil
+}
+
+func DeleteConcurrencyQuota(quotaType config.ConcurrencyQuotaType, target string, log *zap.SugaredLogger) error {
+	if err := commonrepo.NewConcurrencyQuotaColl().Delete(quotaType, target); err != nil {
+		log.Errorf("Failed to delete concurrency quota %s/%s, the error is: %s", quotaType, target, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/shared/encryption"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+type RotateEncryptionKeyResp struct {
+	Key *encryption.DataKey `json:"key"`
+	// Updated is the number of documents whose credentials are re-encrypted by the new key
+	Updated int `json:"updated"`
+}
+
+func ListEncryptionKeys(log *zap.SugaredLogger) ([]*encryption.DataKey, error) {
+	keys, err := encryption.NewDataKeyColl().List()
+	if err != nil {
+		log.Errorf("failed to list data keys: %s", err)
+		return nil, e.ErrListEncryptionKeys.AddErr(err)
+	}
+	return keys, nil
+}
+
+// RotateEncryptionKey creates a new data key and re-encrypts all stored credentials with it
+func RotateEncryptionKey(log *zap.SugaredLogger) (*RotateEncryptionKeyResp, error) {
+	key, err := encryption.RotateKey()
+	if err != nil {
+		log.Errorf("failed to create data key: %s", err)
+		return nil, e.ErrRotateEncryptionKey.AddErr(err)
+	}
+
+	updated, err := encryption.EncryptSecrets()
+	if err != nil {
+		// secrets sealed by the old keys can still be decrypted, the rotation can be retried safely
+		log.Errorf("failed to re-encrypt credentials with data key %s: %s", key.ID.Hex(), err)
+		return nil, e.ErrRotateEncryptionKey.AddErr(err)
+	}
+	log.Infof("data key is rotated to %s, %d documents are re-encrypted", key.ID.Hex(), updated)
+
+	return &RotateEncryptionKeyResp{Key: key, Updated: updated}, nil
+}
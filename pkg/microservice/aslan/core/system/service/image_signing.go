@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/registry"
+	"github.com/koderover/zadig/pkg/tool/cosign"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// GetImageSigning returns the image signing settings, the private key and its password are never returned
+func GetImageSigning() (*ImageSigningSettings, error) {
+	configuration, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		return nil, err
+	}
+	if configuration.ImageSigning == nil {
+		return &ImageSigningSettings{}, nil
+	}
+	return &ImageSigningSettings{
+		PublicKey:      configuration.ImageSigning.PublicKey,
+		VerifyOnDeploy: configuration.ImageSigning.VerifyOnDeploy,
+		HasPrivateKey:  configuration.ImageSigning.PrivateKey != "",
+	}, nil
+}
+
+// UpdateImageSigning updates the image signing settings, the configured private key and its password
+// are kept if the private key is left empty
+func UpdateImageSigning(args *ImageSigningSettings, log *zap.SugaredLogger) error {
+	configuration, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		return err
+	}
+
+	imageSigning := &commonmodels.ImageSigning{
+		PrivateKey:     args.PrivateKey,
+		Password:       args.Password,
+		PublicKey:      args.PublicKey,
+		VerifyOnDeploy: args.VerifyOnDeploy,
+	}
+	if imageSigning.PrivateKey == "" && configuration.ImageSigning != nil {
+		imageSigning.PrivateKey = configuration.ImageSigning.PrivateKey
+		imageSigning.Password = configuration.ImageSigning.Password
+	}
+
+	if imageSigning.PrivateKey != "" {
+		if block, _ := pem.Decode([]byte(imageSigning.PrivateKey)); block == nil {
+			return e.ErrInvalidParam.AddDesc("invalid private key: no PEM block found")
+		}
+	}
+	if imageSigning.PublicKey != "" {
+		if _, err := cosign.ParsePublicKey(imageSigning.PublicKey); err != nil {
+			return e.ErrInvalidParam.AddErr(err)
+		}
+	}
+	if imageSigning.VerifyOnDeploy && imageSigning.PublicKey == "" {
+		return e.ErrInvalidParam.AddDesc("public key is required to verify images on deploy")
+	}
+
+	if err := commonrepo.NewSystemSettingColl().UpdateImageSigning(imageSigning); err != nil {
+		log.Errorf("Failed to update image signing settings, the error is: %s", err)
+		return err
+	}
+	return nil
+}
+
+// SignImageInternal signs the image pushed by a job, and the SBOM attached to it, with the configured signing key.
+// It is only used by warpdrive after the job is done, the key never leaves aslan so that it is never exposed
+// to the jobs which run user scripts
+func SignImageInternal(args *types.ImageProvenance, log *zap.SugaredLogger) error {
+	configuration, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		return e.ErrSignImage.AddErr(err)
+	}
+	if configuration.ImageSigning == nil || configuration.ImageSigning.PrivateKey == "" {
+		return e.ErrSignImage.AddDesc("no image signing key is configured in the system settings")
+	}
+
+	if _, err := digest.Parse(args.Digest); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	if args.SBOMRef != "" && cosign.ImageRepo(args.SBOMRef) != cosign.ImageRepo(args.Image) {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("sbom %s is not attached to image %s", args.SBOMRef, args.Image))
+	}
+	named, reg, err := commonservice.FindImageRegistry(args.Image, log)
+	if err != nil {
+		return e.ErrSignImage.AddErr(err)
+	}
+
+	dir, err := ioutil.TempDir("", "image-signing-")
+	if err != nil {
+		return e.ErrSignImage.AddErr(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := writeDockerConfig(dir, reference.Domain(named), reg.AccessKey, reg.SecretKey); err != nil {
+		return e.ErrSignImage.AddErr(err)
+	}
+
+	var out bytes.Buffer
+	err = cosign.Sign(args, &cosign.SignOptions{
+		PrivateKey:   configuration.ImageSigning.PrivateKey,
+		Password:     configuration.ImageSigning.Password,
+		DockerConfig: dir,
+		Output:       &out,
+	})
+	if err != nil {
+		log.Errorf("Failed to sign image %s, the error is: %s, output: %s", args.Image, err, out.String())
+		return e.ErrSignImage.AddErr(err)
+	}
+	return nil
+}
+
+func writeDockerConfig(dir, host, username, password string) error {
+	cfg := map[string]map[string]map[string]string{
+		"auths": {
+			host: {
+				"auth": base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "config.json"), data, 0600)
+}
+
+// VerifyImageSignature checks that the image is signed by the configured key pair,
+// the signatures are read from the integrated registry which the image belongs to
+func VerifyImageSignature(image string, log *zap.SugaredLogger) error {
+	configuration, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		return e.ErrVerifyImageSignature.AddErr(err)
+	}
+	if configuration.ImageSigning == nil || configuration.ImageSigning.PublicKey == "" {
+		return e.ErrVerifyImageSignature.AddDesc("public key for image signing is not configured")
+	}
+
+	named, reg, err := commonservice.FindImageRegistry(image, log)
+	if err != nil {
+		return e.ErrVerifyImageSignature.AddErr(err)
+	}
+	ref := "latest"
+	if digested, ok := named.(reference.Digested); ok {
+		ref = digested.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		ref = tagged.Tag()
+	}
+
+	tlsEnabled, tlsCert := true, ""
+	if reg.AdvancedSetting != nil {
+		tlsEnabled, tlsCert = reg.AdvancedSetting.TLSEnabled, reg.AdvancedSetting.TLSCert
+	}
+	dgst, signatures, err := registry.GetImageSignatures(registry.GetRepoImageDetailOption{
+		Endpoint: registry.Endpoint{
+			Addr:   reg.RegAddr,
+			Ak:     reg.AccessKey,
+			Sk:     reg.SecretKey,
+			Region: reg.Region,
+		},
+		Image: reference.Path(named),
+		Tag:   ref,
+	}, tlsEnabled, tlsCert, log)
+	if err != nil {
+		log.Errorf("Failed to get signatures of image %s, the error is: %s", image, err)
+		return e.ErrVerifyImageSignature.AddErr(err)
+	}
+
+	if err := cosign.Verify(configuration.ImageSigning.PublicKey, dgst, signatures); err != nil {
+		return e.ErrVerifyImageSignature.AddDesc(fmt.Sprintf("image %s: %s", image, err))
+	}
+	return nil
+}
@@ -25,8 +25,7 @@ import (
 
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
-	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
-	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/types"
 )
@@ -51,33 +50,34 @@ func CreateJenkinsIntegration(args *commonmodels.JenkinsIntegration, log *zap.Su
 	return nil
 }
 
-func ListJenkinsIntegration(encryptedKey string, log *zap.SugaredLogger) ([]*commonmodels.JenkinsIntegration, error) {
+// ListJenkinsIntegration lists the integrations, the passwords are blanked unless withPassword is set for internal use
+func ListJenkinsIntegration(withPassword bool, log *zap.SugaredLogger) ([]*commonmodels.JenkinsIntegration, error) {
 	jenkinsIntegrations, err := commonrepo.NewJenkinsIntegrationColl().List()
 	if err != nil {
 		log.Errorf("ListJenkinsIntegration err:%v", err)
 		return []*commonmodels.JenkinsIntegration{}, e.ErrListJenkinsIntegration.AddErr(err)
 	}
 
-	if len(encryptedKey) == 0 {
-		return jenkinsIntegrations, nil
-	}
-
-	aesKey, err := service.GetAesKeyFromEncryptedKey(encryptedKey, log)
-	if err != nil {
-		log.Errorf("ListJenkinsIntegration GetAesKeyFromEncryptedKey err:%v", err)
-		return nil, err
-	}
-	for _, integration := range jenkinsIntegrations {
-		integration.Password, err = crypto.AesEncryptByKey(integration.Password, aesKey.PlainText)
-		if err != nil {
-			log.Errorf("ListJenkinsIntegration AesEncryptByKey err:%v", err)
-			return nil, err
+	if !withPassword {
+		for _, integration := range jenkinsIntegrations {
+			integration.Password = ""
 		}
 	}
 	return jenkinsIntegrations, nil
 }
 
 func UpdateJenkinsIntegration(ID string, args *commonmodels.JenkinsIntegration, log *zap.SugaredLogger) error {
+	err := encryption.KeepStored(&args.Password, func() (string, error) {
+		old, err := commonrepo.NewJenkinsIntegrationColl().Get(ID)
+		if err != nil {
+			return "", err
+		}
+		return old.Password, nil
+	})
+	if err != nil {
+		log.Errorf("UpdateJenkinsIntegration err:%v", err)
+		return e.ErrUpdateJenkinsIntegration.AddErr(err)
+	}
 	if err := commonrepo.NewJenkinsIntegrationColl().Update(ID, args); err != nil {
 		log.Errorf("UpdateJenkinsIntegration err:%v", err)
 		return e.ErrUpdateJenkinsIntegration.AddErr(err)
@@ -105,7 +105,7 @@ func TestJenkinsConnection(args *JenkinsArgs, log *zap.SugaredLogger) error {
 
 func getJenkinsClient(log *zap.SugaredLogger) (*gojenkins.Jenkins, context.Context, error) {
 	ctx := context.Background()
-	jenkinsIntegrations, err := ListJenkinsIntegration("", log)
+	jenkinsIntegrations, err := ListJenkinsIntegration(true, log)
 	if err != nil {
 		return nil, ctx, err
 	}
@@ -25,25 +25,19 @@ import (
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/pm"
-	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 )
 
-func ListPrivateKeys(encryptedKey string, log *zap.SugaredLogger) ([]*commonmodels.PrivateKey, error) {
+// ListPrivateKeys lists the hosts without their private keys, use ListPrivateKeysInternal if the keys are needed
+func ListPrivateKeys(log *zap.SugaredLogger) ([]*commonmodels.PrivateKey, error) {
 	resp, err := commonrepo.NewPrivateKeyColl().List(&commonrepo.PrivateKeyArgs{})
 	if err != nil {
 		log.Errorf("PrivateKey.List error: %v", err)
 		return resp, e.ErrListPrivateKeys
 	}
-	aesKey, err := commonservice.GetAesKeyFromEncryptedKey(encryptedKey, log)
-	if err != nil {
-		return nil, err
-	}
 	for _, key := range resp {
-		key.PrivateKey, err = crypto.AesEncryptByKey(key.PrivateKey, aesKey.PlainText)
-		if err != nil {
-			return nil, err
-		}
+		key.PrivateKey = ""
 	}
 	return resp, nil
 }
@@ -60,7 +54,17 @@ func ListPrivateKeysInternal(log *zap.SugaredLogger) ([]*commonmodels.PrivateKey
 	return resp, nil
 }
 
+// GetPrivateKey returns the host without its private key, use GetPrivateKeyInternal if the key is needed
 func GetPrivateKey(id string, log *zap.SugaredLogger) (*commonmodels.PrivateKey, error) {
+	resp, err := GetPrivateKeyInternal(id, log)
+	if err != nil {
+		return nil, err
+	}
+	resp.PrivateKey = ""
+	return resp, nil
+}
+
+func GetPrivateKeyInternal(id string, log *zap.SugaredLogger) (*commonmodels.PrivateKey, error) {
 	resp, err := commonrepo.NewPrivateKeyColl().Find(commonrepo.FindPrivateKeyOption{
 		ID: id,
 	})
@@ -89,6 +93,19 @@ func CreatePrivateKey(args *commonmodels.PrivateKey, log *zap.SugaredLogger) err
 }
 
 func UpdatePrivateKey(id string, args *commonmodels.PrivateKey, log *zap.SugaredLogger) error {
+	if !args.UpdateStatus {
+		err := encryption.KeepStored(&args.PrivateKey, func() (string, error) {
+			old, err := GetPrivateKeyInternal(id, log)
+			if err != nil {
+				return "", err
+			}
+			return old.PrivateKey, nil
+		})
+		if err != nil {
+			return e.ErrUpdatePrivateKey
+		}
+	}
+
 	err := commonrepo.NewPrivateKeyColl().Update(id, args)
 	if err != nil {
 		log.Errorf("PrivateKey.Update %s error: %v", id, err)
@@ -29,6 +29,7 @@ import (
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/registry"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	registrytool "github.com/koderover/zadig/pkg/tool/registries"
@@ -57,7 +58,7 @@ const (
 
 // ListRegistries 为了抹掉ak和sk的数据
 func ListRegistries(log *zap.SugaredLogger) ([]*commonmodels.RegistryNamespace, error) {
-	registryNamespaces, err := commonservice.ListRegistryNamespaces("", false, log)
+	registryNamespaces, err := commonservice.ListRegistryNamespaces(false, log)
 	if err != nil {
 		log.Errorf("RegistryNamespace.List error: %v", err)
 		return registryNamespaces, fmt.Errorf("RegistryNamespace.List error: %v", err)
@@ -69,6 +70,18 @@ func ListRegistries(log *zap.SugaredLogger) ([]*commonmodels.RegistryNamespace,
 	return registryNamespaces, nil
 }
 
+// ListRegistryNamespaces lists the registries for the client, the secret keys are never returned to it
+func ListRegistryNamespaces(log *zap.SugaredLogger) ([]*commonmodels.RegistryNamespace, error) {
+	registryNamespaces, err := commonservice.ListRegistryNamespaces(false, log)
+	if err != nil {
+		return nil, err
+	}
+	for _, registryNamespace := range registryNamespaces {
+		registryNamespace.SecretKey = ""
+	}
+	return registryNamespaces, nil
+}
+
 func CreateRegistryNamespace(username string, args *commonmodels.RegistryNamespace, log *zap.SugaredLogger) error {
 	regOps := new(commonrepo.FindRegOps)
 	regOps.IsDefault = true
@@ -128,6 +141,18 @@ func UpdateRegistryNamespace(username, id string, args *commonmodels.RegistryNam
 	args.UpdateBy = username
 	args.Namespace = strings.TrimSpace(args.Namespace)
 
+	err := encryption.KeepStored(&args.SecretKey, func() (string, error) {
+		old, _, err := commonservice.FindRegistryById(id, false, log)
+		if err != nil {
+			return "", err
+		}
+		return old.SecretKey, nil
+	})
+	if err != nil {
+		log.Errorf("failed to find registry %s: %s", id, err)
+		return fmt.Errorf("RegistryNamespace.Update error: %v", err)
+	}
+
 	if err := commonrepo.NewRegistryNamespaceColl().Update(id, args); err != nil {
 		log.Errorf("RegistryNamespace.Update error: %v", err)
 		return fmt.Errorf("RegistryNamespace.Update error: %v", err)
@@ -181,7 +206,7 @@ func DeleteRegistryNamespace(id string, log *zap.SugaredLogger) error {
 
 func ListAllRepos(log *zap.SugaredLogger) ([]*RepoInfo, error) {
 	repoInfos := make([]*RepoInfo, 0)
-	resp, err := commonservice.ListRegistryNamespaces("", false, log)
+	resp, err := commonservice.ListRegistryNamespaces(false, log)
 	if err != nil {
 		log.Errorf("RegistryNamespace.List error: %v", err)
 		return nil, fmt.Errorf("RegistryNamespace.List error: %v", err)
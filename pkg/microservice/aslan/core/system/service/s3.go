@@ -27,15 +27,26 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
-	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
 	"github.com/koderover/zadig/pkg/setting"
-	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	"github.com/koderover/zadig/pkg/tool/errors"
 	s3tool "github.com/koderover/zadig/pkg/tool/s3"
 )
 
 func UpdateS3Storage(updateBy, id string, storage *commonmodels.S3Storage, logger *zap.SugaredLogger) error {
+	err := encryption.KeepStored(&storage.Sk, func() (string, error) {
+		old, err := commonrepo.NewS3StorageColl().Find(id)
+		if err != nil {
+			return "", err
+		}
+		return old.Sk, nil
+	})
+	if err != nil {
+		logger.Errorf("can't find store by id %s", id)
+		return err
+	}
+
 	s3Storage := &s3.S3{S3Storage: storage}
 	forcedPathStyle := true
 	if s3Storage.Provider == setting.ProviderSourceAli {
@@ -75,24 +86,20 @@ func CreateS3Storage(updateBy string, storage *commonmodels.S3Storage, logger *z
 	return commonrepo.NewS3StorageColl().Create(storage)
 }
 
-func ListS3Storage(encryptedKey string, logger *zap.SugaredLogger) ([]*commonmodels.S3Storage, error) {
+// ListS3Storage lists the storages without their secret keys
+func ListS3Storage(logger *zap.SugaredLogger) ([]*commonmodels.S3Storage, error) {
 	stores, err := commonrepo.NewS3StorageColl().FindAll()
-	if err == nil && len(stores) == 0 {
-		stores = make([]*commonmodels.S3Storage, 0)
-	}
-	aesKey, err := service.GetAesKeyFromEncryptedKey(encryptedKey, logger)
 	if err != nil {
-		logger.Errorf("ListS3Storage GetAesKeyFromEncryptedKey err:%s", err)
+		logger.Errorf("ListS3Storage err:%s", err)
 		return nil, err
 	}
+	if len(stores) == 0 {
+		stores = make([]*commonmodels.S3Storage, 0)
+	}
 	for _, store := range stores {
-		store.Sk, err = crypto.AesEncryptByKey(store.Sk, aesKey.PlainText)
-		if err != nil {
-			logger.Errorf("ListS3Storage AesEncryptByKey err:%s", err)
-			return nil, err
-		}
+		store.Sk = ""
 	}
-	return stores, err
+	return stores, nil
 }
 
 func DeleteS3Storage(deleteBy string, id string, logger *zap.SugaredLogger) error {
@@ -111,6 +118,7 @@ func GetS3Storage(id string, logger *zap.SugaredLogger) (*commonmodels.S3Storage
 		logger.Errorf("can't find store by id %s", id)
 		return nil, err
 	}
+	store.Sk = ""
 
 	return store, nil
 }
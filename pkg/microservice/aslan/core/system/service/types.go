@@ -27,3 +27,20 @@ type WorkflowConcurrencySettings struct {
 	WorkflowConcurrency int64 `json:"workflow_concurrency"`
 	BuildConcurrency    int64 `json:"build_concurrency"`
 }
+
+type ProjectQueueWeightSettings struct {
+	ProjectQueueWeights map[string]int64 `json:"project_queue_weights"`
+}
+
+type ImageSigningSettings struct {
+	PrivateKey     string `json:"private_key,omitempty"`
+	Password       string `json:"password,omitempty"`
+	PublicKey      string `json:"public_key"`
+	VerifyOnDeploy bool   `json:"verify_on_deploy"`
+	// HasPrivateKey tells whether a private key is configured since the key itself is never returned
+	HasPrivateKey bool `json:"has_private_key"`
+}
+
+type VerifyImageSignatureArgs struct {
+	Image string `json:"image"`
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// NotifyApprovers is called by warpdrive when the approval stage of a task starts
+func NotifyApprovers(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	ctx.Err = workflow.NotifyApprovers(c.Param("name"), taskID, ctx.Logger)
+}
+
+func ListApprovalDecisions(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	ctx.Resp, ctx.Err = workflow.ListApprovalDecisions(c.Param("name"), taskID, ctx.Logger)
+}
+
+type approveWorkflowTaskReq struct {
+	Approve bool   `json:"approve"`
+	Comment string `json:"comment"`
+}
+
+// ApproveWorkflowTask approves or rejects the approval stage of a task
+func ApproveWorkflowTask(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	args := new(approveWorkflowTaskReq)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid approval args")
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, c.Query("projectName"), "审批", "工作流-task", c.Param("name"), strconv.FormatBool(args.Approve), ctx.Logger)
+
+	ctx.Err = workflow.ApproveWorkflowTask(c.Param("name"), taskID, ctx.UserName, args.Approve, args.Comment, ctx.Logger)
+}
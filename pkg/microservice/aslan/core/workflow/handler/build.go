@@ -22,6 +22,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
 )
 
 func BuildModuleToSubTasks(c *gin.Context) {
@@ -41,3 +42,25 @@ func BuildModuleToSubTasks(c *gin.Context) {
 	workflow.EnsureSubTasksResp(resp)
 	ctx.Resp = resp
 }
+
+// GetBuildResultCache is called by warpdrive to find the image of a previous build with the same content key
+func GetBuildResultCache(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = workflow.GetBuildResultCache(c.Param("key"), ctx.Logger)
+}
+
+// SaveBuildResultCache is called by warpdrive when a build succeeds
+func SaveBuildResultCache(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(models.BuildResultCache)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid build result cache args")
+		return
+	}
+
+	ctx.Err = workflow.SaveBuildResultCache(args, ctx.Logger)
+}
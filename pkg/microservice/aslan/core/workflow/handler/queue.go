@@ -0,0 +1,60 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// ListQueuePositions lists queued tasks in scheduling order
+func ListQueuePositions(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp = workflow.ListQueuePositions()
+}
+
+type updateQueuedTaskPriorityReq struct {
+	Priority int `json:"priority"`
+}
+
+// UpdateQueuedTaskPriority bumps or demotes a queued task
+func UpdateQueuedTaskPriority(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	args := new(updateQueuedTaskPriorityReq)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid priority")
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, c.Query("projectName"), "调整优先级", "工作流task", c.Param("name"), strconv.Itoa(args.Priority), ctx.Logger)
+
+	ctx.Err = workflow.UpdateQueuedTaskPriority(c.Param("name"), taskID, args.Priority, ctx.Logger)
+}
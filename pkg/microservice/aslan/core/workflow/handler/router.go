@@ -36,6 +36,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	build := router.Group("build")
 	{
 		build.GET("/:name/:version/to/subtasks", BuildModuleToSubTasks)
+		build.GET("/result-cache/:key", GetBuildResultCache)
+		build.POST("/result-cache", SaveBuildResultCache)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -72,6 +74,15 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		statusV2.GET("/task/info", FindTasks)
 	}
 
+	// ---------------------------------------------------------------------------------------
+	// 任务队列接口
+	// ---------------------------------------------------------------------------------------
+	queue := router.Group("queue")
+	{
+		queue.GET("", ListQueuePositions)
+		queue.PUT("/id/:id/pipelines/:name/priority", gin2.UpdateOperationLogStatus, UpdateQueuedTaskPriority)
+	}
+
 	// ---------------------------------------------------------------------------------------
 	// Pipeline 任务管理接口
 	// ---------------------------------------------------------------------------------------
@@ -130,6 +141,9 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		workflowtask.POST("/id/:id/pipelines/:name/restart", gin2.UpdateOperationLogStatus, RestartWorkflowTask)
 		workflowtask.DELETE("/id/:id/pipelines/:name", gin2.UpdateOperationLogStatus, CancelWorkflowTaskV2)
 		workflowtask.GET("/callback/id/:id/name/:name", GetWorkflowTaskCallback)
+		workflowtask.POST("/approval/id/:id/pipelines/:name/notify", NotifyApprovers)
+		workflowtask.GET("/approval/id/:id/pipelines/:name", ListApprovalDecisions)
+		workflowtask.POST("/approval/id/:id/pipelines/:name", gin2.UpdateOperationLogStatus, ApproveWorkflowTask)
 	}
 
 	serviceTask := router.Group("servicetask")
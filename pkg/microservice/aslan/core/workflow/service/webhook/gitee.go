@@ -83,7 +83,15 @@ func ProcessGiteeHook(payload []byte, req *http.Request, requestID string, log *
 			}
 		}()
 	case *gitee.PullRequestEvent:
-		if event.Action != "open" && event.Action != "update" {
+		// recycle the preview environments of the pull request
+		if event.Action == "close" || event.Action == "merge" {
+			if event.PullRequest == nil {
+				return nil
+			}
+			return RecyclePreviewEnvs(setting.SourceFromGitee, event.PullRequest.Base.Repo.HTMLURL, event.PullRequest.Base.Repo.FullName, event.PullRequest.Number, requestID, log)
+		}
+		// a reopened pull request gets its preview environment back
+		if event.Action != "open" && event.Action != "reopen" && event.Action != "update" {
 			return fmt.Errorf("action %s is skipped", event.Action)
 		}
 
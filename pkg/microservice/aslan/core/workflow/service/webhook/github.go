@@ -434,7 +434,16 @@ func ProcessGithubWebHook(payload []byte, req *http.Request, requestID string, l
 
 	switch et := event.(type) {
 	case *github.PullRequestEvent:
-		if *et.Action != "opened" && *et.Action != "synchronize" {
+		// recycle the preview environments of the pull request, a merged pull request is closed as well
+		if *et.Action == "closed" {
+			if err = RecyclePreviewEnvs(setting.SourceFromGithub, et.GetRepo().GetHTMLURL(), et.GetRepo().GetFullName(), et.GetPullRequest().GetNumber(), requestID, log); err != nil {
+				log.Errorf("RecyclePreviewEnvs error: %v", err)
+				return e.ErrGithubWebHook.AddErr(err)
+			}
+			return nil
+		}
+		// a reopened pull request gets its preview environment back
+		if *et.Action != "opened" && *et.Action != "synchronize" && *et.Action != "reopened" {
 			return nil
 		}
 
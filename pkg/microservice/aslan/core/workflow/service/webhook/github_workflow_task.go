@@ -390,6 +390,18 @@ func TriggerWorkflowByGithubEvent(event interface{}, baseURI, deliveryID, reques
 					args.HookPayload = hookPayload
 
 					// 3. create task with args
+					if isPreviewEnvEnabled(item.WorkflowArgs) {
+						if mergeRequestID == "" {
+							log.Warnf("It's not a PR event,BaseNamespace:%s", item.WorkflowArgs.BaseNamespace)
+							continue
+						}
+						prID, _ := strconv.Atoi(mergeRequestID)
+						if err := CreatePreviewEnvAndTask(args, prID, requestID, log); err != nil {
+							log.Errorf("CreatePreviewEnvAndTask err:%v", err)
+							mErr = multierror.Append(mErr, err)
+						}
+						continue
+					}
 					if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
 						log.Errorf("failed to create workflow task when receive push event due to %v ", err)
 						mErr = multierror.Append(mErr, err)
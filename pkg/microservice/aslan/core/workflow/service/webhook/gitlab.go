@@ -95,6 +95,12 @@ func ProcessGitlabHook(payload []byte, req *http.Request, requestID string, log
 		}
 	case *gitlab.MergeEvent:
 		mergeEvent = event
+		// recycle the preview environments of the merge request
+		if event.ObjectAttributes.State == "closed" || event.ObjectAttributes.State == "merged" {
+			if err = RecyclePreviewEnvs(setting.SourceFromGitlab, event.ObjectAttributes.Target.WebURL, event.ObjectAttributes.Target.PathWithNamespace, event.ObjectAttributes.IID, requestID, log); err != nil {
+				errorList = multierror.Append(errorList, err)
+			}
+		}
 	case *gitlab.TagEvent:
 		tagEvent = event
 	}
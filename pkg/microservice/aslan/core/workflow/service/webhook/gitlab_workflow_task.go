@@ -642,7 +642,16 @@ func TriggerWorkflowByGitlabEvent(event interface{}, baseURI, requestID string,
 			args.RepoName = item.MainRepo.RepoName
 			args.Committer = item.MainRepo.Committer
 			// 3. create task with args
-			if item.WorkflowArgs.BaseNamespace == "" {
+			if isPreviewEnvEnabled(item.WorkflowArgs) {
+				if !isMergeRequest {
+					log.Warnf("It's not a PR event,BaseNamespace:%s", item.WorkflowArgs.BaseNamespace)
+					continue
+				}
+				if err = CreatePreviewEnvAndTask(args, prID, requestID, log); err != nil {
+					log.Errorf("CreatePreviewEnvAndTask err:%v", err)
+					mErr = multierror.Append(mErr, err)
+				}
+			} else if item.WorkflowArgs.BaseNamespace == "" {
 				if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
 					log.Errorf("failed to create workflow task when receive push event %v due to %v ", event, err)
 					mErr = multierror.Append(mErr, err)
@@ -689,8 +698,7 @@ var mutex sync.Mutex
 // CreateEnvAndTaskByPR 根据pr触发创建环境、使用工作流更新该创建的环境、根据环境删除策略删除环境
 func CreateEnvAndTaskByPR(workflowArgs *commonmodels.WorkflowTaskArgs, prID int, requestID string, log *zap.SugaredLogger) error {
 	//获取基准环境的详细信息
-	opt := &commonrepo.ProductFindOptions{Name: workflowArgs.ProductTmplName, EnvName: workflowArgs.BaseNamespace}
-	baseProduct, err := commonrepo.NewProductColl().Find(opt)
+	baseProduct, err := newEnvFromBase(workflowArgs.ProductTmplName, workflowArgs.BaseNamespace, prID)
 	if err != nil {
 		return fmt.Errorf("CreateEnvAndTaskByPR Product Find err:%v", err)
 	}
@@ -699,17 +707,8 @@ func CreateEnvAndTaskByPR(workflowArgs *commonmodels.WorkflowTaskArgs, prID int,
 	defer func() {
 		mutex.Unlock()
 	}()
-	if baseProduct.Render != nil {
-		if renderSet, _ := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: baseProduct.Render.Name, Revision: baseProduct.Render.Revision}); renderSet != nil {
-			baseProduct.Vars = renderSet.KVs
-		}
-	}
 
-	envName := fmt.Sprintf("%s-%d-%s%s", "pr", prID, util.GetRandomNumString(3), util.GetRandomString(3))
-	util.Clear(&baseProduct.ID)
-	baseProduct.Namespace = commonservice.GetProductEnvNamespace(envName, workflowArgs.ProductTmplName, "")
-	baseProduct.UpdateBy = setting.SystemUser
-	baseProduct.EnvName = envName
+	envName := baseProduct.EnvName
 	err = environmentservice.CreateProduct(setting.SystemUser, requestID, baseProduct, log)
 	if err != nil {
 		return fmt.Errorf("CreateEnvAndTaskByPR CreateProduct err:%v", err)
@@ -759,6 +758,27 @@ func CreateEnvAndTaskByPR(workflowArgs *commonmodels.WorkflowTaskArgs, prID int,
 	return nil
 }
 
+// newEnvFromBase copies the base environment into a new environment for the pull request
+func newEnvFromBase(productName, baseEnvName string, prID int) (*commonmodels.Product, error) {
+	opt := &commonrepo.ProductFindOptions{Name: productName, EnvName: baseEnvName}
+	baseProduct, err := commonrepo.NewProductColl().Find(opt)
+	if err != nil {
+		return nil, err
+	}
+	if baseProduct.Render != nil {
+		if renderSet, _ := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: baseProduct.Render.Name, Revision: baseProduct.Render.Revision}); renderSet != nil {
+			baseProduct.Vars = renderSet.KVs
+		}
+	}
+
+	envName := fmt.Sprintf("%s-%d-%s%s", "pr", prID, util.GetRandomNumString(3), util.GetRandomString(3))
+	util.Clear(&baseProduct.ID)
+	baseProduct.Namespace = commonservice.GetProductEnvNamespace(envName, productName, "")
+	baseProduct.UpdateBy = setting.SystemUser
+	baseProduct.EnvName = envName
+	return baseProduct, nil
+}
+
 func WaitEnvCreate(timeoutSeconds int, envName string, workflowArgs *commonmodels.WorkflowTaskArgs, log *zap.SugaredLogger) error {
 	timeout := false
 	go func() {
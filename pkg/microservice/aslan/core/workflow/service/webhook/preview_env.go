@@ -0,0 +1,284 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
+	environmentservice "github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
+	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+const (
+	previewEnvStatusCreating  = "创建中"
+	previewEnvStatusDeploying = "部署中"
+	previewEnvStatusFailed    = "创建失败"
+	previewEnvStatusDeleted   = "已销毁"
+)
+
+var previewEnvMutex sync.Mutex
+
+// isPreviewEnvEnabled reports whether the workflow creates a preview environment for every pull request
+func isPreviewEnvEnabled(args *commonmodels.WorkflowTaskArgs) bool {
+	return args != nil && args.BaseNamespace != "" && args.EnvRecyclePolicy == setting.EnvRecyclePolicyPRClosed
+}
+
+// CreatePreviewEnvAndTask creates the preview environment of the pull request from the base environment when the pull
+// request is opened, and deploys the changes of every push to it by the workflow
+func CreatePreviewEnvAndTask(workflowArgs *commonmodels.WorkflowTaskArgs, prID int, requestID string, log *zap.SugaredLogger) error {
+	preview, created, err := ensurePreviewEnv(workflowArgs, prID, requestID, log)
+	if err != nil {
+		return fmt.Errorf("CreatePreviewEnvAndTask ensurePreviewEnv err:%v", err)
+	}
+
+	if created {
+		updatePreviewEnvComment(preview, previewEnvStatusCreating, nil, log)
+		if err = WaitEnvCreate(config.ServiceStartTimeout(), preview.EnvName, workflowArgs, log); err != nil {
+			updatePreviewEnvComment(preview, previewEnvStatusFailed, nil, log)
+			return err
+		}
+	}
+
+	workflowArgs.Namespace = preview.EnvName
+	taskResp, err := workflowservice.CreateWorkflowTask(workflowArgs, setting.WebhookTaskCreator, log)
+	if err != nil {
+		return fmt.Errorf("CreatePreviewEnvAndTask CreateWorkflowTask err：%v ", err)
+	}
+	if err = commonrepo.NewPreviewEnvColl().UpdateCommit(preview.ID.Hex(), workflowArgs.CommitID, time.Now().Unix()); err != nil {
+		log.Errorf("CreatePreviewEnvAndTask UpdateCommit of env %s err:%v", preview.EnvName, err)
+	}
+	updatePreviewEnvComment(preview, previewEnvStatusDeploying, taskResp, log)
+	return nil
+}
+
+// ensurePreviewEnv returns the preview environment of the pull request, it is created if not exists
+func ensurePreviewEnv(workflowArgs *commonmodels.WorkflowTaskArgs, prID int, requestID string, log *zap.SugaredLogger) (*commonmodels.PreviewEnv, bool, error) {
+	previewEnvMutex.Lock()
+	defer previewEnvMutex.Unlock()
+
+	coll := commonrepo.NewPreviewEnvColl()
+	preview, err := coll.Find(&commonrepo.PreviewEnvFindOption{
+		WorkflowName: workflowArgs.WorkflowName,
+		CodehostID:   workflowArgs.CodehostID,
+		RepoOwner:    workflowArgs.RepoOwner,
+		RepoName:     workflowArgs.RepoName,
+		PrID:         prID,
+	})
+	var commentID string
+	switch {
+	case err == nil:
+		_, err = commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: preview.ProductName, EnvName: preview.EnvName})
+		if err == nil {
+			return preview, false, nil
+		}
+		if !commonrepo.IsErrNoDocuments(err) {
+			return nil, false, err
+		}
+		// the environment is deleted by hand or by its TTL, a new one is created for the pull request
+		log.Infof("preview env %s of %s/%s#%d is deleted, recreate it", preview.EnvName, preview.RepoOwner, preview.RepoName, prID)
+		if err = coll.Delete(preview.ID.Hex()); err != nil {
+			return nil, false, err
+		}
+		commentID = preview.CommentID
+	case !commonrepo.IsErrNoDocuments(err):
+		return nil, false, err
+	}
+
+	prod, err := newEnvFromBase(workflowArgs.ProductTmplName, workflowArgs.BaseNamespace, prID)
+	if err != nil {
+		return nil, false, err
+	}
+	prod = newPreviewEnvProduct(prod, workflowArgs.BaseNamespace, workflowArgs.Target)
+	if err = environmentservice.CreateProduct(setting.SystemUser, requestID, prod, log); err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now().Unix()
+	preview = &commonmodels.PreviewEnv{
+		ProductName:  workflowArgs.ProductTmplName,
+		WorkflowName: workflowArgs.WorkflowName,
+		BaseEnvName:  workflowArgs.BaseNamespace,
+		EnvName:      prod.EnvName,
+		Source:       workflowArgs.Source,
+		CodehostID:   workflowArgs.CodehostID,
+		RepoOwner:    workflowArgs.RepoOwner,
+		RepoName:     workflowArgs.RepoName,
+		PrID:         prID,
+		CommitID:     workflowArgs.CommitID,
+		CommentID:    commentID,
+		CreateTime:   now,
+		UpdateTime:   now,
+	}
+	if err = coll.Create(preview); err != nil {
+		return nil, false, err
+	}
+	return preview, true, nil
+}
+
+// newPreviewEnvProduct makes the copy of a shared base environment a sub env which only contains the services
+// changed in the pull request, the other requests are served by the base environment
+func newPreviewEnvProduct(prod *commonmodels.Product, baseEnvName string, targets []*commonmodels.TargetArgs) *commonmodels.Product {
+	if !prod.ShareEnv.Enable || !prod.ShareEnv.IsBase {
+		return prod
+	}
+
+	serviceNames := sets.NewString()
+	for _, target := range targets {
+		serviceNames.Insert(target.ServiceName)
+	}
+	services := make([][]*commonmodels.ProductService, 0, len(prod.Services))
+	for _, group := range prod.Services {
+		svcs := make([]*commonmodels.ProductService, 0, len(group))
+		for _, svc := range group {
+			if serviceNames.Has(svc.ServiceName) {
+				svcs = append(svcs, svc)
+			}
+		}
+		services = append(services, svcs)
+	}
+
+	prod.Services = services
+	prod.ShareEnv = commonmodels.ProductShareEnv{
+		Enable:  true,
+		IsBase:  false,
+		BaseEnv: baseEnvName,
+	}
+	return prod
+}
+
+// RecyclePreviewEnvs deletes the preview environments of the pull request when it is closed or merged, source is the
+// type of the codehost which sends the webhook and repoURL is the web url of the repo
+func RecyclePreviewEnvs(source, repoURL, repoFullName string, prID int, requestID string, log *zap.SugaredLogger) error {
+	owner, name := repoFullName, ""
+	if i := strings.LastIndex(repoFullName, "/"); i >= 0 {
+		owner, name = repoFullName[:i], repoFullName[i+1:]
+	}
+
+	codehostIDs, err := listCodehostIDs(source, repoURL)
+	if err != nil {
+		return fmt.Errorf("RecyclePreviewEnvs listCodehostIDs err:%v", err)
+	}
+
+	// hold the lock so that a push event of the pull request handled at the same time can not create the env again
+	previewEnvMutex.Lock()
+	defer previewEnvMutex.Unlock()
+
+	previews, err := commonrepo.NewPreviewEnvColl().List(&commonrepo.PreviewEnvListOption{
+		CodehostIDs: codehostIDs,
+		RepoOwner:   owner,
+		RepoName:    name,
+		PrID:        prID,
+	})
+	if err != nil {
+		return fmt.Errorf("RecyclePreviewEnvs List err:%v", err)
+	}
+
+	for _, preview := range previews {
+		log.Infof("recycle preview env %s of %s#%d", preview.EnvName, repoFullName, prID)
+		_, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: preview.ProductName, EnvName: preview.EnvName})
+		if err == nil {
+			if err = environmentservice.DeleteProduct(setting.SystemUser, preview.EnvName, preview.ProductName, requestID, log); err != nil {
+				log.Errorf("RecyclePreviewEnvs DeleteProduct %s err:%v", preview.EnvName, err)
+				continue
+			}
+		} else if !commonrepo.IsErrNoDocuments(err) {
+			log.Errorf("RecyclePreviewEnvs Product Find %s err:%v", preview.EnvName, err)
+			continue
+		}
+
+		if err = commonrepo.NewPreviewEnvColl().Delete(preview.ID.Hex()); err != nil {
+			log.Errorf("RecyclePreviewEnvs Delete %s err:%v", preview.EnvName, err)
+		}
+		updatePreviewEnvComment(preview, previewEnvStatusDeleted, nil, log)
+	}
+	return nil
+}
+
+// listCodehostIDs returns the codehosts of the server which hosts the repo, all the accounts of a server see the same repos
+func listCodehostIDs(source, repoURL string) ([]int, error) {
+	address, err := util.GetAddress(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	codehosts, err := systemconfig.New().ListCodeHostsInternal()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0)
+	for _, codehost := range codehosts {
+		if codehost.Type == source && strings.TrimSuffix(codehost.Address, "/") == address {
+			ids = append(ids, codehost.ID)
+		}
+	}
+	return ids, nil
+}
+
+func updatePreviewEnvComment(preview *commonmodels.PreviewEnv, status string, task *workflowservice.CreateTaskResp, log *zap.SugaredLogger) {
+	var access *environmentservice.PreviewEnvAccess
+	if status == previewEnvStatusDeploying {
+		var err error
+		if access, err = environmentservice.GetPreviewEnvAccess(preview.EnvName, preview.ProductName, log); err != nil {
+			log.Warnf("failed to get access of preview env %s, err:%v", preview.EnvName, err)
+		}
+	}
+
+	comment := previewEnvComment(preview, config.SystemAddress(), status, task, access)
+	if err := scmnotify.NewService().UpdatePreviewEnvComment(preview, comment, log); err != nil {
+		log.Errorf("failed to comment preview env %s, err:%v", preview.EnvName, err)
+	}
+}
+
+// previewEnvComment renders the comment of the preview environment in its pull request
+func previewEnvComment(preview *commonmodels.PreviewEnv, baseURI, status string, task *workflowservice.CreateTaskResp, access *environmentservice.PreviewEnvAccess) string {
+	if status == previewEnvStatusDeleted {
+		return fmt.Sprintf("预览环境：%s 状态：%s", preview.EnvName, status)
+	}
+
+	lines := []string{
+		fmt.Sprintf("预览环境：[%s](%s/v1/projects/detail/%s/envs/detail?envName=%s) 状态：%s", preview.EnvName, baseURI, preview.ProductName, preview.EnvName, status),
+		fmt.Sprintf("基准环境：%s", preview.BaseEnvName),
+	}
+	if task != nil {
+		lines = append(lines, fmt.Sprintf("部署工作流：[%s#%d](%s/v1/projects/detail/%s/pipelines/multi/%s/%d)", task.PipelineName, task.TaskID, baseURI, task.ProjectName, task.PipelineName, task.TaskID))
+	}
+	if access != nil && len(access.Hosts) > 0 {
+		hosts := make([]string, 0, len(access.Hosts))
+		for _, host := range access.Hosts {
+			hosts = append(hosts, fmt.Sprintf("- http://%s", host))
+		}
+		lines = append(lines, "访问地址：\n"+strings.Join(hosts, "\n"))
+		if access.Header != "" {
+			lines = append(lines, fmt.Sprintf("访问时请携带请求头：`%s`", access.Header))
+		}
+	}
+	lines = append(lines, "PR 关闭或合并之后该环境将被自动销毁")
+	return strings.Join(lines, "\n\n")
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	environmentservice "github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
+	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+var _ = Describe("Testing preview env", func() {
+
+	newProduct := func(shareEnv commonmodels.ProductShareEnv) *commonmodels.Product {
+		return &commonmodels.Product{
+			ProductName: "demo",
+			EnvName:     "pr-1-abc",
+			ShareEnv:    shareEnv,
+			Services: [][]*commonmodels.ProductService{
+				{{ServiceName: "a"}, {ServiceName: "b"}},
+				{{ServiceName: "c"}},
+			},
+		}
+	}
+	targets := []*commonmodels.TargetArgs{{ServiceName: "b"}, {ServiceName: "c"}}
+
+	It("should only be enabled for the pr_closed recycle policy with a base env", func() {
+		Expect(isPreviewEnvEnabled(nil)).To(BeFalse())
+		Expect(isPreviewEnvEnabled(&commonmodels.WorkflowTaskArgs{BaseNamespace: "dev", EnvRecyclePolicy: setting.EnvRecyclePolicyAlways})).To(BeFalse())
+		Expect(isPreviewEnvEnabled(&commonmodels.WorkflowTaskArgs{EnvRecyclePolicy: setting.EnvRecyclePolicyPRClosed})).To(BeFalse())
+		Expect(isPreviewEnvEnabled(&commonmodels.WorkflowTaskArgs{BaseNamespace: "dev", EnvRecyclePolicy: setting.EnvRecyclePolicyPRClosed})).To(BeTrue())
+	})
+
+	It("should copy all services of a normal base env", func() {
+		prod := newPreviewEnvProduct(newProduct(commonmodels.ProductShareEnv{}), "dev", targets)
+		Expect(prod.ShareEnv.Enable).To(BeFalse())
+		Expect(prod.Services[0]).To(HaveLen(2))
+		Expect(prod.Services[1]).To(HaveLen(1))
+	})
+
+	It("should create a sub env with the changed services of a shared base env", func() {
+		prod := newPreviewEnvProduct(newProduct(commonmodels.ProductShareEnv{Enable: true, IsBase: true}), "dev", targets)
+		Expect(prod.ShareEnv).To(Equal(commonmodels.ProductShareEnv{Enable: true, IsBase: false, BaseEnv: "dev"}))
+		Expect(prod.Services[0]).To(HaveLen(1))
+		Expect(prod.Services[0][0].ServiceName).To(Equal("b"))
+		Expect(prod.Services[1]).To(HaveLen(1))
+	})
+
+	It("should render how to access the preview env", func() {
+		preview := &commonmodels.PreviewEnv{ProductName: "demo", EnvName: "pr-1-abc", BaseEnvName: "dev"}
+		task := &workflowservice.CreateTaskResp{ProjectName: "demo", PipelineName: "demo-workflow", TaskID: 3}
+		access := &environmentservice.PreviewEnvAccess{Hosts: []string{"demo.example.com"}, Header: "x-env: pr-1-abc"}
+
+		comment := previewEnvComment(preview, "https://zadig.example.com", previewEnvStatusDeploying, task, access)
+		Expect(comment).To(ContainSubstring("https://zadig.example.com/v1/projects/detail/demo/envs/detail?envName=pr-1-abc"))
+		Expect(comment).To(ContainSubstring("https://zadig.example.com/v1/projects/detail/demo/pipelines/multi/demo-workflow/3"))
+		Expect(comment).To(ContainSubstring("http://demo.example.com"))
+		Expect(comment).To(ContainSubstring("x-env: pr-1-abc"))
+
+		comment = previewEnvComment(preview, "https://zadig.example.com", previewEnvStatusDeleted, nil, nil)
+		Expect(comment).NotTo(ContainSubstring("http"))
+		Expect(comment).To(ContainSubstring(previewEnvStatusDeleted))
+	})
+})
@@ -83,7 +83,7 @@ Strategy
 	Dynamic selection of idle environment;
  base:
 	Create a new environment based on the baseline environment
-	And Need to set EnvRecyclePolicy：success/always/never
+	And Need to set EnvRecyclePolicy：success/always/never/pr_closed
 */
 type Deploy struct {
 	Strategy         DeployStrategy   `yaml:"strategy"`
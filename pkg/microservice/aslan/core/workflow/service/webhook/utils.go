@@ -664,8 +664,10 @@ func checkTriggerYamlParams(triggerYaml *TriggerYaml) error {
 		if triggerYaml.Deploy.BaseNamespace == "" {
 			return errors.New("deploy.base_env is empty")
 		}
-		if triggerYaml.Deploy.EnvRecyclePolicy != EnvRecyclePolicySuccess && triggerYaml.Deploy.EnvRecyclePolicy != EnvRecyclePolicyAlways && triggerYaml.Deploy.EnvRecyclePolicy != EnvRecyclePolicyNever {
-			return errors.New("deploy.env_recycle_policy must success/always/never")
+		switch triggerYaml.Deploy.EnvRecyclePolicy {
+		case EnvRecyclePolicySuccess, EnvRecyclePolicyAlways, EnvRecyclePolicyNever, setting.EnvRecyclePolicyPRClosed:
+		default:
+			return errors.New("deploy.env_recycle_policy must success/always/never/pr_closed")
 		}
 	} else {
 		if triggerYaml.Deploy.BaseNamespace != "" {
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// findApprovalTask returns the approval subtask of a workflow task
+func findApprovalTask(pipelineName string, taskID int64) (*task.Task, *task.Approval, error) {
+	pipelineTask, err := commonrepo.NewTaskColl().Find(taskID, pipelineName, config.WorkflowType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, stage := range pipelineTask.Stages {
+		if stage.TaskType != config.TaskApproval {
+			continue
+		}
+		for _, subTask := range stage.SubTasks {
+			approval, err := base.ToApprovalTask(subTask)
+			if err != nil {
+				return nil, nil, err
+			}
+			return pipelineTask, approval, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("task %s:%d has no approval stage", pipelineName, taskID)
+}
+
+// NotifyApprovers is called by warpdrive when the approval stage starts, decisions of previous runs are
+// cleared so that a restarted task must be approved again
+func NotifyApprovers(pipelineName string, taskID int64, log *zap.SugaredLogger) error {
+	pipelineTask, approval, err := findApprovalTask(pipelineName, taskID)
+	if err != nil {
+		log.Errorf("find approval task %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrNotifyApprovers.AddErr(err)
+	}
+
+	if err := commonrepo.NewApprovalDecisionColl().Delete(pipelineName, taskID); err != nil {
+		log.Errorf("delete approval decisions of task %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrNotifyApprovers.AddErr(err)
+	}
+
+	if err := instantmessage.NewWeChatClient().SendApprovalMessage(pipelineTask, approval); err != nil {
+		log.Errorf("send approval message of task %s:%d error: %s", pipelineName, taskID, err)
+	}
+
+	title := "工作流待审批"
+	content := fmt.Sprintf("工作流 %s #%d 等待您的审批", pipelineName, taskID)
+	if approval.Description != "" {
+		content = fmt.Sprintf("%s：%s", content, approval.Description)
+	}
+	for _, approver := range approval.Approvers {
+		commonservice.SendMessage(approver, title, content, pipelineTask.ReqID, log)
+	}
+
+	return nil
+}
+
+func ListApprovalDecisions(pipelineName string, taskID int64, log *zap.SugaredLogger) ([]*commonmodels.ApprovalDecision, error) {
+	decisions, err := commonrepo.NewApprovalDecisionColl().List(pipelineName, taskID)
+	if err != nil {
+		log.Errorf("list approval decisions of task %s:%d error: %s", pipelineName, taskID, err)
+		return nil, e.ErrGetTask.AddErr(err)
+	}
+	return decisions, nil
+}
+
+// ApproveWorkflowTask records the decision of an approver, warpdrive picks it up and continues or fails the task
+func ApproveWorkflowTask(pipelineName string, taskID int64, userName string, approved bool, comment string, log *zap.SugaredLogger) error {
+	_, approval, err := findApprovalTask(pipelineName, taskID)
+	if err != nil {
+		log.Errorf("find approval task %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrApproveTask.AddErr(err)
+	}
+
+	if approval.TaskStatus != config.StatusRunning {
+		return e.ErrApproveTask.AddDesc(fmt.Sprintf("approval stage of task %s:%d is not waiting for approval", pipelineName, taskID))
+	}
+	if !sets.NewString(approval.Approvers...).Has(userName) {
+		return e.ErrApproveTask.AddDesc(fmt.Sprintf("%s is not an approver of task %s:%d", userName, pipelineName, taskID))
+	}
+
+	err = commonrepo.NewApprovalDecisionColl().Create(&commonmodels.ApprovalDecision{
+		PipelineName: pipelineName,
+		TaskID:       taskID,
+		UserName:     userName,
+		Approved:     approved,
+		Comment:      comment,
+		CreateTime:   time.Now().Unix(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return e.ErrApproveTask.AddDesc(fmt.Sprintf("%s has already handled task %s:%d", userName, pipelineName, taskID))
+	}
+	if err != nil {
+		log.Errorf("create approval decision of task %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrApproveTask.AddErr(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/registry"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// GetBuildResultCache is called by warpdrive before a build starts, a not found error means the build has to run
+func GetBuildResultCache(key string, log *zap.SugaredLogger) (*commonmodels.BuildResultCache, error) {
+	resp, err := commonrepo.NewBuildResultCacheColl().Find(key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, e.ErrNotFound.AddDesc("no build result for key " + key)
+		}
+		log.Errorf("find build result cache %s error: %s", key, err)
+		return nil, e.ErrFindBuildResultCache.AddErr(err)
+	}
+
+	// the image may be deleted from the registry by a retention policy since it was built,
+	// a build result whose image is gone is dropped so that the build runs again
+	exists, err := buildResultImageExists(resp.Image, log)
+	if err != nil {
+		log.Warnf("failed to check image %s of build result cache %s: %s", resp.Image, key, err)
+		return nil, e.ErrFindBuildResultCache.AddErr(err)
+	}
+	if !exists {
+		log.Infof("image %s of build result cache %s is gone", resp.Image, key)
+		if err := commonrepo.NewBuildResultCacheColl().Delete(key); err != nil {
+			log.Warnf("failed to delete build result cache %s: %s", key, err)
+		}
+		return nil, e.ErrNotFound.AddDesc("no build result for key " + key)
+	}
+
+	if err := commonrepo.NewBuildResultCacheColl().Touch(key); err != nil {
+		log.Warnf("failed to touch build result cache %s: %s", key, err)
+	}
+	return resp, nil
+}
+
+func buildResultImageExists(image string, log *zap.SugaredLogger) (bool, error) {
+	named, reg, err := commonservice.FindImageRegistry(image, log)
+	if err != nil {
+		return false, err
+	}
+	ref := "latest"
+	if digested, ok := named.(reference.Digested); ok {
+		ref = digested.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		ref = tagged.Tag()
+	}
+
+	tlsEnabled, tlsCert := true, ""
+	if reg.AdvancedSetting != nil {
+		tlsEnabled, tlsCert = reg.AdvancedSetting.TLSEnabled, reg.AdvancedSetting.TLSCert
+	}
+	exists, err := registry.ImageExists(registry.GetRepoImageDetailOption{
+		Endpoint: registry.Endpoint{
+			Addr:   reg.RegAddr,
+			Ak:     reg.AccessKey,
+			Sk:     reg.SecretKey,
+			Region: reg.Region,
+		},
+		Image: reference.Path(named),
+		Tag:   ref,
+	}, tlsEnabled, tlsCert, log)
+	if err != nil {
+		return false, fmt.Errorf("failed to check the manifest of %s: %s", image, err)
+	}
+	return exists, nil
+}
+
+// SaveBuildResultCache is called by warpdrive after a build succeeds
+func SaveBuildResultCache(args *commonmodels.BuildResultCache, log *zap.SugaredLogger) error {
+	if args.Key == "" || args.Image == "" {
+		return e.ErrInvalidParam.AddDesc("key and image are required")
+	}
+	if err := commonrepo.NewBuildResultCacheColl().Upsert(args); err != nil {
+		log.Errorf("save build result cache %s error: %s", args.Key, err)
+		return e.ErrCreateBuildResultCache.AddErr(err)
+	}
+	return nil
+}
@@ -75,6 +75,10 @@ func ConvertQueueToTask(queueTask *commonmodels.Queue) *task.Task {
 		Features:                queueTask.Features,
 		IsRestart:               queueTask.IsRestart,
 		StorageEndpoint:         queueTask.StorageEndpoint,
+		Priority:                queueTask.Priority,
+		BlockedReason:           queueTask.BlockedReason,
+		DAG:                     queueTask.DAG,
+		StagePolicies:           queueTask.StagePolicies,
 	}
 }
 
@@ -122,6 +126,10 @@ func ConvertTaskToQueue(task *task.Task) *commonmodels.Queue {
 		Features:                task.Features,
 		IsRestart:               task.IsRestart,
 		StorageEndpoint:         task.StorageEndpoint,
+		Priority:                task.Priority,
+		BlockedReason:           task.BlockedReason,
+		DAG:                     task.DAG,
+		StagePolicies:           task.StagePolicies,
 	}
 }
 
@@ -157,7 +165,7 @@ func JenkinsBuildModuleToSubTasks(jenkinsBuildOption *JenkinsBuildOption, log *z
 		return nil, e.ErrConvertSubTasks.AddErr(err)
 	}
 
-	registries, err := commonservice.ListRegistryNamespaces("", true, log)
+	registries, err := commonservice.ListRegistryNamespaces(true, log)
 	if err != nil {
 		return nil, e.ErrConvertSubTasks.AddErr(err)
 	}
@@ -197,7 +197,15 @@ func (h *TaskAckHandler) handle(message *nsq.Message) error {
 	for _, deploy := range deploys {
 		if deploy.Enabled && !pt.ResetImage {
 			containerName := strings.TrimSuffix(deploy.ContainerName, "_"+deploy.ServiceName)
-			if err := h.updateProductImageByNs(deploy.Namespace, deploy.ProductName, deploy.ServiceName, containerName, deploy.Image); err != nil {
+			image := deploy.Image
+			if deploy.RolledBack {
+				// the environment is running the previous image again, helm releases keep it in the product already
+				image = rolledBackImage(deploy, containerName)
+				if image == "" {
+					continue
+				}
+			}
+			if err := h.updateProductImageByNs(deploy.Namespace, deploy.ProductName, deploy.ServiceName, containerName, image); err != nil {
 				h.log.Errorf("updateProductImage %v error: %v", deploy, err)
 				continue
 			} else {
@@ -311,6 +319,7 @@ func (h *TaskAckHandler) uploadTaskData(pt *task.Task) error {
 							deliveryArtifact.Type = string(config.Image)
 							deliveryArtifact.Name = imageName
 							deliveryArtifact.ImageTag = imageTag
+							deliveryArtifact.Provenance = buildInfo.Provenance
 							//get image detail info
 							imageInfo, _ := getImageInfo(buildInfo.ProductName, buildInfo.EnvName, imageName, imageTag, h.log)
 							if imageInfo != nil {
@@ -448,6 +457,7 @@ func (h *TaskAckHandler) uploadTaskData(pt *task.Task) error {
 							h.log.Errorf("uploadTaskData get testInfo ToTestingTask failed ! err:%v", err)
 							continue
 						}
+						saveTestCoverage(pt, testInfo)
 
 						if testInfo.JobCtx.TestType == setting.FunctionTestType {
 							testTaskStat, _ = h.TestTaskStatColl.FindTestTaskStat(&commonrepo.TestTaskStatOption{Name: testInfo.TestModuleName})
@@ -496,6 +506,7 @@ func (h *TaskAckHandler) uploadTaskData(pt *task.Task) error {
 								msg := fmt.Sprintf("uploadTaskData testSuite unmarshal it report xml error: %v", err)
 								h.log.Error(msg)
 							}
+							saveTestCaseResults(pt, testInfo, testReport)
 							totalCaseNum := testReport.Tests
 							if totalCaseNum != 0 {
 								testTaskStat.TestCaseNum = totalCaseNum
@@ -621,6 +632,7 @@ func (h *TaskAckHandler) uploadTaskData(pt *task.Task) error {
 						h.log.Errorf("uploadTaskData get testInfo ToTestingTask failed ! err:%v", err)
 						continue
 					}
+					saveTestCoverage(pt, testInfo)
 
 					if testInfo.JobCtx.TestType == setting.FunctionTestType {
 						isNew := false
@@ -668,6 +680,7 @@ func (h *TaskAckHandler) uploadTaskData(pt *task.Task) error {
 							msg := fmt.Sprintf("uploadTaskData testSuite unmarshal it report xml error: %v", err)
 							h.log.Error(msg)
 						}
+						saveTestCaseResults(pt, testInfo, testReport)
 						totalCaseNum := testReport.Tests
 						if totalCaseNum != 0 {
 							testTaskStat.TestCaseNum = totalCaseNum
@@ -810,6 +823,16 @@ func (h *TaskAckHandler) getDeployTasks(subTasks []map[string]interface{}) ([]*t
 	return deploys, nil
 }
 
+// rolledBackImage returns the image the container was rolled back to, or an empty string if it is unknown
+func rolledBackImage(deploy *task.Deploy, containerName string) string {
+	for _, resource := range deploy.ReplaceResources {
+		if resource.Container == containerName {
+			return resource.Origin
+		}
+	}
+	return ""
+}
+
 // 更新subtasks中的所有容器部署任务对应服务的镜像
 func (h *TaskAckHandler) updateProductImageByNs(namespace, productName, serviceName, containerName, imageName string) error {
 	opt := &commonrepo.ProductEnvFindOptions{
@@ -313,6 +313,11 @@ func PipelineTaskSender() {
 								continue
 							}
 						}
+						// 超出项目/集群/构建类型并发配额的任务继续阻塞
+						if reason := checkConcurrencyQuota(blockTask); reason != "" {
+							blockTaskByQuota(blockTask, reason)
+							continue
+						}
 						// update agent and queue
 						if err := updateAgentAndQueue(blockTask); err != nil {
 							continue
@@ -323,6 +328,10 @@ func PipelineTaskSender() {
 				}
 				continue
 			}
+			if reason := checkConcurrencyQuota(t); reason != "" {
+				blockTaskByQuota(t, reason)
+				continue
+			}
 			// update agent and queue
 			if err := updateAgentAndQueue(t); err != nil {
 				continue
@@ -371,22 +380,28 @@ func ListTasks() []*task.Task {
 }
 
 // NextWaitingTask 查询下一个等待的task
+// 按项目权重公平调度, 同一项目中优先级高的task先被调度
 func NextWaitingTask() (*task.Task, error) {
 	opt := &commonrepo.ListQueueOption{
 		Status: config.StatusWaiting,
 	}
 
-	tasks, err := commonrepo.NewQueueColl().List(opt)
+	queues, err := commonrepo.NewQueueColl().List(opt)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, t := range tasks {
-		if t.AgentID == "" {
-			return ConvertQueueToTask(t), nil
+	candidates := make([]*task.Task, 0, len(queues))
+	for _, queue := range queues {
+		if queue.AgentID == "" {
+			candidates = append(candidates, ConvertQueueToTask(queue))
 		}
 	}
 
+	if t := newFairShareScheduler(projectQueueWeights(), RunningAndQueuedTasks()).next(candidates); t != nil {
+		return t, nil
+	}
+
 	return nil, errors.New("no waiting task found")
 }
 
@@ -406,7 +421,9 @@ func BlockedTaskQueue() ([]*task.Task, error) {
 		tasks = append(tasks, task)
 	}
 
-	return tasks, nil
+	// blocked tasks are retried in the same fair share order as waiting tasks, so that a project
+	// with many blocked tasks does not starve the others
+	return newFairShareScheduler(projectQueueWeights(), RunningAndQueuedTasks()).order(tasks), nil
 }
 
 func ParallelRunningAndQueuedTasks(currentTask *task.Task) bool {
@@ -505,6 +522,7 @@ func updateAgentAndQueue(t *task.Task) error {
 	}
 	// 更新当前任务状态为 TaskQueued
 	t.Status = config.StatusQueued
+	t.BlockedReason = ""
 	// 更新队列状态为TaskQueued
 	if success := UpdateQueue(t); !success {
 		log.Errorf("%s:%d update t status error", t.PipelineName, t.TaskID)
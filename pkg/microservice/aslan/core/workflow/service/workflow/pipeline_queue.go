@@ -80,22 +80,28 @@ func (q *Queue) Push(pt *task.Task) error {
 }
 
 // NextWaitingTask 查询下一个等待的task
+// 按项目权重公平调度, 同一项目中优先级高的task先被调度
 func (q *Queue) NextWaitingTask() (*task.Task, error) {
 	opt := &commonrepo.ListQueueOption{
 		Status: config.StatusWaiting,
 	}
 
-	tasks, err := q.pqColl.List(opt)
+	queues, err := q.pqColl.List(opt)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, t := range tasks {
-		if t.AgentID == "" {
-			return ConvertQueueToTask(t), nil
+	candidates := make([]*task.Task, 0, len(queues))
+	for _, queue := range queues {
+		if queue.AgentID == "" {
+			candidates = append(candidates, ConvertQueueToTask(queue))
 		}
 	}
 
+	if t := newFairShareScheduler(projectQueueWeights(), RunningAndQueuedTasks()).next(candidates); t != nil {
+		return t, nil
+	}
+
 	return nil, errors.New("no waiting task found")
 }
 
@@ -128,7 +134,9 @@ func (q *Queue) BlockedTaskQueue() ([]*task.Task, error) {
 		tasks = append(tasks, t)
 	}
 
-	return tasks, nil
+	// blocked tasks are retried in the same fair share order as waiting tasks, so that a project
+	// with many blocked tasks does not starve the others
+	return newFairShareScheduler(projectQueueWeights(), RunningAndQueuedTasks()).order(tasks), nil
 }
 
 // UpdateAgent ...
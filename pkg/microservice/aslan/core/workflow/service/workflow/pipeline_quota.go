@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+// quotaTargets returns the targets a task occupies for every quota type
+func quotaTargets(t *task.Task) map[config.ConcurrencyQuotaType]sets.String {
+	targets := map[config.ConcurrencyQuotaType]sets.String{
+		config.ProjectQuota:   sets.NewString(t.ProductName),
+		config.ClusterQuota:   sets.NewString(),
+		config.BuildTypeQuota: sets.NewString(),
+	}
+
+	if t.ConfigPayload != nil && t.ConfigPayload.DeployClusterID != "" {
+		targets[config.ClusterQuota].Insert(t.ConfigPayload.DeployClusterID)
+	}
+	for _, stage := range t.Stages {
+		targets[config.BuildTypeQuota].Insert(string(stage.TaskType))
+		for _, subTask := range stage.SubTasks {
+			if clusterID, ok := subTask["cluster_id"].(string); ok && clusterID != "" {
+				targets[config.ClusterQuota].Insert(clusterID)
+			}
+		}
+	}
+
+	return targets
+}
+
+// exceededQuotaReason returns why the task can not be started under the given quotas,
+// an empty string means all quotas still have free slots for the task
+func exceededQuotaReason(t *task.Task, running []*task.Task, quotas []*commonmodels.ConcurrencyQuota) string {
+	if len(quotas) == 0 {
+		return ""
+	}
+
+	runningTargets := make([]map[config.ConcurrencyQuotaType]sets.String, 0, len(running))
+	for _, r := range running {
+		runningTargets = append(runningTargets, quotaTargets(r))
+	}
+
+	targets := quotaTargets(t)
+	for _, quota := range quotas {
+		if !targets[quota.Type].Has(quota.Target) {
+			continue
+		}
+
+		var used int64
+		for _, rt := range runningTargets {
+			if rt[quota.Type].Has(quota.Target) {
+				used++
+			}
+		}
+		if used >= quota.Limit {
+			return fmt.Sprintf("%s %s has reached its concurrency quota: %d/%d", quota.Type, quota.Target, used, quota.Limit)
+		}
+	}
+
+	return ""
+}
+
+// checkConcurrencyQuota returns the reason if the task must stay blocked because of a concurrency quota
+func checkConcurrencyQuota(t *task.Task) string {
+	quotas, err := commonrepo.NewConcurrencyQuotaColl().List()
+	if err != nil {
+		log.Errorf("failed to list concurrency quotas, err: %s", err)
+		return ""
+	}
+
+	return exceededQuotaReason(t, RunningAndQueuedTasks(), quotas)
+}
+
+// blockTaskByQuota keeps the task blocked in the queue with a visible reason until a slot opens
+func blockTaskByQuota(t *task.Task, reason string) {
+	if t.Status == config.StatusBlocked && t.BlockedReason == reason {
+		return
+	}
+
+	log.Infof("task %s:%d is blocked: %s", t.PipelineName, t.TaskID, reason)
+	t.Status = config.StatusBlocked
+	t.BlockedReason = reason
+	if success := UpdateQueue(t); !success {
+		log.Errorf("%s:%d update blocked reason error", t.PipelineName, t.TaskID)
+	}
+}
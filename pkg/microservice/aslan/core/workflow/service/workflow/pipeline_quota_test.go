@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+)
+
+var _ = Describe("Testing concurrency quota", func() {
+
+	newTask := func(productName, clusterID string, taskType config.TaskType) *task.Task {
+		return &task.Task{
+			ProductName: productName,
+			Stages: []*commonmodels.Stage{{
+				TaskType: taskType,
+				SubTasks: map[string]map[string]interface{}{
+					"svc": {"cluster_id": clusterID},
+				},
+			}},
+		}
+	}
+
+	It("should pass when there is no quota", func() {
+		Expect(exceededQuotaReason(newTask("a", "c1", config.TaskBuild), nil, nil)).To(BeEmpty())
+	})
+
+	It("should block when the project quota is used up", func() {
+		quotas := []*commonmodels.ConcurrencyQuota{{Type: config.ProjectQuota, Target: "a", Limit: 1}}
+		running := []*task.Task{newTask("a", "c1", config.TaskBuild)}
+		Expect(exceededQuotaReason(newTask("a", "c2", config.TaskBuild), running, quotas)).NotTo(BeEmpty())
+		Expect(exceededQuotaReason(newTask("b", "c2", config.TaskBuild), running, quotas)).To(BeEmpty())
+	})
+
+	It("should block when the cluster quota is used up", func() {
+		quotas := []*commonmodels.ConcurrencyQuota{{Type: config.ClusterQuota, Target: "c1", Limit: 2}}
+		running := []*task.Task{newTask("a", "c1", config.TaskBuild), newTask("b", "c1", config.TaskDeploy)}
+		Expect(exceededQuotaReason(newTask("c", "c1", config.TaskBuild), running, quotas)).NotTo(BeEmpty())
+		Expect(exceededQuotaReason(newTask("c", "c2", config.TaskBuild), running, quotas)).To(BeEmpty())
+	})
+
+	It("should block when the build type quota is used up", func() {
+		quotas := []*commonmodels.ConcurrencyQuota{{Type: config.BuildTypeQuota, Target: string(config.TaskJenkinsBuild), Limit: 1}}
+		running := []*task.Task{newTask("a", "c1", config.TaskJenkinsBuild)}
+		Expect(exceededQuotaReason(newTask("b", "c2", config.TaskJenkinsBuild), running, quotas)).NotTo(BeEmpty())
+		Expect(exceededQuotaReason(newTask("b", "c2", config.TaskBuild), running, quotas)).To(BeEmpty())
+	})
+})
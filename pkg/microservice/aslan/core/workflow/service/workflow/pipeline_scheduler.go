@@ -0,0 +1,238 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+const defaultProjectQueueWeight int64 = 1
+
+// QueuedTaskPosition is the position of a waiting or blocked task in the pipeline queue
+type QueuedTaskPosition struct {
+	Position     int                 `json:"position"`
+	TaskID       int64               `json:"task_id"`
+	PipelineName string              `json:"pipeline_name"`
+	ProductName  string              `json:"product_name"`
+	Type         config.PipelineType `json:"type"`
+	Status       config.Status       `json:"status"`
+	Priority     int                 `json:"priority"`
+	TaskCreator  string              `json:"task_creator"`
+	CreateTime   int64               `json:"create_time"`
+	// BlockedReason is why the task stays blocked, e.g. a concurrency quota is exceeded
+	BlockedReason string `json:"blocked_reason,omitempty"`
+}
+
+// ByQueuePriority sorts tasks by priority desc, then by create time asc
+type ByQueuePriority []*task.Task
+
+func (a ByQueuePriority) Len() int      { return len(a) }
+func (a ByQueuePriority) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByQueuePriority) Less(i, j int) bool {
+	if a[i].Priority != a[j].Priority {
+		return a[i].Priority > a[j].Priority
+	}
+	return a[i].CreateTime < a[j].CreateTime
+}
+
+// fairShareScheduler picks tasks so that every project gets a share of warpdrive slots
+// proportional to its weight, tasks in the same project are picked by priority
+type fairShareScheduler struct {
+	weights map[string]int64
+	running map[string]int64
+}
+
+func newFairShareScheduler(weights map[string]int64, running []*task.Task) *fairShareScheduler {
+	s := &fairShareScheduler{
+		weights: weights,
+		running: make(map[string]int64),
+	}
+	for _, t := range running {
+		s.running[t.ProductName]++
+	}
+	return s
+}
+
+func (s *fairShareScheduler) weight(productName string) int64 {
+	if w, ok := s.weights[productName]; ok && w > 0 {
+		return w
+	}
+	return defaultProjectQueueWeight
+}
+
+// less reports whether project a has a lower usage than project b relative to their weights
+func (s *fairShareScheduler) less(a, b string) bool {
+	// running[a]/weight[a] < running[b]/weight[b]
+	return s.running[a]*s.weight(b) < s.running[b]*s.weight(a)
+}
+
+// next returns the next task to be scheduled from candidates, or nil if there is no candidate
+func (s *fairShareScheduler) next(candidates []*task.Task) *task.Task {
+	heads := make(map[string]*task.Task)
+	for _, t := range candidates {
+		head, ok := heads[t.ProductName]
+		if !ok || ByQueuePriority([]*task.Task{t, head}).Less(0, 1) {
+			heads[t.ProductName] = t
+		}
+	}
+
+	productNames := make([]string, 0, len(heads))
+	for productName := range heads {
+		productNames = append(productNames, productName)
+	}
+	sort.Strings(productNames)
+
+	var picked *task.Task
+	for _, productName := range productNames {
+		head := heads[productName]
+		if picked == nil {
+			picked = head
+			continue
+		}
+		switch {
+		case s.less(productName, picked.ProductName):
+			picked = head
+		case s.less(picked.ProductName, productName):
+		case ByQueuePriority([]*task.Task{head, picked}).Less(0, 1):
+			picked = head
+		}
+	}
+
+	return picked
+}
+
+// take marks the task as running so that later picks take it into account
+func (s *fairShareScheduler) take(t *task.Task) {
+	s.running[t.ProductName]++
+}
+
+// order returns all candidates in the order they would be scheduled
+func (s *fairShareScheduler) order(candidates []*task.Task) []*task.Task {
+	rest := make([]*task.Task, len(candidates))
+	copy(rest, candidates)
+
+	ordered := make([]*task.Task, 0, len(candidates))
+	for len(rest) > 0 {
+		t := s.next(rest)
+		s.take(t)
+		ordered = append(ordered, t)
+		for i := range rest {
+			if rest[i] == t {
+				rest = append(rest[:i], rest[i+1:]...)
+				break
+			}
+		}
+	}
+	return ordered
+}
+
+func projectQueueWeights() map[string]int64 {
+	setting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		log.Warnf("failed to get project queue weights, use default weight, err: %s", err)
+		return nil
+	}
+	return setting.ProjectQueueWeights
+}
+
+// ListQueuePositions lists the waiting and blocked tasks in the order they will be sent to warpdrive
+func ListQueuePositions() []*QueuedTaskPosition {
+	waiting := make([]*task.Task, 0)
+	blocked := make([]*task.Task, 0)
+	for _, t := range ListTasks() {
+		switch t.Status {
+		case config.StatusWaiting:
+			if t.AgentID == "" {
+				waiting = append(waiting, t)
+			}
+		case config.StatusBlocked:
+			blocked = append(blocked, t)
+		}
+	}
+
+	scheduler := newFairShareScheduler(projectQueueWeights(), RunningAndQueuedTasks())
+	ordered := scheduler.order(waiting)
+	ordered = append(ordered, scheduler.order(blocked)...)
+
+	resp := make([]*QueuedTaskPosition, 0, len(ordered))
+	for i, t := range ordered {
+		resp = append(resp, &QueuedTaskPosition{
+			Position:      i + 1,
+			TaskID:        t.TaskID,
+			PipelineName:  t.PipelineName,
+			ProductName:   t.ProductName,
+			Type:          t.Type,
+			Status:        t.Status,
+			Priority:      t.Priority,
+			TaskCreator:   t.TaskCreator,
+			CreateTime:    t.CreateTime,
+			BlockedReason: t.BlockedReason,
+		})
+	}
+	return resp
+}
+
+// fillQueueState copies the status and the blocked reason of the task which is still in the queue,
+// the task saved in the task collection is not updated until it is sent to warpdrive
+func fillQueueState(t *task.Task) {
+	switch t.Status {
+	case config.StatusCreated, config.StatusWaiting, config.StatusBlocked, config.StatusQueued:
+	default:
+		return
+	}
+
+	queues, err := commonrepo.NewQueueColl().List(&commonrepo.ListQueueOption{PipelineName: t.PipelineName})
+	if err != nil {
+		log.Warnf("failed to list the queue of pipeline %s, err: %s", t.PipelineName, err)
+		return
+	}
+	for _, queue := range queues {
+		if queue.TaskID == t.TaskID {
+			t.Status = queue.Status
+			t.BlockedReason = queue.BlockedReason
+			return
+		}
+	}
+}
+
+// UpdateQueuedTaskPriority bumps or demotes a task which is still waiting in the queue
+func UpdateQueuedTaskPriority(pipelineName string, taskID int64, priority int, log *zap.SugaredLogger) error {
+	for _, t := range ListTasks() {
+		if t.PipelineName != pipelineName || t.TaskID != taskID {
+			continue
+		}
+		if t.Status != config.StatusWaiting && t.Status != config.StatusBlocked {
+			return e.ErrUpdateTaskPriority.AddDesc(fmt.Sprintf("task %s:%d is %s, only queued task can be reprioritized", pipelineName, taskID, t.Status))
+		}
+		if err := commonrepo.NewQueueColl().UpdatePriority(t.TaskID, t.PipelineName, t.CreateTime, priority); err != nil {
+			log.Errorf("update priority of task %s:%d error: %s", pipelineName, taskID, err)
+			return e.ErrUpdateTaskPriority.AddErr(err)
+		}
+		return nil
+	}
+
+	return e.ErrUpdateTaskPriority.AddDesc(fmt.Sprintf("task %s:%d is not in the queue", pipelineName, taskID))
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+)
+
+var _ = Describe("Testing fair share scheduler", func() {
+
+	newTask := func(productName string, taskID int64, priority int) *task.Task {
+		return &task.Task{ProductName: productName, PipelineName: productName + "-workflow", TaskID: taskID, CreateTime: taskID, Priority: priority}
+	}
+
+	Context("next", func() {
+		It("should return nil when there is no candidate", func() {
+			s := newFairShareScheduler(nil, nil)
+			Expect(s.next(nil)).To(BeNil())
+		})
+
+		It("should pick the project with less running tasks", func() {
+			running := []*task.Task{newTask("a", 1, 0), newTask("a", 2, 0)}
+			s := newFairShareScheduler(nil, running)
+			picked := s.next([]*task.Task{newTask("a", 3, 0), newTask("b", 4, 0)})
+			Expect(picked.ProductName).To(Equal("b"))
+		})
+
+		It("should respect project weights", func() {
+			running := []*task.Task{newTask("a", 1, 0), newTask("b", 2, 0)}
+			s := newFairShareScheduler(map[string]int64{"a": 3}, running)
+			picked := s.next([]*task.Task{newTask("b", 3, 0), newTask("a", 4, 0)})
+			Expect(picked.ProductName).To(Equal("a"))
+		})
+
+		It("should pick the task with the highest priority in the same project", func() {
+			s := newFairShareScheduler(nil, nil)
+			picked := s.next([]*task.Task{newTask("a", 1, 0), newTask("a", 2, 10), newTask("a", 3, 5)})
+			Expect(picked.TaskID).To(Equal(int64(2)))
+		})
+	})
+
+	Context("order", func() {
+		It("should interleave projects instead of draining a large batch first", func() {
+			s := newFairShareScheduler(nil, nil)
+			candidates := []*task.Task{
+				newTask("a", 1, 0), newTask("a", 2, 0), newTask("a", 3, 0), newTask("b", 4, 0),
+			}
+			ordered := s.order(candidates)
+			ids := make([]int64, 0, len(ordered))
+			for _, t := range ordered {
+				ids = append(ids, t.TaskID)
+			}
+			Expect(ids).To(Equal([]int64{1, 4, 2, 3}))
+		})
+	})
+})
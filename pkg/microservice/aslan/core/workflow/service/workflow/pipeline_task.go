@@ -114,7 +114,7 @@ func CreatePipelineTask(args *commonmodels.TaskArgs, log *zap.SugaredLogger) (*C
 			}
 
 			if build.Registries == nil {
-				registries, err := commonservice.ListRegistryNamespaces("", true, log)
+				registries, err := commonservice.ListRegistryNamespaces(true, log)
 				if err != nil {
 					log.Errorf("ListRegistryNamespaces err:%v", err)
 				} else {
@@ -148,7 +148,7 @@ func CreatePipelineTask(args *commonmodels.TaskArgs, log *zap.SugaredLogger) (*C
 			}
 
 			if testing.Registries == nil {
-				registries, err := commonservice.ListRegistryNamespaces("", true, log)
+				registries, err := commonservice.ListRegistryNamespaces(true, log)
 				if err != nil {
 					log.Errorf("ListRegistryNamespaces err:%v", err)
 				} else {
@@ -199,6 +199,7 @@ func CreatePipelineTask(args *commonmodels.TaskArgs, log *zap.SugaredLogger) (*C
 		BuildModuleVer: pipeline.BuildModuleVer,
 		Target:         pipeline.Target,
 		StorageURI:     defaultStorageURI,
+		Priority:       args.Priority,
 	}
 
 	sort.Sort(ByTaskKind(pt.SubTasks))
@@ -232,7 +233,7 @@ func CreatePipelineTask(args *commonmodels.TaskArgs, log *zap.SugaredLogger) (*C
 		}
 	}
 
-	repos, err := commonservice.ListRegistryNamespaces("", false, log)
+	repos, err := commonservice.ListRegistryNamespaces(false, log)
 	if err != nil {
 		return nil, e.ErrCreateTask.AddErr(err)
 	}
@@ -399,6 +400,7 @@ func GetPipelineTaskV2(taskID int64, pipelineName string, typeString config.Pipe
 		return resp, e.ErrGetTask
 	}
 
+	fillQueueState(resp)
 	Clean(resp)
 	return resp, nil
 }
@@ -532,6 +534,9 @@ func RestartPipelineTaskV2(userName string, taskID int64, pipelineName string, t
 									DockerFile: newBuildInfo.PostBuild.DockerBuild.DockerFile,
 									BuildArgs:  newBuildInfo.PostBuild.DockerBuild.BuildArgs,
 									ImageName:  buildInfo.JobCtx.Image,
+									Builder:    newBuildInfo.PostBuild.DockerBuild.Builder,
+									Platforms:  newBuildInfo.PostBuild.DockerBuild.Platforms,
+									Provenance: newBuildInfo.PostBuild.DockerBuild.Provenance,
 								}
 							}
 
@@ -566,6 +571,11 @@ func RestartPipelineTaskV2(userName string, taskID int64, pipelineName string, t
 							}
 
 							testInfo.JobCtx.TestResultPath = newTestInfo.TestResultPath
+							testInfo.JobCtx.TestReportFormat = newTestInfo.TestReportFormat
+							testInfo.JobCtx.TestResultGlob = newTestInfo.TestResultGlob
+							testInfo.JobCtx.Coverage = newTestInfo.Coverage
+							testInfo.JobCtx.QuarantinedCases = newTestInfo.QuarantinedCases
+							testInfo.JobCtx.CoverageBaseline = coverageBaseline(newTestInfo.Name, newTestInfo.Coverage, testInfo.JobCtx.Builds)
 							testInfo.JobCtx.Caches = newTestInfo.Caches
 							testInfo.JobCtx.ArtifactPaths = newTestInfo.ArtifactPaths
 
@@ -711,12 +721,16 @@ func TestArgsToTestSubtask(args *commonmodels.TestTaskArgs, pt *task.Task, log *
 	testTask.JobCtx.BuildSteps = append(testTask.JobCtx.BuildSteps, &task.BuildStep{BuildType: "shell", Scripts: testModule.Scripts})
 
 	testTask.JobCtx.TestResultPath = testModule.TestResultPath
+	testTask.JobCtx.TestReportFormat = testModule.TestReportFormat
+	testTask.JobCtx.TestResultGlob = testModule.TestResultGlob
 	testTask.JobCtx.TestReportPath = testModule.TestReportPath
 	testTask.JobCtx.TestThreshold = testModule.Threshold
+	testTask.JobCtx.Coverage = testModule.Coverage
+	testTask.JobCtx.QuarantinedCases = testModule.QuarantinedCases
 	testTask.JobCtx.Caches = testModule.Caches
 	testTask.JobCtx.ArtifactPaths = testModule.ArtifactPaths
 	if testTask.Registries == nil {
-		registries, err := commonservice.ListRegistryNamespaces("", true, log)
+		registries, err := commonservice.ListRegistryNamespaces(true, log)
 		if err != nil {
 			log.Errorf("ListRegistryNamespaces err:%v", err)
 		} else {
@@ -729,6 +743,7 @@ func TestArgsToTestSubtask(args *commonmodels.TestTaskArgs, pt *task.Task, log *
 		testTask.JobCtx.EnableProxy = testModule.PreTest.EnableProxy
 		testTask.Namespace = testModule.PreTest.Namespace
 		testTask.ClusterID = testModule.PreTest.ClusterID
+		testTask.Scheduling = testModule.PreTest.Scheduling
 
 		envs := testModule.PreTest.Envs[:]
 
@@ -759,6 +774,7 @@ func TestArgsToTestSubtask(args *commonmodels.TestTaskArgs, pt *task.Task, log *
 	// Iterate test jobctx builds, and replace it if params specified from task.
 	// 外部触发的pipeline
 	_ = setManunalBuilds(testTask.JobCtx.Builds, testArg.Builds, log)
+	testTask.JobCtx.CoverageBaseline = coverageBaseline(testModule.Name, testModule.Coverage, testTask.JobCtx.Builds)
 	return testTask, nil
 }
 
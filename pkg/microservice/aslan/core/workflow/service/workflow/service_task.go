@@ -234,6 +234,7 @@ func CreateServiceTask(args *commonmodels.ServiceTaskArgs, log *zap.SugaredLogge
 		TaskArgs:      serviceTaskArgsToTaskArgs(args),
 		ConfigPayload: configPayload,
 		StorageURI:    defaultURL,
+		Priority:      args.Priority,
 	}
 	sort.Sort(ByTaskKind(task.SubTasks))
 
@@ -41,15 +41,16 @@ var SubtaskOrder = map[config.TaskType]int{
 	config.TaskType("docker_build"):    6,
 	config.TaskType("archive"):         7,
 	config.TaskType("artifact"):        8,
-	config.TaskType("artifact_deploy"): 9,
-	config.TaskType("deploy"):          10,
-	config.TaskType("testingv2"):       11,
-	config.TaskType("security"):        12,
-	config.TaskType("distribute2kodo"): 13,
-	config.TaskType("release_image"):   14,
-	config.TaskType("reset_image"):     15,
-	config.TaskType("trigger"):         16,
-	config.TaskType("extension"):       17,
+	config.TaskType("approval"):        9,
+	config.TaskType("artifact_deploy"): 10,
+	config.TaskType("deploy"):          11,
+	config.TaskType("testingv2"):       12,
+	config.TaskType("security"):        13,
+	config.TaskType("distribute2kodo"): 14,
+	config.TaskType("release_image"):   15,
+	config.TaskType("reset_image"):     16,
+	config.TaskType("trigger"):         17,
+	config.TaskType("extension"):       18,
 }
 
 type ByStageKind []*commonmodels.Stage
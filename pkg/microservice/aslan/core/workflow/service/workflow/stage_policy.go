@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workflow
+
+import (
+	"fmt"
+	"path"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+var validTriggerSources = map[string]bool{"manual": true, "webhook": true, "timer": true}
+
+var validRetryFailureClasses = map[string]bool{"image_pull": true, "evicted": true, "registry": true, "timeout": true}
+
+// maxRetryAttempts keeps a flaky stage from holding a warpdrive slot for too long
+const maxRetryAttempts = 10
+
+// validateStagePolicies checks the policies before they are saved, they are evaluated by warpdrive when the task runs
+func validateStagePolicies(policies []*commonmodels.StagePolicy) error {
+	for _, policy := range policies {
+		if policy == nil {
+			continue
+		}
+		if policy.Name == "" && policy.TaskType == "" {
+			return fmt.Errorf("stage policy must have a stage name or a task type")
+		}
+		if err := validateRetryPolicy(policy.Retry); err != nil {
+			return err
+		}
+		if policy.When == nil {
+			continue
+		}
+		for _, pattern := range policy.When.Branches {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid branch pattern %s: %s", pattern, err)
+			}
+		}
+		for _, source := range policy.When.Sources {
+			if !validTriggerSources[source] {
+				return fmt.Errorf("invalid trigger source %s, it must be one of manual, webhook and timer", source)
+			}
+		}
+		for _, cond := range policy.When.StageStatus {
+			if cond.Stage == "" || len(cond.Statuses) == 0 {
+				return fmt.Errorf("stage status condition must have a stage and at least one status")
+			}
+		}
+	}
+	return nil
+}
+
+func validateRetryPolicy(retry *commonmodels.RetryPolicy) error {
+	if retry == nil {
+		return nil
+	}
+	if retry.MaxAttempts < 1 || retry.MaxAttempts > maxRetryAttempts {
+		return fmt.Errorf("max attempts of retry policy must be between 1 and %d", maxRetryAttempts)
+	}
+	if retry.Backoff < 0 || retry.MaxBackoff < 0 || retry.Factor < 0 {
+		return fmt.Errorf("backoff of retry policy can not be negative")
+	}
+	for _, class := range retry.RetryOn {
+		if !validRetryFailureClasses[class] {
+			return fmt.Errorf("invalid failure class %s, it must be one of image_pull, evicted, registry and timeout", class)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"time"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// testCaseResults returns the result of every test case in the test report of the test
+func testCaseResults(pt *task.Task, testInfo *task.Testing, testReport *commonmodels.TestSuite) []*commonmodels.TestCaseResult {
+	var commitID string
+	if len(testInfo.JobCtx.Builds) > 0 {
+		commitID = testInfo.JobCtx.Builds[0].CommitID
+	}
+
+	now := time.Now().Unix()
+	results := make([]*commonmodels.TestCaseResult, 0, len(testReport.TestCases))
+	for _, tc := range testReport.TestCases {
+		status := commonmodels.TestCasePassed
+		switch {
+		case tc.Failure != nil, tc.Error != nil:
+			status = commonmodels.TestCaseFailed
+		case tc.Skipped != nil:
+			status = commonmodels.TestCaseSkipped
+		}
+
+		results = append(results, &commonmodels.TestCaseResult{
+			ProductName:  pt.ProductName,
+			TestName:     testInfo.TestModuleName,
+			CaseName:     types.TestCaseName(tc.ClassName, tc.Name),
+			PipelineName: pt.PipelineName,
+			TaskID:       pt.TaskID,
+			CommitID:     commitID,
+			Status:       status,
+			CreateTime:   now,
+		})
+	}
+	return results
+}
+
+// saveTestCaseResults records the history of the test cases to find flaky ones
+func saveTestCaseResults(pt *task.Task, testInfo *task.Testing, testReport *commonmodels.TestSuite) {
+	if testReport == nil || len(testReport.TestCases) == 0 {
+		return
+	}
+
+	if err := commonrepo.NewTestCaseResultColl().UpsertMany(testCaseResults(pt, testInfo, testReport)); err != nil {
+		log.Errorf("failed to save test case results of test %s in task %s:%d, err: %s", testInfo.TestModuleName, pt.PipelineName, pt.TaskID, err)
+	}
+}
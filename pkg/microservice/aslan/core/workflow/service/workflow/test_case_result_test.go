@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+var _ = Describe("Testing test case results", func() {
+
+	It("should record the status and the commit of every test case", func() {
+		pt := &task.Task{ProductName: "demo", PipelineName: "demo-workflow", TaskID: 3}
+		testInfo := &task.Testing{TestModuleName: "unit"}
+		testInfo.JobCtx.Builds = []*types.Repository{{CommitID: "abc"}}
+		testReport := &commonmodels.TestSuite{TestCases: []commonmodels.TestCase{
+			{ClassName: "suite", Name: "passes"},
+			{ClassName: "suite", Name: "fails", Failure: &commonmodels.Failure{}},
+			{ClassName: "suite", Name: "errors", Error: &commonmodels.Error{}},
+			{Name: "skipped", Skipped: &commonmodels.Skipped{}},
+		}}
+
+		results := testCaseResults(pt, testInfo, testReport)
+		Expect(results).To(HaveLen(4))
+		Expect(results[0].CaseName).To(Equal("suite.passes"))
+		Expect(results[0].Status).To(Equal(commonmodels.TestCasePassed))
+		Expect(results[1].Status).To(Equal(commonmodels.TestCaseFailed))
+		Expect(results[2].Status).To(Equal(commonmodels.TestCaseFailed))
+		Expect(results[3].CaseName).To(Equal("skipped"))
+		Expect(results[3].Status).To(Equal(commonmodels.TestCaseSkipped))
+		for _, result := range results {
+			Expect(result.CommitID).To(Equal("abc"))
+			Expect(result.TestName).To(Equal("unit"))
+		}
+	})
+})
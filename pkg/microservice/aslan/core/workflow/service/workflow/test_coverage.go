@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"time"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// coverageBaseline returns the latest coverage of the base branch of the test if the test fails on coverage regressions,
+// the branch of the first repository is taken as the base branch, which is the target branch for pull requests
+func coverageBaseline(testName string, coverage *types.CoverageConfig, repos []*types.Repository) *types.CoverageSummary {
+	if coverage == nil || !coverage.FailOnRegression {
+		return nil
+	}
+
+	var branch string
+	if len(repos) > 0 {
+		branch = repos[0].Branch
+	}
+	latest, err := commonrepo.NewTestCoverageColl().FindLatest(testName, branch)
+	if err != nil || latest.Coverage == nil {
+		log.Infof("no coverage baseline found for test %s on branch %s", testName, branch)
+		return nil
+	}
+	return &latest.Coverage.CoverageSummary
+}
+
+// saveTestCoverage records the coverage collected by the test for the coverage trend and later baselines
+func saveTestCoverage(pt *task.Task, testInfo *task.Testing) {
+	if testInfo.Coverage == nil {
+		return
+	}
+
+	coverage := &commonmodels.TestCoverage{
+		ProductName:  pt.ProductName,
+		PipelineName: pt.PipelineName,
+		TaskID:       pt.TaskID,
+		TestName:     testInfo.TestModuleName,
+		Coverage:     testInfo.Coverage,
+		CreateTime:   time.Now().Unix(),
+	}
+	if len(testInfo.JobCtx.Builds) > 0 {
+		repo := testInfo.JobCtx.Builds[0]
+		coverage.RepoName = repo.RepoName
+		coverage.Branch = repo.Branch
+		coverage.PR = repo.PR
+	}
+
+	if err := commonrepo.NewTestCoverageColl().Upsert(coverage); err != nil {
+		log.Errorf("failed to save coverage of test %s in task %s:%d, err: %s", testInfo.TestModuleName, pt.PipelineName, pt.TaskID, err)
+	}
+}
@@ -16,13 +16,19 @@ limitations under the License.
 
 package workflow
 
+import (
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
 type WorkflowV3 struct {
-	ID          string                   `json:"id"`
-	Name        string                   `json:"name"`
-	ProjectName string                   `json:"project_name"`
-	Description string                   `json:"description"`
-	Parameters  []*ParameterSetting      `json:"parameters"`
-	SubTasks    []map[string]interface{} `json:"sub_tasks"`
+	ID            string                      `json:"id"`
+	Name          string                      `json:"name"`
+	ProjectName   string                      `json:"project_name"`
+	Description   string                      `json:"description"`
+	Parameters    []*ParameterSetting         `json:"parameters"`
+	SubTasks      []map[string]interface{}    `json:"sub_tasks"`
+	DAG           []*commonmodels.DAGNode     `json:"dag"`
+	StagePolicies []*commonmodels.StagePolicy `json:"stage_policies"`
 }
 
 type WorkflowV3Brief struct {
@@ -430,6 +430,26 @@ func CreateWorkflow(workflow *commonmodels.Workflow, log *zap.SugaredLogger) err
 		return e.ErrUpsertWorkflow.AddDesc(err.Error())
 	}
 
+	if err := validateApprovalStage(workflow.ApprovalStage); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
+	if err := validateSecurityStage(workflow.SecurityStage); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
+	if err := validateDistributeStage(workflow.DistributeStage); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
+	if err := validateStagePolicies(workflow.StagePolicies); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
+	if err := validateDeployStrategies(workflow.DeployStrategies); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
 	err = commonservice.ProcessWebhook(workflow.HookCtl.Items, nil, webhook.WorkflowPrefix+workflow.Name, log)
 	if err != nil {
 		log.Errorf("Failed to process webhook, err: %s", err)
@@ -498,6 +518,26 @@ func UpdateWorkflow(workflow *commonmodels.Workflow, log *zap.SugaredLogger) err
 		return e.ErrUpsertWorkflow.AddDesc(err.Error())
 	}
 
+	if err := validateApprovalStage(workflow.ApprovalStage); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
+	if err := validateSecurityStage(workflow.SecurityStage); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
+	if err := validateDistributeStage(workflow.DistributeStage); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
+	if err := validateStagePolicies(workflow.StagePolicies); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
+	if err := validateDeployStrategies(workflow.DeployStrategies); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
 	err = commonservice.ProcessWebhook(workflow.HookCtl.Items, currentWorkflow.HookCtl.Items, webhook.WorkflowPrefix+workflow.Name, log)
 	if err != nil {
 		log.Errorf("Failed to process webhook, err: %s", err)
@@ -542,6 +582,77 @@ func validateWorkflowHookNames(w *commonmodels.Workflow) error {
 	return validateHookNames(names)
 }
 
+func validateApprovalStage(stage *commonmodels.ApprovalStage) error {
+	if stage == nil || !stage.Enabled {
+		return nil
+	}
+
+	if len(stage.Approvers) == 0 {
+		return fmt.Errorf("approval stage must have at least one approver")
+	}
+	if stage.NeededApprovers > len(stage.Approvers) {
+		return fmt.Errorf("approval stage needs %d approvers but only %d are configured", stage.NeededApprovers, len(stage.Approvers))
+	}
+
+	return nil
+}
+
+var validSecurityScanners = map[string]bool{"": true, "clair": true, "trivy": true}
+
+var validSeverities = map[string]bool{"Unknown": true, "Negligible": true, "Low": true, "Medium": true, "High": true, "Critical": true}
+
+func validateSecurityStage(stage *commonmodels.SecurityStage) error {
+	if stage == nil || !stage.Enabled {
+		return nil
+	}
+
+	if !validSecurityScanners[stage.Scanner] {
+		return fmt.Errorf("invalid scanner %s, it must be clair or trivy", stage.Scanner)
+	}
+	if stage.SeverityThreshold != "" && !validSeverities[stage.SeverityThreshold] {
+		return fmt.Errorf("invalid severity threshold %s", stage.SeverityThreshold)
+	}
+
+	return nil
+}
+
+func validateDistributeStage(stage *commonmodels.DistributeStage) error {
+	if stage == nil || !stage.Enabled {
+		return nil
+	}
+
+	return stage.Provenance.Validate()
+}
+
+func validateDeployStrategies(strategies []*commonmodels.DeployStrategy) error {
+	services := sets.NewString()
+	for _, strategy := range strategies {
+		if services.Has(strategy.ServiceName) {
+			return fmt.Errorf("duplicated deploy strategy of service %q", strategy.ServiceName)
+		}
+		services.Insert(strategy.ServiceName)
+
+		switch strategy.Type {
+		case setting.DeployStrategyCanary:
+			if strategy.TrafficWeight < 0 || strategy.TrafficWeight > 100 {
+				return fmt.Errorf("traffic weight of canary must be between 0 and 100")
+			}
+			if strategy.CanaryReplicas < 0 {
+				return fmt.Errorf("replicas of canary must not be negative")
+			}
+		case setting.DeployStrategyBlueGreen:
+		default:
+			return fmt.Errorf("invalid deploy strategy %s, it must be canary or blue_green", strategy.Type)
+		}
+
+		if strategy.VerifySeconds < 0 {
+			return fmt.Errorf("verification window must not be negative")
+		}
+	}
+
+	return nil
+}
+
 func ListWorkflows(projects []string, userID string, names []string, log *zap.SugaredLogger) ([]*Workflow, error) {
 	existingProjects, err := template.NewProductColl().ListNames(projects)
 	if err != nil {
@@ -541,6 +541,7 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 				fmt.Sprintf("找不到 %s 的 %s 环境 ", args.ProductTmplName, args.Namespace),
 			)
 		}
+		commonservice.TouchEnv(args.ProductTmplName, args.Namespace, log)
 	}
 
 	// get global configPayload
@@ -581,6 +582,7 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 				ProductName: args.ProductTmplName,
 				Variables:   target.Envs,
 				Env:         env,
+				ReuseResult: workflow.ReuseBuildResult,
 			}
 			subTasks, err = BuildModuleToSubTasks(buildModuleArgs, log)
 		} else {
@@ -602,7 +604,7 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 				if deployEnv.Type == setting.PMDeployType {
 					continue
 				}
-				deployTask, err := deployEnvToSubTasks(deployEnv, env, project.Timeout)
+				deployTask, err := deployEnvToSubTasks(deployEnv, env, project.Timeout, workflow)
 				if err != nil {
 					log.Errorf("deploy env to subtask error: %v", err)
 					return nil, e.ErrCreateTask.AddErr(err)
@@ -645,6 +647,7 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 						workflow.DistributeStage.ImageRepo,
 						workflow.DistributeStage.JumpBoxHost,
 						distributeS3StoreURL,
+						workflow.DistributeStage.Provenance,
 						distribute,
 					)
 					if err != nil {
@@ -667,7 +670,7 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 		}
 
 		if workflow.SecurityStage != nil && workflow.SecurityStage.Enabled {
-			securityTask, err := addSecurityToSubTasks()
+			securityTask, err := addSecurityToSubTasks(workflow.SecurityStage)
 			if err != nil {
 				log.Errorf("add security task error: %v", err)
 				return nil, e.ErrCreateTask.AddErr(err)
@@ -731,6 +734,15 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 		}
 		AddSubtaskToStage(&stages, extensionTask, string(config.TaskExtension))
 	}
+	// add approval to stage
+	if workflow.ApprovalStage != nil && workflow.ApprovalStage.Enabled {
+		approvalTask, err := addApprovalToSubTasks(workflow.ApprovalStage)
+		if err != nil {
+			log.Errorf("add approval task error: %s", err)
+			return nil, e.ErrCreateTask.AddErr(err)
+		}
+		AddSubtaskToStage(&stages, approvalTask, string(config.TaskApproval))
+	}
 
 	testTask := &taskmodels.Task{
 		TaskID:       nextTaskID,
@@ -785,6 +797,8 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 		ResetImage:       workflow.ResetImage,
 		ResetImagePolicy: workflow.ResetImagePolicy,
 		TriggerBy:        triggerBy,
+		Priority:         args.Priority,
+		StagePolicies:    workflow.StagePolicies,
 	}
 
 	if len(task.Stages) <= 0 {
@@ -831,7 +845,7 @@ func generateNextTaskID(workflowName string) (int64, error) {
 }
 
 func modifyConfigPayload(configPayload *commonmodels.ConfigPayload, ignoreCache, resetCache bool) {
-	repos, err := commonservice.ListRegistryNamespaces("", true, log.SugaredLogger())
+	repos, err := commonservice.ListRegistryNamespaces(true, log.SugaredLogger())
 	if err == nil {
 		configPayload.RepoConfigs = make(map[string]*commonmodels.RegistryNamespace)
 		for _, repo := range repos {
@@ -985,7 +999,7 @@ func AddDataToArgsOrCreateReleaseImageTask(args *commonmodels.WorkflowTaskArgs,
 }
 
 func buildRegistryMap() (map[string]*commonmodels.RegistryNamespace, error) {
-	registries, err := commonservice.ListRegistryNamespaces("", true, log.SugaredLogger())
+	registries, err := commonservice.ListRegistryNamespaces(true, log.SugaredLogger())
 	if err != nil {
 		return nil, fmt.Errorf("failed to query registries")
 	}
@@ -1076,6 +1090,7 @@ func createReleaseImageTask(workflow *commonmodels.Workflow, args *commonmodels.
 						workflow.DistributeStage.ImageRepo,
 						workflow.DistributeStage.JumpBoxHost,
 						distributeS3StoreURL,
+						workflow.DistributeStage.Provenance,
 						distribute,
 					)
 					if err != nil {
@@ -1097,6 +1112,7 @@ func createReleaseImageTask(workflow *commonmodels.Workflow, args *commonmodels.
 				ConfigPayload: configPayload,
 				TaskArgs:      &commonmodels.TaskArgs{PipelineName: workflow.Name, TaskCreator: setting.RequestModeOpenAPI, Deploy: commonmodels.DeployArgs{Image: imageInfo.Image}},
 				ProductName:   workflow.ProductTmplName,
+				Priority:      args.Priority,
 			}
 			sort.Sort(ByTaskKind(task.SubTasks))
 
@@ -1140,6 +1156,7 @@ func createReleaseImageTask(workflow *commonmodels.Workflow, args *commonmodels.
 		Stages:        stages,
 		ConfigPayload: configPayload,
 		StorageURI:    defaultS3StoreURL,
+		Priority:      args.Priority,
 	}
 
 	if len(task.Stages) <= 0 {
@@ -1287,7 +1304,7 @@ func GetS3Storage(id string, logger *zap.SugaredLogger) (*commonmodels.S3Storage
 	return store, nil
 }
 
-func deployEnvToSubTasks(env commonmodels.DeployEnv, prodEnv *commonmodels.Product, timeout int) (map[string]interface{}, error) {
+func deployEnvToSubTasks(env commonmodels.DeployEnv, prodEnv *commonmodels.Product, timeout int, workflow *commonmodels.Workflow) (map[string]interface{}, error) {
 	var (
 		resp       map[string]interface{}
 		deployTask = taskmodels.Deploy{
@@ -1298,6 +1315,8 @@ func deployEnvToSubTasks(env commonmodels.DeployEnv, prodEnv *commonmodels.Produ
 			EnvName:     prodEnv.EnvName,
 			Timeout:     timeout,
 			ClusterID:   prodEnv.ClusterID,
+
+			RollbackOnFailure: workflow.RollbackOnFailure,
 		}
 	)
 
@@ -1316,6 +1335,7 @@ func deployEnvToSubTasks(env commonmodels.DeployEnv, prodEnv *commonmodels.Produ
 	switch env.Type {
 	case setting.K8SDeployType:
 		deployTask.ServiceType = setting.K8SDeployType
+		deployTask.Strategy = workflow.DeployStrategyOf(deployTask.ServiceName)
 		return deployTask.ToSubTask()
 	case setting.HelmDeployType:
 		deployTask.ServiceType = setting.HelmDeployType
@@ -1381,7 +1401,7 @@ func artifactToSubTasks(name, image string) (map[string]interface{}, error) {
 	return artifactTask.ToSubTask()
 }
 
-func formatDistributeSubtasks(serviceModule *commonmodels.ServiceModuleTarget, releaseImages []commonmodels.RepoImage, imageRepo, jumpboxHost, destStorageURL string, distribute *commonmodels.ProductDistribute) ([]map[string]interface{}, error) {
+func formatDistributeSubtasks(serviceModule *commonmodels.ServiceModuleTarget, releaseImages []commonmodels.RepoImage, imageRepo, jumpboxHost, destStorageURL string, provenance *types.ProvenanceOptions, distribute *commonmodels.ProductDistribute) ([]map[string]interface{}, error) {
 	var resp []map[string]interface{}
 	productName := serviceModule.ProductName
 	if distribute.ImageDistribute {
@@ -1437,6 +1457,8 @@ func formatDistributeSubtasks(serviceModule *commonmodels.ServiceModuleTarget, r
 		}
 		t.DistributeInfo = distributeInfo
 		t.Releases = releaseImages
+		t.Provenance = provenance
+		t.Platforms = imagePlatforms(serviceModule)
 
 		// convert to subtask
 		subtask, err := t.ToSubTask()
@@ -1461,6 +1483,32 @@ func formatDistributeSubtasks(serviceModule *commonmodels.ServiceModuleTarget, r
 	return resp, nil
 }
 
+// imagePlatforms returns the platforms of the image built for the service module, or nil if it is a single platform image
+func imagePlatforms(serviceModule *commonmodels.ServiceModuleTarget) []string {
+	opt := &commonrepo.BuildListOption{
+		ServiceName: serviceModule.ServiceName,
+		ProductName: serviceModule.ProductName,
+		Targets:     []string{serviceModule.ServiceModule},
+	}
+	modules, err := commonrepo.NewBuildColl().List(opt)
+	// The service may be a shared service
+	if err == nil && len(modules) == 0 {
+		opt.ProductName = ""
+		modules, err = commonrepo.NewBuildColl().List(opt)
+	}
+	if err != nil {
+		log.Warnf("failed to list builds of %s/%s: %s", serviceModule.ServiceName, serviceModule.ServiceModule, err)
+		return nil
+	}
+
+	for _, module := range modules {
+		if module.PostBuild != nil && module.PostBuild.DockerBuild != nil && len(module.PostBuild.DockerBuild.Platforms) > 0 {
+			return module.PostBuild.DockerBuild.Platforms
+		}
+	}
+	return nil
+}
+
 func AddJiraSubTask(moduleName, target, serviceName, productName string, log *zap.SugaredLogger) (map[string]interface{}, error) {
 	repos := make([]*types.Repository, 0)
 
@@ -1489,8 +1537,13 @@ func AddJiraSubTask(moduleName, target, serviceName, productName string, log *za
 	return jira.ToSubTask()
 }
 
-func addSecurityToSubTasks() (map[string]interface{}, error) {
-	securityTask := taskmodels.Security{TaskType: config.TaskSecurity, Enabled: true}
+func addSecurityToSubTasks(stage *commonmodels.SecurityStage) (map[string]interface{}, error) {
+	securityTask := taskmodels.Security{
+		TaskType:          config.TaskSecurity,
+		Enabled:           true,
+		Scanner:           stage.Scanner,
+		SeverityThreshold: stage.SeverityThreshold,
+	}
 	return securityTask.ToSubTask()
 }
 
@@ -1508,6 +1561,18 @@ func addExtensionToSubTasks(stage *commonmodels.ExtensionStage, serviceInfos []*
 	return extensionTask.ToSubTask()
 }
 
+func addApprovalToSubTasks(stage *commonmodels.ApprovalStage) (map[string]interface{}, error) {
+	approvalTask := taskmodels.Approval{
+		TaskType:        config.TaskApproval,
+		Enabled:         true,
+		Approvers:       stage.Approvers,
+		NeededApprovers: stage.NeededApprovers,
+		Description:     stage.Description,
+		Timeout:         stage.Timeout,
+	}
+	return approvalTask.ToSubTask()
+}
+
 func workFlowArgsToTaskArgs(target string, workflowArgs *commonmodels.WorkflowTaskArgs) *commonmodels.TaskArgs {
 	resp := &commonmodels.TaskArgs{PipelineName: workflowArgs.WorkflowName, TaskCreator: workflowArgs.WorkflowTaskCreator}
 	for _, build := range workflowArgs.Target {
@@ -1542,7 +1607,7 @@ func testArgsToSubtask(args *commonmodels.WorkflowTaskArgs, pt *taskmodels.Task,
 	testArgs := args.Tests
 	testCreator := args.WorkflowTaskCreator
 
-	registries, err := commonservice.ListRegistryNamespaces("", true, log)
+	registries, err := commonservice.ListRegistryNamespaces(true, log)
 	if err != nil {
 		log.Errorf("ListRegistryNamespaces err:%v", err)
 	}
@@ -1581,7 +1646,11 @@ func testArgsToSubtask(args *commonmodels.WorkflowTaskArgs, pt *taskmodels.Task,
 		testTask.JobCtx.TestThreshold = testModule.Threshold
 		testTask.JobCtx.Caches = testModule.Caches
 		testTask.JobCtx.TestResultPath = testModule.TestResultPath
+		testTask.JobCtx.TestReportFormat = testModule.TestReportFormat
+		testTask.JobCtx.TestResultGlob = testModule.TestResultGlob
 		testTask.JobCtx.TestReportPath = testModule.TestReportPath
+		testTask.JobCtx.Coverage = testModule.Coverage
+		testTask.JobCtx.QuarantinedCases = testModule.QuarantinedCases
 
 		clusterInfo, err := commonrepo.NewK8SClusterColl().Get(testModule.PreTest.ClusterID)
 		if err != nil {
@@ -1608,6 +1677,7 @@ func testArgsToSubtask(args *commonmodels.WorkflowTaskArgs, pt *taskmodels.Task,
 			testTask.JobCtx.EnableProxy = testModule.PreTest.EnableProxy
 			testTask.Namespace = testModule.PreTest.Namespace
 			testTask.ClusterID = testModule.PreTest.ClusterID
+			testTask.Scheduling = testModule.PreTest.Scheduling
 
 			envs := testModule.PreTest.Envs[:]
 
@@ -1656,6 +1726,7 @@ func testArgsToSubtask(args *commonmodels.WorkflowTaskArgs, pt *taskmodels.Task,
 		} else {
 			_ = setManunalBuilds(testTask.JobCtx.Builds, testArg.Builds, log)
 		}
+		testTask.JobCtx.CoverageBaseline = coverageBaseline(testModule.Name, testModule.Coverage, testTask.JobCtx.Builds)
 
 		resp = append(resp, testTask)
 	}
@@ -1693,6 +1764,7 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 				fmt.Sprintf("找不到 %s 的 %s 环境 ", args.ProductTmplName, args.Namespace),
 			)
 		}
+		commonservice.TouchEnv(args.ProductTmplName, args.Namespace, log)
 	}
 
 	nextTaskID, err := commonrepo.NewCounterColl().GetNextSeq(fmt.Sprintf(setting.WorkflowTaskFmt, args.WorkflowName))
@@ -1703,7 +1775,7 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 
 	// 获取全局configpayload
 	configPayload := commonservice.GetConfigPayload(args.CodehostID)
-	repos, err := commonservice.ListRegistryNamespaces("", true, log)
+	repos, err := commonservice.ListRegistryNamespaces(true, log)
 	if err == nil {
 		configPayload.RepoConfigs = make(map[string]*commonmodels.RegistryNamespace)
 		for _, repo := range repos {
@@ -1734,7 +1806,7 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 			if env != nil {
 				// 生成部署的subtask
 				for _, deployEnv := range artifact.Deploy {
-					deployTask, err := deployEnvToSubTasks(deployEnv, env, productTempl.Timeout)
+					deployTask, err := deployEnvToSubTasks(deployEnv, env, productTempl.Timeout, workflow)
 					if err != nil {
 						log.Errorf("deploy env to subtask error: %v", err)
 						return nil, err
@@ -1795,6 +1867,7 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 						workflow.DistributeStage.ImageRepo,
 						workflow.DistributeStage.JumpBoxHost,
 						distributeS3StoreURL,
+						workflow.DistributeStage.Provenance,
 						distribute,
 					)
 					if err != nil {
@@ -1807,7 +1880,7 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 		}
 
 		if workflow.SecurityStage != nil && workflow.SecurityStage.Enabled {
-			securityTask, err := addSecurityToSubTasks()
+			securityTask, err := addSecurityToSubTasks(workflow.SecurityStage)
 			if err != nil {
 				log.Errorf("add security task error: %v", err)
 				return nil, err
@@ -1910,6 +1983,7 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 		ResetImage:       workflow.ResetImage,
 		ResetImagePolicy: workflow.ResetImagePolicy,
 		TriggerBy:        triggerBy,
+		Priority:         args.Priority,
 	}
 
 	if len(task.Stages) <= 0 {
@@ -1976,7 +2050,7 @@ func BuildModuleToSubTasks(args *commonmodels.BuildModuleArgs, log *zap.SugaredL
 		}
 	}
 
-	registries, err := commonservice.ListRegistryNamespaces("", true, log)
+	registries, err := commonservice.ListRegistryNamespaces(true, log)
 	if err != nil {
 		return nil, e.ErrConvertSubTasks.AddErr(err)
 	}
@@ -1999,6 +2073,12 @@ func BuildModuleToSubTasks(args *commonmodels.BuildModuleArgs, log *zap.SugaredL
 			ProductName:  args.ProductName,
 			Namespace:    module.PreBuild.Namespace,
 			ClusterID:    module.PreBuild.ClusterID,
+			Scheduling:   module.PreBuild.Scheduling,
+		}
+
+		if args.ReuseResult {
+			build.ReuseResult = true
+			build.BuildRevision = module.UpdateTime
 		}
 
 		// In some old build configurations, the `pre_build.cluster_id` field is empty indicating that's a local cluster.
@@ -2021,6 +2101,7 @@ func BuildModuleToSubTasks(args *commonmodels.BuildModuleArgs, log *zap.SugaredL
 			build.CacheEnable = module.CacheEnable
 			build.CacheDirType = module.CacheDirType
 			build.CacheUserDir = module.CacheUserDir
+			build.CacheDefaultBranch = cacheDefaultBranch(module.Repos)
 		}
 
 		if args.TaskType != "" {
@@ -2169,6 +2250,9 @@ func BuildModuleToSubTasks(args *commonmodels.BuildModuleArgs, log *zap.SugaredL
 				DockerFile:            module.PostBuild.DockerBuild.DockerFile,
 				BuildArgs:             module.PostBuild.DockerBuild.BuildArgs,
 				DockerTemplateContent: dockerTemplateContent,
+				Builder:               module.PostBuild.DockerBuild.Builder,
+				Platforms:             module.PostBuild.DockerBuild.Platforms,
+				Provenance:            module.PostBuild.DockerBuild.Provenance,
 			}
 		}
 
@@ -2895,3 +2979,17 @@ func nextTargetID(subTasks map[string]map[string]interface{}, target string) str
 
 	return strconv.Itoa(count)
 }
+
+// cacheDefaultBranch returns the branch configured for the primary repository of the build module,
+// its cache is restored when the branch being built has no cache yet
+func cacheDefaultBranch(repos []*types.Repository) string {
+	for _, repo := range repos {
+		if repo.IsPrimary {
+			return repo.Branch
+		}
+	}
+	if len(repos) > 0 {
+		return repos[0].Branch
+	}
+	return ""
+}
@@ -115,9 +115,15 @@ func CreateWorkflowTaskV3(args *commonmodels.WorkflowV3Args, username, reqID str
 		SubTasks:      workflowV3.SubTasks,
 		ConfigPayload: commonservice.GetConfigPayload(0),
 		StorageURI:    defaultStorageURI,
+		DAG:           workflowV3.DAG,
+		StagePolicies: workflowV3.StagePolicies,
+		Priority:      args.Priority,
 	}
 
-	sort.Sort(ByTaskKind(pt.SubTasks))
+	// sub tasks are bound to dag nodes by position, the order must be kept
+	if len(pt.DAG) == 0 {
+		sort.Sort(ByTaskKind(pt.SubTasks))
+	}
 
 	if err := ensurePipelineTask(&task.TaskOpt{
 		Task: pt,
@@ -203,6 +209,7 @@ func GetWorkflowTaskV3(taskID int64, pipelineName string, typeString config.Pipe
 		return resp, e.ErrGetTask
 	}
 
+	fillQueueState(resp)
 	CleanWorkflow3(resp)
 	return resp, nil
 }
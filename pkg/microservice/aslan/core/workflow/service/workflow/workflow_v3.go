@@ -68,6 +68,16 @@ func ensureWorkflowV3(args *commonmodels.WorkflowV3, log *zap.SugaredLogger) err
 		return err
 	}
 
+	if err := validateWorkflowV3DAG(args.SubTasks, args.DAG); err != nil {
+		log.Errorf("validateWorkflowV3DAG: %+v", err)
+		return err
+	}
+
+	if err := validateStagePolicies(args.StagePolicies); err != nil {
+		log.Errorf("validateStagePolicies: %+v", err)
+		return err
+	}
+
 	if workflowV3, err := commonrepo.NewWorkflowV3Coll().Find(args.Name); err == nil {
 		errStr := fmt.Sprintf("workflow [%s] 在项目 [%s] 中已经存在!", workflowV3.Name, workflowV3.ProjectName)
 		return e.ErrCreatePipeline.AddDesc(errStr)
@@ -168,6 +178,14 @@ func UpdateWorkflowV3(id, user string, workflowModel *commonmodels.WorkflowV3, l
 		errStr := "Workflow has no sub-module, please set the sub-module first"
 		return e.ErrCreatePipeline.AddDesc(errStr)
 	}
+	if err := validateWorkflowV3DAG(workflowModel.SubTasks, workflowModel.DAG); err != nil {
+		logger.Errorf("validateWorkflowV3DAG: %+v", err)
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+	if err := validateStagePolicies(workflowModel.StagePolicies); err != nil {
+		logger.Errorf("validateStagePolicies: %+v", err)
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
 	workflowModel.UpdatedBy = user
 	workflowModel.UpdateTime = time.Now().Unix()
 	err := commonrepo.NewWorkflowV3Coll().Update(
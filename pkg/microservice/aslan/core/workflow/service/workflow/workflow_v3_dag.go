@@ -0,0 +1,92 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+// validateWorkflowV3DAG makes sure that every sub task has a unique name, all dependencies exist
+// and the dependencies do not form a cycle
+func validateWorkflowV3DAG(subTasks []map[string]interface{}, dag []*commonmodels.DAGNode) error {
+	if len(dag) == 0 {
+		return nil
+	}
+	if len(dag) != len(subTasks) {
+		return fmt.Errorf("dag has %d nodes but there are %d sub tasks", len(dag), len(subTasks))
+	}
+
+	nodes := make(map[string]*commonmodels.DAGNode, len(dag))
+	for _, node := range dag {
+		if node == nil || node.Name == "" {
+			return fmt.Errorf("every sub task in dag must have a name")
+		}
+		if _, ok := nodes[node.Name]; ok {
+			return fmt.Errorf("duplicated sub task name %s in dag", node.Name)
+		}
+		nodes[node.Name] = node
+	}
+
+	// Kahn's algorithm, nodes left with upstream dependencies are part of a cycle
+	inDegree := make(map[string]int, len(dag))
+	downstream := make(map[string][]string, len(dag))
+	for _, node := range dag {
+		inDegree[node.Name] += 0
+		for _, dep := range node.DependsOn {
+			if _, ok := nodes[dep]; !ok {
+				return fmt.Errorf("sub task %s depends on unknown sub task %s", node.Name, dep)
+			}
+			if dep == node.Name {
+				return fmt.Errorf("sub task %s depends on itself", node.Name)
+			}
+			inDegree[node.Name]++
+			downstream[dep] = append(downstream[dep], node.Name)
+		}
+	}
+
+	queue := make([]string, 0, len(dag))
+	for name, degree := range inDegree {
+		if degree == 0 {
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, next := range downstream[name] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+		delete(inDegree, name)
+	}
+
+	if len(inDegree) > 0 {
+		cycle := make([]string, 0, len(inDegree))
+		for name := range inDegree {
+			cycle = append(cycle, name)
+		}
+		sort.Strings(cycle)
+		return fmt.Errorf("there is a cycle in the dependencies of sub tasks %s", strings.Join(cycle, ", "))
+	}
+
+	return nil
+}
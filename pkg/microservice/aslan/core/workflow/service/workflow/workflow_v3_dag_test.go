@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+var _ = Describe("Testing workflow v3 dag", func() {
+
+	subTasks := func(n int) []map[string]interface{} {
+		resp := make([]map[string]interface{}, n)
+		for i := range resp {
+			resp[i] = map[string]interface{}{}
+		}
+		return resp
+	}
+
+	It("should pass when there is no dag", func() {
+		Expect(validateWorkflowV3DAG(subTasks(2), nil)).To(Succeed())
+	})
+
+	It("should pass with independent and dependent sub tasks", func() {
+		dag := []*commonmodels.DAGNode{
+			{Name: "build-a"},
+			{Name: "build-b"},
+			{Name: "test", DependsOn: []string{"build-a"}},
+			{Name: "notify", DependsOn: []string{"test", "build-b"}},
+		}
+		Expect(validateWorkflowV3DAG(subTasks(4), dag)).To(Succeed())
+	})
+
+	It("should reject a cycle", func() {
+		dag := []*commonmodels.DAGNode{
+			{Name: "a", DependsOn: []string{"c"}},
+			{Name: "b", DependsOn: []string{"a"}},
+			{Name: "c", DependsOn: []string{"b"}},
+		}
+		Expect(validateWorkflowV3DAG(subTasks(3), dag)).To(MatchError(ContainSubstring("cycle")))
+	})
+
+	It("should reject unknown and duplicated sub tasks", func() {
+		Expect(validateWorkflowV3DAG(subTasks(1), []*commonmodels.DAGNode{{Name: "a", DependsOn: []string{"b"}}})).NotTo(Succeed())
+		Expect(validateWorkflowV3DAG(subTasks(2), []*commonmodels.DAGNode{{Name: "a"}, {Name: "a"}})).NotTo(Succeed())
+		Expect(validateWorkflowV3DAG(subTasks(2), []*commonmodels.DAGNode{{Name: "a"}})).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/testing/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+func ListFlakyTestCases(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("empty projectName")
+		return
+	}
+
+	var days int
+	if c.Query("days") != "" {
+		var err error
+		if days, err = strconv.Atoi(c.Query("days")); err != nil {
+			ctx.Err = e.ErrInvalidParam.AddDesc("invalid days")
+			return
+		}
+	}
+
+	ctx.Resp, ctx.Err = service.ListFlakyTestCases(projectName, c.Query("testName"), days, ctx.Logger)
+}
+
+func ListQuarantinedTestCases(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("empty projectName")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.ListQuarantinedTestCases(c.Param("name"), projectName, ctx.Logger)
+}
+
+type updateQuarantineReq struct {
+	Cases []string `json:"cases"`
+}
+
+func UpdateQuarantinedTestCases(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("empty projectName")
+		return
+	}
+
+	args := new(updateQuarantineReq)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid quarantine args")
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "更新", "项目管理-测试隔离用例", c.Param("name"), "", ctx.Logger)
+
+	ctx.Err = service.UpdateQuarantinedTestCases(c.Param("name"), projectName, args.Cases, ctx.Logger)
+}
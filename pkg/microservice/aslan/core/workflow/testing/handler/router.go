@@ -51,6 +51,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		tester.GET("", ListTestModules)
 		tester.GET("/:name", GetTestModule)
 		tester.DELETE("/:name", gin2.UpdateOperationLogStatus, DeleteTestModule)
+		tester.GET("/:name/quarantine", ListQuarantinedTestCases)
+		tester.PUT("/:name/quarantine", gin2.UpdateOperationLogStatus, UpdateQuarantinedTestCases)
 	}
 
 	testStat := router.Group("teststat")
@@ -64,6 +66,11 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		testDetail.GET("", ListDetailTestModules)
 	}
 
+	flaky := router.Group("flaky")
+	{
+		flaky.GET("", ListFlakyTestCases)
+	}
+
 	// ---------------------------------------------------------------------------------------
 	// test 任务接口
 	// ---------------------------------------------------------------------------------------
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+const defaultFlakyDetectionDays = 30
+
+// FlakyTestCase is a test case which both passed and failed on the same commit
+type FlakyTestCase struct {
+	TestName     string `json:"test_name"`
+	CaseName     string `json:"case_name"`
+	Runs         int    `json:"runs"`
+	Failures     int    `json:"failures"`
+	FlakyCommits int    `json:"flaky_commits"`
+	LastFailure  int64  `json:"last_failure"`
+	Quarantined  bool   `json:"quarantined"`
+}
+
+// ListFlakyTestCases ranks the flaky test cases of the project in the last days by the number of commits they flipped on
+func ListFlakyTestCases(productName, testName string, days int, log *zap.SugaredLogger) ([]*FlakyTestCase, error) {
+	if days <= 0 {
+		days = defaultFlakyDetectionDays
+	}
+
+	results, err := commonrepo.NewTestCaseResultColl().List(&commonrepo.TestCaseResultListOption{
+		ProductName: productName,
+		TestName:    testName,
+		StartTime:   time.Now().AddDate(0, 0, -days).Unix(),
+	})
+	if err != nil {
+		log.Errorf("failed to list test case results of project %s, err: %s", productName, err)
+		return nil, e.ErrListFlakyTestCases.AddErr(err)
+	}
+
+	testings, err := commonrepo.NewTestingColl().List(&commonrepo.ListTestOption{ProductName: productName})
+	if err != nil {
+		log.Errorf("failed to list testings of project %s, err: %s", productName, err)
+		return nil, e.ErrListFlakyTestCases.AddErr(err)
+	}
+	quarantined := make(map[string]sets.String, len(testings))
+	for _, testing := range testings {
+		quarantined[testing.Name] = sets.NewString(testing.QuarantinedCases...)
+	}
+
+	return rankFlakyTestCases(results, quarantined), nil
+}
+
+// rankFlakyTestCases finds the test cases with different results on the same commit,
+// results without a commit are counted as runs but never make a test case flaky
+func rankFlakyTestCases(results []*commonmodels.TestCaseResult, quarantined map[string]sets.String) []*FlakyTestCase {
+	type caseKey struct {
+		testName string
+		caseName string
+	}
+
+	cases := make(map[caseKey]*FlakyTestCase)
+	// test case => commit => the statuses of the test case on that commit
+	commits := make(map[caseKey]map[string]sets.String)
+	for _, result := range results {
+		if result.Status == commonmodels.TestCaseSkipped {
+			continue
+		}
+
+		key := caseKey{testName: result.TestName, caseName: result.CaseName}
+		tc, ok := cases[key]
+		if !ok {
+			tc = &FlakyTestCase{TestName: result.TestName, CaseName: result.CaseName}
+			cases[key] = tc
+			commits[key] = make(map[string]sets.String)
+		}
+		tc.Runs++
+		if result.Status == commonmodels.TestCaseFailed {
+			tc.Failures++
+			if result.CreateTime > tc.LastFailure {
+				tc.LastFailure = result.CreateTime
+			}
+		}
+
+		if result.CommitID == "" {
+			continue
+		}
+		if _, ok := commits[key][result.CommitID]; !ok {
+			commits[key][result.CommitID] = sets.NewString()
+		}
+		commits[key][result.CommitID].Insert(string(result.Status))
+	}
+
+	resp := make([]*FlakyTestCase, 0)
+	for key, tc := range cases {
+		for _, statuses := range commits[key] {
+			if statuses.Has(string(commonmodels.TestCasePassed)) && statuses.Has(string(commonmodels.TestCaseFailed)) {
+				tc.FlakyCommits++
+			}
+		}
+		if tc.FlakyCommits == 0 {
+			continue
+		}
+		tc.Quarantined = quarantined[tc.TestName].Has(tc.CaseName)
+		resp = append(resp, tc)
+	}
+
+	sort.Slice(resp, func(i, j int) bool {
+		if resp[i].FlakyCommits != resp[j].FlakyCommits {
+			return resp[i].FlakyCommits > resp[j].FlakyCommits
+		}
+		if resp[i].Failures != resp[j].Failures {
+			return resp[i].Failures > resp[j].Failures
+		}
+		if resp[i].TestName != resp[j].TestName {
+			return resp[i].TestName < resp[j].TestName
+		}
+		return resp[i].CaseName < resp[j].CaseName
+	})
+	return resp
+}
+
+func ListQuarantinedTestCases(name, productName string, log *zap.SugaredLogger) ([]string, error) {
+	testing, err := commonrepo.NewTestingColl().Find(name, productName)
+	if err != nil {
+		log.Errorf("failed to find testing %s of project %s, err: %s", name, productName, err)
+		return nil, e.ErrGetTestModule.AddErr(err)
+	}
+
+	if testing.QuarantinedCases == nil {
+		return []string{}, nil
+	}
+	return testing.QuarantinedCases, nil
+}
+
+// UpdateQuarantinedTestCases replaces the quarantine list of the testing module,
+// the failures of the test cases in the list are reported but do not fail the workflow
+func UpdateQuarantinedTestCases(name, productName string, cases []string, log *zap.SugaredLogger) error {
+	if _, err := commonrepo.NewTestingColl().Find(name, productName); err != nil {
+		log.Errorf("failed to find testing %s of project %s, err: %s", name, productName, err)
+		return e.ErrUpdateTestQuarantine.AddErr(err)
+	}
+
+	quarantined := sets.NewString()
+	for _, c := range cases {
+		if c == "" {
+			return e.ErrUpdateTestQuarantine.AddDesc("empty test case name")
+		}
+		quarantined.Insert(c)
+	}
+
+	if err := commonrepo.NewTestingColl().UpdateQuarantinedCases(name, productName, quarantined.List()); err != nil {
+		log.Errorf("failed to update quarantined test cases of testing %s, err: %s", name, err)
+		return e.ErrUpdateTestQuarantine.AddErr(err)
+	}
+	return nil
+}
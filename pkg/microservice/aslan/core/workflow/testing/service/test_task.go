@@ -120,6 +120,7 @@ func CreateTestTask(args *commonmodels.TestTaskArgs, log *zap.SugaredLogger) (*C
 		ConfigPayload: configPayload,
 		StorageURI:    defaultURL,
 		TriggerBy:     triggerBy,
+		Priority:      args.Priority,
 	}
 
 	if len(task.Stages) <= 0 {
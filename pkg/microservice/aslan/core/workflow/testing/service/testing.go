@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -38,6 +39,7 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/types"
 	"github.com/koderover/zadig/pkg/util"
 )
 
@@ -48,6 +50,15 @@ func CreateTesting(username string, testing *commonmodels.Testing, log *zap.Suga
 	if err := commonutil.CheckDefineResourceParam(testing.PreTest.ResReq, testing.PreTest.ResReqSpec); err != nil {
 		return e.ErrCreateTestModule.AddDesc(err.Error())
 	}
+	if err := checkTestReportFormat(testing); err != nil {
+		return e.ErrCreateTestModule.AddDesc(err.Error())
+	}
+	if err := checkTestCoverage(testing.Coverage); err != nil {
+		return e.ErrCreateTestModule.AddDesc(err.Error())
+	}
+	if err := testing.PreTest.Scheduling.ValidateTemplate(); err != nil {
+		return e.ErrCreateTestModule.AddDesc(err.Error())
+	}
 	err := HandleCronjob(testing, log)
 	if err != nil {
 		return e.ErrCreateTestModule.AddErr(err)
@@ -68,6 +79,46 @@ func CreateTesting(username string, testing *commonmodels.Testing, log *zap.Suga
 	return nil
 }
 
+// checkTestReportFormat makes sure the test results can be parsed by reaper
+func checkTestReportFormat(testing *commonmodels.Testing) error {
+	switch testing.TestReportFormat {
+	case "", setting.TestReportFormatGinkgo, setting.TestReportFormatJUnit, setting.TestReportFormatGoTest, setting.TestReportFormatTAP:
+	default:
+		return fmt.Errorf("unsupported test report format: %s", testing.TestReportFormat)
+	}
+
+	if testing.TestResultGlob != "" {
+		if _, err := filepath.Match(testing.TestResultGlob, ""); err != nil {
+			return fmt.Errorf("invalid test result glob %s: %s", testing.TestResultGlob, err)
+		}
+	}
+	return nil
+}
+
+// checkTestCoverage makes sure the coverage files can be parsed by reaper
+func checkTestCoverage(coverage *types.CoverageConfig) error {
+	if coverage == nil {
+		return nil
+	}
+
+	switch coverage.Format {
+	case setting.CoverageFormatCobertura, setting.CoverageFormatLCOV, setting.CoverageFormatJaCoCo, setting.CoverageFormatGo:
+	default:
+		return fmt.Errorf("unsupported coverage format: %s", coverage.Format)
+	}
+
+	if coverage.Path == "" {
+		return fmt.Errorf("empty coverage path")
+	}
+	if _, err := filepath.Match(coverage.Path, ""); err != nil {
+		return fmt.Errorf("invalid coverage path %s: %s", coverage.Path, err)
+	}
+	if coverage.Threshold < 0 || coverage.Threshold > 100 {
+		return fmt.Errorf("coverage threshold must be between 0 and 100")
+	}
+	return nil
+}
+
 func HandleCronjob(testing *commonmodels.Testing, log *zap.SugaredLogger) error {
 	testSchedule := testing.Schedules
 
@@ -108,6 +159,15 @@ func UpdateTesting(username string, testing *commonmodels.Testing, log *zap.Suga
 	if err := commonutil.CheckDefineResourceParam(testing.PreTest.ResReq, testing.PreTest.ResReqSpec); err != nil {
 		return e.ErrUpdateTestModule.AddDesc(err.Error())
 	}
+	if err := checkTestReportFormat(testing); err != nil {
+		return e.ErrUpdateTestModule.AddDesc(err.Error())
+	}
+	if err := checkTestCoverage(testing.Coverage); err != nil {
+		return e.ErrUpdateTestModule.AddDesc(err.Error())
+	}
+	if err := testing.PreTest.Scheduling.ValidateTemplate(); err != nil {
+		return e.ErrUpdateTestModule.AddDesc(err.Error())
+	}
 	err := HandleCronjob(testing, log)
 	if err != nil {
 		return e.ErrUpdateTestModule.AddErr(err)
@@ -67,6 +67,17 @@ func (c *Client) TriggerCleanProducts(log *zap.SugaredLogger) error {
 	return err
 }
 
+// TriggerDetectEnvDrift trigger drift detection of environments
+func (c *Client) TriggerDetectEnvDrift(log *zap.SugaredLogger) error {
+	url := fmt.Sprintf("%s/environment/cron/drift", c.APIBase)
+	log.Info("start detect env drift..")
+	err := c.sendRequest(url)
+	if err != nil {
+		log.Errorf("trigger detect env drift error :%v", err)
+	}
+	return err
+}
+
 // TriggerCleanCIResources trigger clean CollaborationInstance Resources
 func (c *Client) TriggerCleanCIResources(log *zap.SugaredLogger) error {
 	url := fmt.Sprintf("%s/collaboration/collaborations/cron/clean", c.APIBase)
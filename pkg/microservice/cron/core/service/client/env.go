@@ -191,7 +191,7 @@ func (c *Client) GetHostInfo(hostID string, log *zap.SugaredLogger) (*service.Pr
 		err        error
 		privateKey = new(service.PrivateKey)
 	)
-	url := fmt.Sprintf("%s/system/privateKey/%s", c.APIBase, hostID)
+	url := fmt.Sprintf("%s/system/privateKey/internal/%s", c.APIBase, hostID)
 	request, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		log.Errorf("GetPrivateKey new http request error: %v", err)
@@ -130,6 +130,8 @@ const (
 	InitHealthCheckPmHostScheduler = "InitHealthCheckPmHostScheduler"
 
 	InitHelmEnvSyncValuesScheduler = "InitHelmEnvSyncValuesScheduler"
+
+	EnvDriftScheduler = "EnvDriftScheduler"
 )
 
 // NewCronClient ...
@@ -186,7 +188,7 @@ func (c *CronClient) Init() {
 	// 测试管理的定时任务触发
 	c.InitTestScheduler()
 
-	// 定时清理环境
+	// 定时清理环境，并按计划休眠和唤醒环境
 	c.InitCleanProductScheduler()
 	// clean collaboration instance resource every 5 minutes
 	c.InitCleanCIResourcesScheduler()
@@ -202,6 +204,8 @@ func (c *CronClient) Init() {
 	c.InitHealthCheckPmHostScheduler()
 	// sync values from remote for helm envs at regular intervals
 	c.InitHelmEnvSyncValuesScheduler()
+	// detect live cluster drift of environments
+	c.InitEnvDriftScheduler()
 }
 
 func (c *CronClient) InitCleanJobScheduler() {
@@ -222,6 +226,15 @@ func (c *CronClient) InitCleanProductScheduler() {
 	c.Schedulers[CleanProductScheduler].Start()
 }
 
+func (c *CronClient) InitEnvDriftScheduler() {
+
+	c.Schedulers[EnvDriftScheduler] = gocron.NewScheduler()
+
+	c.Schedulers[EnvDriftScheduler].Every(30).Minutes().Do(c.AslanCli.TriggerDetectEnvDrift, c.log)
+
+	c.Schedulers[EnvDriftScheduler].Start()
+}
+
 func (c *CronClient) InitCleanCIResourcesScheduler() {
 
 	c.Schedulers[CleanCIResourcesScheduler] = gocron.NewScheduler()
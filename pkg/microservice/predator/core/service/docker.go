@@ -21,7 +21,10 @@ import (
 	"strings"
 )
 
-const dockerExe = "/usr/local/bin/docker"
+const (
+	dockerExe = "/usr/local/bin/docker"
+	skopeoExe = "/usr/local/bin/skopeo"
+)
 
 func dockerVersion() *exec.Cmd {
 	return exec.Command(dockerExe, "version")
@@ -63,6 +66,20 @@ func dockerTag(sourceFullImage, targetFullImage string) *exec.Cmd {
 	return exec.Command(dockerExe, args...)
 }
 
+// skopeoCopy copies the image with the manifest list and all the images in it,
+// the credentials are in the form of username:password and can be empty
+func skopeoCopy(sourceImage, sourceCreds, targetImage, targetCreds string) *exec.Cmd {
+	args := []string{"copy", "--all"}
+	if sourceCreds != "" {
+		args = append(args, "--src-creds", sourceCreds)
+	}
+	if targetCreds != "" {
+		args = append(args, "--dest-creds", targetCreds)
+	}
+	args = append(args, "docker://"+sourceImage, "docker://"+targetImage)
+	return exec.Command(skopeoExe, args...)
+}
+
 func dockerPush(fullImage string) *exec.Cmd {
 	args := []string{
 		"push",
@@ -16,6 +16,8 @@ limitations under the License.
 
 package service
 
+import "github.com/koderover/zadig/pkg/types"
+
 // Context ...
 type Context struct {
 	JobType        string            `yaml:"job_type"`
@@ -24,6 +26,11 @@ type Context struct {
 	OnSetup        string            `yaml:"setup,omitempty"`
 	ReleaseImages  []RepoImage       `yaml:"release_images"`
 	DistributeList []*DistributeInfo `yaml:"distribute_info"`
+	// CopyAllPlatforms copies the images with all the platforms in their manifest lists between registries
+	// instead of pulling and pushing the image of the current platform
+	CopyAllPlatforms bool `yaml:"copy_all_platforms"`
+	// Provenance are the options of the SBOM and the signature generated for the released images
+	Provenance *types.ProvenanceOptions `yaml:"provenance,omitempty"`
 }
 
 type RepoImage struct {
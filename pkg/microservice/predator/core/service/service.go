@@ -31,6 +31,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/predator/config"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/cosign"
 	"github.com/koderover/zadig/pkg/tool/log"
 )
 
@@ -59,13 +60,15 @@ func NewPredator() (*Predator, error) {
 
 // BeforeExec ...
 func (p *Predator) BeforeExec() error {
-	log.Info("wait for docker daemon to start")
-	for i := 0; i < 120; i++ {
-		err := dockerInfo().Run()
-		if err == nil {
-			break
+	if !p.copyImages() {
+		log.Info("wait for docker daemon to start")
+		for i := 0; i < 120; i++ {
+			err := dockerInfo().Run()
+			if err == nil {
+				break
+			}
+			time.Sleep(time.Second * 1)
 		}
-		time.Sleep(time.Second * 1)
 	}
 
 	if p.Ctx.OnSetup != "" {
@@ -82,6 +85,10 @@ func (p *Predator) BeforeExec() error {
 
 // Exec ...
 func (p *Predator) Exec() error {
+	if p.copyImages() {
+		return p.copyReleaseImages()
+	}
+
 	err := writeDockerConfig(p.Ctx.DockerRegistry.Host, p.Ctx.DockerRegistry.UserName, p.Ctx.DockerRegistry.Password)
 	if err != nil {
 		return err
@@ -102,6 +109,11 @@ func (p *Predator) Exec() error {
 
 // AfterExec ...
 func (p *Predator) AfterExec() error {
+	// the images are copied to the target registries already
+	if p.copyImages() {
+		return nil
+	}
+
 	for _, distribute := range p.Ctx.DistributeList {
 		// get the docker host info from the image
 		splitString := strings.Split(distribute.Image, "/")
@@ -118,11 +130,72 @@ func (p *Predator) AfterExec() error {
 		if err := cmd.Run(); err != nil {
 			return cmd.Run()
 		}
+
+		if err := p.attestImage(distribute.Image); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// copyImages reports whether the images are copied between registries without a docker daemon
+func (p *Predator) copyImages() bool {
+	return p.Ctx.JobType == setting.ReleaseImageJob && p.Ctx.CopyAllPlatforms
+}
+
+// copyReleaseImages copies the image to every target registry, a multi-arch image is copied with all its platforms
+func (p *Predator) copyReleaseImages() error {
+	sourceCreds := ""
+	if p.Ctx.DockerRegistry != nil && p.Ctx.DockerRegistry.UserName != "" {
+		sourceCreds = p.Ctx.DockerRegistry.UserName + ":" + p.Ctx.DockerRegistry.Password
+	}
+
+	for _, distribute := range p.Ctx.DistributeList {
+		targetCreds := ""
+		if distribute.RepoAK != "" {
+			targetCreds = distribute.RepoAK + ":" + distribute.RepoSK
+		}
+
+		cmd := skopeoCopy(p.Ctx.DockerBuildCtx.ImageName, sourceCreds, distribute.Image, targetCreds)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		log.Infof("copy image %s to %s", p.Ctx.DockerBuildCtx.ImageName, distribute.Image)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to copy image %s to %s: %s", p.Ctx.DockerBuildCtx.ImageName, distribute.Image, err)
+		}
+
+		if p.Ctx.Provenance.Enabled() {
+			if err := writeDockerConfig(strings.Split(distribute.Image, "/")[0], distribute.RepoAK, distribute.RepoSK); err != nil {
+				return fmt.Errorf("failed to write docker config file %v", err)
+			}
+			if err := p.attestImage(distribute.Image); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// attestImage resolves the digest of the released image and attaches its SBOM, the provenance is printed into the log
+// so that warpdrive can record it
+func (p *Predator) attestImage(image string) error {
+	if !p.Ctx.Provenance.Enabled() {
+		return nil
+	}
+
+	log.Infof("generate provenance of %s", image)
+	provenance, err := cosign.Attest(image, &cosign.AttestOptions{
+		ProvenanceOptions: p.Ctx.Provenance,
+		Envs:              []string{"DOCKER_CONFIG=" + path.Join(config.Home(), ".docker")},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate provenance of %s: %s", image, err)
+	}
+	fmt.Println(provenance.Marker())
+	return nil
+}
+
 func writeDockerConfig(host string, username string, password string) error {
 	if username == "" {
 		return nil
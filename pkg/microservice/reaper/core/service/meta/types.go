@@ -125,6 +125,8 @@ type Context struct {
 	Cache        types.Cache        `yaml:"cache"`
 	CacheDirType types.CacheDirType `yaml:"cache_dir_type"`
 	CacheUserDir string             `yaml:"cache_user_dir"`
+	// CacheDefaultBranch is the branch whose cache is restored when the branch being built has no cache yet
+	CacheDefaultBranch string `yaml:"cache_default_branch"`
 
 	// Upload To S3 related context
 	UploadEnabled     bool                             `yaml:"upload_enabled"`
@@ -152,6 +154,12 @@ type DockerBuildCtx struct {
 	BuildArgs             string `yaml:"build_args"  bson:"build_args"  json:"build_args"`
 	ImageReleaseTag       string `yaml:"image_release_tag,omitempty" bson:"image_release_tag,omitempty" json:"image_release_tag"`
 	DockerTemplateContent string `yaml:"docker_template_content" bson:"docker_template_content" json:"docker_template_content"`
+	// Builder is the image builder, docker is used if it is empty
+	Builder string `yaml:"builder,omitempty" bson:"builder,omitempty" json:"builder,omitempty"`
+	// Platforms are the target platforms, an image index of the per-platform images is pushed if it is not empty
+	Platforms []string `yaml:"platforms,omitempty" bson:"platforms,omitempty" json:"platforms,omitempty"`
+	// Provenance are the options of the SBOM and the signature generated for the pushed image
+	Provenance *types.ProvenanceOptions `yaml:"provenance,omitempty" bson:"provenance,omitempty" json:"provenance,omitempty"`
 }
 
 func (c *DockerBuildCtx) GetDockerFile() string {
@@ -270,6 +278,7 @@ type Repo struct {
 	Password     string `yaml:"password"`
 	CheckoutRef  string `yaml:"checkout_ref"`
 	EnableProxy  bool   `yaml:"enable_proxy"`
+	IsPrimary    bool   `yaml:"is_primary"`
 }
 
 // PRRef returns refs format
@@ -326,6 +335,12 @@ type GinkgoTest struct {
 	ResultPath     string   `yaml:"result_path"`
 	TestReportPath string   `yaml:"test_report_path"`
 	ArtifactPaths  []string `yaml:"artifact_paths"`
+	// ReportFormat is the format of the result files matching ResultGlob in ResultPath
+	ReportFormat string `yaml:"report_format,omitempty"`
+	ResultGlob   string `yaml:"result_glob,omitempty"`
+	// CoverageFormat is the format of the coverage files matching CoveragePath in the workspace
+	CoverageFormat string `yaml:"coverage_format,omitempty"`
+	CoveragePath   string `yaml:"coverage_path,omitempty"`
 }
 
 // DockerRegistry 推送镜像到 docker registry 配置
@@ -77,6 +77,11 @@ func (r *Reaper) archiveTestFiles() error {
 		return nil
 	}
 
+	return r.archiveTestFile(r.Ctx.Archive.File)
+}
+
+// archiveTestFile uploads the file in the archive dir, the file is skipped if it does not exist
+func (r *Reaper) archiveTestFile(fileName string) error {
 	store, err := s3.NewS3StorageFromEncryptedURI(r.Ctx.StorageURI, r.Ctx.AesKey)
 	if err != nil {
 		log.Errorf("failed to create s3 storage %s, err: %s", r.Ctx.StorageURI, err)
@@ -84,7 +89,7 @@ func (r *Reaper) archiveTestFiles() error {
 	}
 
 	fileType := "test"
-	if strings.Contains(r.Ctx.Archive.File, ".tar.gz") {
+	if strings.Contains(fileName, ".tar.gz") {
 		fileType = "file"
 	}
 	if store.Subfolder != "" {
@@ -93,7 +98,7 @@ func (r *Reaper) archiveTestFiles() error {
 		store.Subfolder = fmt.Sprintf("%s/%d/%s", r.Ctx.PipelineName, r.Ctx.TaskID, fileType)
 	}
 
-	filePath := path.Join(r.Ctx.Archive.Dir, r.Ctx.Archive.File)
+	filePath := path.Join(r.Ctx.Archive.Dir, fileName)
 
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		// no file found, skipped
@@ -109,7 +114,7 @@ func (r *Reaper) archiveTestFiles() error {
 		log.Errorf("failed to create s3 client, error is: %+v", err)
 		return err
 	}
-	objectKey := store.GetObjectPath(r.Ctx.Archive.File)
+	objectKey := store.GetObjectPath(fileName)
 
 	err = s3client.Upload(store.Bucket, filePath, objectKey)
 	if err != nil {
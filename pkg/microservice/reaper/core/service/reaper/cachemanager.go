@@ -76,6 +76,7 @@ func (gcm *GoCacheManager) Unarchive(source, dest string) error {
 	return getAchiver().Unarchive(source, dest)
 }
 
+// TarCacheManager is deprecated, it uploads the whole cache after every build, use ChunkCacheManager instead
 type TarCacheManager struct {
 	StorageURI   string
 	PipelineName string
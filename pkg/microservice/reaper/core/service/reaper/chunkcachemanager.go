@@ -0,0 +1,760 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+	"github.com/koderover/zadig/pkg/microservice/reaper/internal/s3"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+const (
+	// chunks are shared by all pipelines and services so that the same content is stored only once,
+	// the manifests of all pipelines are kept in one folder so that the garbage collection finds them by listing it
+	cacheChunkFolder    = "cache/chunks"
+	cacheManifestFolder = "cache/manifests"
+	cacheGCMarker       = "cache/gc"
+	defaultCacheKey     = "default"
+	// legacyCacheKey is reported when the cache is restored from the tar cache
+	legacyCacheKey = "legacy"
+
+	// the garbage collection runs at most once per interval after a cache is saved
+	cacheGCInterval = 24 * time.Hour
+	// a manifest which is not saved again within the TTL belongs to a branch or a pull request which is gone
+	cacheManifestTTL = 30 * 24 * time.Hour
+	// an unreferenced chunk is kept for a while since a running build may have uploaded it without saving its manifest yet
+	cacheChunkGracePeriod = 24 * time.Hour
+	// a stored chunk older than this is uploaded again when it is reused, so that it is not deleted as an unreferenced
+	// chunk before the manifest which reuses it is saved
+	cacheChunkRefreshAge = cacheChunkGracePeriod / 2
+
+	minCacheChunkSize = 256 << 10
+	maxCacheChunkSize = 4 << 20
+	// a chunk boundary is found every 1MiB on average
+	cacheChunkMask = uint64(1<<20-1) << 44
+)
+
+// gearTable holds the random numbers of the gear rolling hash, it is generated from a fixed seed
+// because the chunk boundaries must stay the same across builds
+var gearTable [256]uint64
+
+func init() {
+	seed := uint64(0x5a646967)
+	for i := range gearTable {
+		// splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		gearTable[i] = z ^ (z >> 31)
+	}
+}
+
+// CacheReporter is implemented by the cache managers which are able to tell the cache hit or miss
+type CacheReporter interface {
+	Report() *types.BuildCache
+}
+
+// chunkStore is the storage of the chunks and the manifests, names are relative to the root of the storage
+type chunkStore interface {
+	// Stat returns nil if the object does not exist
+	Stat(name string) (*storedObject, error)
+	Upload(src, name string) error
+	Download(name, dest string) error
+	// List returns all the objects whose names start with the prefix
+	List(prefix string) ([]*storedObject, error)
+	Delete(names []string) error
+}
+
+type storedObject struct {
+	Name         string
+	LastModified time.Time
+}
+
+// cacheManifest describes a cache directory, the content of all regular files is concatenated
+// in the order of Files and split into Chunks
+type cacheManifest struct {
+	Key    string        `json:"key"`
+	Files  []*cacheFile  `json:"files"`
+	Chunks []*cacheChunk `json:"chunks"`
+}
+
+type cacheFile struct {
+	Path string      `json:"path"`
+	Mode os.FileMode `json:"mode"`
+	Size int64       `json:"size,omitempty"`
+	Link string      `json:"link,omitempty"`
+}
+
+type cacheChunk struct {
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+// ChunkCacheManager stores the cache as content-addressed chunks, so that only the chunks which
+// changed since the last build are uploaded, and the same chunks are shared by all builds.
+// The cache is saved under the first key in Keys and restored from the first key which has a cache,
+// the tar cache saved before the chunks were introduced is restored if none of the keys has a cache.
+type ChunkCacheManager struct {
+	StorageURI   string
+	PipelineName string
+	ServiceName  string
+	Keys         []string
+	aesKey       string
+
+	store  chunkStore
+	legacy CacheManager
+	// known holds the chunks which are saved or refreshed by this build
+	known  map[string]bool
+	report *types.BuildCache
+}
+
+func NewChunkCacheManager(storageURI, pipelineName, serviceName, aesKey string, keys []string) *ChunkCacheManager {
+	if len(keys) == 0 {
+		keys = []string{defaultCacheKey}
+	}
+	return &ChunkCacheManager{
+		StorageURI:   storageURI,
+		PipelineName: pipelineName,
+		ServiceName:  serviceName,
+		Keys:         keys,
+		aesKey:       aesKey,
+		legacy:       NewTarCacheManager(storageURI, pipelineName, serviceName, aesKey),
+		known:        make(map[string]bool),
+		report:       &types.BuildCache{Key: keys[0]},
+	}
+}
+
+// cacheKeys returns the cache keys of a build in the order they are tried when restoring the cache:
+// the pull request, the branch of the primary repository and at last the default branch
+func cacheKeys(repos []*meta.Repo, defaultBranch string) []string {
+	var repo *meta.Repo
+	for _, r := range repos {
+		if r.IsPrimary {
+			repo = r
+			break
+		}
+	}
+	if repo == nil && len(repos) > 0 {
+		repo = repos[0]
+	}
+
+	var candidates []string
+	if repo != nil {
+		if repo.PR > 0 {
+			candidates = append(candidates, fmt.Sprintf("pr-%d", repo.PR))
+		}
+		candidates = append(candidates, repo.Branch)
+	}
+	candidates = append(candidates, defaultBranch)
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range candidates {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, defaultCacheKey)
+	}
+	return keys
+}
+
+// Report returns the hit or miss of the restored cache and the chunks uploaded for the saved cache
+func (m *ChunkCacheManager) Report() *types.BuildCache {
+	return m.report
+}
+
+// Archive uploads the chunks of the source folder which are not in the storage yet and saves the manifest, dest is not used
+func (m *ChunkCacheManager) Archive(source, dest string) error {
+	store, err := m.getStore()
+	if err != nil {
+		return err
+	}
+
+	manifest := &cacheManifest{Key: m.Keys[0]}
+	err = filepath.Walk(source, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, p)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		file := &cacheFile{Path: filepath.ToSlash(rel), Mode: info.Mode()}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			if file.Link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			file.Size = info.Size()
+		case !info.IsDir():
+			// sockets, pipes and devices can not be cached
+			return nil
+		}
+		manifest.Files = append(manifest.Files, file)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	reader := &cacheFilesReader{root: source, files: manifest.Files}
+	defer reader.Close()
+
+	m.report.TotalChunks, m.report.UploadedChunks, m.report.UploadedBytes = 0, 0, 0
+	buf := make([]byte, maxCacheChunkSize)
+	filled, eof := 0, false
+	for {
+		if !eof {
+			n, err := io.ReadFull(reader, buf[filled:])
+			filled += n
+			switch {
+			case err == io.EOF || err == io.ErrUnexpectedEOF:
+				eof = true
+			case err != nil:
+				return err
+			}
+		}
+		if filled == 0 {
+			break
+		}
+
+		size := nextChunkSize(buf[:filled])
+		chunk, err := m.saveChunk(store, buf[:size])
+		if err != nil {
+			return err
+		}
+		manifest.Chunks = append(manifest.Chunks, chunk)
+		filled = copy(buf, buf[size:filled])
+	}
+	m.report.TotalChunks = len(manifest.Chunks)
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	temp, err := writeTempFile(data)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(temp)
+	}()
+	if err := store.Upload(temp, m.manifestName(manifest.Key)); err != nil {
+		return fmt.Errorf("failed to upload cache manifest: %s", err)
+	}
+
+	log.Infof("Cache %s saved: %d chunks, %d uploaded (%d bytes).", manifest.Key, m.report.TotalChunks, m.report.UploadedChunks, m.report.UploadedBytes)
+
+	// the cache is saved already, a failed garbage collection only leaves some garbage for the next one
+	if err := m.collectGarbage(store, time.Now()); err != nil {
+		log.Warnf("Failed to collect the garbage of the cache: %s", err)
+	}
+	return nil
+}
+
+// Unarchive restores the cache of the first key which has one into the dest folder, source is not used.
+// If a cache fails to be restored, e.g. one of its chunks is gone, the dest folder is cleaned and the next key is tried.
+func (m *ChunkCacheManager) Unarchive(source, dest string) error {
+	store, err := m.getStore()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range m.Keys {
+		obj, err := store.Stat(m.manifestName(key))
+		if err != nil {
+			return err
+		}
+		if obj == nil {
+			continue
+		}
+
+		manifest, err := m.loadManifest(store, key)
+		if err == nil {
+			err = m.restore(store, manifest, dest)
+		}
+		if err != nil {
+			log.Warnf("Failed to restore the cache of %s: %s", key, err)
+			if err := os.RemoveAll(dest); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, chunk := range manifest.Chunks {
+			m.report.RestoredBytes += chunk.Size
+		}
+		m.report.Hit = true
+		m.report.RestoredFrom = manifest.Key
+		log.Infof("Cache hit, restored from %s: %d chunks (%d bytes).", manifest.Key, len(manifest.Chunks), m.report.RestoredBytes)
+		return nil
+	}
+	return m.unarchiveLegacy(source, dest)
+}
+
+func (m *ChunkCacheManager) restore(store chunkStore, manifest *cacheManifest, dest string) error {
+	if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+		return err
+	}
+	reader := &cacheChunkReader{manager: m, store: store, chunks: manifest.Chunks}
+	for _, file := range manifest.Files {
+		if err := restoreCacheFile(dest, file, reader); err != nil {
+			return fmt.Errorf("failed to restore %s: %s", file.Path, err)
+		}
+	}
+	return nil
+}
+
+// unarchiveLegacy restores the tar cache of the pipeline, so that the first build after the upgrade is not a cache miss
+func (m *ChunkCacheManager) unarchiveLegacy(source, dest string) error {
+	if m.legacy == nil || m.legacy.Unarchive(source, dest) != nil {
+		log.Infof("Cache miss, no cache found for %s.", strings.Join(m.Keys, ", "))
+		return fmt.Errorf("cache not found")
+	}
+
+	m.report.Hit = true
+	m.report.RestoredFrom = legacyCacheKey
+	log.Infof("Cache hit, restored from the tar cache.")
+	return nil
+}
+
+// collectGarbage deletes the expired manifests and the chunks which are no longer referenced by any manifest,
+// it runs at most once per cacheGCInterval in all builds
+func (m *ChunkCacheManager) collectGarbage(store chunkStore, now time.Time) error {
+	markers, err := store.List(cacheGCMarker)
+	if err != nil {
+		return err
+	}
+	for _, marker := range markers {
+		if marker.Name == cacheGCMarker && now.Sub(marker.LastModified) < cacheGCInterval {
+			return nil
+		}
+	}
+	// claim this round before the sweep, so that the builds finished meanwhile skip it
+	temp, err := writeTempFile([]byte(now.UTC().Format(time.RFC3339)))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(temp)
+	}()
+	if err := store.Upload(temp, cacheGCMarker); err != nil {
+		return err
+	}
+
+	manifests, err := store.List(cacheManifestFolder + "/")
+	if err != nil {
+		return err
+	}
+	referenced := make(map[string]bool)
+	var expired []string
+	for _, obj := range manifests {
+		if now.Sub(obj.LastModified) > cacheManifestTTL {
+			expired = append(expired, obj.Name)
+			continue
+		}
+		data, err := downloadData(store, obj.Name)
+		if err != nil {
+			return err
+		}
+		manifest := &cacheManifest{}
+		if err := json.Unmarshal(data, manifest); err != nil {
+			// an invalid manifest can not be restored either
+			expired = append(expired, obj.Name)
+			continue
+		}
+		for _, chunk := range manifest.Chunks {
+			referenced[chunk.Hash] = true
+		}
+	}
+
+	chunks, err := store.List(cacheChunkFolder + "/")
+	if err != nil {
+		return err
+	}
+	var unreferenced []string
+	for _, obj := range chunks {
+		if !referenced[path.Base(obj.Name)] && now.Sub(obj.LastModified) > cacheChunkGracePeriod {
+			unreferenced = append(unreferenced, obj.Name)
+		}
+	}
+
+	if err := store.Delete(append(expired, unreferenced...)); err != nil {
+		return err
+	}
+	log.Infof("Cache garbage collected: %d expired manifests, %d unreferenced chunks.", len(expired), len(unreferenced))
+	return nil
+}
+
+// saveChunk uploads the chunk unless it is already in the storage, a stored chunk is uploaded again
+// if it is older than cacheChunkRefreshAge since it may be unreferenced and collected as garbage meanwhile
+func (m *ChunkCacheManager) saveChunk(store chunkStore, data []byte) (*cacheChunk, error) {
+	sum := sha256.Sum256(data)
+	chunk := &cacheChunk{Hash: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+	if m.known[chunk.Hash] {
+		return chunk, nil
+	}
+
+	name := m.chunkName(chunk.Hash)
+	obj, err := store.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil || time.Since(obj.LastModified) > cacheChunkRefreshAge {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+
+		temp, err := writeTempFile(compressed.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = os.Remove(temp)
+		}()
+		if err := store.Upload(temp, name); err != nil {
+			return nil, fmt.Errorf("failed to upload cache chunk %s: %s", chunk.Hash, err)
+		}
+		m.report.UploadedChunks++
+		m.report.UploadedBytes += int64(compressed.Len())
+	}
+
+	m.known[chunk.Hash] = true
+	return chunk, nil
+}
+
+// loadChunk downloads the chunk and verifies its content
+func (m *ChunkCacheManager) loadChunk(store chunkStore, chunk *cacheChunk) ([]byte, error) {
+	data, err := downloadData(store, m.chunkName(chunk.Hash))
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	if data, err = ioutil.ReadAll(zr); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != chunk.Hash || int64(len(data)) != chunk.Size {
+		return nil, fmt.Errorf("cache chunk %s is corrupted", chunk.Hash)
+	}
+	return data, nil
+}
+
+func (m *ChunkCacheManager) loadManifest(store chunkStore, key string) (*cacheManifest, error) {
+	data, err := downloadData(store, m.manifestName(key))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &cacheManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid cache manifest of %s: %s", key, err)
+	}
+	manifest.Key = key
+	return manifest, nil
+}
+
+func (m *ChunkCacheManager) chunkName(hash string) string {
+	return path.Join(cacheChunkFolder, hash[:2], hash)
+}
+
+func (m *ChunkCacheManager) manifestName(key string) string {
+	return path.Join(cacheManifestFolder, m.PipelineName, m.ServiceName, url.PathEscape(key)+".json")
+}
+
+func (m *ChunkCacheManager) getStore() (chunkStore, error) {
+	if m.store != nil {
+		return m.store, nil
+	}
+
+	store, err := s3.NewS3StorageFromEncryptedURI(m.StorageURI, m.aesKey)
+	if err != nil {
+		log.Errorf("failed to create s3 storage %s", m.StorageURI)
+		return nil, err
+	}
+	forcedPathStyle := true
+	if store.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3tool.NewClient(store.Endpoint, store.Ak, store.Sk, store.Insecure, forcedPathStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	m.store = &s3ChunkStore{client: client, store: store}
+	return m.store, nil
+}
+
+// nextChunkSize returns the size of the next chunk in data using the gear rolling hash,
+// so that an insertion or a deletion only changes the chunks around it
+func nextChunkSize(data []byte) int {
+	if len(data) <= minCacheChunkSize {
+		return len(data)
+	}
+
+	end := len(data)
+	if end > maxCacheChunkSize {
+		end = maxCacheChunkSize
+	}
+	var hash uint64
+	for i := minCacheChunkSize; i < end; i++ {
+		hash = (hash << 1) + gearTable[data[i]]
+		if hash&cacheChunkMask == 0 {
+			return i + 1
+		}
+	}
+	return end
+}
+
+// cacheFilesReader reads the content of the regular files one after another
+type cacheFilesReader struct {
+	root    string
+	files   []*cacheFile
+	current *os.File
+	remain  int64
+}
+
+func (r *cacheFilesReader) Read(p []byte) (int, error) {
+	for r.current == nil {
+		if len(r.files) == 0 {
+			return 0, io.EOF
+		}
+		file := r.files[0]
+		r.files = r.files[1:]
+		if !file.Mode.IsRegular() || file.Size == 0 {
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(r.root, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return 0, err
+		}
+		r.current, r.remain = f, file.Size
+	}
+
+	if int64(len(p)) > r.remain {
+		p = p[:r.remain]
+	}
+	n, err := r.current.Read(p)
+	r.remain -= int64(n)
+	if err == io.EOF && r.remain > 0 {
+		return n, fmt.Errorf("%s changed while caching", r.current.Name())
+	}
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+	if r.remain == 0 {
+		_ = r.current.Close()
+		r.current = nil
+	}
+	return n, nil
+}
+
+func (r *cacheFilesReader) Close() {
+	if r.current != nil {
+		_ = r.current.Close()
+		r.current = nil
+	}
+}
+
+// cacheChunkReader downloads the chunks one after another
+type cacheChunkReader struct {
+	manager *ChunkCacheManager
+	store   chunkStore
+	chunks  []*cacheChunk
+	current *bytes.Reader
+}
+
+func (r *cacheChunkReader) Read(p []byte) (int, error) {
+	for r.current == nil || r.current.Len() == 0 {
+		if len(r.chunks) == 0 {
+			return 0, io.EOF
+		}
+		data, err := r.manager.loadChunk(r.store, r.chunks[0])
+		if err != nil {
+			return 0, err
+		}
+		r.chunks = r.chunks[1:]
+		r.current = bytes.NewReader(data)
+	}
+	return r.current.Read(p)
+}
+
+func restoreCacheFile(root string, file *cacheFile, content io.Reader) error {
+	target := filepath.Join(root, filepath.FromSlash(file.Path))
+	if !strings.HasPrefix(target, filepath.Clean(root)+string(os.PathSeparator)) {
+		return fmt.Errorf("invalid path")
+	}
+
+	switch {
+	case file.Mode.IsDir():
+		return os.MkdirAll(target, file.Mode.Perm()|0700)
+	case file.Mode&os.ModeSymlink != 0:
+		_ = os.Remove(target)
+		return os.Symlink(file.Link, target)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	_ = os.Remove(target)
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.Mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(f, content, file.Size); err != nil {
+		return err
+	}
+	return nil
+}
+
+func writeTempFile(data []byte) (string, error) {
+	temp, err := ioutil.TempFile("", "reaper-cache-*")
+	if err != nil {
+		return "", err
+	}
+	defer temp.Close()
+
+	if _, err := temp.Write(data); err != nil {
+		_ = os.Remove(temp.Name())
+		return "", err
+	}
+	return temp.Name(), nil
+}
+
+func downloadData(store chunkStore, name string) ([]byte, error) {
+	temp, err := ioutil.TempFile("", "reaper-cache-*")
+	if err != nil {
+		return nil, err
+	}
+	_ = temp.Close()
+	defer func() {
+		_ = os.Remove(temp.Name())
+	}()
+
+	if err := store.Download(name, temp.Name()); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(temp.Name())
+}
+
+type s3ChunkStore struct {
+	client *s3tool.Client
+	store  *s3.S3
+}
+
+func (s *s3ChunkStore) Stat(name string) (*storedObject, error) {
+	objects, err := s.List(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		if obj.Name == name {
+			return obj, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *s3ChunkStore) Upload(src, name string) error {
+	return s.client.Upload(s.store.Bucket, src, s.store.GetObjectPath(name))
+}
+
+func (s *s3ChunkStore) Download(name, dest string) error {
+	return s.client.Download(s.store.Bucket, s.store.GetObjectPath(name), dest)
+}
+
+func (s *s3ChunkStore) List(prefix string) ([]*storedObject, error) {
+	root := s.store.GetObjectPath("")
+	if root != "" {
+		root += "/"
+	}
+
+	var objects []*storedObject
+	input := &awss3.ListObjectsInput{
+		Bucket: aws.String(s.store.Bucket),
+		Prefix: aws.String(root + prefix),
+	}
+	err := s.client.ListObjectsPages(input, func(output *awss3.ListObjectsOutput, _ bool) bool {
+		for _, item := range output.Contents {
+			objects = append(objects, &storedObject{
+				Name:         strings.TrimPrefix(aws.StringValue(item.Key), root),
+				LastModified: aws.TimeValue(item.LastModified),
+			})
+		}
+		return true
+	})
+	return objects, err
+}
+
+func (s *s3ChunkStore) Delete(names []string) error {
+	// at most 1000 objects can be deleted in one request
+	for len(names) > 0 {
+		n := len(names)
+		if n > 1000 {
+			n = 1000
+		}
+		keys := make([]string, 0, n)
+		for _, name := range names[:n] {
+			keys = append(keys, s.store.GetObjectPath(name))
+		}
+		if err := s.client.DeleteObjects(s.store.Bucket, keys); err != nil {
+			return err
+		}
+		names = names[n:]
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+)
+
+type localChunkStore struct {
+	root string
+}
+
+func (s *localChunkStore) Exists(name string) (bool, error) {
+	_, err := os.Stat(filepath.Join(s.root, name))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *localChunkStore) Stat(name string) (*storedObject, error) {
+	info, err := os.Stat(filepath.Join(s.root, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &storedObject{Name: name, LastModified: info.ModTime()}, nil
+}
+
+func (s *localChunkStore) Upload(src, name string) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(s.root, name)), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(s.root, name), data, 0644)
+}
+
+func (s *localChunkStore) Download(name, dest string) error {
+	data, err := ioutil.ReadFile(filepath.Join(s.root, name))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dest, data, 0644)
+}
+
+func (s *localChunkStore) List(prefix string) ([]*storedObject, error) {
+	var objects []*storedObject
+	err := filepath.Walk(s.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		if name = filepath.ToSlash(name); strings.HasPrefix(name, prefix) {
+			objects = append(objects, &storedObject{Name: name, LastModified: info.ModTime()})
+		}
+		return nil
+	})
+	return objects, err
+}
+
+func (s *localChunkStore) Delete(names []string) error {
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(s.root, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *localChunkStore) age(t *testing.T, prefix string, d time.Duration) {
+	objects, err := s.List(prefix)
+	assert.Nil(t, err)
+	for _, obj := range objects {
+		mtime := obj.LastModified.Add(-d)
+		assert.Nil(t, os.Chtimes(filepath.Join(s.root, obj.Name), mtime, mtime))
+	}
+}
+
+type fakeLegacyCacheManager struct {
+	err      error
+	restored string
+}
+
+func (m *fakeLegacyCacheManager) Archive(source, dest string) error {
+	return nil
+}
+
+func (m *fakeLegacyCacheManager) Unarchive(source, dest string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.restored = dest
+	return nil
+}
+
+func newTestChunkCacheManager(store chunkStore, keys ...string) *ChunkCacheManager {
+	m := NewChunkCacheManager("", "pipeline", "service", "", keys)
+	m.store = store
+	m.legacy = nil
+	return m
+}
+
+func TestChunkCacheManager_ArchiveAndUnarchive(t *testing.T) {
+	store := &localChunkStore{root: t.TempDir()}
+	source := t.TempDir()
+
+	large := make([]byte, 6<<20)
+	rand.New(rand.NewSource(1)).Read(large)
+	assert.Nil(t, os.MkdirAll(filepath.Join(source, "pkg", "mod"), os.ModePerm))
+	assert.Nil(t, os.MkdirAll(filepath.Join(source, "empty"), os.ModePerm))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(source, "pkg", "mod", "large.bin"), large, 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(source, "readme.md"), []byte("hello world"), 0755))
+	assert.Nil(t, os.Symlink("readme.md", filepath.Join(source, "link")))
+
+	master := newTestChunkCacheManager(store, "master")
+	assert.Nil(t, master.Archive(source, ""))
+	saved := master.Report()
+	assert.True(t, saved.TotalChunks > 1)
+	assert.Equal(t, saved.TotalChunks, saved.UploadedChunks)
+
+	// a new branch falls back to the cache of the default branch
+	feature := newTestChunkCacheManager(store, "feature/a", "master")
+	dest := t.TempDir()
+	assert.Nil(t, feature.Unarchive("", dest))
+	assert.True(t, feature.Report().Hit)
+	assert.Equal(t, "master", feature.Report().RestoredFrom)
+
+	data, err := ioutil.ReadFile(filepath.Join(dest, "pkg", "mod", "large.bin"))
+	assert.Nil(t, err)
+	assert.Equal(t, large, data)
+	data, err = ioutil.ReadFile(filepath.Join(dest, "readme.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", string(data))
+	link, err := os.Readlink(filepath.Join(dest, "link"))
+	assert.Nil(t, err)
+	assert.Equal(t, "readme.md", link)
+	info, err := os.Stat(filepath.Join(dest, "empty"))
+	assert.Nil(t, err)
+	assert.True(t, info.IsDir())
+
+	// only the changed chunks are uploaded
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dest, "readme.md"), []byte("hello zadig"), 0755))
+	assert.Nil(t, feature.Archive(dest, ""))
+	assert.Equal(t, "feature/a", feature.Report().Key)
+	assert.Equal(t, 1, feature.Report().UploadedChunks)
+
+	again := newTestChunkCacheManager(store, "feature/a", "master")
+	assert.Nil(t, again.Unarchive("", t.TempDir()))
+	assert.Equal(t, "feature/a", again.Report().RestoredFrom)
+}
+
+func TestChunkCacheManager_UnarchiveFallback(t *testing.T) {
+	store := &localChunkStore{root: t.TempDir()}
+	source := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(source, "a.txt"), []byte("master"), 0644))
+	assert.Nil(t, newTestChunkCacheManager(store, "master").Archive(source, ""))
+
+	content := make([]byte, 2<<20)
+	rand.New(rand.NewSource(5)).Read(content)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(source, "b.bin"), content, 0644))
+	assert.Nil(t, newTestChunkCacheManager(store, "feature").Archive(source, ""))
+
+	// a chunk which only the cache of feature refers to is gone
+	m := newTestChunkCacheManager(store, "feature")
+	manifest, err := m.loadManifest(store, "feature")
+	assert.Nil(t, err)
+	last := manifest.Chunks[len(manifest.Chunks)-1]
+	assert.Nil(t, store.Delete([]string{m.chunkName(last.Hash)}))
+
+	m = newTestChunkCacheManager(store, "feature", "master")
+	dest := t.TempDir()
+	assert.Nil(t, m.Unarchive("", dest))
+	assert.Equal(t, "master", m.Report().RestoredFrom)
+	data, err := ioutil.ReadFile(filepath.Join(dest, "a.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "master", string(data))
+	_, err = os.Stat(filepath.Join(dest, "b.bin"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestChunkCacheManager_RefreshReusedChunks(t *testing.T) {
+	store := &localChunkStore{root: t.TempDir()}
+	source := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(source, "a.txt"), []byte("hello"), 0644))
+	assert.Nil(t, newTestChunkCacheManager(store, "master").Archive(source, ""))
+
+	// a recently stored chunk is reused as it is
+	m := newTestChunkCacheManager(store, "feature")
+	assert.Nil(t, m.Archive(source, ""))
+	assert.Equal(t, 0, m.Report().UploadedChunks)
+
+	// an old chunk is uploaded again so that the garbage collection keeps it
+	store.age(t, cacheChunkFolder+"/", cacheChunkRefreshAge+time.Hour)
+	m = newTestChunkCacheManager(store, "feature")
+	assert.Nil(t, m.Archive(source, ""))
+	assert.Equal(t, 1, m.Report().UploadedChunks)
+	chunks, err := store.List(cacheChunkFolder + "/")
+	assert.Nil(t, err)
+	for _, chunk := range chunks {
+		assert.True(t, time.Since(chunk.LastModified) < cacheChunkRefreshAge)
+	}
+}
+
+func TestChunkCacheManager_UnarchiveMiss(t *testing.T) {
+	m := newTestChunkCacheManager(&localChunkStore{root: t.TempDir()}, "master")
+	assert.NotNil(t, m.Unarchive("", t.TempDir()))
+	assert.False(t, m.Report().Hit)
+}
+
+func TestChunkCacheManager_UnarchiveLegacy(t *testing.T) {
+	m := newTestChunkCacheManager(&localChunkStore{root: t.TempDir()}, "master")
+	legacy := &fakeLegacyCacheManager{}
+	m.legacy = legacy
+
+	dest := t.TempDir()
+	assert.Nil(t, m.Unarchive("", dest))
+	assert.Equal(t, dest, legacy.restored)
+	assert.True(t, m.Report().Hit)
+	assert.Equal(t, legacyCacheKey, m.Report().RestoredFrom)
+
+	m = newTestChunkCacheManager(&localChunkStore{root: t.TempDir()}, "master")
+	m.legacy = &fakeLegacyCacheManager{err: errors.New("cache not found")}
+	assert.NotNil(t, m.Unarchive("", t.TempDir()))
+	assert.False(t, m.Report().Hit)
+}
+
+func TestChunkCacheManager_CollectGarbage(t *testing.T) {
+	store := &localChunkStore{root: t.TempDir()}
+	source := t.TempDir()
+	content := make([]byte, 2<<20)
+	rand.New(rand.NewSource(3)).Read(content)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(source, "a.bin"), content, 0644))
+
+	stale := newTestChunkCacheManager(store, "pr-1")
+	assert.Nil(t, stale.Archive(source, ""))
+	staleChunks, err := store.List(cacheChunkFolder + "/")
+	assert.Nil(t, err)
+
+	// the manifest of pr-1 expires and a new cache with other content is saved
+	store.age(t, "", cacheManifestTTL+time.Hour)
+	rand.New(rand.NewSource(4)).Read(content)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(source, "a.bin"), content, 0644))
+	master := newTestChunkCacheManager(store, "master")
+	assert.Nil(t, master.Archive(source, ""))
+
+	exists, err := store.Exists(stale.manifestName("pr-1"))
+	assert.Nil(t, err)
+	assert.False(t, exists)
+	for _, chunk := range staleChunks {
+		exists, err := store.Exists(chunk.Name)
+		assert.Nil(t, err)
+		assert.False(t, exists)
+	}
+	assert.Nil(t, newTestChunkCacheManager(store, "master").Unarchive("", t.TempDir()))
+
+	// the garbage collection runs once per interval
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(source, "orphan"), []byte("orphan"), 0644))
+	assert.Nil(t, store.Upload(filepath.Join(source, "orphan"), cacheChunkFolder+"/or/orphan"))
+	store.age(t, cacheChunkFolder+"/or/", cacheChunkGracePeriod+time.Hour)
+	assert.Nil(t, master.collectGarbage(store, time.Now()))
+	exists, err = store.Exists(cacheChunkFolder + "/or/orphan")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	assert.Nil(t, master.collectGarbage(store, time.Now().Add(cacheGCInterval+time.Hour)))
+	exists, err = store.Exists(cacheChunkFolder + "/or/orphan")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+}
+
+func TestNextChunkSize(t *testing.T) {
+	data := make([]byte, 8<<20)
+	rand.New(rand.NewSource(2)).Read(data)
+
+	size := nextChunkSize(data)
+	assert.True(t, size >= minCacheChunkSize && size <= maxCacheChunkSize)
+	assert.Equal(t, 100, nextChunkSize(data[:100]))
+
+	// boundaries only depend on the content around them
+	shifted := append([]byte("prefix"), data...)
+	assert.Equal(t, size+len("prefix"), nextChunkSize(shifted))
+}
+
+func TestCacheKeys(t *testing.T) {
+	assert.Equal(t, []string{defaultCacheKey}, cacheKeys(nil, ""))
+	assert.Equal(t, []string{"master"}, cacheKeys(nil, "master"))
+
+	repos := []*meta.Repo{{Branch: "dev"}, {Branch: "feature", PR: 12, IsPrimary: true}}
+	assert.Equal(t, []string{"pr-12", "feature", "master"}, cacheKeys(repos, "master"))
+	assert.Equal(t, []string{"master"}, cacheKeys([]*meta.Repo{{Branch: "master"}}, "master"))
+}
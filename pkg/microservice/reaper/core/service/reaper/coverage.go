@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// coverageParser returns the coverage of every package in a coverage file
+type coverageParser func(data []byte) ([]*types.PackageCoverage, error)
+
+var coverageParsers = map[string]coverageParser{
+	setting.CoverageFormatCobertura: parseCoberturaCoverage,
+	setting.CoverageFormatLCOV:      parseLCOVCoverage,
+	setting.CoverageFormatJaCoCo:    parseJaCoCoCoverage,
+	setting.CoverageFormatGo:        parseGoCoverage,
+}
+
+// collectCoverage parses the coverage files matching the glob and merges them into one report
+func collectCoverage(format, workspace, glob string) (*types.CoverageReport, error) {
+	parser, ok := coverageParsers[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported coverage format: %s", format)
+	}
+
+	if !strings.HasPrefix(glob, "/") {
+		glob = filepath.Join(workspace, glob)
+	}
+	files, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid coverage path %s: %s", glob, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("coverage files matching %s not found", glob)
+	}
+
+	var packages []*types.PackageCoverage
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Warningf("Read file [%s], error: %v", file, err)
+			continue
+		}
+		filePackages, err := parser(data)
+		if err != nil {
+			log.Warningf("Parse %s coverage file [%s], error: %v", format, file, err)
+			continue
+		}
+		packages = append(packages, filePackages...)
+	}
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no coverage found in files matching %s", glob)
+	}
+
+	return types.NewCoverageReport(format, packages), nil
+}
+
+// archiveCoverage writes the normalized coverage report into the archive dir and uploads it
+func (r *Reaper) archiveCoverage() error {
+	report, err := collectCoverage(r.Ctx.GinkgoTest.CoverageFormat, r.ActiveWorkspace, r.Ctx.GinkgoTest.CoveragePath)
+	if err != nil {
+		return err
+	}
+	log.Infof("Line coverage: %.2f%%, branch coverage: %.2f%%.", report.LineRate, report.BranchRate)
+
+	if r.Ctx.Archive == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	fileName := types.CoverageReportFile(r.Ctx.Archive.File)
+	if err := ioutil.WriteFile(path.Join(r.Ctx.Archive.Dir, fileName), data, 0644); err != nil {
+		return err
+	}
+
+	return r.archiveTestFile(fileName)
+}
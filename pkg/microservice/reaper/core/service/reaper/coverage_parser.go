@@ -0,0 +1,255 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/types"
+)
+
+type coberturaCoverage struct {
+	Packages []struct {
+		Name    string `xml:"name,attr"`
+		Classes []struct {
+			Lines []struct {
+				Hits              int    `xml:"hits,attr"`
+				Branch            bool   `xml:"branch,attr"`
+				ConditionCoverage string `xml:"condition-coverage,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// conditionCoverage matches the condition-coverage attribute of cobertura, e.g. 50% (1/2)
+var conditionCoverage = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+func parseCoberturaCoverage(data []byte) ([]*types.PackageCoverage, error) {
+	report := &coberturaCoverage{}
+	if err := xml.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cobertura report: %s", err)
+	}
+
+	packages := make([]*types.PackageCoverage, 0, len(report.Packages))
+	for _, p := range report.Packages {
+		pkg := &types.PackageCoverage{Name: p.Name}
+		for _, class := range p.Classes {
+			for _, line := range class.Lines {
+				pkg.LinesValid++
+				if line.Hits > 0 {
+					pkg.LinesCovered++
+				}
+				if !line.Branch {
+					continue
+				}
+				if matches := conditionCoverage.FindStringSubmatch(line.ConditionCoverage); matches != nil {
+					covered, _ := strconv.Atoi(matches[1])
+					valid, _ := strconv.Atoi(matches[2])
+					pkg.BranchesCovered += covered
+					pkg.BranchesValid += valid
+				}
+			}
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+type jacocoCoverage struct {
+	Packages []struct {
+		Name     string          `xml:"name,attr"`
+		Counters []jacocoCounter `xml:"counter"`
+	} `xml:"package"`
+}
+
+type jacocoCounter struct {
+	Type    string `xml:"type,attr"`
+	Missed  int    `xml:"missed,attr"`
+	Covered int    `xml:"covered,attr"`
+}
+
+func parseJaCoCoCoverage(data []byte) ([]*types.PackageCoverage, error) {
+	report := &jacocoCoverage{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// jacoco reports refer to report.dtd which is not available
+	decoder.Strict = false
+	if err := decoder.Decode(report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal jacoco report: %s", err)
+	}
+
+	packages := make([]*types.PackageCoverage, 0, len(report.Packages))
+	for _, p := range report.Packages {
+		pkg := &types.PackageCoverage{Name: p.Name}
+		for _, counter := range p.Counters {
+			switch counter.Type {
+			case "LINE":
+				pkg.LinesCovered = counter.Covered
+				pkg.LinesValid = counter.Covered + counter.Missed
+			case "BRANCH":
+				pkg.BranchesCovered = counter.Covered
+				pkg.BranchesValid = counter.Covered + counter.Missed
+			}
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// parseLCOVCoverage groups the source files in an lcov tracefile by directory
+func parseLCOVCoverage(data []byte) ([]*types.PackageCoverage, error) {
+	var (
+		packages []*types.PackageCoverage
+		file     string
+		summary  types.CoverageSummary
+		// the DA and BRDA records are counted in case LF, LH, BRF or BRH is missing
+		counted  types.CoverageSummary
+		hasLines bool
+		hasBrs   bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+
+		switch key {
+		case "SF":
+			file = value
+			summary, counted = types.CoverageSummary{}, types.CoverageSummary{}
+			hasLines, hasBrs = false, false
+		case "DA":
+			fields := strings.Split(value, ",")
+			counted.LinesValid++
+			if len(fields) > 1 && fields[1] != "0" {
+				counted.LinesCovered++
+			}
+		case "BRDA":
+			fields := strings.Split(value, ",")
+			counted.BranchesValid++
+			if len(fields) > 3 && fields[3] != "-" && fields[3] != "0" {
+				counted.BranchesCovered++
+			}
+		case "LF":
+			summary.LinesValid, _ = strconv.Atoi(value)
+			hasLines = true
+		case "LH":
+			summary.LinesCovered, _ = strconv.Atoi(value)
+		case "BRF":
+			summary.BranchesValid, _ = strconv.Atoi(value)
+			hasBrs = true
+		case "BRH":
+			summary.BranchesCovered, _ = strconv.Atoi(value)
+		case "end_of_record":
+			if file == "" {
+				continue
+			}
+			if !hasLines {
+				summary.LinesValid, summary.LinesCovered = counted.LinesValid, counted.LinesCovered
+			}
+			if !hasBrs {
+				summary.BranchesValid, summary.BranchesCovered = counted.BranchesValid, counted.BranchesCovered
+			}
+			packages = append(packages, &types.PackageCoverage{Name: path.Dir(file), CoverageSummary: summary})
+			file = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read lcov report: %s", err)
+	}
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no source file found in lcov report")
+	}
+	return packages, nil
+}
+
+// parseGoCoverage parses a go coverprofile, statements are counted as lines and there is no branch coverage
+func parseGoCoverage(data []byte) ([]*types.PackageCoverage, error) {
+	type block struct {
+		pkg        string
+		statements int
+		covered    bool
+	}
+
+	var (
+		blocks = make(map[string]*block)
+		order  []string
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// e.g. github.com/koderover/zadig/pkg/util/file.go:25.35,27.2 1 1
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid coverprofile line: %s", line)
+		}
+		i := strings.LastIndex(fields[0], ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid coverprofile line: %s", line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid coverprofile line: %s", line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid coverprofile line: %s", line)
+		}
+
+		// the same block shows up multiple times if the profiles of several packages are merged
+		b, ok := blocks[fields[0]]
+		if !ok {
+			b = &block{pkg: path.Dir(fields[0][:i]), statements: statements}
+			blocks[fields[0]] = b
+			order = append(order, fields[0])
+		}
+		b.covered = b.covered || count > 0
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read coverprofile: %s", err)
+	}
+
+	packages := make(map[string]*types.PackageCoverage)
+	var resp []*types.PackageCoverage
+	for _, key := range order {
+		b := blocks[key]
+		pkg, ok := packages[b.pkg]
+		if !ok {
+			pkg = &types.PackageCoverage{Name: b.pkg}
+			packages[b.pkg] = pkg
+			resp = append(resp, pkg)
+		}
+		pkg.LinesValid += b.statements
+		if b.covered {
+			pkg.LinesCovered += b.statements
+		}
+	}
+	return resp, nil
+}
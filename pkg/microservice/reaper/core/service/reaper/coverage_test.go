@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/types"
+)
+
+func TestParseCoberturaCoverage(t *testing.T) {
+	data := `<?xml version="1.0" ?>
+<coverage line-rate="0.5" branch-rate="0.5">
+  <packages>
+    <package name="app">
+      <classes>
+        <class name="main.py" filename="app/main.py">
+          <lines>
+            <line number="1" hits="1"/>
+            <line number="2" hits="0"/>
+            <line number="3" hits="2" branch="true" condition-coverage="50% (1/2)"/>
+            <line number="4" hits="0"/>
+          </lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>`
+	packages, err := parseCoberturaCoverage([]byte(data))
+	assert.Nil(t, err)
+	report := types.NewCoverageReport("cobertura", packages)
+	assert.Equal(t, 4, report.LinesValid)
+	assert.Equal(t, 2, report.LinesCovered)
+	assert.Equal(t, 50.0, report.LineRate)
+	assert.Equal(t, 2, report.BranchesValid)
+	assert.Equal(t, 1, report.BranchesCovered)
+}
+
+func TestParseJaCoCoCoverage(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<!DOCTYPE report PUBLIC "-//JACOCO//DTD Report 1.1//EN" "report.dtd">
+<report name="demo">
+  <package name="com/demo">
+    <counter type="INSTRUCTION" missed="10" covered="30"/>
+    <counter type="BRANCH" missed="1" covered="3"/>
+    <counter type="LINE" missed="2" covered="6"/>
+  </package>
+  <counter type="LINE" missed="2" covered="6"/>
+</report>`
+	packages, err := parseJaCoCoCoverage([]byte(data))
+	assert.Nil(t, err)
+	report := types.NewCoverageReport("jacoco", packages)
+	assert.Equal(t, 75.0, report.LineRate)
+	assert.Equal(t, 75.0, report.BranchRate)
+	assert.Equal(t, "com/demo", report.Packages[0].Name)
+}
+
+func TestParseLCOVCoverage(t *testing.T) {
+	data := `TN:
+SF:src/a.js
+DA:1,1
+DA:2,0
+LF:2
+LH:1
+BRF:2
+BRH:2
+end_of_record
+SF:src/b.js
+DA:1,3
+DA:2,1
+BRDA:2,0,0,1
+BRDA:2,0,1,-
+end_of_record
+SF:lib/c.js
+LF:4
+LH:0
+end_of_record`
+	packages, err := parseLCOVCoverage([]byte(data))
+	assert.Nil(t, err)
+	report := types.NewCoverageReport("lcov", packages)
+	assert.Equal(t, 2, len(report.Packages))
+	assert.Equal(t, "lib", report.Packages[0].Name)
+	assert.Equal(t, 75.0, report.Packages[1].LineRate)
+	assert.Equal(t, 75.0, report.Packages[1].BranchRate)
+	assert.Equal(t, 8, report.LinesValid)
+	assert.Equal(t, 3, report.LinesCovered)
+}
+
+func TestParseGoCoverage(t *testing.T) {
+	data := `mode: set
+example.com/demo/pkg/a/a.go:3.20,5.2 2 1
+example.com/demo/pkg/a/a.go:7.20,9.2 2 0
+example.com/demo/pkg/b/b.go:3.20,5.2 1 0
+example.com/demo/pkg/a/a.go:7.20,9.2 2 1
+`
+	packages, err := parseGoCoverage([]byte(data))
+	assert.Nil(t, err)
+	report := types.NewCoverageReport("go", packages)
+	assert.Equal(t, 5, report.LinesValid)
+	assert.Equal(t, 4, report.LinesCovered)
+	assert.Equal(t, 100.0, report.Packages[0].LineRate)
+	assert.Equal(t, "example.com/demo/pkg/b", report.Packages[1].Name)
+	assert.Equal(t, 0.0, report.Packages[1].LineRate)
+}
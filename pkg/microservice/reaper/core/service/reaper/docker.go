@@ -36,6 +36,11 @@ func dockerInfo() *exec.Cmd {
 	return exec.Command(dockerExe, "info")
 }
 
+// dockerServerPlatform prints the platform of the docker daemon, e.g. linux/amd64
+func dockerServerPlatform() *exec.Cmd {
+	return exec.Command(dockerExe, "version", "--format", "{{.Server.Os}}/{{.Server.Arch}}")
+}
+
 func dockerPush(fullImage string) *exec.Cmd {
 	args := []string{
 		"push",
@@ -43,3 +48,12 @@ func dockerPush(fullImage string) *exec.Cmd {
 	}
 	return exec.Command(dockerExe, args...)
 }
+
+func dockerManifestCreate(manifestList string, images []string) *exec.Cmd {
+	args := append([]string{"manifest", "create", "--amend", manifestList}, images...)
+	return exec.Command(dockerExe, args...)
+}
+
+func dockerManifestPush(manifestList string) *exec.Cmd {
+	return exec.Command(dockerExe, "manifest", "push", "--purge", manifestList)
+}
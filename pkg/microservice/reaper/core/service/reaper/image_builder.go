@@ -0,0 +1,461 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/config"
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+const (
+	buildctlExe = "buildctl"
+	// kanikoExe is the kaniko executor in the kaniko container, not in the reaper image
+	kanikoExe = "/kaniko/executor"
+	// daemonTimeout is how long to wait for the buildkitd in the image builder container to be ready
+	daemonTimeout = 60 * time.Second
+)
+
+// ImageBuilder builds the image of a build and pushes it to the registry
+type ImageBuilder interface {
+	// Login saves the credential of the registry which is used when pushing the image
+	Login(registry *meta.DockerRegistry) error
+	// Build builds the image and pushes it
+	Build(opts *ImageBuildOptions) error
+}
+
+// ImageBuildOptions are the options of an image build, the paths are relative to Dir
+type ImageBuildOptions struct {
+	Dockerfile string
+	Image      string
+	ContextDir string
+	// BuildArgs are the docker build flags given by the user, daemonless builders only support `--build-arg`
+	BuildArgs   string
+	IgnoreCache bool
+	// Platforms are the target platforms, the image is an index of the per-platform images if it is not empty
+	Platforms []string
+	Dir       string
+	Envs      []string
+}
+
+func (o *ImageBuildOptions) abs(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(o.Dir, p)
+}
+
+func newImageBuilder(builder string) ImageBuilder {
+	switch builder {
+	case setting.ImageBuilderBuildKit:
+		return &buildKitBuilder{addr: types.BuildKitdAddr}
+	case setting.ImageBuilderKaniko:
+		return &kanikoBuilder{dir: types.ImageBuildDir, executor: kanikoExe}
+	default:
+		return &dockerBuilder{}
+	}
+}
+
+// isDaemonlessBuilder reports whether the builder works without a docker daemon
+func isDaemonlessBuilder(builder string) bool {
+	return builder == setting.ImageBuilderBuildKit || builder == setting.ImageBuilderKaniko
+}
+
+type dockerBuilder struct{}
+
+func (b *dockerBuilder) Login(registry *meta.DockerRegistry) error {
+	cmd := dockerLogin(registry.UserName, registry.Password, registry.Host)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	return cmd.Run()
+}
+
+func (b *dockerBuilder) Build(opts *ImageBuildOptions) error {
+	if len(opts.Platforms) == 0 {
+		if err := runBuildCommands(opts.Dir, opts.Envs, dockerBuildCmd(opts.Dockerfile, opts.Image, opts.ContextDir, opts.BuildArgs, opts.IgnoreCache)); err != nil {
+			return err
+		}
+		return pushImage(opts.Dir, opts.Envs, dockerPush(opts.Image))
+	}
+
+	// dind has neither buildx nor QEMU, so it can only build images for its own platform
+	serverPlatform, err := b.serverPlatform(opts)
+	if err != nil {
+		return err
+	}
+	for _, platform := range opts.Platforms {
+		if !samePlatform(platform, serverPlatform) {
+			return fmt.Errorf("the docker daemon on %s can not build the image for %s, use the buildkit builder on a node which emulates %s instead", serverPlatform, platform, platform)
+		}
+	}
+
+	// every platform is built and pushed with its own tag, then the manifest list of them is pushed as the image
+	envs := append(append([]string{}, opts.Envs...), "DOCKER_CLI_EXPERIMENTAL=enabled")
+	var images []string
+	for _, platform := range opts.Platforms {
+		image := platformImage(opts.Image, platform)
+		images = append(images, image)
+		buildArgs := strings.TrimSpace("--platform=" + platform + " " + opts.BuildArgs)
+		if err := runBuildCommands(opts.Dir, envs, dockerBuildCmd(opts.Dockerfile, image, opts.ContextDir, buildArgs, opts.IgnoreCache)); err != nil {
+			return err
+		}
+		if err := pushImage(opts.Dir, envs, dockerPush(image)); err != nil {
+			return err
+		}
+	}
+	if err := runBuildCommands(opts.Dir, envs, dockerManifestCreate(opts.Image, images)); err != nil {
+		return err
+	}
+	return pushImage(opts.Dir, envs, dockerManifestPush(opts.Image))
+}
+
+func (b *dockerBuilder) serverPlatform(opts *ImageBuildOptions) (string, error) {
+	cmd := dockerServerPlatform()
+	cmd.Dir = opts.Dir
+	cmd.Env = opts.Envs
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the platform of the docker daemon: %s", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// samePlatform reports whether the platforms have the same os and architecture, the variant is ignored
+func samePlatform(platform, other string) bool {
+	osArch := func(p string) string {
+		parts := strings.SplitN(p, "/", 3)
+		if len(parts) < 2 {
+			return p
+		}
+		return parts[0] + "/" + parts[1]
+	}
+	return osArch(platform) == osArch(other)
+}
+
+// platformImage returns the tag of the image built for the platform, e.g. repo/app:v1-linux-arm64
+func platformImage(image, platform string) string {
+	if strings.LastIndex(image, ":") <= strings.LastIndex(image, "/") {
+		image += ":latest"
+	}
+	return image + "-" + strings.ReplaceAll(platform, "/", "-")
+}
+
+// buildKitBuilder sends the build to the rootless buildkitd which runs in the image builder container of the job,
+// reaper only ships the buildctl client
+type buildKitBuilder struct {
+	dockerConfigDir string
+	addr            string
+}
+
+func (b *buildKitBuilder) Login(registry *meta.DockerRegistry) error {
+	dir := filepath.Join(config.Home(), ".docker")
+	if err := writeDockerConfig(dir, registry); err != nil {
+		return err
+	}
+	b.dockerConfigDir = dir
+	return nil
+}
+
+func (b *buildKitBuilder) Build(opts *ImageBuildOptions) error {
+	if err := b.waitDaemon(opts); err != nil {
+		return err
+	}
+	return runBuildCommands(opts.Dir, b.envs(opts), b.command(opts))
+}
+
+// waitDaemon waits until buildkitd is ready since the containers of the job start at the same time
+func (b *buildKitBuilder) waitDaemon(opts *ImageBuildOptions) error {
+	deadline := time.Now().Add(daemonTimeout)
+	for {
+		cmd := exec.Command(buildctlExe, "--addr", b.addr, "debug", "workers")
+		cmd.Env = b.envs(opts)
+		out, err := cmd.CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("buildkitd at %s is not ready in %s: %s", b.addr, daemonTimeout, strings.TrimSpace(string(out)))
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func (b *buildKitBuilder) envs(opts *ImageBuildOptions) []string {
+	return envWithDockerConfig(opts.Envs, b.dockerConfigDir)
+}
+
+func (b *buildKitBuilder) command(opts *ImageBuildOptions) *exec.Cmd {
+	dockerfile := opts.abs(opts.Dockerfile)
+	args := []string{
+		"--addr", b.addr,
+		"build",
+		"--frontend", "dockerfile.v0",
+		"--local", "context=" + opts.abs(opts.ContextDir),
+		"--local", "dockerfile=" + filepath.Dir(dockerfile),
+		"--opt", "filename=" + filepath.Base(dockerfile),
+		"--output", fmt.Sprintf("type=image,name=%s,push=true", opts.Image),
+	}
+	if len(opts.Platforms) > 0 {
+		args = append(args, "--opt", "platform="+strings.Join(opts.Platforms, ","))
+	}
+	for _, arg := range parseBuildArgs(opts.BuildArgs) {
+		args = append(args, "--opt", "build-arg:"+arg)
+	}
+	if opts.IgnoreCache {
+		args = append(args, "--no-cache")
+	} else {
+		// the cache is stored in the pushed image and imported from it in the next build
+		args = append(args, "--export-cache", "type=inline", "--import-cache", "type=registry,ref="+opts.Image)
+	}
+
+	return exec.Command(buildctlExe, args...)
+}
+
+// kanikoBuilder hands the build over to the kaniko container of the job, kaniko must run in its own image
+// since it unpacks the base image over the root filesystem. The context and the dockerfile are copied into
+// the directory shared with the kaniko container, then reaper writes the build script and waits for its exit code.
+type kanikoBuilder struct {
+	dir      string
+	executor string
+}
+
+func (b *kanikoBuilder) Login(registry *meta.DockerRegistry) error {
+	// reaper reads the credential in its home to resolve the digest of the pushed image
+	if err := writeDockerConfig(filepath.Join(config.Home(), ".docker"), registry); err != nil {
+		return err
+	}
+	return writeDockerConfig(b.dockerConfigDir(), registry)
+}
+
+func (b *kanikoBuilder) dockerConfigDir() string {
+	return filepath.Join(b.dir, ".docker")
+}
+
+func (b *kanikoBuilder) Build(opts *ImageBuildOptions) error {
+	args, err := b.args(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(b.dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create image build dir: %s", err)
+	}
+	tar := exec.Command("tar", "-czf", filepath.Join(b.dir, "context.tar.gz"), "-C", opts.abs(opts.ContextDir), ".")
+	if err := runBuildCommands(opts.Dir, opts.Envs, tar); err != nil {
+		return fmt.Errorf("failed to archive the build context: %s", err)
+	}
+	dockerfile, err := ioutil.ReadFile(opts.abs(opts.Dockerfile))
+	if err != nil {
+		return fmt.Errorf("failed to read dockerfile: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(b.dir, "Dockerfile"), dockerfile, 0644); err != nil {
+		return fmt.Errorf("failed to copy dockerfile: %s", err)
+	}
+
+	if err := b.writeScript(args); err != nil {
+		return err
+	}
+	return b.wait(os.Stdout)
+}
+
+func (b *kanikoBuilder) args(opts *ImageBuildOptions) ([]string, error) {
+	args := []string{
+		b.executor,
+		"--context", "tar://" + filepath.Join(b.dir, "context.tar.gz"),
+		"--dockerfile", filepath.Join(b.dir, "Dockerfile"),
+		"--destination", opts.Image,
+	}
+	for _, arg := range parseBuildArgs(opts.BuildArgs) {
+		args = append(args, "--build-arg", arg)
+	}
+	// layers are cached in <image repository>/cache
+	args = append(args, fmt.Sprintf("--cache=%t", !opts.IgnoreCache))
+	switch len(opts.Platforms) {
+	case 0:
+	case 1:
+		args = append(args, "--custom-platform="+opts.Platforms[0])
+	default:
+		return nil, fmt.Errorf("kaniko can not build the image for multiple platforms: %s", strings.Join(opts.Platforms, ","))
+	}
+	return args, nil
+}
+
+// writeScript writes the build script atomically so the kaniko container never runs a partial one
+func (b *kanikoBuilder) writeScript(args []string) error {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	script := fmt.Sprintf("export DOCKER_CONFIG=%s\nexec %s\n", shellQuote(b.dockerConfigDir()), strings.Join(quoted, " "))
+
+	tmp := filepath.Join(b.dir, types.KanikoBuildScript+".tmp")
+	if err := ioutil.WriteFile(tmp, []byte(script), 0644); err != nil {
+		return fmt.Errorf("failed to write kaniko build script: %s", err)
+	}
+	return os.Rename(tmp, filepath.Join(b.dir, types.KanikoBuildScript))
+}
+
+// wait copies the output of kaniko to out until its exit code is written
+func (b *kanikoBuilder) wait(out io.Writer) error {
+	var offset int64
+	for {
+		// the exit code is written after the output is flushed, so the log is complete once it exists
+		code, codeErr := ioutil.ReadFile(filepath.Join(b.dir, types.KanikoBuildExitCode))
+
+		n, err := copyFileFrom(filepath.Join(b.dir, types.KanikoBuildLog), offset, out)
+		if err != nil {
+			return fmt.Errorf("failed to read kaniko log: %s", err)
+		}
+		offset += n
+
+		if codeErr == nil {
+			if exitCode := strings.TrimSpace(string(code)); exitCode != "0" {
+				return fmt.Errorf("kaniko exited with code %s", exitCode)
+			}
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// copyFileFrom copies the content of the file after offset to out, a missing file is treated as an empty one
+func copyFileFrom(path string, offset int64, out io.Writer) (int64, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(out, f)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// registryError is returned when the image is built but can not be pushed to the registry
+type registryError struct {
+	err error
+}
+
+func (e *registryError) Error() string {
+	return fmt.Sprintf("failed to push the image: %s", e.err)
+}
+
+func (e *registryError) Unwrap() error {
+	return e.err
+}
+
+func pushImage(dir string, envs []string, cmds ...*exec.Cmd) error {
+	if err := runBuildCommands(dir, envs, cmds...); err != nil {
+		return &registryError{err: err}
+	}
+	return nil
+}
+
+func runBuildCommands(dir string, envs []string, cmds ...*exec.Cmd) error {
+	for _, c := range cmds {
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		c.Dir = dir
+		c.Env = envs
+		if err := c.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseBuildArgs returns the KEY=VALUE pairs of the `--build-arg` flags, other flags are dropped
+// since they are specific to docker
+func parseBuildArgs(raw string) []string {
+	var args []string
+	fields := strings.Fields(raw)
+	for i := 0; i < len(fields); i++ {
+		switch {
+		case fields[i] == "--build-arg" && i+1 < len(fields):
+			i++
+			args = append(args, fields[i])
+		case strings.HasPrefix(fields[i], "--build-arg="):
+			args = append(args, strings.TrimPrefix(fields[i], "--build-arg="))
+		default:
+			log.Warnf("Build arg %s is not supported by the daemonless image builder, ignore it.", fields[i])
+		}
+	}
+	return args
+}
+
+type dockerAuth struct {
+	Auth string `json:"auth"`
+}
+
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+// writeDockerConfig writes the credential of the registry as the docker config file in dir, which daemonless builders
+// read when pushing images
+func writeDockerConfig(dir string, registry *meta.DockerRegistry) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create docker config dir: %s", err)
+	}
+
+	cfg := &dockerConfig{Auths: map[string]dockerAuth{
+		registryHost(registry.Host): {Auth: base64.StdEncoding.EncodeToString([]byte(registry.UserName + ":" + registry.Password))},
+	}}
+	content, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), content, 0600); err != nil {
+		return fmt.Errorf("failed to write docker config: %s", err)
+	}
+	return nil
+}
+
+func registryHost(addr string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(addr, "https://"), "http://"), "/")
+}
+
+func envWithDockerConfig(envs []string, dir string) []string {
+	res := append([]string{}, envs...)
+	if dir != "" {
+		res = append(res, "DOCKER_CONFIG="+dir)
+	}
+	return res
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+)
+
+// FakeImageBuilder records the logins and the builds instead of running them, it is used in unit tests
+type FakeImageBuilder struct {
+	Registries []*meta.DockerRegistry
+	Builds     []*ImageBuildOptions
+	// Err is returned by Build if it is set
+	Err error
+}
+
+func (b *FakeImageBuilder) Login(registry *meta.DockerRegistry) error {
+	b.Registries = append(b.Registries, registry)
+	return nil
+}
+
+func (b *FakeImageBuilder) Build(opts *ImageBuildOptions) error {
+	b.Builds = append(b.Builds, opts)
+	return b.Err
+}
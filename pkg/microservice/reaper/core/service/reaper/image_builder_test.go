@@ -0,0 +1,211 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/config"
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+func TestParseBuildArgs(t *testing.T) {
+	args := parseBuildArgs("--build-arg A=1 --network host --build-arg=B=2")
+	assert.Equal(t, []string{"A=1", "B=2"}, args)
+	assert.Empty(t, parseBuildArgs(""))
+}
+
+func TestPlatformImage(t *testing.T) {
+	assert.Equal(t, "koderover.io/demo/app:v1-linux-arm64", platformImage("koderover.io/demo/app:v1", "linux/arm64"))
+	assert.Equal(t, "koderover.io:5000/app:latest-linux-arm-v7", platformImage("koderover.io:5000/app", "linux/arm/v7"))
+}
+
+func TestSamePlatform(t *testing.T) {
+	assert.True(t, samePlatform("linux/amd64", "linux/amd64"))
+	assert.True(t, samePlatform("linux/arm64/v8", "linux/arm64"))
+	assert.False(t, samePlatform("linux/arm64", "linux/amd64"))
+	assert.False(t, samePlatform("linux/arm/v7", "linux/arm64"))
+}
+
+func TestNewImageBuilder(t *testing.T) {
+	assert.IsType(t, &dockerBuilder{}, newImageBuilder(""))
+	assert.IsType(t, &buildKitBuilder{}, newImageBuilder(setting.ImageBuilderBuildKit))
+	assert.IsType(t, &kanikoBuilder{}, newImageBuilder(setting.ImageBuilderKaniko))
+	assert.False(t, isDaemonlessBuilder(setting.ImageBuilderDocker))
+	assert.True(t, isDaemonlessBuilder(setting.ImageBuilderKaniko))
+}
+
+func TestRunDockerBuildWithFakeBuilder(t *testing.T) {
+	fake := &FakeImageBuilder{}
+	r := &Reaper{
+		Ctx: &meta.Context{
+			DockerBuildCtx: &meta.DockerBuildCtx{
+				WorkDir:   ".",
+				ImageName: "koderover.io/demo/app:v1",
+				BuildArgs: "--build-arg A=1",
+				Builder:   setting.ImageBuilderBuildKit,
+				Platforms: []string{"linux/amd64", "linux/arm64"},
+			},
+			Proxy:       &meta.Proxy{EnableRepoProxy: true, Type: "http", Address: "proxy.local", Port: 8080},
+			IgnoreCache: true,
+		},
+		ActiveWorkspace: "/workspace",
+		imageBuilder:    fake,
+	}
+
+	assert.Nil(t, r.runDockerBuild())
+	assert.Len(t, fake.Builds, 1)
+	build := fake.Builds[0]
+	assert.Equal(t, "Dockerfile", build.Dockerfile)
+	assert.Equal(t, "koderover.io/demo/app:v1", build.Image)
+	assert.Equal(t, "/workspace", build.Dir)
+	assert.True(t, build.IgnoreCache)
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, build.Platforms)
+	assert.Contains(t, parseBuildArgs(build.BuildArgs), "A=1")
+	assert.Contains(t, parseBuildArgs(build.BuildArgs), "http_proxy=http://proxy.local:8080")
+
+	fake.Err = errors.New("build failed")
+	assert.NotNil(t, r.runDockerBuild())
+}
+
+func TestKanikoRejectsMultiplePlatforms(t *testing.T) {
+	err := (&kanikoBuilder{dir: t.TempDir()}).Build(&ImageBuildOptions{Image: "app:v1", Platforms: []string{"linux/amd64", "linux/arm64"}})
+	assert.NotNil(t, err)
+}
+
+func TestBuildKitCommand(t *testing.T) {
+	b := &buildKitBuilder{dockerConfigDir: "/root/.docker", addr: types.BuildKitdAddr}
+	opts := &ImageBuildOptions{
+		Dockerfile: "build/Dockerfile",
+		Image:      "koderover.io/demo/app:v1",
+		ContextDir: ".",
+		BuildArgs:  "--build-arg A=1",
+		Platforms:  []string{"linux/amd64", "linux/arm64"},
+		Dir:        "/workspace",
+	}
+
+	cmd := b.command(opts)
+	assert.Equal(t, []string{buildctlExe, "--addr", types.BuildKitdAddr, "build"}, cmd.Args[:4])
+	assert.Subset(t, cmd.Args, []string{
+		"--local", "context=/workspace",
+		"dockerfile=/workspace/build",
+		"filename=Dockerfile",
+		"type=image,name=koderover.io/demo/app:v1,push=true",
+		"platform=linux/amd64,linux/arm64",
+		"build-arg:A=1",
+		"type=registry,ref=koderover.io/demo/app:v1",
+	})
+	assert.NotContains(t, cmd.Args, "--no-cache")
+	assert.Contains(t, b.envs(opts), "DOCKER_CONFIG=/root/.docker")
+
+	opts.IgnoreCache = true
+	assert.Contains(t, b.command(opts).Args, "--no-cache")
+}
+
+func TestKanikoArgs(t *testing.T) {
+	opts := &ImageBuildOptions{
+		Dockerfile: "Dockerfile",
+		Image:      "koderover.io/demo/app:v1",
+		ContextDir: "app",
+		BuildArgs:  "--build-arg=A=1",
+		Platforms:  []string{"linux/arm64"},
+		Dir:        "/workspace",
+	}
+
+	args, err := (&kanikoBuilder{dir: types.ImageBuildDir, executor: kanikoExe}).args(opts)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		kanikoExe,
+		"--context", "tar://" + types.ImageBuildDir + "/context.tar.gz",
+		"--dockerfile", types.ImageBuildDir + "/Dockerfile",
+		"--destination", "koderover.io/demo/app:v1",
+		"--build-arg", "A=1",
+		"--cache=true",
+		"--custom-platform=linux/arm64",
+	}, args)
+}
+
+// TestKanikoContainerHandshake runs the script of the kaniko container against a fake executor, which checks
+// that reaper and the kaniko container agree on the files in the shared directory
+func TestKanikoContainerHandshake(t *testing.T) {
+	for name, tc := range map[string]struct {
+		exitCode int
+		wantErr  bool
+	}{
+		"success": {exitCode: 0},
+		"failure": {exitCode: 3, wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			workspace := t.TempDir()
+			sharedDir := t.TempDir()
+			assert.Nil(t, os.MkdirAll(filepath.Join(workspace, "app"), os.ModePerm))
+			assert.Nil(t, ioutil.WriteFile(filepath.Join(workspace, "app", "main.go"), []byte("package main"), 0644))
+			assert.Nil(t, ioutil.WriteFile(filepath.Join(workspace, "Dockerfile"), []byte("FROM scratch"), 0644))
+
+			// the fake executor prints its args and the docker config it would push with
+			executor := filepath.Join(t.TempDir(), "executor")
+			script := fmt.Sprintf("#!/bin/sh\necho \"$DOCKER_CONFIG $*\"\nexit %d\n", tc.exitCode)
+			assert.Nil(t, ioutil.WriteFile(executor, []byte(script), 0755))
+
+			container := exec.Command("sh", "-c", types.KanikoContainerScript(sharedDir))
+			assert.Nil(t, container.Start())
+			defer container.Process.Kill()
+
+			b := &kanikoBuilder{dir: sharedDir, executor: executor}
+			assert.Nil(t, writeDockerConfig(b.dockerConfigDir(), &meta.DockerRegistry{Host: "https://koderover.io", UserName: "u", Password: "p"}))
+			err := b.Build(&ImageBuildOptions{
+				Dockerfile: "Dockerfile",
+				Image:      "koderover.io/demo/app:it's",
+				ContextDir: "app",
+				Dir:        workspace,
+			})
+			if tc.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Nil(t, container.Wait())
+
+			output, err := ioutil.ReadFile(filepath.Join(sharedDir, types.KanikoBuildLog))
+			assert.Nil(t, err)
+			assert.Contains(t, string(output), filepath.Join(sharedDir, ".docker")+" --context tar://"+sharedDir+"/context.tar.gz")
+			assert.Contains(t, string(output), "--destination koderover.io/demo/app:it's")
+			assert.FileExists(t, filepath.Join(sharedDir, ".docker", "config.json"))
+			assert.FileExists(t, filepath.Join(sharedDir, "Dockerfile"))
+		})
+	}
+}
+
+func TestDaemonlessBuildEnvs(t *testing.T) {
+	r := &Reaper{Ctx: &meta.Context{DockerBuildCtx: &meta.DockerBuildCtx{Builder: setting.ImageBuilderKaniko}}}
+	for _, env := range r.getUserEnvs() {
+		assert.NotContains(t, env, "DOCKER_HOST=")
+	}
+
+	r.Ctx.DockerBuildCtx.Builder = setting.ImageBuilderDocker
+	assert.Contains(t, r.getUserEnvs(), "DOCKER_HOST="+config.DockerHost())
+}
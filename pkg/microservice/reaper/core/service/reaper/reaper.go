@@ -17,7 +17,6 @@ limitations under the License.
 package reaper
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -34,6 +33,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/reaper/config"
 	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/cosign"
 	"github.com/koderover/zadig/pkg/tool/log"
 	"github.com/koderover/zadig/pkg/types"
 	"github.com/koderover/zadig/pkg/util/fs"
@@ -51,7 +51,8 @@ type Reaper struct {
 	UserEnvs        map[string]string
 	Type            types.ReaperType
 
-	cm CacheManager
+	cm           CacheManager
+	imageBuilder ImageBuilder
 }
 
 func NewReaper() (*Reaper, error) {
@@ -69,7 +70,7 @@ func NewReaper() (*Reaper, error) {
 
 	reaper := &Reaper{
 		Ctx: ctx,
-		cm:  NewTarCacheManager(ctx.StorageURI, ctx.PipelineName, ctx.ServiceName, ctx.AesKey),
+		cm:  NewChunkCacheManager(ctx.StorageURI, ctx.PipelineName, ctx.ServiceName, ctx.AesKey, cacheKeys(ctx.Repos, ctx.CacheDefaultBranch)),
 	}
 
 	if ctx.TestType == "" {
@@ -149,6 +150,13 @@ func (r *Reaper) DecompressCache() error {
 	return err
 }
 
+// reportCache prints the cache hit or miss into the log so that warpdrive can record it in the task
+func (r *Reaper) reportCache() {
+	if reporter, ok := r.cm.(CacheReporter); ok {
+		fmt.Println(reporter.Report().Marker())
+	}
+}
+
 func (r *Reaper) EnsureActiveWorkspace(workspace string) error {
 	if workspace == "" {
 		tempWorkspace, err := ioutil.TempDir(os.TempDir(), "reaper")
@@ -175,25 +183,27 @@ func (r *Reaper) EnsureDir(dir string) error {
 func (r *Reaper) BeforeExec() error {
 	r.StartTime = time.Now()
 
-	log.Infof("Checking Docker Connectivity.")
-	startTimeCheckDocker := time.Now()
-	for i := 0; i < 15; i++ {
-		if err := dockerInfo().Run(); err == nil {
-			break
+	return r.runStep(StepPrepare, r.prepare)
+}
+
+func (r *Reaper) prepare() error {
+	if !r.daemonlessBuild() {
+		log.Infof("Checking Docker Connectivity.")
+		startTimeCheckDocker := time.Now()
+		for i := 0; i < 15; i++ {
+			if err := dockerInfo().Run(); err == nil {
+				break
+			}
+			time.Sleep(time.Second * 1)
 		}
-		time.Sleep(time.Second * 1)
+		log.Infof("Check ended. Duration: %.2f seconds.", time.Since(startTimeCheckDocker).Seconds())
 	}
-	log.Infof("Check ended. Duration: %.2f seconds.", time.Since(startTimeCheckDocker).Seconds())
 
 	if r.Ctx.DockerRegistry != nil {
 		if r.Ctx.DockerRegistry.UserName != "" {
 			log.Infof("Logining Docker Registry: %s.", r.Ctx.DockerRegistry.Host)
 			startTimeDockerLogin := time.Now()
-			cmd := dockerLogin(r.Ctx.DockerRegistry.UserName, r.Ctx.DockerRegistry.Password, r.Ctx.DockerRegistry.Host)
-			var out bytes.Buffer
-			cmd.Stdout = &out
-			cmd.Stderr = &out
-			if err := cmd.Run(); err != nil {
+			if err := r.getImageBuilder().Login(r.Ctx.DockerRegistry); err != nil {
 				return fmt.Errorf("failed to login docker registry: %s", err)
 			}
 
@@ -210,6 +220,7 @@ func (r *Reaper) BeforeExec() error {
 		} else {
 			log.Infof("Succeed to pull cache. Duration: %.2f seconds.", time.Since(startTimePullCache).Seconds())
 		}
+		r.reportCache()
 	}
 
 	if err := os.MkdirAll(path.Join(os.Getenv("HOME"), "/.ssh"), os.ModePerm); err != nil {
@@ -285,20 +296,20 @@ func (r *Reaper) setProxy(ctx *meta.DockerBuildCtx, cfg *meta.Proxy) {
 	}
 }
 
-func (r *Reaper) dockerCommands() []*exec.Cmd {
-	cmds := make([]*exec.Cmd, 0)
-	cmds = append(
-		cmds,
-		dockerBuildCmd(
-			r.Ctx.DockerBuildCtx.GetDockerFile(),
-			r.Ctx.DockerBuildCtx.ImageName,
-			r.Ctx.DockerBuildCtx.WorkDir,
-			r.Ctx.DockerBuildCtx.BuildArgs,
-			r.Ctx.IgnoreCache,
-		),
-		dockerPush(r.Ctx.DockerBuildCtx.ImageName),
-	)
-	return cmds
+// daemonlessBuild reports whether the image is built without a docker daemon
+func (r *Reaper) daemonlessBuild() bool {
+	return r.Ctx.DockerBuildCtx != nil && isDaemonlessBuilder(r.Ctx.DockerBuildCtx.Builder)
+}
+
+func (r *Reaper) getImageBuilder() ImageBuilder {
+	if r.imageBuilder == nil {
+		builder := ""
+		if r.Ctx.DockerBuildCtx != nil {
+			builder = r.Ctx.DockerBuildCtx.Builder
+		}
+		r.imageBuilder = newImageBuilder(builder)
+	}
+	return r.imageBuilder
 }
 
 func (r *Reaper) runDockerBuild() error {
@@ -320,21 +331,42 @@ func (r *Reaper) runDockerBuild() error {
 
 	log.Info("Runing Docker Build.")
 	startTimeDockerBuild := time.Now()
-	envs := r.getUserEnvs()
-	for _, c := range r.dockerCommands() {
-		c.Stdout = os.Stdout
-		c.Stderr = os.Stderr
-		c.Dir = r.ActiveWorkspace
-		c.Env = envs
-		if err := c.Run(); err != nil {
-			return fmt.Errorf("failed to run docker build: %s", err)
-		}
+	opts := &ImageBuildOptions{
+		Dockerfile:  r.Ctx.DockerBuildCtx.GetDockerFile(),
+		Image:       r.Ctx.DockerBuildCtx.ImageName,
+		ContextDir:  r.Ctx.DockerBuildCtx.WorkDir,
+		BuildArgs:   r.Ctx.DockerBuildCtx.BuildArgs,
+		IgnoreCache: r.Ctx.IgnoreCache,
+		Platforms:   r.Ctx.DockerBuildCtx.Platforms,
+		Dir:         r.ActiveWorkspace,
+		Envs:        r.getUserEnvs(),
+	}
+	if err := r.getImageBuilder().Build(opts); err != nil {
+		return fmt.Errorf("failed to run docker build: %w", err)
 	}
 	log.Infof("Docker build ended. Duration: %.2f seconds.", time.Since(startTimeDockerBuild).Seconds())
 
 	return nil
 }
 
+// attestImage resolves the digest of the pushed image and attaches its SBOM, the provenance is printed into the log
+// so that warpdrive can record it
+func (r *Reaper) attestImage() error {
+	log.Info("Generating Image Provenance.")
+	startTimeProvenance := time.Now()
+	provenance, err := cosign.Attest(r.Ctx.DockerBuildCtx.ImageName, &cosign.AttestOptions{
+		ProvenanceOptions: r.Ctx.DockerBuildCtx.Provenance,
+		Envs:              envWithDockerConfig(nil, filepath.Join(config.Home(), ".docker")),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(provenance.Marker())
+	log.Infof("Provenance generated. Duration: %.2f seconds.", time.Since(startTimeProvenance).Seconds())
+
+	return nil
+}
+
 func (r *Reaper) prepareDockerfile() error {
 	if r.Ctx.DockerBuildCtx.Source == setting.DockerfileSourceTemplate {
 		reader := strings.NewReader(r.Ctx.DockerBuildCtx.DockerTemplateContent)
@@ -351,14 +383,14 @@ func (r *Reaper) prepareDockerfile() error {
 func (r *Reaper) Exec() error {
 	log.Info("Installing Dependency Packages.")
 	startTimeInstallDeps := time.Now()
-	if err := r.runIntallationScripts(); err != nil {
+	if err := r.runStep(StepInstall, r.runIntallationScripts); err != nil {
 		return fmt.Errorf("failed to install dependency packages: %s", err)
 	}
 	log.Infof("Install ended. Duration: %.2f seconds.", time.Since(startTimeInstallDeps).Seconds())
 
 	log.Info("Cloning Repository.")
 	startTimeCloneRepo := time.Now()
-	if err := r.runGitCmds(); err != nil {
+	if err := r.runStep(StepGit, r.runGitCmds); err != nil {
 		return fmt.Errorf("failed to clone repository: %s", err)
 	}
 	log.Infof("Clone ended. Duration: %.2f seconds.", time.Since(startTimeCloneRepo).Seconds())
@@ -369,128 +401,175 @@ func (r *Reaper) Exec() error {
 
 	log.Info("Executing User Build Script.")
 	startTimeRunBuildScript := time.Now()
-	if err := r.runScripts(); err != nil {
+	if err := r.runStep(StepScript, r.runScripts); err != nil {
 		return fmt.Errorf("failed to execute user build script: %s", err)
 	}
 	log.Infof("Execution ended. Duration: %.2f seconds.", time.Since(startTimeRunBuildScript).Seconds())
 
-	return r.runDockerBuild()
+	if r.Ctx.DockerBuildCtx == nil {
+		return nil
+	}
+	if err := r.runStep(StepDockerBuild, r.runDockerBuild); err != nil {
+		return err
+	}
+
+	if !r.Ctx.DockerBuildCtx.Provenance.Enabled() {
+		return nil
+	}
+	return r.runStep(StepProvenance, r.attestImage)
 }
 
 func (r *Reaper) AfterExec() error {
 	if r.Ctx.GinkgoTest != nil {
-		resultPath := r.Ctx.GinkgoTest.ResultPath
-		if resultPath != "" && !strings.HasPrefix(resultPath, "/") {
-			resultPath = filepath.Join(r.ActiveWorkspace, resultPath)
+		if err := r.runStep(StepTestResult, r.archiveTestResults); err != nil {
+			return err
 		}
+	}
 
-		if r.Ctx.TestType == "" {
-			r.Ctx.TestType = setting.FunctionTest
+	if r.Ctx.ArtifactInfo == nil {
+		if err := r.runStep(StepArchive, r.archiveS3Files); err != nil {
+			return fmt.Errorf("failed to archive S3 files: %s", err)
 		}
 
-		switch r.Ctx.TestType {
-		case setting.FunctionTest:
-			err := mergeGinkgoTestResults(r.Ctx.Archive.File, resultPath, r.Ctx.Archive.Dir, r.StartTime)
-			if err != nil {
-				return fmt.Errorf("failed to merge test result: %s", err)
-			}
-		case setting.PerformanceTest:
-			err := JmeterTestResults(r.Ctx.Archive.File, resultPath, r.Ctx.Archive.Dir)
-			if err != nil {
-				return fmt.Errorf("failed to archive performance test result: %s", err)
+		if len(r.Ctx.PostScripts) > 0 {
+			if err := r.runStep(StepPostScript, r.RunPostScripts); err != nil {
+				return fmt.Errorf("failed to run postscripts: %s", err)
 			}
 		}
-
-		if len(r.Ctx.GinkgoTest.ArtifactPaths) > 0 {
-			if err := artifactsUpload(r.Ctx, r.ActiveWorkspace, r.Ctx.GinkgoTest.ArtifactPaths); err != nil {
-				return fmt.Errorf("failed to upload artifacts: %s", err)
-			}
+	} else {
+		if err := r.runStep(StepDownload, r.downloadArtifactFile); err != nil {
+			return fmt.Errorf("failed to download artifact files: %s", err)
 		}
+	}
 
-		if err := r.archiveTestFiles(); err != nil {
-			return fmt.Errorf("failed to archive test files: %s", err)
+	if r.Ctx.UploadEnabled {
+		if err := r.runStep(StepUpload, r.uploadFiles); err != nil {
+			return err
 		}
+	}
 
-		if err := r.archiveHTMLTestReportFile(); err != nil {
-			return fmt.Errorf("failed to archive HTML test report: %s", err)
+	if r.Ctx.ArtifactPath != "" {
+		if err := artifactsUpload(r.Ctx, r.ActiveWorkspace, []string{r.Ctx.ArtifactPath}, "buildv3"); err != nil {
+			return fmt.Errorf("failed to upload artifacts: %s", err)
 		}
 	}
 
-	if r.Ctx.ArtifactInfo == nil {
-		if err := r.archiveS3Files(); err != nil {
-			return fmt.Errorf("failed to archive S3 files: %s", err)
+	if len(r.Ctx.PMDeployScripts) > 0 {
+		if err := r.runStep(StepPMDeploy, r.RunPMDeployScripts); err != nil {
+			return fmt.Errorf("failed to run deploy scripts on physical machine: %s", err)
 		}
+	}
 
-		if err := r.RunPostScripts(); err != nil {
-			return fmt.Errorf("failed to run postscripts: %s", err)
-		}
-	} else {
-		if err := r.downloadArtifactFile(); err != nil {
-			return fmt.Errorf("failed to download artifact files: %s", err)
-		}
+	// Upload workspace cache if the user turns on caching and uses object storage.
+	// Note: Whether the cache is uploaded successfully or not cannot hinder the progress of the overall process,
+	//       so only exceptions are printed here and the process is not interrupted.
+	if r.Ctx.CacheEnable && r.Ctx.Cache.MediumType == types.ObjectMedium {
+		_ = r.runStep(StepUploadCache, func() error {
+			log.Info("Uploading Build Cache.")
+			startTimeUploadBuildCache := time.Now()
+			defer r.reportCache()
+			if err := r.CompressCache(r.Ctx.StorageURI); err != nil {
+				log.Warnf("Failed to upload build cache: %s. Duration: %.2f seconds.", err, time.Since(startTimeUploadBuildCache).Seconds())
+				return err
+			}
+			log.Infof("Upload ended. Duration: %.2f seconds.", time.Since(startTimeUploadBuildCache).Seconds())
+			return nil
+		})
 	}
 
-	if r.Ctx.UploadEnabled {
-		forcedPathStyle := true
-		if r.Ctx.UploadStorageInfo.Provider == setting.ProviderSourceAli {
-			forcedPathStyle = false
+	return nil
+}
+
+func (r *Reaper) archiveTestResults() error {
+	resultPath := r.Ctx.GinkgoTest.ResultPath
+	if resultPath != "" && !strings.HasPrefix(resultPath, "/") {
+		resultPath = filepath.Join(r.ActiveWorkspace, resultPath)
+	}
+
+	if r.Ctx.TestType == "" {
+		r.Ctx.TestType = setting.FunctionTest
+	}
+
+	switch r.Ctx.TestType {
+	case setting.FunctionTest:
+		var err error
+		switch format := r.Ctx.GinkgoTest.ReportFormat; format {
+		case "", setting.TestReportFormatGinkgo:
+			err = mergeGinkgoTestResults(r.Ctx.Archive.File, resultPath, r.Ctx.Archive.Dir, r.StartTime)
+		default:
+			err = mergeTestResults(format, r.Ctx.GinkgoTest.ResultGlob, r.Ctx.Archive.File, resultPath, r.Ctx.Archive.Dir, r.StartTime)
 		}
-		client, err := s3.NewClient(r.Ctx.UploadStorageInfo.Endpoint, r.Ctx.UploadStorageInfo.AK, r.Ctx.UploadStorageInfo.SK, r.Ctx.UploadStorageInfo.Insecure, forcedPathStyle)
 		if err != nil {
-			return fmt.Errorf("failed to create s3 client to upload file, err: %s", err)
+			return fmt.Errorf("failed to merge test result: %s", err)
 		}
-		for _, upload := range r.Ctx.UploadInfo {
-			info, err := os.Stat(upload.FilePath)
-			if err != nil {
-				return fmt.Errorf("failed to upload file path [%s] to destination [%s], the error is: %s", upload.FilePath, upload.DestinationPath, err)
-			}
-			// if the given path is a directory
-			if info.IsDir() {
-				// we get ALL files in this directory and upload it to the object storage
-				files, err := ioutil.ReadDir(upload.FilePath)
-				if err != nil {
-					return fmt.Errorf("failed to read file information in directory: [%s], the error is: %s", upload.FilePath, err)
-				}
-				for _, file := range files {
-					if !file.IsDir() {
-						key := filepath.Join(upload.DestinationPath, file.Name())
-						originalFilePath := filepath.Join(upload.FilePath, file.Name())
-						err := client.Upload(r.Ctx.UploadStorageInfo.Bucket, originalFilePath, key)
-						if err != nil {
-							fmt.Printf("Failed to upload [%s] to key [%s] on s3, the error is: %s", originalFilePath, key, err)
-						}
-					}
-				}
-			} else {
-				key := filepath.Join(upload.DestinationPath, info.Name())
-				err := client.Upload(r.Ctx.UploadStorageInfo.Bucket, upload.FilePath, key)
-				if err != nil {
-					fmt.Printf("Failed to upload [%s] to key [%s] on s3, the error is: %s", upload.FilePath, key, err)
-				}
+
+		if r.Ctx.GinkgoTest.CoverageFormat != "" {
+			if err := r.archiveCoverage(); err != nil {
+				return fmt.Errorf("failed to collect coverage: %s", err)
 			}
 		}
+	case setting.PerformanceTest:
+		err := JmeterTestResults(r.Ctx.Archive.File, resultPath, r.Ctx.Archive.Dir)
+		if err != nil {
+			return fmt.Errorf("failed to archive performance test result: %s", err)
+		}
 	}
 
-	if r.Ctx.ArtifactPath != "" {
-		if err := artifactsUpload(r.Ctx, r.ActiveWorkspace, []string{r.Ctx.ArtifactPath}, "buildv3"); err != nil {
+	if len(r.Ctx.GinkgoTest.ArtifactPaths) > 0 {
+		if err := artifactsUpload(r.Ctx, r.ActiveWorkspace, r.Ctx.GinkgoTest.ArtifactPaths); err != nil {
 			return fmt.Errorf("failed to upload artifacts: %s", err)
 		}
 	}
 
-	if err := r.RunPMDeployScripts(); err != nil {
-		return fmt.Errorf("failed to run deploy scripts on physical machine: %s", err)
+	if err := r.archiveTestFiles(); err != nil {
+		return fmt.Errorf("failed to archive test files: %s", err)
 	}
 
-	// Upload workspace cache if the user turns on caching and uses object storage.
-	// Note: Whether the cache is uploaded successfully or not cannot hinder the progress of the overall process,
-	//       so only exceptions are printed here and the process is not interrupted.
-	if r.Ctx.CacheEnable && r.Ctx.Cache.MediumType == types.ObjectMedium {
-		log.Info("Uploading Build Cache.")
-		startTimeUploadBuildCache := time.Now()
-		if err := r.CompressCache(r.Ctx.StorageURI); err != nil {
-			log.Warnf("Failed to upload build cache: %s. Duration: %.2f seconds.", err, time.Since(startTimeUploadBuildCache).Seconds())
+	if err := r.archiveHTMLTestReportFile(); err != nil {
+		return fmt.Errorf("failed to archive HTML test report: %s", err)
+	}
+
+	return nil
+}
+
+// uploadFiles uploads the files of the build to the object storage configured by the user
+func (r *Reaper) uploadFiles() error {
+	forcedPathStyle := true
+	if r.Ctx.UploadStorageInfo.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3.NewClient(r.Ctx.UploadStorageInfo.Endpoint, r.Ctx.UploadStorageInfo.AK, r.Ctx.UploadStorageInfo.SK, r.Ctx.UploadStorageInfo.Insecure, forcedPathStyle)
+	if err != nil {
+		return fmt.Errorf("failed to create s3 client to upload file, err: %s", err)
+	}
+	for _, upload := range r.Ctx.UploadInfo {
+		info, err := os.Stat(upload.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to upload file path [%s] to destination [%s], the error is: %s", upload.FilePath, upload.DestinationPath, err)
+		}
+		// if the given path is a directory
+		if info.IsDir() {
+			// we get ALL files in this directory and upload it to the object storage
+			files, err := ioutil.ReadDir(upload.FilePath)
+			if err != nil {
+				return fmt.Errorf("failed to read file information in directory: [%s], the error is: %s", upload.FilePath, err)
+			}
+			for _, file := range files {
+				if !file.IsDir() {
+					key := filepath.Join(upload.DestinationPath, file.Name())
+					originalFilePath := filepath.Join(upload.FilePath, file.Name())
+					err := client.Upload(r.Ctx.UploadStorageInfo.Bucket, originalFilePath, key)
+					if err != nil {
+						fmt.Printf("Failed to upload [%s] to key [%s] on s3, the error is: %s", originalFilePath, key, err)
+					}
+				}
+			}
 		} else {
-			log.Infof("Upload ended. Duration: %.2f seconds.", time.Since(startTimeUploadBuildCache).Seconds())
+			key := filepath.Join(upload.DestinationPath, info.Name())
+			err := client.Upload(r.Ctx.UploadStorageInfo.Bucket, upload.FilePath, key)
+			if err != nil {
+				fmt.Printf("Failed to upload [%s] to key [%s] on s3, the error is: %s", upload.FilePath, key, err)
+			}
 		}
 	}
 
@@ -548,7 +627,9 @@ func (r *Reaper) getUserEnvs() []string {
 
 	r.Ctx.Paths = strings.Replace(r.Ctx.Paths, "$HOME", config.Home(), -1)
 	envs = append(envs, fmt.Sprintf("PATH=%s", r.Ctx.Paths))
-	envs = append(envs, fmt.Sprintf("DOCKER_HOST=%s", config.DockerHost()))
+	if !r.daemonlessBuild() {
+		envs = append(envs, fmt.Sprintf("DOCKER_HOST=%s", config.DockerHost()))
+	}
 	envs = append(envs, r.Ctx.Envs...)
 	envs = append(envs, r.Ctx.SecretEnvs...)
 
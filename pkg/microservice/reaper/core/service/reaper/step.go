@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/koderover/zadig/pkg/types"
+)
+
+const (
+	StepPrepare     = "prepare"
+	StepInstall     = "install"
+	StepGit         = "git"
+	StepScript      = "script"
+	StepDockerBuild = "docker_build"
+	StepProvenance  = "provenance"
+	StepTestResult  = "test_result"
+	StepArchive     = "archive"
+	StepPostScript  = "post_script"
+	StepDownload    = "download_artifact"
+	StepUpload      = "upload"
+	StepPMDeploy    = "pm_deploy"
+	StepUploadCache = "upload_cache"
+)
+
+// runStep runs fn as a build step, the boundaries of the step are printed into the log as markers
+// so that warpdrive knows the duration, the result and the log of every step
+func (r *Reaper) runStep(name string, fn func() error) error {
+	step := &types.BuildStep{
+		Name:      name,
+		Status:    types.BuildStepRunning,
+		StartTime: time.Now().Unix(),
+	}
+	fmt.Println(step.Marker())
+
+	err := fn()
+
+	step.EndTime = time.Now().Unix()
+	step.Status = types.BuildStepPassed
+	if err != nil {
+		step.Status = types.BuildStepFailed
+		step.ExitCode = exitCode(err)
+		step.Error = r.maskSecretEnvs(err.Error())
+		var regErr *registryError
+		if errors.As(err, &regErr) {
+			step.FailureReason = types.BuildStepFailureRegistry
+		}
+	}
+	fmt.Println(step.Marker())
+
+	return err
+}
+
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
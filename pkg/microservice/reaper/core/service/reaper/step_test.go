@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// captureStdout returns what fn prints, build step markers are printed to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	fn()
+	os.Stdout = stdout
+	_ = w.Close()
+
+	out, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	return string(out)
+}
+
+func TestBuildStepsInLog(t *testing.T) {
+	git := &types.BuildStep{Name: StepGit, Status: types.BuildStepRunning, StartTime: 100}
+	script := &types.BuildStep{Name: StepScript, Status: types.BuildStepRunning, StartTime: 110}
+	lines := []string{"Checking Docker Connectivity.", git.Marker(), "Cloning into 'zadig'..."}
+	git.Status, git.EndTime = types.BuildStepPassed, 110
+	lines = append(lines, git.Marker(), script.Marker(), "make build", "exit status 2")
+	script.Status, script.EndTime, script.ExitCode = types.BuildStepFailed, 130, 2
+	lines = append(lines, script.Marker())
+	log := strings.Join(lines, "\n")
+
+	steps := types.ParseBuildSteps(log)
+	assert.Equal(t, []*types.BuildStep{git, script}, steps)
+
+	scriptLog, found := types.BuildStepLog(log, StepScript)
+	assert.True(t, found)
+	assert.Equal(t, "make build\nexit status 2", scriptLog)
+
+	_, found = types.BuildStepLog(log, StepDockerBuild)
+	assert.False(t, found)
+}
+
+func TestExitCode(t *testing.T) {
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	assert.Equal(t, 3, exitCode(err))
+	assert.Equal(t, 1, exitCode(fmt.Errorf("failed to prepare dockerfile")))
+
+	pushErr := fmt.Errorf("failed to run docker build: %w", &registryError{err: exec.Command("sh", "-c", "exit 4").Run()})
+	assert.Equal(t, 4, exitCode(pushErr))
+}
+
+func TestRunStepFailureReason(t *testing.T) {
+	r := &Reaper{Ctx: &meta.Context{}}
+	out := captureStdout(t, func() {
+		_ = r.runStep(StepDockerBuild, func() error {
+			return fmt.Errorf("failed to run docker build: %w", &registryError{err: fmt.Errorf("503 Service Unavailable")})
+		})
+		_ = r.runStep(StepScript, func() error {
+			return fmt.Errorf("failed to push the image: 503 Service Unavailable")
+		})
+	})
+
+	steps := types.ParseBuildSteps(out)
+	assert.Len(t, steps, 2)
+	assert.Equal(t, types.BuildStepFailureRegistry, steps[0].FailureReason)
+	// the message is never matched, only the typed error
+	assert.Empty(t, steps[1].FailureReason)
+}
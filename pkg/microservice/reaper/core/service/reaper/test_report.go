@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+// TestReportParser normalizes the test result files of a format into test suites
+type TestReportParser interface {
+	// DefaultGlob is the pattern of the result files if no glob is given
+	DefaultGlob() string
+	// Parse returns the test suites in the result file
+	Parse(name string, data []byte) ([]*meta.TestSuite, error)
+}
+
+var testReportParsers = map[string]TestReportParser{}
+
+// RegisterTestReportParser makes a parser available for the format
+func RegisterTestReportParser(format string, parser TestReportParser) {
+	testReportParsers[format] = parser
+}
+
+func getTestReportParser(format string) (TestReportParser, error) {
+	parser, ok := testReportParsers[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported test report format: %s", format)
+	}
+	return parser, nil
+}
+
+func init() {
+	RegisterTestReportParser(setting.TestReportFormatJUnit, &junitParser{})
+	RegisterTestReportParser(setting.TestReportFormatGoTest, &goTestParser{})
+	RegisterTestReportParser(setting.TestReportFormatTAP, &tapParser{})
+}
+
+// findTestResultFiles returns the files matching the glob in the result path, sorted by modified time
+func findTestResultFiles(testResultPath, glob string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(testResultPath, glob))
+	if err != nil {
+		return nil, fmt.Errorf("invalid test result glob %s: %s", glob, err)
+	}
+
+	modTimes := make(map[string]time.Time, len(files))
+	res := make([]string, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		modTimes[file] = info.ModTime()
+		res = append(res, file)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return modTimes[res[i]].Before(modTimes[res[j]])
+	})
+	return res, nil
+}
+
+// summarizeTestSuites merges the test cases of all suites into one suite, the counts are recalculated from the cases
+func summarizeTestSuites(suites []*meta.TestSuite) *meta.TestSuite {
+	summary := &meta.TestSuite{
+		TestCases: []meta.TestCase{},
+	}
+	for _, suite := range suites {
+		summary.TestCases = append(summary.TestCases, suite.TestCases...)
+	}
+
+	for _, tc := range summary.TestCases {
+		switch {
+		case tc.Error != nil:
+			summary.Errors++
+		case tc.Failure != nil:
+			summary.Failures++
+		case tc.Skipped != nil:
+			summary.Skips++
+		}
+	}
+	summary.Tests = len(summary.TestCases)
+	summary.Successes = summary.Tests - summary.Failures - summary.Errors - summary.Skips
+	return summary
+}
+
+// mergeTestResults parses the result files with the parser of the format and writes the summary
+// in the same junit format as mergeGinkgoTestResults does
+func mergeTestResults(format, glob, testResultFile, testResultPath, testUploadPath string, startTime time.Time) error {
+	if len(testResultPath) == 0 {
+		return nil
+	}
+
+	parser, err := getTestReportParser(format)
+	if err != nil {
+		return err
+	}
+	if glob == "" {
+		glob = parser.DefaultGlob()
+	}
+
+	files, err := findTestResultFiles(testResultPath, glob)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("test result files matching %s not found in path %s", glob, testResultPath)
+	}
+
+	var suites []*meta.TestSuite
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Warningf("Read file [%s], error: %v", file, err)
+			continue
+		}
+		fileSuites, err := parser.Parse(filepath.Base(file), data)
+		if err != nil {
+			log.Warningf("Parse %s file [%s], error: %v", format, file, err)
+			continue
+		}
+		suites = append(suites, fileSuites...)
+	}
+
+	summary := summarizeTestSuites(suites)
+	summary.Time = getSecondSince(startTime)
+
+	xmlBytes, err := xml.MarshalIndent(summary, "  ", "    ")
+	if err != nil {
+		return err
+	}
+	xmlBytes = append([]byte(xml.Header), xmlBytes...)
+	xmlStr := replaceTestSuiteTag(string(xmlBytes), ReploaceTestSuite, strings.ToLower(ReploaceTestSuite))
+
+	if err := ioutil.WriteFile(path.Join(testUploadPath, testResultFile), []byte(xmlStr), 0644); err != nil {
+		return err
+	}
+
+	log.Infof("merge %s test results files %s succeeded", format, testResultFile)
+	return nil
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+)
+
+// goTestEvent is an event printed by `go test -json`
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// goTestParser parses the output of `go test -json`, every package becomes a test suite
+type goTestParser struct{}
+
+func (p *goTestParser) DefaultGlob() string {
+	return "*.json"
+}
+
+func (p *goTestParser) Parse(name string, data []byte) ([]*meta.TestSuite, error) {
+	type goTest struct {
+		action  string
+		elapsed float64
+		output  strings.Builder
+	}
+	type goPackage struct {
+		action  string
+		elapsed float64
+		output  strings.Builder
+		tests   map[string]*goTest
+		order   []string
+	}
+
+	packages := make(map[string]*goPackage)
+	getPackage := func(name string) *goPackage {
+		pkg, ok := packages[name]
+		if !ok {
+			pkg = &goPackage{tests: make(map[string]*goTest)}
+			packages[name] = pkg
+		}
+		return pkg
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		// go test -json mixes non json lines such as build errors into the output
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+
+		event := &goTestEvent{}
+		if err := json.Unmarshal(line, event); err != nil {
+			continue
+		}
+
+		pkg := getPackage(event.Package)
+		if event.Test == "" {
+			switch event.Action {
+			case "output":
+				pkg.output.WriteString(event.Output)
+			case "pass", "fail", "skip":
+				pkg.action = event.Action
+				pkg.elapsed = event.Elapsed
+			}
+			continue
+		}
+
+		test, ok := pkg.tests[event.Test]
+		if !ok {
+			test = &goTest{}
+			pkg.tests[event.Test] = test
+			pkg.order = append(pkg.order, event.Test)
+		}
+		switch event.Action {
+		case "output":
+			test.output.WriteString(event.Output)
+		case "pass", "fail", "skip":
+			test.action = event.Action
+			test.elapsed = event.Elapsed
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read go test report %s: %s", name, err)
+	}
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no go test events found in %s", name)
+	}
+
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	suites := make([]*meta.TestSuite, 0, len(packages))
+	for _, pkgName := range names {
+		pkg := packages[pkgName]
+		suite := &meta.TestSuite{
+			Name:      pkgName,
+			Time:      pkg.elapsed,
+			TestCases: []meta.TestCase{},
+		}
+
+		reported := leafGoTests(pkg.order, func(testName string) bool {
+			action := pkg.tests[testName].action
+			return action != "pass" && action != "skip"
+		})
+
+		var failedTests int
+		for _, testName := range pkg.order {
+			if !reported[testName] {
+				continue
+			}
+			test := pkg.tests[testName]
+			tc := meta.TestCase{
+				Name:      testName,
+				ClassName: pkgName,
+				Time:      test.elapsed,
+			}
+			switch test.action {
+			case "fail":
+				failedTests++
+				tc.Failure = &meta.Failure{Message: "Failed", Text: test.output.String()}
+			case "skip":
+				tc.Skipped = &meta.Skipped{}
+			case "pass":
+			default:
+				// the test never finished, e.g. the package timed out or panicked
+				failedTests++
+				tc.Error = &meta.Error{Message: "No test result found", Text: test.output.String()}
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+
+		// the package failed without any failed test, e.g. build failure or TestMain exits
+		if pkg.action == "fail" && failedTests == 0 {
+			suite.TestCases = append(suite.TestCases, meta.TestCase{
+				Name:      "package",
+				ClassName: pkgName,
+				Time:      pkg.elapsed,
+				Error:     &meta.Error{Message: "Failed", Text: pkg.output.String()},
+			})
+		}
+		suites = append(suites, suite)
+	}
+
+	return suites, nil
+}
+
+// leafGoTests returns the tests to be reported as test cases, the parents of subtests are left out since
+// go test reports a parent as failed if any of its subtests fails. A parent which fails without any
+// failed subtest, e.g. it panics between its subtests, is still reported.
+func leafGoTests(names []string, failed func(name string) bool) map[string]bool {
+	parents := make(map[string]bool)
+	for _, name := range names {
+		for i := strings.Index(name, "/"); i >= 0; i = nextSlash(name, i) {
+			parents[name[:i]] = true
+		}
+	}
+
+	// the deeper tests are decided first so that a parent knows whether a failure is reported below it
+	sorted := make([]string, len(names))
+	copy(sorted, names)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.Count(sorted[i], "/") > strings.Count(sorted[j], "/")
+	})
+
+	reported := make(map[string]bool)
+	failedBelow := make(map[string]bool)
+	for _, name := range sorted {
+		if parents[name] && (!failed(name) || failedBelow[name]) {
+			continue
+		}
+		reported[name] = true
+		if failed(name) {
+			for i := strings.Index(name, "/"); i >= 0; i = nextSlash(name, i) {
+				failedBelow[name[:i]] = true
+			}
+		}
+	}
+	return reported
+}
+
+// nextSlash returns the index of the next slash in name after the one at i, or -1 if there is none
+func nextSlash(name string, i int) int {
+	j := strings.Index(name[i+1:], "/")
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+)
+
+// junitParser parses the junit xml reports of pytest, surefire, jest-junit and so on,
+// the root element can be either <testsuites> or <testsuite>
+type junitParser struct{}
+
+func (p *junitParser) DefaultGlob() string {
+	return "*.xml"
+}
+
+func (p *junitParser) Parse(name string, data []byte) ([]*meta.TestSuite, error) {
+	root, err := xmlRootElement(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse junit report %s: %s", name, err)
+	}
+
+	switch root {
+	case "testsuites":
+		suites := &meta.TestSuites{}
+		if err := xml.Unmarshal(data, suites); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal junit report %s: %s", name, err)
+		}
+		return suites.TestSuites, nil
+	case "testsuite":
+		suite := &meta.TestSuite{}
+		if err := xml.Unmarshal(data, suite); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal junit report %s: %s", name, err)
+		}
+		return []*meta.TestSuite{suite}, nil
+	default:
+		return nil, fmt.Errorf("unexpected root element <%s> in junit report %s", root, name)
+	}
+}
+
+// xmlRootElement returns the local name of the first element in the xml document
+func xmlRootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", fmt.Errorf("no root element found")
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+)
+
+var tapTestLine = regexp.MustCompile(`^(not ok|ok)\b\s*(\d+)?\s*-?\s*([^#]*?)\s*(?:#\s*(.*))?$`)
+
+// tapParser parses Test Anything Protocol output, every file becomes a test suite
+type tapParser struct{}
+
+func (p *tapParser) DefaultGlob() string {
+	return "*.tap"
+}
+
+func (p *tapParser) Parse(name string, data []byte) ([]*meta.TestSuite, error) {
+	suite := &meta.TestSuite{
+		Name:      name,
+		TestCases: []meta.TestCase{},
+	}
+
+	var (
+		found      bool
+		inYAML     bool
+		diagnostic strings.Builder
+	)
+	// flushDiagnostic attaches the yaml diagnostic block to the last failed test case
+	flushDiagnostic := func() {
+		if diagnostic.Len() > 0 && len(suite.TestCases) > 0 {
+			if last := &suite.TestCases[len(suite.TestCases)-1]; last.Failure != nil {
+				last.Failure.Text = diagnostic.String()
+			}
+		}
+		diagnostic.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if inYAML {
+			if trimmed == "..." {
+				inYAML = false
+				flushDiagnostic()
+				continue
+			}
+			diagnostic.WriteString(line)
+			diagnostic.WriteString("\n")
+			continue
+		}
+
+		switch {
+		case trimmed == "---":
+			inYAML = true
+		case strings.HasPrefix(trimmed, "Bail out!"):
+			found = true
+			suite.TestCases = append(suite.TestCases, meta.TestCase{
+				Name:      "Bail out",
+				ClassName: name,
+				Error:     &meta.Error{Message: strings.TrimSpace(strings.TrimPrefix(trimmed, "Bail out!"))},
+			})
+		default:
+			matches := tapTestLine.FindStringSubmatch(trimmed)
+			if matches == nil {
+				continue
+			}
+			found = true
+
+			testName := matches[3]
+			if testName == "" {
+				testName = fmt.Sprintf("test %s", matches[2])
+			}
+			tc := meta.TestCase{Name: testName, ClassName: name}
+
+			directive := strings.ToUpper(matches[4])
+			switch {
+			case strings.HasPrefix(directive, "SKIP"), strings.HasPrefix(directive, "TODO"):
+				tc.Skipped = &meta.Skipped{}
+			case matches[1] == "not ok":
+				tc.Failure = &meta.Failure{Message: "not ok"}
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tap report %s: %s", name, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("no tap test lines found in %s", name)
+	}
+
+	return []*meta.TestSuite{suite}, nil
+}
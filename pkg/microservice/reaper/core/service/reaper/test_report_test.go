@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+func parseTestReport(t *testing.T, format, data string) *meta.TestSuite {
+	parser, err := getTestReportParser(format)
+	assert.Nil(t, err)
+	suites, err := parser.Parse("report", []byte(data))
+	assert.Nil(t, err)
+	return summarizeTestSuites(suites)
+}
+
+func TestJUnitParser(t *testing.T) {
+	suites := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="pytest" tests="3">
+    <testcase classname="test_a" name="test_pass" time="0.1"/>
+    <testcase classname="test_a" name="test_fail" time="0.2"><failure message="assert 1 == 2">trace</failure></testcase>
+    <testcase classname="test_a" name="test_skip"><skipped/></testcase>
+  </testsuite>
+</testsuites>`
+	summary := parseTestReport(t, setting.TestReportFormatJUnit, suites)
+	assert.Equal(t, 3, summary.Tests)
+	assert.Equal(t, 1, summary.Failures)
+	assert.Equal(t, 1, summary.Skips)
+	assert.Equal(t, 1, summary.Successes)
+
+	suite := `<testsuite name="surefire" tests="1"><testcase classname="A" name="b"><error message="npe"/></testcase></testsuite>`
+	summary = parseTestReport(t, setting.TestReportFormatJUnit, suite)
+	assert.Equal(t, 1, summary.Tests)
+	assert.Equal(t, 1, summary.Errors)
+	assert.Equal(t, 0, summary.Successes)
+}
+
+func TestGoTestParser(t *testing.T) {
+	output := `{"Action":"run","Package":"a","Test":"TestPass"}
+{"Action":"pass","Package":"a","Test":"TestPass","Elapsed":0.1}
+{"Action":"run","Package":"a","Test":"TestFail"}
+{"Action":"output","Package":"a","Test":"TestFail","Output":"a_test.go:10: boom\n"}
+{"Action":"fail","Package":"a","Test":"TestFail","Elapsed":0.2}
+{"Action":"run","Package":"a","Test":"TestSkip"}
+{"Action":"skip","Package":"a","Test":"TestSkip"}
+{"Action":"fail","Package":"a","Elapsed":0.5}
+# b
+b.go:3:1: syntax error
+{"Action":"output","Package":"b","Output":"FAIL\tb [build failed]\n"}
+{"Action":"fail","Package":"b","Elapsed":0}`
+	summary := parseTestReport(t, setting.TestReportFormatGoTest, output)
+	assert.Equal(t, 4, summary.Tests)
+	assert.Equal(t, 1, summary.Failures)
+	assert.Equal(t, 1, summary.Errors)
+	assert.Equal(t, 1, summary.Skips)
+	assert.Equal(t, 1, summary.Successes)
+	assert.Equal(t, "a_test.go:10: boom\n", summary.TestCases[1].Failure.Text)
+	assert.Equal(t, "b", summary.TestCases[3].ClassName)
+}
+
+func TestGoTestParserSubtests(t *testing.T) {
+	output := `{"Action":"run","Package":"a","Test":"TestTable"}
+{"Action":"run","Package":"a","Test":"TestTable/ok"}
+{"Action":"run","Package":"a","Test":"TestTable/bad"}
+{"Action":"pass","Package":"a","Test":"TestTable/ok","Elapsed":0.1}
+{"Action":"output","Package":"a","Test":"TestTable/bad","Output":"a_test.go:20: bad case\n"}
+{"Action":"fail","Package":"a","Test":"TestTable/bad","Elapsed":0.1}
+{"Action":"fail","Package":"a","Test":"TestTable","Elapsed":0.2}
+{"Action":"run","Package":"a","Test":"TestSetup"}
+{"Action":"run","Package":"a","Test":"TestSetup/ok"}
+{"Action":"pass","Package":"a","Test":"TestSetup/ok","Elapsed":0.1}
+{"Action":"output","Package":"a","Test":"TestSetup","Output":"a_test.go:40: teardown failed\n"}
+{"Action":"fail","Package":"a","Test":"TestSetup","Elapsed":0.2}
+{"Action":"fail","Package":"a","Elapsed":0.5}`
+	summary := parseTestReport(t, setting.TestReportFormatGoTest, output)
+	assert.Equal(t, 4, summary.Tests)
+	assert.Equal(t, 2, summary.Failures)
+	assert.Equal(t, 2, summary.Successes)
+	assert.Equal(t, "TestTable/bad", summary.TestCases[1].Name)
+	assert.Equal(t, "TestSetup", summary.TestCases[2].Name)
+}
+
+func TestTAPParser(t *testing.T) {
+	output := `TAP version 13
+1..5
+ok 1 - passes
+not ok 2 - fails
+  ---
+  message: expected 1
+  ...
+ok 3 - skipped # SKIP no database
+not ok 4 - todo # TODO later
+Bail out! database is gone`
+	summary := parseTestReport(t, setting.TestReportFormatTAP, output)
+	assert.Equal(t, 5, summary.Tests)
+	assert.Equal(t, 1, summary.Failures)
+	assert.Equal(t, 1, summary.Errors)
+	assert.Equal(t, 2, summary.Skips)
+	assert.Equal(t, 1, summary.Successes)
+	assert.Equal(t, "fails", summary.TestCases[1].Name)
+	assert.Contains(t, summary.TestCases[1].Failure.Text, "expected 1")
+}
+
+func TestUnsupportedTestReportFormat(t *testing.T) {
+	_, err := getTestReportParser("unknown")
+	assert.NotNil(t, err)
+}
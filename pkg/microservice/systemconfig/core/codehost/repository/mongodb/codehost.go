@@ -26,6 +26,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/systemconfig/config"
 	"github.com/koderover/zadig/pkg/microservice/systemconfig/core/codehost/repository/models"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 	"github.com/koderover/zadig/pkg/tool/log"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
 )
@@ -57,8 +58,12 @@ func (c *CodehostColl) EnsureIndex(ctx context.Context) error {
 }
 
 func (c *CodehostColl) AddCodeHost(iCodeHost *models.CodeHost) (*models.CodeHost, error) {
+	doc, err := encryptCodeHost(iCodeHost)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := c.Collection.InsertOne(context.TODO(), iCodeHost)
+	_, err = c.Collection.InsertOne(context.TODO(), doc)
 	if err != nil {
 		log.Error("repository AddCodeHost err : %v", err)
 		return nil, err
@@ -87,6 +92,9 @@ func (c *CodehostColl) GetCodeHostByID(ID int) (*models.CodeHost, error) {
 	if err := c.Collection.FindOne(context.TODO(), query).Decode(codehost); err != nil {
 		return nil, err
 	}
+	if err := decryptCodeHost(codehost); err != nil {
+		return nil, err
+	}
 	return codehost, nil
 }
 
@@ -115,6 +123,11 @@ func (c *CodehostColl) List(args *ListArgs) ([]*models.CodeHost, error) {
 	if err != nil {
 		return nil, err
 	}
+	for _, codeHost := range codeHosts {
+		if err := decryptCodeHost(codeHost); err != nil {
+			return nil, err
+		}
+	}
 	return codeHosts, nil
 }
 
@@ -129,6 +142,11 @@ func (c *CodehostColl) CodeHostList() ([]*models.CodeHost, error) {
 	if err != nil {
 		return nil, err
 	}
+	for _, codeHost := range codeHosts {
+		if err := decryptCodeHost(codeHost); err != nil {
+			return nil, err
+		}
+	}
 	return codeHosts, nil
 }
 
@@ -145,41 +163,87 @@ func (c *CodehostColl) DeleteCodeHostByID(ID int) error {
 	return nil
 }
 
+// UpdateCodeHost updates the code host, the credentials which are left empty keep their stored values,
+// see encryption.KeepStored
 func (c *CodehostColl) UpdateCodeHost(host *models.CodeHost) (*models.CodeHost, error) {
+	doc, err := encryptCodeHost(host)
+	if err != nil {
+		return nil, err
+	}
+
 	query := bson.M{"id": host.ID, "deleted_at": 0}
 	modifyValue := bson.M{
 		"type":           host.Type,
 		"address":        host.Address,
 		"namespace":      host.Namespace,
 		"application_id": host.ApplicationId,
-		"client_secret":  host.ClientSecret,
 		"region":         host.Region,
 		"username":       host.Username,
-		"password":       host.Password,
 		"enable_proxy":   host.EnableProxy,
 		"updated_at":     time.Now().Unix(),
 	}
-	if host.Type == setting.SourceFromGerrit {
-		modifyValue["access_token"] = host.AccessToken
-	} else if host.Type == setting.SourceFromGitee {
-		modifyValue["access_token"] = host.AccessToken
-		modifyValue["refresh_token"] = host.RefreshToken
-		modifyValue["updated_at"] = host.UpdatedAt
+	secrets := map[string]string{
+		"client_secret": doc.ClientSecret,
+		"password":      doc.Password,
+		"access_token":  doc.AccessToken,
+		"refresh_token": doc.RefreshToken,
+	}
+	for field, value := range secrets {
+		if value != "" {
+			modifyValue[field] = value
+		}
+	}
+	// updated_at of gitee is the time when the tokens are refreshed
+	if host.Type == setting.SourceFromGitee {
+		if host.AccessToken != "" {
+			modifyValue["updated_at"] = host.UpdatedAt
+		} else {
+			delete(modifyValue, "updated_at")
+		}
 	}
 
 	change := bson.M{"$set": modifyValue}
-	_, err := c.Collection.UpdateOne(context.TODO(), query, change)
+	_, err = c.Collection.UpdateOne(context.TODO(), query, change)
 	return host, err
 }
 
 func (c *CodehostColl) UpdateCodeHostByToken(host *models.CodeHost) (*models.CodeHost, error) {
+	doc, err := encryptCodeHost(host)
+	if err != nil {
+		return nil, err
+	}
+
 	query := bson.M{"id": host.ID, "deleted_at": 0}
 	change := bson.M{"$set": bson.M{
 		"is_ready":      "2",
-		"access_token":  host.AccessToken,
+		"access_token":  doc.AccessToken,
 		"updated_at":    time.Now().Unix(),
-		"refresh_token": host.RefreshToken,
+		"refresh_token": doc.RefreshToken,
 	}}
-	_, err := c.Collection.UpdateOne(context.TODO(), query, change)
+	_, err = c.Collection.UpdateOne(context.TODO(), query, change)
 	return host, err
 }
+
+// encryptCodeHost returns a copy of the code host to be stored, whose credentials are encrypted
+func encryptCodeHost(host *models.CodeHost) (*models.CodeHost, error) {
+	doc := *host
+	for _, secret := range []*string{&doc.AccessToken, &doc.RefreshToken, &doc.Password, &doc.ClientSecret} {
+		encrypted, err := encryption.Encrypt(*secret)
+		if err != nil {
+			return nil, err
+		}
+		*secret = encrypted
+	}
+	return &doc, nil
+}
+
+func decryptCodeHost(host *models.CodeHost) error {
+	for _, secret := range []*string{&host.AccessToken, &host.RefreshToken, &host.Password, &host.ClientSecret} {
+		decrypted, err := encryption.Decrypt(*secret)
+		if err != nil {
+			return err
+		}
+		*secret = decrypted
+	}
+	return nil
+}
@@ -33,9 +33,8 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/systemconfig/core/codehost/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/systemconfig/core/codehost/repository/mongodb"
 	"github.com/koderover/zadig/pkg/setting"
-	"github.com/koderover/zadig/pkg/shared/client/aslan"
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
-	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/shared/encryption"
 )
 
 const callback = "/api/directory/codehosts/callback"
@@ -56,34 +55,20 @@ func CreateCodeHost(codehost *models.CodeHost, _ *zap.SugaredLogger) (*models.Co
 		return nil, err
 	}
 	codehost.ID = len(list) + 1
-	return mongodb.NewCodehostColl().AddCodeHost(codehost)
+	return maskCodeHost(mongodb.NewCodehostColl().AddCodeHost(codehost))
 }
 
-func encypteCodeHost(encryptedKey string, codeHosts []*models.CodeHost, log *zap.SugaredLogger) ([]*models.CodeHost, error) {
-	aesKey, err := aslan.New(config.AslanServiceAddress()).GetTextFromEncryptedKey(encryptedKey)
-	if err != nil {
-		log.Errorf("ListCodeHost GetTextFromEncryptedKey error:%s", err)
-		return nil, err
-	}
-	var result []*models.CodeHost
-	for _, codeHost := range codeHosts {
-		if len(codeHost.Password) > 0 {
-			codeHost.Password, err = crypto.AesEncryptByKey(codeHost.Password, aesKey.PlainText)
-			if err != nil {
-				log.Errorf("ListCodeHost AesEncryptByKey error:%s", err)
-				return nil, err
-			}
-		}
-		if len(codeHost.ClientSecret) > 0 {
-			codeHost.ClientSecret, err = crypto.AesEncryptByKey(codeHost.ClientSecret, aesKey.PlainText)
-			if err != nil {
-				log.Errorf("ListCodeHost AesEncryptByKey error:%s", err)
-				return nil, err
-			}
-		}
-		result = append(result, codeHost)
-	}
-	return result, nil
+// maskCodeHost hides the credentials of the code host, they are never returned to the client in plain text
+func maskCodeHost(host *models.CodeHost, err error) (*models.CodeHost, error) {
+	if err != nil || host == nil {
+		return host, err
+	}
+	masked := *host
+	masked.AccessToken = ""
+	masked.RefreshToken = ""
+	masked.Password = ""
+	masked.ClientSecret = ""
+	return &masked, nil
 }
 
 func ListInternal(address, owner, source string, _ *zap.SugaredLogger) ([]*models.CodeHost, error) {
@@ -94,7 +79,8 @@ func ListInternal(address, owner, source string, _ *zap.SugaredLogger) ([]*model
 	})
 }
 
-func List(encryptedKey, address, owner, source string, log *zap.SugaredLogger) ([]*models.CodeHost, error) {
+// List lists the code hosts without their credentials, use ListInternal if the credentials are needed
+func List(address, owner, source string, log *zap.SugaredLogger) ([]*models.CodeHost, error) {
 	codeHosts, err := mongodb.NewCodehostColl().List(&mongodb.ListArgs{
 		Address: address,
 		Owner:   owner,
@@ -104,7 +90,12 @@ func List(encryptedKey, address, owner, source string, log *zap.SugaredLogger) (
 		log.Errorf("ListCodeHost error:%s", err)
 		return nil, err
 	}
-	return encypteCodeHost(encryptedKey, codeHosts, log)
+	result := make([]*models.CodeHost, 0, len(codeHosts))
+	for _, codeHost := range codeHosts {
+		masked, _ := maskCodeHost(codeHost, nil)
+		result = append(result, masked)
+	}
+	return result, nil
 }
 
 func DeleteCodeHost(id int, _ *zap.SugaredLogger) error {
@@ -113,9 +104,19 @@ func DeleteCodeHost(id int, _ *zap.SugaredLogger) error {
 
 func UpdateCodeHost(host *models.CodeHost, _ *zap.SugaredLogger) (*models.CodeHost, error) {
 	if host.Type == setting.SourceFromGerrit {
+		err := encryption.KeepStored(&host.Password, func() (string, error) {
+			old, err := mongodb.NewCodehostColl().GetCodeHostByID(host.ID)
+			if err != nil {
+				return "", err
+			}
+			return old.Password, nil
+		})
+		if err != nil {
+			return nil, err
+		}
 		host.AccessToken = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", host.Username, host.Password)))
 	}
-	return mongodb.NewCodehostColl().UpdateCodeHost(host)
+	return maskCodeHost(mongodb.NewCodehostColl().UpdateCodeHost(host))
 }
 
 func UpdateCodeHostByToken(host *models.CodeHost, _ *zap.SugaredLogger) (*models.CodeHost, error) {
@@ -123,6 +124,10 @@ func UpdateCodeHostByToken(host *models.CodeHost, _ *zap.SugaredLogger) (*models
 }
 
 func GetCodeHost(id int, _ *zap.SugaredLogger) (*models.CodeHost, error) {
+	return maskCodeHost(mongodb.NewCodehostColl().GetCodeHostByID(id))
+}
+
+func GetCodeHostInternal(id int, _ *zap.SugaredLogger) (*models.CodeHost, error) {
 	return mongodb.NewCodehostColl().GetCodeHostByID(id)
 }
 
@@ -132,7 +137,7 @@ type state struct {
 }
 
 func AuthCodeHost(redirectURI string, codeHostID int, logger *zap.SugaredLogger) (string, error) {
-	codeHost, err := GetCodeHost(codeHostID, logger)
+	codeHost, err := GetCodeHostInternal(codeHostID, logger)
 	if err != nil {
 		logger.Errorf("GetCodeHost:%s err:%s", codeHostID, err)
 		return "", err
@@ -178,7 +183,7 @@ func HandleCallback(stateStr string, r *http.Request, logger *zap.SugaredLogger)
 		logger.Errorf("ParseURL:%s err:%s", sta.RedirectURL, err)
 		return "", err
 	}
-	codehost, err := GetCodeHost(sta.CodeHostID, logger)
+	codehost, err := GetCodeHostInternal(sta.CodeHostID, logger)
 	if err != nil {
 		return handle(redirectParsedURL, err)
 	}
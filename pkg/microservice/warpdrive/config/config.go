@@ -53,3 +53,8 @@ func DefaultRegistryAK() string {
 func DefaultRegistrySK() string {
 	return viper.GetString(setting.DefaultRegistrySK)
 }
+
+// TrivyCacheDir is where the offline vulnerability db of trivy is kept
+func TrivyCacheDir() string {
+	return viper.GetString(setting.TrivyCacheDir)
+}
@@ -26,4 +26,56 @@ type Stage struct {
 	Desc        string                            `bson:"desc,omitempty"     json:"desc,omitempty"`
 	SubTasks    map[string]map[string]interface{} `bson:"sub_tasks"          json:"sub_tasks"`
 	AfterAll    bool                              `bson:"after_all"          json:"after_all"`
+	// Name and DependsOn are only set for custom workflows which run their stages as a DAG
+	Name      string   `bson:"name,omitempty"       json:"name,omitempty"`
+	DependsOn []string `bson:"depends_on,omitempty" json:"depends_on,omitempty"`
+	// Policy is copied from the workflow when the task starts
+	Policy *StagePolicy `bson:"policy,omitempty"     json:"policy,omitempty"`
+}
+
+// StagePolicy controls when a stage runs and how its failure affects the task,
+// it applies to the stage with the same Name, or to the stages of TaskType if Name is empty
+type StagePolicy struct {
+	TaskType config.TaskType `bson:"type,omitempty"            json:"type,omitempty"`
+	Name     string          `bson:"name,omitempty"            json:"name,omitempty"`
+	When     *StageCondition `bson:"when,omitempty"            json:"when,omitempty"`
+	// ContinueOnError keeps running the following stages and does not fail the task if the stage fails
+	ContinueOnError bool `bson:"continue_on_error"         json:"continue_on_error"`
+	// AlwaysRun runs the stage even if earlier stages failed, it is used for cleanup and notification
+	AlwaysRun bool `bson:"always_run"                json:"always_run"`
+	// Retry reruns the failed sub tasks of the stage
+	Retry *RetryPolicy `bson:"retry,omitempty"           json:"retry,omitempty"`
+}
+
+// RetryPolicy reruns a failed sub task after a backoff, the backoff grows by Factor after each attempt
+type RetryPolicy struct {
+	// MaxAttempts includes the first run
+	MaxAttempts int `bson:"max_attempts"              json:"max_attempts"`
+	// Backoff is the seconds to wait before the first retry
+	Backoff    int     `bson:"backoff"                   json:"backoff"`
+	Factor     float64 `bson:"factor,omitempty"          json:"factor,omitempty"`
+	MaxBackoff int     `bson:"max_backoff,omitempty"     json:"max_backoff,omitempty"`
+	// RetryOn are the failure classes to retry: image_pull, evicted, registry and timeout, empty means any failure
+	RetryOn []string `bson:"retry_on,omitempty"        json:"retry_on,omitempty"`
+}
+
+// StageCondition is met only if all its non-empty parts are met
+type StageCondition struct {
+	// Branches are glob patterns, one of the branches of the task must match one of them
+	Branches []string `bson:"branches,omitempty"        json:"branches,omitempty"`
+	// Sources are the trigger sources: manual, webhook or timer
+	Sources     []string                `bson:"sources,omitempty"         json:"sources,omitempty"`
+	Parameters  []*ParameterCondition   `bson:"parameters,omitempty"      json:"parameters,omitempty"`
+	StageStatus []*StageStatusCondition `bson:"stage_status,omitempty"    json:"stage_status,omitempty"`
+}
+
+type ParameterCondition struct {
+	Key   string `bson:"key"                       json:"key"`
+	Value string `bson:"value"                     json:"value"`
+}
+
+// StageStatusCondition requires an earlier stage, identified by its name or task type, to end with one of the statuses
+type StageStatusCondition struct {
+	Stage    string          `bson:"stage"                     json:"stage"`
+	Statuses []config.Status `bson:"statuses"                  json:"statuses"`
 }
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package taskcontroller
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/common"
+)
+
+type dagStageState int
+
+const (
+	dagStageWaiting dagStageState = iota
+	dagStageReady
+	dagStageBlocked
+)
+
+// dagState decides whether a stage can be started according to its finished upstream stages,
+// a stage is blocked and will never run once one of its upstream stages fails or is blocked, unless it is set to always run.
+// An upstream stage skipped by its "when" condition is finished as well and does not block its downstream stages.
+func dagState(stage *common.Stage, finished map[string]*common.Stage, blocked sets.String) dagStageState {
+	for _, dep := range stage.DependsOn {
+		upstream, ok := finished[dep]
+		if !ok {
+			return dagStageWaiting
+		}
+		if stageAlwaysRun(stage) {
+			continue
+		}
+		if stageFailed(upstream) || blocked.Has(dep) {
+			return dagStageBlocked
+		}
+	}
+	return dagStageReady
+}
+
+// runStagesInDAG runs every stage as soon as all its upstream stages pass, independent stages run in parallel.
+// Stages whose upstream stages fail or whose conditions are not met are marked as skipped.
+func (h *ExecHandler) runStagesInDAG(stages []*common.Stage, concurrency int64) {
+	// finished only contains stages which have ended, so that their status is no longer written by other goroutines
+	finished := make(map[string]*common.Stage, len(stages))
+	// blocked contains the skipped stages whose upstream stages did not pass
+	blocked := sets.NewString()
+	started := make([]bool, len(stages))
+	done := make(chan int)
+	running := 0
+
+	for {
+		for changed := true; changed; {
+			changed = false
+			for pos, stage := range stages {
+				if started[pos] {
+					continue
+				}
+				state := dagState(stage, finished, blocked)
+				if state == dagStageWaiting {
+					continue
+				}
+
+				started[pos] = true
+				reason := "upstream stages did not pass"
+				if state == dagStageReady {
+					reason = checkStageCondition(stage, pipelineTask, finished)
+				}
+				if reason != "" {
+					changed = true
+					skipPipelineStage(reason, pipelineTask, pos, xl)
+					h.SendAck()
+					finished[stage.Name] = stage
+					if state == dagStageBlocked {
+						blocked.Insert(stage.Name)
+					}
+					continue
+				}
+
+				running++
+				go func(pos int, stage *common.Stage) {
+					h.runStage(pos, stage, concurrency)
+					done <- pos
+				}(pos, stage)
+			}
+		}
+
+		if running == 0 {
+			break
+		}
+		pos := <-done
+		running--
+		finished[stages[pos].Name] = stages[pos]
+	}
+	h.SendAck()
+}
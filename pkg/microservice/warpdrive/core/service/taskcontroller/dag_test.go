@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package taskcontroller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/common"
+)
+
+func TestDagState(t *testing.T) {
+	assert := assert.New(t)
+	stage := &common.Stage{Name: "deploy", DependsOn: []string{"build-a", "build-b"}}
+	passed := &common.Stage{Status: config.StatusPassed}
+	failed := &common.Stage{Status: config.StatusFailed}
+
+	skipped := &common.Stage{Status: config.StatusSkipped}
+	none := sets.NewString()
+
+	assert.Equal(dagStageReady, dagState(&common.Stage{Name: "build-a"}, nil, none))
+	assert.Equal(dagStageWaiting, dagState(stage, map[string]*common.Stage{"build-a": passed}, none))
+	assert.Equal(dagStageReady, dagState(stage, map[string]*common.Stage{"build-a": passed, "build-b": passed}, none))
+	assert.Equal(dagStageBlocked, dagState(stage, map[string]*common.Stage{"build-a": failed}, none))
+
+	// a stage skipped by its condition does not block the downstream stages, a blocked one does
+	assert.Equal(dagStageReady, dagState(stage, map[string]*common.Stage{"build-a": passed, "build-b": skipped}, none))
+	assert.Equal(dagStageBlocked, dagState(stage, map[string]*common.Stage{"build-a": passed, "build-b": skipped}, sets.NewString("build-b")))
+
+	ignored := &common.Stage{Status: config.StatusFailed, Policy: &common.StagePolicy{ContinueOnError: true}}
+	assert.Equal(dagStageReady, dagState(stage, map[string]*common.Stage{"build-a": passed, "build-b": ignored}, none))
+
+	cleanup := &common.Stage{Name: "cleanup", DependsOn: []string{"build-a"}, Policy: &common.StagePolicy{AlwaysRun: true}}
+	assert.Equal(dagStageReady, dagState(cleanup, map[string]*common.Stage{"build-a": failed}, none))
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/common"
+	plugins "github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin/s3"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+)
+
+// retryAttemptsKey is the key of the attempts in a sub task which runs with a retry policy
+const retryAttemptsKey = "retry_attempts"
+
+const (
+	failureClassImagePull = plugins.FailureReasonImagePull
+	failureClassEvicted   = plugins.FailureReasonEvicted
+	failureClassRegistry  = plugins.FailureReasonRegistry
+	failureClassTimeout   = "timeout"
+	failureClassOther     = "other"
+)
+
+// subTaskAttempt is one run of a sub task which runs with a retry policy
+type subTaskAttempt struct {
+	Attempt      int           `json:"attempt"`
+	Status       config.Status `json:"status"`
+	Error        string        `json:"error,omitempty"`
+	FailureClass string        `json:"failure_class,omitempty"`
+	LogFile      string        `json:"log_file,omitempty"`
+	StartTime    int64         `json:"start_time"`
+	EndTime      int64         `json:"end_time"`
+}
+
+// stageRetryPolicy returns the retry policy of the stage at pos, or nil if failed sub tasks should not be retried
+func stageRetryPolicy(pipelineTask *task.Task, pos int) *common.RetryPolicy {
+	pipelineTask.RwLock.Lock()
+	defer pipelineTask.RwLock.Unlock()
+
+	if pos >= len(pipelineTask.Stages) || pipelineTask.Stages[pos] == nil || pipelineTask.Stages[pos].Policy == nil {
+		return nil
+	}
+	retry := pipelineTask.Stages[pos].Policy.Retry
+	if retry == nil || retry.MaxAttempts <= 1 {
+		return nil
+	}
+	return retry
+}
+
+// classifyFailure returns the failure class of a failed sub task from its status and the exact failure reason
+// recorded by the plugin, the error message is never parsed since it may mention anything
+func classifyFailure(status config.Status, reason string) string {
+	switch reason {
+	case failureClassImagePull, failureClassEvicted, failureClassRegistry:
+		return reason
+	}
+	if status == config.StatusTimeout {
+		return failureClassTimeout
+	}
+	return failureClassOther
+}
+
+// shouldRetry reports whether a sub task should run again after the given attempt
+func shouldRetry(retry *common.RetryPolicy, attempt int, status config.Status, failureClass string) bool {
+	if retry == nil || attempt >= retry.MaxAttempts {
+		return false
+	}
+	if status != config.StatusFailed && status != config.StatusTimeout {
+		return false
+	}
+	if len(retry.RetryOn) == 0 {
+		return true
+	}
+	for _, class := range retry.RetryOn {
+		if class == failureClass {
+			return true
+		}
+	}
+	return false
+}
+
+// retryBackoff returns how long to wait before the attempt after the given one
+func retryBackoff(retry *common.RetryPolicy, attempt int) time.Duration {
+	factor := retry.Factor
+	if factor < 1 {
+		factor = 1
+	}
+	backoff := float64(retry.Backoff) * math.Pow(factor, float64(attempt-1))
+	if retry.MaxBackoff > 0 && backoff > float64(retry.MaxBackoff) {
+		backoff = float64(retry.MaxBackoff)
+	}
+	return time.Duration(backoff) * time.Second
+}
+
+// attemptLogFile is the name the log of an attempt is kept under, the log of the last attempt is also
+// kept under the original name so that it is shown by default
+func attemptLogFile(fileName string, attempt int) string {
+	return fmt.Sprintf("%s-attempt-%d", fileName, attempt)
+}
+
+// subTaskFailure returns the error and the failure reason recorded by the plugin in its sub task
+func subTaskFailure(plugin plugins.TaskPlugin) (string, string) {
+	b, err := json.Marshal(plugin.GetTask())
+	if err != nil {
+		return "", ""
+	}
+	var subTask struct {
+		Error         string `json:"error"`
+		FailureReason string `json:"failure_reason"`
+	}
+	if err := json.Unmarshal(b, &subTask); err != nil {
+		return "", ""
+	}
+	return subTask.Error, subTask.FailureReason
+}
+
+// keepRetryAttempts carries the attempts over when a sub task is replaced by the latest state of its plugin
+func keepRetryAttempts(old, subTask map[string]interface{}) {
+	if attempts, ok := old[retryAttemptsKey]; ok {
+		if _, exists := subTask[retryAttemptsKey]; !exists {
+			subTask[retryAttemptsKey] = attempts
+		}
+	}
+}
+
+// recordRetryAttempts saves the attempts in the sub task so that they can be inspected
+func recordRetryAttempts(attempts []*subTaskAttempt, pipelineTask *task.Task, pos int, servicename string) {
+	pipelineTask.RwLock.Lock()
+	defer pipelineTask.RwLock.Unlock()
+
+	var subTask map[string]interface{}
+	if pipelineTask.Type == config.SingleType || pipelineTask.Type == "" {
+		if pos < len(pipelineTask.SubTasks) {
+			subTask = pipelineTask.SubTasks[pos]
+		}
+	} else if pos < len(pipelineTask.Stages) && pipelineTask.Stages[pos] != nil {
+		subTask = pipelineTask.Stages[pos].SubTasks[servicename]
+	}
+	if subTask != nil {
+		subTask[retryAttemptsKey] = attempts
+	}
+}
+
+// archiveAttemptLog copies the log of an attempt before the next attempt overwrites it
+func archiveAttemptLog(pipelineTask *task.Task, fileName string, attempt int, xl *zap.SugaredLogger) string {
+	store, err := s3.NewS3StorageFromEncryptedURI(pipelineTask.StorageURI)
+	if err != nil {
+		xl.Errorf("failed to get s3 storage, err: %s", err)
+		return ""
+	}
+	if store.Subfolder != "" {
+		store.Subfolder = fmt.Sprintf("%s/%s/%d/%s", store.Subfolder, strings.ToLower(pipelineTask.PipelineName), pipelineTask.TaskID, "log")
+	} else {
+		store.Subfolder = fmt.Sprintf("%s/%d/%s", strings.ToLower(pipelineTask.PipelineName), pipelineTask.TaskID, "log")
+	}
+	forcedPathStyle := true
+	if store.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3tool.NewClient(store.Endpoint, store.Ak, store.Sk, store.Insecure, forcedPathStyle)
+	if err != nil {
+		xl.Errorf("failed to create s3 client, err: %s", err)
+		return ""
+	}
+
+	logFile := attemptLogFile(fileName, attempt)
+	if err := client.CopyObject(store.Bucket, store.GetObjectPath(fileName+".log"), store.GetObjectPath(logFile+".log")); err != nil {
+		// sub tasks like deploy have no log
+		xl.Infof("no log is archived for attempt %d of %s: %s", attempt, fileName, err)
+		return ""
+	}
+	return logFile
+}
+
+// waitRetryBackoff waits before the next attempt, it returns false if the task is cancelled in the meantime
+func waitRetryBackoff(taskCtx context.Context, backoff time.Duration) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-taskCtx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
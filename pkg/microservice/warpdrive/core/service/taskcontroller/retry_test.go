@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package taskcontroller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/common"
+	plugins "github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(failureClassImagePull, classifyFailure(config.StatusFailed, plugins.FailureReasonImagePull))
+	assert.Equal(failureClassEvicted, classifyFailure(config.StatusTimeout, plugins.FailureReasonEvicted))
+	assert.Equal(failureClassRegistry, classifyFailure(config.StatusFailed, plugins.FailureReasonRegistry))
+	assert.Equal(failureClassTimeout, classifyFailure(config.StatusTimeout, ""))
+	assert.Equal(failureClassOther, classifyFailure(config.StatusFailed, ""))
+	assert.Equal(failureClassOther, classifyFailure(config.StatusFailed, "unknown"))
+}
+
+func TestShouldRetry(t *testing.T) {
+	assert := assert.New(t)
+	retry := &common.RetryPolicy{MaxAttempts: 3, RetryOn: []string{failureClassImagePull}}
+
+	assert.True(shouldRetry(retry, 1, config.StatusFailed, failureClassImagePull))
+	assert.False(shouldRetry(retry, 3, config.StatusFailed, failureClassImagePull))
+	assert.False(shouldRetry(retry, 1, config.StatusFailed, failureClassOther))
+	assert.False(shouldRetry(retry, 1, config.StatusCancelled, failureClassImagePull))
+	assert.True(shouldRetry(&common.RetryPolicy{MaxAttempts: 2}, 1, config.StatusTimeout, failureClassTimeout))
+	assert.False(shouldRetry(nil, 1, config.StatusFailed, failureClassOther))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(10*time.Second, retryBackoff(&common.RetryPolicy{Backoff: 10}, 3))
+	retry := &common.RetryPolicy{Backoff: 10, Factor: 2, MaxBackoff: 30}
+	assert.Equal(10*time.Second, retryBackoff(retry, 1))
+	assert.Equal(20*time.Second, retryBackoff(retry, 2))
+	assert.Equal(30*time.Second, retryBackoff(retry, 3))
+}
+
+func TestKeepRetryAttempts(t *testing.T) {
+	assert := assert.New(t)
+	pipelineTask := &task.Task{
+		Type: config.WorkflowType,
+		Stages: []*common.Stage{{
+			TaskType: config.TaskBuild,
+			SubTasks: map[string]map[string]interface{}{"svc": {"status": "failed"}},
+		}},
+	}
+	attempts := []*subTaskAttempt{{Attempt: 1, Status: config.StatusFailed}}
+
+	recordRetryAttempts(attempts, pipelineTask, 0, "svc")
+	subTask := map[string]interface{}{"status": "running"}
+	keepRetryAttempts(pipelineTask.Stages[0].SubTasks["svc"], subTask)
+	assert.Equal(attempts, subTask[retryAttemptsKey])
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package taskcontroller
+
+import (
+	"fmt"
+	"path"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/common"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+const (
+	triggerSourceManual  = "manual"
+	triggerSourceWebhook = "webhook"
+	triggerSourceTimer   = "timer"
+)
+
+// stageKey identifies a stage in policies and conditions, custom workflow stages use their names
+func stageKey(stage *common.Stage) string {
+	if stage.Name != "" {
+		return stage.Name
+	}
+	return string(stage.TaskType)
+}
+
+// applyStagePolicies attaches the policies of the task to its stages, policies with a name take precedence
+func applyStagePolicies(pipelineTask *task.Task) {
+	for _, stage := range pipelineTask.Stages {
+		for _, policy := range pipelineTask.StagePolicies {
+			if policy == nil {
+				continue
+			}
+			if policy.Name != "" && policy.Name == stage.Name {
+				stage.Policy = policy
+				break
+			}
+			if policy.Name == "" && policy.TaskType == stage.TaskType && stage.Policy == nil {
+				stage.Policy = policy
+			}
+		}
+	}
+}
+
+// stageFailed reports whether the stage fails the task and stops the following stages
+func stageFailed(stage *common.Stage) bool {
+	switch stage.Status {
+	case config.StatusCancelled:
+		return true
+	case config.StatusFailed, config.StatusTimeout:
+		return stage.Policy == nil || !stage.Policy.ContinueOnError
+	}
+	return false
+}
+
+func stageAlwaysRun(stage *common.Stage) bool {
+	return stage.Policy != nil && stage.Policy.AlwaysRun
+}
+
+func triggerSource(pipelineTask *task.Task) string {
+	switch pipelineTask.TaskCreator {
+	case setting.WebhookTaskCreator:
+		return triggerSourceWebhook
+	case setting.CronTaskCreator:
+		return triggerSourceTimer
+	}
+	return triggerSourceManual
+}
+
+func taskBranches(pipelineTask *task.Task) []string {
+	var branches []string
+	if pipelineTask.TaskArgs != nil {
+		for _, repo := range pipelineTask.TaskArgs.Builds {
+			branches = append(branches, repo.Branch)
+		}
+	}
+	if pipelineTask.WorkflowArgs != nil {
+		for _, target := range pipelineTask.WorkflowArgs.Target {
+			if target.Build == nil {
+				continue
+			}
+			for _, repo := range target.Build.Repos {
+				branches = append(branches, repo.Branch)
+			}
+		}
+	}
+	return branches
+}
+
+func taskParameters(pipelineTask *task.Task) map[string]string {
+	params := make(map[string]string)
+	if pipelineTask.TaskArgs != nil {
+		for _, kv := range pipelineTask.TaskArgs.BuildArgs {
+			params[kv.Key] = kv.Value
+		}
+	}
+	if pipelineTask.WorkflowArgs != nil {
+		for _, target := range pipelineTask.WorkflowArgs.Target {
+			for _, kv := range target.Envs {
+				params[kv.Key] = kv.Value
+			}
+		}
+	}
+	return params
+}
+
+// checkStageCondition returns an empty string if the "when" condition of the stage is met,
+// otherwise the reason why the stage is skipped. finished holds the stages that have ended.
+func checkStageCondition(stage *common.Stage, pipelineTask *task.Task, finished map[string]*common.Stage) string {
+	if stage.Policy == nil || stage.Policy.When == nil {
+		return ""
+	}
+	when := stage.Policy.When
+
+	if len(when.Branches) > 0 {
+		matched := false
+		for _, branch := range taskBranches(pipelineTask) {
+			for _, pattern := range when.Branches {
+				if ok, _ := path.Match(pattern, branch); ok {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return fmt.Sprintf("no branch matches %v", when.Branches)
+		}
+	}
+
+	if len(when.Sources) > 0 {
+		if source := triggerSource(pipelineTask); !sets.NewString(when.Sources...).Has(source) {
+			return fmt.Sprintf("trigger source %s is not in %v", source, when.Sources)
+		}
+	}
+
+	if len(when.Parameters) > 0 {
+		params := taskParameters(pipelineTask)
+		for _, param := range when.Parameters {
+			if value, ok := params[param.Key]; !ok || value != param.Value {
+				return fmt.Sprintf("parameter %s is not %s", param.Key, param.Value)
+			}
+		}
+	}
+
+	for _, cond := range when.StageStatus {
+		upstream, ok := finished[cond.Stage]
+		if !ok {
+			return fmt.Sprintf("stage %s has not run", cond.Stage)
+		}
+		matched := false
+		for _, status := range cond.Statuses {
+			if upstream.Status == status {
+				matched = true
+			}
+		}
+		if !matched {
+			return fmt.Sprintf("stage %s is %s", cond.Stage, upstream.Status)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package taskcontroller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/common"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+func TestApplyStagePolicies(t *testing.T) {
+	assert := assert.New(t)
+	byType := &common.StagePolicy{TaskType: config.TaskBuildV3, ContinueOnError: true}
+	byName := &common.StagePolicy{Name: "notify", AlwaysRun: true}
+	pipelineTask := &task.Task{
+		Stages: []*common.Stage{
+			{TaskType: config.TaskBuildV3, Name: "build"},
+			{TaskType: config.TaskTrigger, Name: "notify"},
+		},
+		StagePolicies: []*common.StagePolicy{byType, byName},
+	}
+
+	applyStagePolicies(pipelineTask)
+	assert.Equal(byType, pipelineTask.Stages[0].Policy)
+	assert.Equal(byName, pipelineTask.Stages[1].Policy)
+}
+
+func TestStageFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(stageFailed(&common.Stage{Status: config.StatusPassed}))
+	assert.False(stageFailed(&common.Stage{Status: config.StatusSkipped}))
+	assert.True(stageFailed(&common.Stage{Status: config.StatusFailed}))
+	assert.False(stageFailed(&common.Stage{Status: config.StatusFailed, Policy: &common.StagePolicy{ContinueOnError: true}}))
+	assert.True(stageFailed(&common.Stage{Status: config.StatusCancelled, Policy: &common.StagePolicy{ContinueOnError: true}}))
+}
+
+func TestCheckStageCondition(t *testing.T) {
+	assert := assert.New(t)
+	pipelineTask := &task.Task{
+		TaskCreator: setting.WebhookTaskCreator,
+		TaskArgs: &task.TaskArgs{
+			Builds:    []*task.Repository{{Branch: "release/1.0"}},
+			BuildArgs: []*task.KeyVal{{Key: "ENV", Value: "prod"}},
+		},
+	}
+	stageWhen := func(when *common.StageCondition) *common.Stage {
+		return &common.Stage{Policy: &common.StagePolicy{When: when}}
+	}
+	finished := map[string]*common.Stage{"build": {Status: config.StatusFailed}}
+
+	assert.Empty(checkStageCondition(&common.Stage{}, pipelineTask, finished))
+	assert.Empty(checkStageCondition(stageWhen(&common.StageCondition{Branches: []string{"release/*"}}), pipelineTask, finished))
+	assert.NotEmpty(checkStageCondition(stageWhen(&common.StageCondition{Branches: []string{"main"}}), pipelineTask, finished))
+	assert.Empty(checkStageCondition(stageWhen(&common.StageCondition{Sources: []string{"webhook"}}), pipelineTask, finished))
+	assert.NotEmpty(checkStageCondition(stageWhen(&common.StageCondition{Sources: []string{"manual"}}), pipelineTask, finished))
+	assert.Empty(checkStageCondition(stageWhen(&common.StageCondition{Parameters: []*common.ParameterCondition{{Key: "ENV", Value: "prod"}}}), pipelineTask, finished))
+	assert.NotEmpty(checkStageCondition(stageWhen(&common.StageCondition{Parameters: []*common.ParameterCondition{{Key: "ENV", Value: "dev"}}}), pipelineTask, finished))
+	assert.Empty(checkStageCondition(stageWhen(&common.StageCondition{StageStatus: []*common.StageStatusCondition{{Stage: "build", Statuses: []config.Status{config.StatusFailed}}}}), pipelineTask, finished))
+	assert.NotEmpty(checkStageCondition(stageWhen(&common.StageCondition{StageStatus: []*common.StageStatusCondition{{Stage: "test", Statuses: []config.Status{config.StatusPassed}}}}), pipelineTask, finished))
+}
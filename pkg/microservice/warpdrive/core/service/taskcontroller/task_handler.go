@@ -372,9 +372,8 @@ func (h *ExecHandler) runStage(stagePosition int, stage *common.Stage, concurren
 	xl.Info("execution completed of subtasks in stage")
 	stageStatus := getStageStatus(workerPool.Tasks, xl)
 	xl.Infof("aggregated stage status of stage %d with type %s is: %s", stagePosition, stage.TaskType, stageStatus)
-	stage.Status = stageStatus
 	// 更新Stage状态
-	updatePipelineStageStatus(stage.Status, pipelineTask, stagePosition, xl)
+	updatePipelineStageStatus(stageStatus, pipelineTask, stagePosition, xl)
 	h.SendAck()
 }
 
@@ -394,21 +393,43 @@ func (h *ExecHandler) execute(ctx context.Context, pipelineTask *task.Task, pipe
 		}
 	}
 
-	// Only serial is supported between stages
-	// If the stage status is StatusFailed/StatusCancelled/StatusTimeout, other than the extension stage will not be executed
-	isSkip := false
-	for stagePosition, stage := range pipelineTask.Stages {
-		if stage.AfterAll {
-			continue
-		}
+	applyStagePolicies(pipelineTask)
+
+	if len(pipelineTask.DAG) > 0 {
+		// 自定义工作流声明了依赖关系时，上游stage全部成功后立即执行下游stage
+		h.runStagesInDAG(pipelineTask.Stages, pipelineTask.ConfigPayload.BuildConcurrency)
+	} else {
+		// Only serial is supported between stages
+		// If the stage status is StatusFailed/StatusCancelled/StatusTimeout, other than the extension stage
+		// and the stages with always_run policy will not be executed
+		isSkip := false
+		finished := make(map[string]*common.Stage)
+		for stagePosition, stage := range pipelineTask.Stages {
+			if stage.AfterAll {
+				continue
+			}
+
+			// skipped stages are finished as well, so that the conditions of later stages can refer to them
+			if isSkip && stage.TaskType != config.TaskExtension && !stageAlwaysRun(stage) {
+				skipPipelineStage("earlier stage failed", pipelineTask, stagePosition, xl)
+				h.SendAck()
+				finished[stageKey(stage)] = stage
+				continue
+			}
+			if reason := checkStageCondition(stage, pipelineTask, finished); reason != "" {
+				skipPipelineStage(reason, pipelineTask, stagePosition, xl)
+				h.SendAck()
+				finished[stageKey(stage)] = stage
+				continue
+			}
 
-		if !isSkip || stage.TaskType == config.TaskExtension {
 			h.runStage(stagePosition, stage, pipelineTask.ConfigPayload.BuildConcurrency)
-		}
+			finished[stageKey(stage)] = stage
 
-		if stage.Status == config.StatusFailed || stage.Status == config.StatusCancelled || stage.Status == config.StatusTimeout {
-			isSkip = true
-			continue
+			if stageFailed(stage) {
+				isSkip = true
+				continue
+			}
 		}
 	}
 
@@ -448,7 +469,55 @@ func (h *ExecHandler) execute(ctx context.Context, pipelineTask *task.Task, pipe
 // 执行单个subtask，并将subtask执行状态更新到pipelineTask中
 // 返回Task状态+Error，Task Status将在Stage Level进行Aggregation到Stage Status
 // SubTask终止状态包括：disabled, passed, skipped, timeout, failed, cancelled.
+// 如果stage配置了重试策略，失败的subtask会在退避之后重新执行，每次执行的结果记录在subtask的retry_attempts中
 func (h *ExecHandler) executeTask(taskCtx context.Context, plugin plugins.TaskPlugin, subTask map[string]interface{}, pos int, servicename string, xl *zap.SugaredLogger) (config.Status, error) {
+	retry := stageRetryPolicy(pipelineTask, pos)
+	if retry == nil {
+		return h.executeTaskOnce(taskCtx, plugin, subTask, pos, servicename, xl)
+	}
+
+	fileName := subTaskFileName(plugin, servicename)
+	var attempts []*subTaskAttempt
+	for attempt := 1; ; attempt++ {
+		startTime := time.Now().Unix()
+		status, err := h.executeTaskOnce(taskCtx, plugin, subTask, pos, servicename, xl)
+		if status == config.StatusDisabled {
+			return status, err
+		}
+
+		errMsg, reason := subTaskFailure(plugin)
+		record := &subTaskAttempt{
+			Attempt:   attempt,
+			Status:    status,
+			Error:     errMsg,
+			StartTime: startTime,
+			EndTime:   time.Now().Unix(),
+		}
+		if status == config.StatusFailed || status == config.StatusTimeout {
+			record.FailureClass = classifyFailure(status, reason)
+		}
+		retrying := shouldRetry(retry, attempt, status, record.FailureClass)
+		if !retrying && attempt == 1 {
+			return status, err
+		}
+
+		record.LogFile = archiveAttemptLog(pipelineTask, fileName, attempt, xl)
+		attempts = append(attempts, record)
+		recordRetryAttempts(attempts, pipelineTask, pos, servicename)
+		h.SendAck()
+		if !retrying {
+			return status, err
+		}
+
+		backoff := retryBackoff(retry, attempt)
+		xl.Infof("attempt %d of sub task %s failed with %s, retry in %s", attempt, servicename, record.FailureClass, backoff)
+		if !waitRetryBackoff(taskCtx, backoff) {
+			return status, err
+		}
+	}
+}
+
+func (h *ExecHandler) executeTaskOnce(taskCtx context.Context, plugin plugins.TaskPlugin, subTask map[string]interface{}, pos int, servicename string, xl *zap.SugaredLogger) (config.Status, error) {
 	//设置Plugin执行参数：JOBNAME; 设置plugin logger;设置plugin log文件名称
 	//e.g. build task JOBNAME = pipelinename-taskid-buildv2-bsonId
 	//e.g. build task FILENAME(singgle模式) = pipelinename-taskid-buildv2-servicename
@@ -474,25 +543,7 @@ func (h *ExecHandler) executeTask(taskCtx context.Context, plugin plugins.TaskPl
 		jobName = strings.TrimLeft(jobName[len(jobName)-57:], "-")
 	}
 
-	fileName := strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.SingleType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), servicename)),
-		"_", "-", -1)
-	if pipelineTask.Type == config.WorkflowType {
-		//fileName = fmt.Sprintf("%s-%s", pipeline.WorkflowType, fileName)
-		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.WorkflowType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), servicename)),
-			"_", "-", -1)
-	} else if pipelineTask.Type == config.TestType {
-		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.TestType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), servicename)),
-			"_", "-", -1)
-	} else if pipelineTask.Type == config.ServiceType {
-		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.ServiceType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), servicename)),
-			"_", "-", -1)
-	} else if pipelineTask.Type == config.WorkflowTypeV3 {
-		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.WorkflowTypeV3, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), fmt.Sprintf("%s-job", pipelineTask.PipelineName))),
-			"_", "-", -1)
-	} else if pipelineTask.Type == config.ArtifactType {
-		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s", config.ArtifactType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type())),
-			"_", "-", -1)
-	}
+	fileName := subTaskFileName(plugin, servicename)
 	plugin.Init(jobName, fileName, xl)
 
 	//设置待执行的subtask
@@ -584,6 +635,35 @@ func (h *ExecHandler) executeTask(taskCtx context.Context, plugin plugins.TaskPl
 	return plugin.Status(), nil
 }
 
+// subTaskFileName returns the name of the log file of the sub task, without the .log suffix
+func subTaskFileName(plugin plugins.TaskPlugin, servicename string) string {
+	fileName := strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.SingleType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), servicename)),
+		"_", "-", -1)
+	if pipelineTask.Type == config.WorkflowType {
+		//fileName = fmt.Sprintf("%s-%s", pipeline.WorkflowType, fileName)
+		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.WorkflowType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), servicename)),
+			"_", "-", -1)
+	} else if pipelineTask.Type == config.TestType {
+		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.TestType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), servicename)),
+			"_", "-", -1)
+	} else if pipelineTask.Type == config.ServiceType {
+		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.ServiceType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), servicename)),
+			"_", "-", -1)
+	} else if pipelineTask.Type == config.WorkflowTypeV3 {
+		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.WorkflowTypeV3, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), fmt.Sprintf("%s-job", pipelineTask.PipelineName))),
+			"_", "-", -1)
+		// stages of the same type run in parallel in a DAG, use the node name to keep their logs apart
+		if len(pipelineTask.DAG) > 0 {
+			fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s-%s", config.WorkflowTypeV3, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type(), servicename)),
+				"_", "-", -1)
+		}
+	} else if pipelineTask.Type == config.ArtifactType {
+		fileName = strings.Replace(strings.ToLower(fmt.Sprintf("%s-%s-%d-%s", config.ArtifactType, pipelineTask.PipelineName, pipelineTask.TaskID, plugin.Type())),
+			"_", "-", -1)
+	}
+	return fileName
+}
+
 func Logger(pipelineTask *task.Task) *zap.SugaredLogger {
 	l := log.Logger()
 	if pipelineTask != nil {
@@ -46,10 +46,14 @@ import (
 // Stage Map: *Stage -> map [service->subtask]
 func transformToStages(pipelineTask *task.Task, xl *zap.SugaredLogger) error {
 	var pipelineStages []*common.Stage
+	if len(pipelineTask.DAG) > 0 && len(pipelineTask.DAG) != len(pipelineTask.SubTasks) {
+		pipelineTask.Status = config.StatusFailed
+		return fmt.Errorf("dag has %d nodes but there are %d sub tasks", len(pipelineTask.DAG), len(pipelineTask.SubTasks))
+	}
 	// 工作流1.0，单服务工作流，SubTasks一维数组
 	// Transform into stages and assign to stages
 	// Task的数据结构中如果没有赋值Type，也按照1.0处理
-	for _, subTask := range pipelineTask.SubTasks {
+	for i, subTask := range pipelineTask.SubTasks {
 		subTaskPreview, err := plugins.ToPreview(subTask)
 		if err != nil {
 			xl.Errorf("preview error: %v", err)
@@ -66,6 +70,15 @@ func transformToStages(pipelineTask *task.Task, xl *zap.SugaredLogger) error {
 				pipelineTask.ServiceName: subTask,
 			},
 		}
+		// 自定义工作流按照DAG执行时，使用节点名称作为subtask的key，以区分各个stage的job、日志和状态
+		// 注意：每个stage的workspace为pipelineCtx.Workspace/<stage.Name>，stage之间不共享workspace
+		if len(pipelineTask.DAG) > 0 {
+			stage.Name = pipelineTask.DAG[i].Name
+			stage.DependsOn = pipelineTask.DAG[i].DependsOn
+			stage.SubTasks = map[string]map[string]interface{}{
+				stage.Name: subTask,
+			}
+		}
 		pipelineStages = append(pipelineStages, stage)
 
 	}
@@ -125,6 +138,15 @@ func updatePipelineSubTask(t interface{}, pipelineTask *task.Task, pos int, serv
 	pipelineTask.RwLock.Lock()
 	defer pipelineTask.RwLock.Unlock()
 
+	// 保留重试过程中已经记录的每次执行结果
+	if pipelineTask.Type == config.SingleType || pipelineTask.Type == "" {
+		if pos < len(pipelineTask.SubTasks) {
+			keepRetryAttempts(pipelineTask.SubTasks[pos], subTask)
+		}
+	} else if pos < len(pipelineTask.Stages) && pipelineTask.Stages[pos] != nil {
+		keepRetryAttempts(pipelineTask.Stages[pos].SubTasks[servicename], subTask)
+	}
+
 	//没有Type的PipelineTask(老的结构)，按照1.0更新方式处理
 	if pipelineTask.Type == config.SingleType || pipelineTask.Type == "" {
 		xl.Info("pipeline type is single type: pipeline 1.0")
@@ -161,17 +183,30 @@ func updatePipelineSubTask(t interface{}, pipelineTask *task.Task, pos int, serv
 // 一个Stage执行结束后，更新PipelineTask的Stage状态
 func updatePipelineStageStatus(stageStatus config.Status, pipelineTask *task.Task, pos int, xl *zap.SugaredLogger) {
 	xl.Infof("updating pipeline task, stage status: %s, stage position: %d", stageStatus, pos)
+	pipelineTask.RwLock.Lock()
+	defer pipelineTask.RwLock.Unlock()
+
 	if pipelineTask.Stages[pos] == nil {
 		pipelineTask.Stages[pos] = &common.Stage{}
 	}
 	pipelineTask.Stages[pos].Status = stageStatus
 }
 
+// skipPipelineStage marks a stage which is not going to run as skipped, the reason is kept in the stage desc
+func skipPipelineStage(reason string, pipelineTask *task.Task, pos int, xl *zap.SugaredLogger) {
+	xl.Infof("skip stage at position %d: %s", pos, reason)
+	pipelineTask.RwLock.Lock()
+	defer pipelineTask.RwLock.Unlock()
+
+	pipelineTask.Stages[pos].Status = config.StatusSkipped
+	pipelineTask.Stages[pos].Desc = reason
+}
+
 func updatePipelineStatus(pipelineTask *task.Task, xl *zap.SugaredLogger) {
 	pipelineTask.EndTime = time.Now().Unix()
 	//这里不需要处理1.0还是2.0了，因为stage内容已经都更新了，所以根据stage来判断就好
 	for _, stage := range pipelineTask.Stages {
-		if stage.Status == config.StatusFailed || stage.Status == config.StatusCancelled || stage.Status == config.StatusTimeout {
+		if stageFailed(stage) {
 			pipelineTask.Status = stage.Status
 			xl.Infof("Pipeline task completed abnormal: %s:%d:%s %+v", pipelineTask.PipelineName, pipelineTask.TaskID, pipelineTask.Status, pipelineTask)
 			return
@@ -571,6 +606,7 @@ func initTaskPlugins(execHandler *ExecHandler) {
 		config.TaskTrigger:         plugins.InitializeTriggerTaskPlugin,
 		config.TaskArtifactPackage: plugins.InitializeArtifactPackagePlugin,
 		config.TaskExtension:       plugins.InitializeExtensionTaskPlugin,
+		config.TaskApproval:        plugins.InitializeApprovalTaskPlugin,
 	}
 	for name, pluginInitiator := range pluginConf {
 		registerTaskPlugin(execHandler, name, pluginInitiator)
@@ -0,0 +1,252 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package taskplugin
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	// ApprovalTaskTimeout ...
+	ApprovalTaskTimeout = 60 * 60 * 24 // 24 hours
+)
+
+// InitializeApprovalTaskPlugin to initialize approval task plugin, and return reference
+func InitializeApprovalTaskPlugin(taskType config.TaskType) TaskPlugin {
+	return &ApprovalTaskPlugin{
+		Name: taskType,
+	}
+}
+
+// ApprovalTaskPlugin pauses the workflow task until the approvers approve or reject it
+type ApprovalTaskPlugin struct {
+	Name         config.TaskType
+	JobName      string
+	FileName     string
+	Task         *task.Approval
+	Log          *zap.SugaredLogger
+	ack          func()
+	pipelineName string
+	taskId       int64
+}
+
+// approvalDecision is the approve or reject operation recorded by aslan
+type approvalDecision struct {
+	UserName   string `json:"user_name"`
+	Approved   bool   `json:"approved"`
+	Comment    string `json:"comment"`
+	CreateTime int64  `json:"create_time"`
+}
+
+func (p *ApprovalTaskPlugin) SetAckFunc(ack func()) {
+	p.ack = ack
+}
+
+// Init ...
+func (p *ApprovalTaskPlugin) Init(jobname, filename string, xl *zap.SugaredLogger) {
+	p.JobName = jobname
+	p.Log = xl
+	p.FileName = filename
+}
+
+func (p *ApprovalTaskPlugin) Type() config.TaskType {
+	return p.Name
+}
+
+// Status ...
+func (p *ApprovalTaskPlugin) Status() config.Status {
+	return p.Task.TaskStatus
+}
+
+// SetStatus ...
+func (p *ApprovalTaskPlugin) SetStatus(status config.Status) {
+	p.Task.TaskStatus = status
+}
+
+// TaskTimeout ...
+func (p *ApprovalTaskPlugin) TaskTimeout() int {
+	if p.Task.Timeout == 0 {
+		p.Task.Timeout = ApprovalTaskTimeout
+	} else {
+		if !p.Task.IsRestart {
+			p.Task.Timeout = p.Task.Timeout * 60
+		}
+	}
+	return p.Task.Timeout
+}
+
+func (p *ApprovalTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineCtx *task.PipelineCtx, serviceName string) {
+	p.pipelineName = pipelineTask.PipelineName
+	p.taskId = pipelineTask.TaskID
+	p.Task.ApproveResults = nil
+
+	url := fmt.Sprintf("/api/workflow/workflowtask/approval/id/%d/pipelines/%s/notify", p.taskId, p.pipelineName)
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+	if _, err := httpClient.Post(url); err != nil {
+		p.Log.Error(err)
+		p.Task.TaskStatus = config.StatusFailed
+		p.Task.Error = fmt.Sprintf("failed to notify approvers: %s", err)
+		return
+	}
+	p.Log.Infof("waiting for approvers %v of task %s:%d", p.Task.Approvers, p.pipelineName, p.taskId)
+}
+
+// Wait ...
+func (p *ApprovalTaskPlugin) Wait(ctx context.Context) {
+	timeout := time.After(time.Duration(p.TaskTimeout()) * time.Second)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.Task.TaskStatus = config.StatusCancelled
+			return
+		case <-timeout:
+			p.Task.TaskStatus = config.StatusTimeout
+			p.Task.Error = "approval timeout"
+			return
+		default:
+			time.Sleep(time.Second * 3)
+			decisions, err := p.getDecisions()
+			if err != nil {
+				p.Log.Warnf("failed to get approval decisions of task %s:%d: %s", p.pipelineName, p.taskId, err)
+				continue
+			}
+
+			if p.updateApproveResults(decisions) && p.ack != nil {
+				p.ack()
+			}
+			if p.IsTaskDone() {
+				return
+			}
+		}
+	}
+}
+
+// updateApproveResults records the decisions into the task and decides the task status,
+// it returns true if the results have been changed
+func (p *ApprovalTaskPlugin) updateApproveResults(decisions []*approvalDecision) bool {
+	results := make([]*task.ApproveResult, 0, len(decisions))
+	approved := 0
+	for _, d := range decisions {
+		results = append(results, &task.ApproveResult{
+			UserName:      d.UserName,
+			Approved:      d.Approved,
+			Comment:       d.Comment,
+			OperationTime: d.CreateTime,
+		})
+		if !d.Approved {
+			p.Task.TaskStatus = config.StatusFailed
+			p.Task.Error = fmt.Sprintf("rejected by %s", d.UserName)
+		} else {
+			approved++
+		}
+	}
+
+	needed := p.Task.NeededApprovers
+	if needed <= 0 {
+		needed = 1
+	}
+	if p.Task.TaskStatus == config.StatusRunning && approved >= needed {
+		p.Task.TaskStatus = config.StatusPassed
+	}
+
+	if reflect.DeepEqual(results, p.Task.ApproveResults) || (len(results) == 0 && len(p.Task.ApproveResults) == 0) {
+		return false
+	}
+	p.Task.ApproveResults = results
+	return true
+}
+
+func (p *ApprovalTaskPlugin) getDecisions() ([]*approvalDecision, error) {
+	url := fmt.Sprintf("/api/workflow/workflowtask/approval/id/%d/pipelines/%s", p.taskId, p.pipelineName)
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+
+	decisions := make([]*approvalDecision, 0)
+	_, err := httpClient.Get(url, httpclient.SetResult(&decisions))
+	if err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+// Complete ...
+func (p *ApprovalTaskPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serviceName string) {
+}
+
+// SetTask ...
+func (p *ApprovalTaskPlugin) SetTask(t map[string]interface{}) error {
+	task, err := ToApprovalTask(t)
+	if err != nil {
+		return err
+	}
+	p.Task = task
+	return nil
+}
+
+// GetTask ...
+func (p *ApprovalTaskPlugin) GetTask() interface{} {
+	return p.Task
+}
+
+// IsTaskDone ...
+func (p *ApprovalTaskPlugin) IsTaskDone() bool {
+	if p.Task.TaskStatus != config.StatusCreated && p.Task.TaskStatus != config.StatusRunning {
+		return true
+	}
+	return false
+}
+
+// IsTaskFailed ...
+func (p *ApprovalTaskPlugin) IsTaskFailed() bool {
+	if p.Task.TaskStatus == config.StatusFailed || p.Task.TaskStatus == config.StatusTimeout || p.Task.TaskStatus == config.StatusCancelled {
+		return true
+	}
+	return false
+}
+
+// SetStartTime ...
+func (p *ApprovalTaskPlugin) SetStartTime() {
+	p.Task.StartTime = time.Now().Unix()
+}
+
+// SetEndTime ...
+func (p *ApprovalTaskPlugin) SetEndTime() {
+	p.Task.EndTime = time.Now().Unix()
+}
+
+// IsTaskEnabled ...
+func (p *ApprovalTaskPlugin) IsTaskEnabled() bool {
+	return p.Task.Enabled
+}
+
+// ResetError ...
+func (p *ApprovalTaskPlugin) ResetError() {
+	p.Task.Error = ""
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package taskplugin
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+)
+
+func approvalPluginForTest(neededApprovers int) *ApprovalTaskPlugin {
+	plugin := InitializeApprovalTaskPlugin(config.TaskApproval).(*ApprovalTaskPlugin)
+	plugin.Task = &task.Approval{
+		TaskType:        config.TaskApproval,
+		Enabled:         true,
+		TaskStatus:      config.StatusRunning,
+		Approvers:       []string{"alice", "bob"},
+		NeededApprovers: neededApprovers,
+	}
+	return plugin
+}
+
+func TestApprovalTaskPlugin_UpdateApproveResults_Waiting(t *testing.T) {
+	assert := assert.New(t)
+	plugin := approvalPluginForTest(2)
+
+	assert.False(plugin.updateApproveResults(nil))
+	assert.True(plugin.updateApproveResults([]*approvalDecision{{UserName: "alice", Approved: true, CreateTime: 1}}))
+	assert.Equal(config.StatusRunning, plugin.Task.TaskStatus)
+	assert.Len(plugin.Task.ApproveResults, 1)
+	assert.False(plugin.updateApproveResults([]*approvalDecision{{UserName: "alice", Approved: true, CreateTime: 1}}))
+}
+
+func TestApprovalTaskPlugin_UpdateApproveResults_Passed(t *testing.T) {
+	assert := assert.New(t)
+	plugin := approvalPluginForTest(0)
+
+	plugin.updateApproveResults([]*approvalDecision{{UserName: "bob", Approved: true, CreateTime: 1}})
+	assert.Equal(config.StatusPassed, plugin.Task.TaskStatus)
+	assert.Equal("bob", plugin.Task.ApproveResults[0].UserName)
+}
+
+func TestApprovalTaskPlugin_UpdateApproveResults_Rejected(t *testing.T) {
+	assert := assert.New(t)
+	plugin := approvalPluginForTest(1)
+
+	plugin.updateApproveResults([]*approvalDecision{
+		{UserName: "alice", Approved: true, CreateTime: 1},
+		{UserName: "bob", Approved: false, CreateTime: 2},
+	})
+	assert.Equal(config.StatusFailed, plugin.Task.TaskStatus)
+	assert.True(plugin.IsTaskFailed())
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/updater"
 )
@@ -51,6 +52,9 @@ func InitializeArtifactTaskPlugin(taskType config.TaskType) TaskPlugin {
 		kubeClient: krkubeclient.Client(),
 		clientset:  krkubeclient.Clientset(),
 		restConfig: krkubeclient.RESTConfig(),
+		httpClient: httpclient.New(
+			httpclient.SetHostURL(zadigconfig.AslanServiceAddress()),
+		),
 	}
 }
 
@@ -63,8 +67,11 @@ type ArtifactDeployTaskPlugin struct {
 	kubeClient    client.Client
 	clientset     kubernetes.Interface
 	restConfig    *rest.Config
+	httpClient    *httpclient.Client
 	Task          *task.Build
 	Log           *zap.SugaredLogger
+	// VerifyImageSignature refuses to deploy the built image if it is not signed
+	VerifyImageSignature bool
 
 	ack func()
 }
@@ -112,6 +119,7 @@ func (p *ArtifactDeployTaskPlugin) SetBuildStatusCompleted(status config.Status)
 }
 
 func (p *ArtifactDeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineCtx *task.PipelineCtx, serviceName string) {
+	p.VerifyImageSignature = pipelineTask.ConfigPayload.VerifyImageSignature
 	switch p.Task.ClusterID {
 	case setting.LocalClusterID:
 		p.KubeNamespace = zadigconfig.Namespace()
@@ -292,6 +300,14 @@ func (p *ArtifactDeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.T
 // Wait ...
 func (p *ArtifactDeployTaskPlugin) Wait(ctx context.Context) {
 	status := waitJobEndWithFile(ctx, p.TaskTimeout(), p.KubeNamespace, p.JobName, true, p.kubeClient, p.clientset, p.restConfig, p.Log)
+	if status == config.StatusPassed && p.VerifyImageSignature {
+		if err := verifyImageSignature(p.httpClient, p.Task.JobCtx.Image); err != nil {
+			msg := fmt.Sprintf("image %s failed signature verification: %v", p.Task.JobCtx.Image, err)
+			p.Log.Error(msg)
+			p.Task.Error = msg
+			status = config.StatusFailed
+		}
+	}
 	p.SetBuildStatusCompleted(status)
 
 	if status == config.StatusPassed {
@@ -38,6 +38,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/updater"
 	"github.com/koderover/zadig/pkg/types"
@@ -54,6 +55,9 @@ func InitializeBuildTaskPlugin(taskType config.TaskType) TaskPlugin {
 		kubeClient: krkubeclient.Client(),
 		clientset:  krkubeclient.Clientset(),
 		restConfig: krkubeclient.RESTConfig(),
+		httpClient: httpclient.New(
+			httpclient.SetHostURL(zadigconfig.AslanServiceAddress()),
+		),
 	}
 }
 
@@ -66,6 +70,7 @@ type BuildTaskPlugin struct {
 	kubeClient    client.Client
 	clientset     kubernetes.Interface
 	restConfig    *rest.Config
+	httpClient    *httpclient.Client
 	Task          *task.Build
 	Log           *zap.SugaredLogger
 
@@ -123,11 +128,26 @@ func (p *BuildTaskPlugin) SetBuildStatusCompleted(status config.Status) {
 
 //TODO: Binded Archive File logic
 func (p *BuildTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineCtx *task.PipelineCtx, serviceName string) {
+	// The key is computed before the task specific variables are added to the envs
+	if p.Task.ReuseResult && p.Task.ResultKey == "" {
+		p.Task.ResultKey = buildResultKey(p.Task)
+	}
+	if p.Task.ResultKey != "" && (pipelineTask.WorkflowArgs == nil || !pipelineTask.WorkflowArgs.ForceRebuild) {
+		result, err := p.findBuildResult(p.Task.ResultKey)
+		if err != nil {
+			p.Log.Warnf("failed to find build result of %s, build it: %s", p.Task.ResultKey, err)
+		} else if result != nil {
+			p.reuseBuildResult(pipelineTask, result)
+			return
+		}
+	}
+
 	if p.Task.CacheEnable && !pipelineTask.ConfigPayload.ResetCache {
 		pipelineCtx.CacheEnable = true
 		pipelineCtx.Cache = p.Task.Cache
 		pipelineCtx.CacheDirType = p.Task.CacheDirType
 		pipelineCtx.CacheUserDir = p.Task.CacheUserDir
+		pipelineCtx.CacheDefaultBranch = p.Task.CacheDefaultBranch
 	} else {
 		pipelineCtx.CacheEnable = false
 	}
@@ -174,6 +194,10 @@ func (p *BuildTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipe
 			dockerHost = strings.Replace(pipelineTask.DockerHost, replaceDindServer, replaceDindServer+"."+pipelineTask.ConfigPayload.Build.KubeNamespace, 1)
 		}
 	}
+	// daemonless image builders run in their own container of the job, the job does not connect to dind
+	if daemonlessImageBuild(p.Task.JobCtx.DockerBuildCtx) {
+		dockerHost = ""
+	}
 	pipelineCtx.DockerHost = dockerHost
 
 	if pipelineTask.Type == config.WorkflowType {
@@ -385,6 +409,8 @@ func (p *BuildTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipe
 		}
 
 		job.Namespace = p.KubeNamespace
+		addImageBuilderContainer(job, p.Task.JobCtx.DockerBuildCtx)
+		applyJobScheduling(job, jobScheduling(p.Task.ClusterID, p.Task.Scheduling, pipelineTask.ConfigPayload.K8SClusters))
 
 		// Set imagePullSecrets of the private image registry integrated with KodeRover into the namespace.
 		if err := createOrUpdateRegistrySecrets(p.KubeNamespace, pipelineTask.ConfigPayload.RegistryID, p.Task.Registries, p.kubeClient); err != nil {
@@ -412,8 +438,15 @@ func (p *BuildTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipe
 }
 
 func (p *BuildTaskPlugin) Wait(ctx context.Context) {
+	if p.Task.ReusedResult != nil {
+		return
+	}
+
 	status := waitJobEndWithFile(ctx, p.TaskTimeout(), p.KubeNamespace, p.JobName, true, p.kubeClient, p.clientset, p.restConfig, p.Log)
 	p.SetBuildStatusCompleted(status)
+	if status == config.StatusFailed || status == config.StatusTimeout {
+		p.Task.FailureReason = jobFailureReason(p.KubeNamespace, p.JobName, p.kubeClient)
+	}
 
 	if status == config.StatusPassed {
 		if p.Task.DockerBuildStatus == nil {
@@ -441,6 +474,10 @@ func (p *BuildTaskPlugin) Wait(ctx context.Context) {
 }
 
 func (p *BuildTaskPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serviceName string) {
+	if p.Task.ReusedResult != nil {
+		return
+	}
+
 	jobLabel := &JobLabel{
 		PipelineName: pipelineTask.PipelineName,
 		ServiceName:  serviceName,
@@ -462,14 +499,45 @@ func (p *BuildTaskPlugin) Complete(ctx context.Context, pipelineTask *task.Task,
 		}()
 	}()
 
-	err := saveContainerLog(pipelineTask, p.KubeNamespace, p.Task.ClusterID, p.FileName, jobLabel, p.kubeClient)
+	buf, err := getContainerLog(pipelineTask, p.KubeNamespace, p.Task.ClusterID, jobLabel, p.kubeClient)
 	if err != nil {
 		p.Log.Error(err)
 		p.Task.Error = err.Error()
 		return
 	}
+	p.Task.Steps = types.ParseBuildSteps(buf.String())
+	if reason := stepsFailureReason(p.Task.Steps); reason != "" {
+		p.Task.FailureReason = reason
+	}
+	p.Task.CacheResult = types.ParseBuildCache(buf.String())
+	if provenances := types.ParseImageProvenances(buf.String()); len(provenances) > 0 {
+		p.Task.Provenance = provenances[0]
+	}
+	if dockerBuildCtx := p.Task.JobCtx.DockerBuildCtx; dockerBuildCtx != nil && dockerBuildCtx.Provenance != nil && dockerBuildCtx.Provenance.Sign &&
+		p.Task.TaskStatus == config.StatusPassed {
+		if p.Task.Provenance == nil {
+			p.Task.Provenance = &types.ImageProvenance{Image: dockerBuildCtx.ImageName}
+		}
+		if err := signImage(p.httpClient, p.Task.Provenance); err != nil {
+			p.Log.Error(err)
+			p.Task.TaskStatus = config.StatusFailed
+			p.Task.Error = err.Error()
+		}
+	}
+
+	if err := uploadContainerLog(pipelineTask, p.FileName, buf); err != nil {
+		p.Log.Error(err)
+		p.Task.Error = err.Error()
+		return
+	}
 
 	p.Task.LogFile = p.FileName
+
+	if p.Task.ResultKey != "" && p.Task.TaskStatus == config.StatusPassed {
+		if err := p.saveBuildResult(pipelineTask, serviceName); err != nil {
+			p.Log.Warnf("failed to save build result of %s: %s", p.Task.ResultKey, err)
+		}
+	}
 }
 
 func (p *BuildTaskPlugin) SetTask(t map[string]interface{}) error {
@@ -514,6 +582,7 @@ func (p *BuildTaskPlugin) IsTaskEnabled() bool {
 
 func (p *BuildTaskPlugin) ResetError() {
 	p.Task.Error = ""
+	p.Task.FailureReason = ""
 }
 
 // Note: Since there are few environment variables and few variables to be replaced,
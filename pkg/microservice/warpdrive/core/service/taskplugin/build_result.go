@@ -0,0 +1,201 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// volatileBuildEnvs are injected per task and never change the content of the image
+var volatileBuildEnvs = sets.NewString("IMAGE", "PKG_FILE", "BUILD_URL", "DIST_DIR")
+
+// imageFields are the fields of sub tasks in later stages which refer to the image built by the build task
+var imageFields = []string{"image", "image_name", "image_test"}
+
+type buildResultCache struct {
+	Key           string `json:"key"`
+	ProductName   string `json:"product_name"`
+	ServiceName   string `json:"service_name"`
+	ServiceModule string `json:"service_module"`
+	Image         string `json:"image"`
+	PipelineName  string `json:"pipeline_name"`
+	TaskID        int64  `json:"task_id"`
+
+	Provenance *types.ImageProvenance `json:"provenance,omitempty"`
+}
+
+// buildResultKey returns the content key of the build, an empty key means the result of the build can not be reused.
+// Only docker builds of fixed commits are cached, a build of a pull request or of a branch without a resolved
+// commit may build different code each time.
+func buildResultKey(t *task.Build) string {
+	if t.JobCtx.DockerBuildCtx == nil || t.JobCtx.UploadPkg || t.ServiceType == setting.PMDeployType {
+		return ""
+	}
+	if len(t.JobCtx.Builds) == 0 {
+		return ""
+	}
+
+	repos := make([]string, 0, len(t.JobCtx.Builds))
+	for _, repo := range t.JobCtx.Builds {
+		if repo.CommitID == "" || repo.PR > 0 {
+			return ""
+		}
+		repos = append(repos, strings.Join([]string{repo.Source, repo.RepoOwner, repo.RepoName, repo.Branch, repo.Tag, repo.CheckoutPath, repo.CommitID}, "/"))
+	}
+	sort.Strings(repos)
+
+	envs := make([]string, 0, len(t.JobCtx.EnvVars))
+	for _, env := range t.JobCtx.EnvVars {
+		if volatileBuildEnvs.Has(env.Key) {
+			continue
+		}
+		envs = append(envs, env.Key+"="+env.Value)
+	}
+	sort.Strings(envs)
+
+	installs := make([]string, 0, len(t.InstallCtx))
+	for _, install := range t.InstallCtx {
+		installs = append(installs, strings.Join([]string{install.Name, install.Version, install.Scripts, install.BinPath, install.DownloadPath, strings.Join(install.Envs, ","), fmt.Sprint(install.UpdateTime)}, "/"))
+	}
+	sort.Strings(installs)
+
+	h := sha256.New()
+	write := func(name string, values ...string) {
+		fmt.Fprintf(h, "%s:%d\n", name, len(values))
+		for _, v := range values {
+			fmt.Fprintf(h, "%q\n", v)
+		}
+	}
+	write("service", t.ProductName, t.Service, t.ServiceName)
+	write("repos", repos...)
+	write("revision", fmt.Sprint(t.BuildRevision))
+	write("base_image", t.ImageFrom, t.BuildOS)
+	write("envs", envs...)
+	write("installs", installs...)
+	dockerBuild := t.JobCtx.DockerBuildCtx
+	// the image is reused only if it is in the registry the build pushes to
+	write("repository", imageRepository(dockerBuild.ImageName))
+	write("docker_build", dockerBuild.Source, dockerBuild.WorkDir, dockerBuild.DockerFile, dockerBuild.BuildArgs, dockerBuild.DockerTemplateContent)
+	write("platforms", dockerBuild.Platforms...)
+	if dockerBuild.Provenance.Enabled() {
+		write("provenance", string(dockerBuild.Provenance.SBOMFormat), fmt.Sprint(dockerBuild.Provenance.Sign))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// imageRepository returns the image without its tag or digest, e.g. registry.io/ns/app for registry.io/ns/app:v1
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// findBuildResult returns the previous build result with the key, or nil if there is none.
+// Aslan only returns a result whose image is still in the registry.
+func (p *BuildTaskPlugin) findBuildResult(key string) (*buildResultCache, error) {
+	res := new(buildResultCache)
+	if _, err := p.httpClient.Get(fmt.Sprintf("/api/workflow/build/result-cache/%s", key), httpclient.SetResult(res)); err != nil {
+		if httpclient.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if res.Image == "" {
+		return nil, nil
+	}
+	return res, nil
+}
+
+func (p *BuildTaskPlugin) saveBuildResult(pipelineTask *task.Task, serviceName string) error {
+	body := &buildResultCache{
+		Key:           p.Task.ResultKey,
+		ProductName:   p.Task.ProductName,
+		ServiceName:   p.Task.Service,
+		ServiceModule: serviceName,
+		Image:         p.Task.JobCtx.Image,
+		PipelineName:  pipelineTask.PipelineName,
+		TaskID:        pipelineTask.TaskID,
+		Provenance:    p.Task.Provenance,
+	}
+	_, err := p.httpClient.Post("/api/workflow/build/result-cache", httpclient.SetBody(body))
+	return err
+}
+
+// reuseBuildResult marks the build as passed with the image of the previous build, the image is also
+// replaced in the sub tasks of later stages which would deploy, scan or release the image of this build
+func (p *BuildTaskPlugin) reuseBuildResult(pipelineTask *task.Task, result *buildResultCache) {
+	oldImage := p.Task.JobCtx.Image
+	if oldImage != "" && oldImage != result.Image {
+		replaceTaskImage(pipelineTask, oldImage, result.Image)
+	}
+
+	p.Task.JobCtx.Image = result.Image
+	if p.Task.JobCtx.DockerBuildCtx != nil {
+		p.Task.JobCtx.DockerBuildCtx.ImageName = result.Image
+	}
+	if p.Task.DockerBuildStatus != nil {
+		p.Task.DockerBuildStatus.ImageName = result.Image
+	}
+	if result.Provenance != nil {
+		p.Task.Provenance = result.Provenance
+	}
+	p.Task.ReusedResult = &task.ReusedBuildResult{
+		PipelineName: result.PipelineName,
+		TaskID:       result.TaskID,
+		Image:        result.Image,
+	}
+	p.Task.TaskStatus = config.StatusPassed
+	p.Log.Infof("build result of %s:%d is reused, image: %s", result.PipelineName, result.TaskID, result.Image)
+}
+
+func replaceTaskImage(pipelineTask *task.Task, oldImage, newImage string) {
+	pipelineTask.RwLock.Lock()
+	defer pipelineTask.RwLock.Unlock()
+
+	if pipelineTask.TaskArgs != nil && pipelineTask.TaskArgs.Deploy.Image == oldImage {
+		pipelineTask.TaskArgs.Deploy.Image = newImage
+	}
+	for _, stage := range pipelineTask.Stages {
+		if stage.TaskType == config.TaskBuild {
+			continue
+		}
+		for _, subTask := range stage.SubTasks {
+			for _, field := range imageFields {
+				if image, ok := subTask[field].(string); ok && image == oldImage {
+					subTask[field] = newImage
+				}
+			}
+		}
+	}
+}
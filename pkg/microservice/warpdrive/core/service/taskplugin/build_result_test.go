@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/common"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+func buildForResultTest() *task.Build {
+	return &task.Build{
+		TaskType:    config.TaskBuild,
+		Enabled:     true,
+		ProductName: "demo",
+		Service:     "svc",
+		ServiceName: "svc_svc",
+		BuildOS:     "focal",
+		ReuseResult: true,
+		JobCtx: task.JobCtx{
+			Builds: []*task.Repository{
+				{Source: "github", RepoOwner: "koderover", RepoName: "b", Branch: "main", CommitID: "222"},
+				{Source: "github", RepoOwner: "koderover", RepoName: "a", Branch: "main", CommitID: "111"},
+			},
+			EnvVars: []*task.KeyVal{
+				{Key: "IMAGE", Value: "koderover/svc:1"},
+				{Key: "GOFLAGS", Value: "-mod=vendor"},
+			},
+			Image:          "koderover/svc:1",
+			DockerBuildCtx: &task.DockerBuildCtx{WorkDir: ".", DockerFile: "Dockerfile", ImageName: "koderover/svc:1"},
+		},
+	}
+}
+
+func TestBuildResultKey(t *testing.T) {
+	assert := assert.New(t)
+
+	key := buildResultKey(buildForResultTest())
+	assert.NotEmpty(key)
+
+	// Order of repos and the tag of the image do not change the key
+	b := buildForResultTest()
+	b.JobCtx.Builds[0], b.JobCtx.Builds[1] = b.JobCtx.Builds[1], b.JobCtx.Builds[0]
+	b.JobCtx.Image = "koderover/svc:2"
+	b.JobCtx.EnvVars[0].Value = "koderover/svc:2"
+	b.JobCtx.DockerBuildCtx.ImageName = "koderover/svc:2"
+	assert.Equal(key, buildResultKey(b))
+
+	changes := map[string]func(b *task.Build){
+		"commit":   func(b *task.Build) { b.JobCtx.Builds[0].CommitID = "333" },
+		"revision": func(b *task.Build) { b.BuildRevision = 1 },
+		"env":      func(b *task.Build) { b.JobCtx.EnvVars[1].Value = "-mod=mod" },
+		"os":       func(b *task.Build) { b.BuildOS = "bionic" },
+		"docker":   func(b *task.Build) { b.JobCtx.DockerBuildCtx.BuildArgs = "--build-arg A=1" },
+		"registry": func(b *task.Build) { b.JobCtx.DockerBuildCtx.ImageName = "registry.io/koderover/svc:1" },
+		"install": func(b *task.Build) {
+			b.InstallCtx = []*task.Install{{Name: "go", Version: "1.16", Scripts: "install go"}}
+		},
+	}
+	for name, change := range changes {
+		b := buildForResultTest()
+		change(b)
+		assert.NotEqual(key, buildResultKey(b), name)
+	}
+
+	noKeys := map[string]func(b *task.Build){
+		"no commit":    func(b *task.Build) { b.JobCtx.Builds[0].CommitID = "" },
+		"pull request": func(b *task.Build) { b.JobCtx.Builds[0].PR = 1 },
+		"package only": func(b *task.Build) { b.JobCtx.DockerBuildCtx = nil },
+	}
+	for name, change := range noKeys {
+		b := buildForResultTest()
+		change(b)
+		assert.Empty(buildResultKey(b), name)
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("koderover/svc", imageRepository("koderover/svc:1"))
+	assert.Equal("registry.io:5000/koderover/svc", imageRepository("registry.io:5000/koderover/svc:1"))
+	assert.Equal("registry.io:5000/koderover/svc", imageRepository("registry.io:5000/koderover/svc"))
+	assert.Equal("koderover/svc", imageRepository("koderover/svc@sha256:abc"))
+}
+
+func TestBuildTaskPlugin_ReuseResult(t *testing.T) {
+	assert := assert.New(t)
+
+	aslan := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"key":"k","image":"koderover/svc:0","pipeline_name":"demo-workflow","task_id":3}`))
+	}))
+	defer aslan.Close()
+
+	plugin := &BuildTaskPlugin{
+		Name:       config.TaskBuild,
+		Task:       buildForResultTest(),
+		Log:        zap.NewNop().Sugar(),
+		httpClient: httpclient.New(httpclient.SetHostURL(aslan.URL)),
+	}
+	pipelineTask := &task.Task{
+		PipelineName: "demo-workflow",
+		TaskID:       4,
+		WorkflowArgs: &task.WorkflowTaskArgs{},
+		Stages: []*common.Stage{{
+			TaskType: config.TaskDeploy,
+			SubTasks: map[string]map[string]interface{}{
+				"svc": {"image": "koderover/svc:1"},
+			},
+		}},
+	}
+
+	plugin.Run(context.Background(), pipelineTask, &task.PipelineCtx{}, "svc")
+	assert.Equal(config.StatusPassed, plugin.Status())
+	assert.Equal("koderover/svc:0", plugin.Task.JobCtx.Image)
+	assert.Equal(&task.ReusedBuildResult{PipelineName: "demo-workflow", TaskID: 3, Image: "koderover/svc:0"}, plugin.Task.ReusedResult)
+	assert.Equal("koderover/svc:0", pipelineTask.Stages[0].SubTasks["svc"]["image"])
+}
@@ -67,9 +67,11 @@ type DeployTaskPlugin struct {
 	ReplaceImage string
 
 	httpClient *httpclient.Client
+	ack        func()
 }
 
-func (p *DeployTaskPlugin) SetAckFunc(func()) {
+func (p *DeployTaskPlugin) SetAckFunc(ack func()) {
+	p.ack = ack
 }
 
 const (
@@ -142,6 +144,8 @@ func (p *DeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, _ *
 		}
 	}()
 
+	p.Task.Rollout = nil
+
 	if pipelineTask.ConfigPayload.DeployClusterID != "" {
 		p.restConfig, err = kubeclient.GetRESTConfig(pipelineTask.ConfigPayload.HubServerAddr, pipelineTask.ConfigPayload.DeployClusterID)
 		if err != nil {
@@ -155,6 +159,13 @@ func (p *DeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, _ *
 			return
 		}
 	}
+	if pipelineTask.ConfigPayload.VerifyImageSignature {
+		if err = verifyImageSignature(p.httpClient, p.Task.Image); err != nil {
+			err = errors.WithMessagef(err, "image %s failed signature verification", p.Task.Image)
+			return
+		}
+	}
+
 	containerName := p.Task.ContainerName
 	containerName = strings.TrimSuffix(containerName, "_"+p.Task.ServiceName)
 
@@ -190,7 +201,7 @@ func (p *DeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, _ *
 		for _, deploy := range deployments {
 			for _, container := range deploy.Spec.Template.Spec.Containers {
 				if container.Name == containerName {
-					err = updater.UpdateDeploymentImage(deploy.Namespace, deploy.Name, containerName, p.Task.Image, p.kubeClient)
+					err = p.updateDeploymentImage(ctx, deploy, containerName)
 					if err != nil {
 						err = errors.WithMessagef(
 							err,
@@ -268,7 +279,7 @@ func (p *DeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, _ *
 			}
 			for _, container := range deployment.Spec.Template.Spec.Containers {
 				if container.Name == containerName {
-					err = updater.UpdateDeploymentImage(deployment.Namespace, deployment.Name, containerName, p.Task.Image, p.kubeClient)
+					err = p.updateDeploymentImage(ctx, deployment, containerName)
 					if err != nil {
 						err = errors.WithMessagef(
 							err,
@@ -309,6 +320,12 @@ func (p *DeployTaskPlugin) getService(ctx context.Context, name, serviceType, pr
 	return s, nil
 }
 
+// verifyImageSignature asks aslan to check the image against the configured signing key
+func verifyImageSignature(httpClient *httpclient.Client, image string) error {
+	_, err := httpClient.Post("/api/system/imageSigning/verify", httpclient.SetBody(map[string]string{"image": image}))
+	return err
+}
+
 // Wait ...
 func (p *DeployTaskPlugin) Wait(ctx context.Context) {
 	// skip waiting for reset image task
@@ -317,6 +334,17 @@ func (p *DeployTaskPlugin) Wait(ctx context.Context) {
 		return
 	}
 
+	if p.Task.Rollout != nil {
+		p.waitRollout(ctx)
+		return
+	}
+
+	defer func() {
+		if p.shouldRollback() {
+			p.rollbackImages()
+		}
+	}()
+
 	timeout := time.After(time.Duration(p.TaskTimeout()) * time.Second)
 
 	selector := labels.Set{setting.ProductLabel: p.Task.ProductName, setting.ServiceLabel: p.Task.ServiceName}.AsSelector()
@@ -335,6 +363,7 @@ func (p *DeployTaskPlugin) Wait(ctx context.Context) {
 				p.Task.Error = err.Error()
 				return
 			}
+			p.Task.FailureReason = podsFailureReason(pods)
 
 			var msg []string
 			for _, pod := range pods {
@@ -409,6 +438,14 @@ func (p *DeployTaskPlugin) Wait(ctx context.Context) {
 
 			if ready {
 				p.Task.TaskStatus = config.StatusPassed
+			} else if p.Task.RollbackOnFailure {
+				// fail fast instead of waiting for the timeout if the new image keeps crashing
+				if pods, e := getter.ListPods(p.Task.Namespace, selector, p.kubeClient); e == nil {
+					if crashed := crashLoopingContainers(pods, p.Task.Image); len(crashed) > 0 {
+						p.Task.TaskStatus = config.StatusFailed
+						p.Task.Error = fmt.Sprintf("containers are crash looping: %s", strings.Join(crashed, ", "))
+					}
+				}
 			}
 
 			if p.IsTaskDone() {
@@ -470,4 +507,5 @@ func (p *DeployTaskPlugin) IsTaskEnabled() bool {
 // ResetError ...
 func (p *DeployTaskPlugin) ResetError() {
 	p.Task.Error = ""
+	p.Task.FailureReason = ""
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/rest"
@@ -134,6 +135,14 @@ func (p *HelmDeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task,
 			return
 		}
 	}
+	if pipelineTask.ConfigPayload.VerifyImageSignature {
+		for _, sPlugin := range p.ContentPlugins {
+			if err = verifyImageSignature(p.httpClient, sPlugin.Task.Image); err != nil {
+				err = errors.WithMessagef(err, "image %s failed signature verification", sPlugin.Task.Image)
+				return
+			}
+		}
+	}
 	// all involved containers
 	containerNameSet := sets.NewString()
 	for _, sPlugin := range p.ContentPlugins {
@@ -313,6 +322,8 @@ func (p *HelmDeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task,
 	}
 
 	releaseName := p.Task.ReleaseName
+	// revision of the release before upgrade, used to roll back if the upgrade fails
+	previousRevision := 0
 
 	ensureUpgrade := func() error {
 		hrs, errHistory := helmClient.ListReleaseHistory(releaseName, 10)
@@ -330,6 +341,7 @@ func (p *HelmDeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task,
 		if rel.Info.Status.IsPending() {
 			return fmt.Errorf("failed to upgrade release: %s with exceptional status: %s", releaseName, rel.Info.Status)
 		}
+		previousRevision = lastDeployedRevision(hrs)
 		return nil
 	}
 
@@ -354,26 +366,32 @@ func (p *HelmDeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task,
 		MaxHistory:  10,
 	}
 
-	done := make(chan bool)
-	go func(chan bool) {
-		if _, err = helmClient.InstallOrUpgradeChart(ctx, &chartSpec); err != nil {
+	installCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, installErr := helmClient.InstallOrUpgradeChart(installCtx, &chartSpec)
+		done <- installErr
+	}()
+
+	select {
+	case err = <-done:
+		if err != nil {
 			err = errors.WithMessagef(
 				err,
 				"failed to upgrade helm chart %s/%s",
 				p.Task.Namespace, p.Task.ServiceName)
-			done <- false
-		} else {
-			done <- true
 		}
-	}(done)
-
-	select {
-	case <-done:
-		break
 	case <-time.After(chartSpec.Timeout + time.Minute):
+		// the upgrade must be stopped before the release is rolled back, otherwise both of them change the release
+		cancel()
+		<-done
 		err = fmt.Errorf("failed to upgrade relase: %s, timeout", chartSpec.ReleaseName)
 	}
 	if err != nil {
+		if p.Task.RollbackOnFailure && previousRevision > 0 {
+			err = p.rollbackRelease(helmClient, releaseName, previousRevision, chartSpec.Timeout, err)
+		}
 		return
 	}
 
@@ -400,6 +418,39 @@ func (p *HelmDeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task,
 	}
 }
 
+// lastDeployedRevision returns the latest revision which was deployed successfully, or 0 if there is none,
+// hrs must be sorted by revision in descending order
+func lastDeployedRevision(hrs []*release.Release) int {
+	for _, rel := range hrs {
+		if rel.Info == nil {
+			continue
+		}
+		if rel.Info.Status == release.StatusDeployed || rel.Info.Status == release.StatusSuperseded {
+			return rel.Version
+		}
+	}
+	return 0
+}
+
+// rollbackRelease rolls the release back to the given revision and returns the upgrade error annotated with the result
+func (p *HelmDeployTaskPlugin) rollbackRelease(helmClient helmclient.Client, releaseName string, revision int, timeout time.Duration, upgradeErr error) error {
+	hClient, ok := helmClient.(*helmtool.HelmClient)
+	if !ok {
+		return upgradeErr
+	}
+
+	p.Log.Infof("roll back release %s to revision %d", releaseName, revision)
+	if err := hClient.RollbackToRevision(releaseName, revision, timeout); err != nil {
+		return errors.WithMessagef(upgradeErr, "failed to roll back to revision %d: %s", revision, err)
+	}
+
+	p.Task.RolledBack = true
+	for _, sPlugin := range p.ContentPlugins {
+		sPlugin.Task.RolledBack = true
+	}
+	return errors.WithMessagef(upgradeErr, "rolled back to revision %d", revision)
+}
+
 func (p *HelmDeployTaskPlugin) getProductInfo(ctx context.Context, args *EnvArgs) (*types.Product, error) {
 	url := fmt.Sprintf("/api/environment/environments/%s/productInfo", args.EnvName)
 	prod := &types.Product{}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+)
+
+const crashLoopBackOff = "CrashLoopBackOff"
+
+// crashLoopingContainers returns the containers running the image which are in crash loop back off
+func crashLoopingContainers(pods []*corev1.Pod, image string) []string {
+	var res []string
+	for _, pod := range pods {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Image != image && !strings.HasSuffix(cs.Image, "/"+image) {
+				continue
+			}
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason == crashLoopBackOff {
+				res = append(res, fmt.Sprintf("%s/%s", pod.Name, cs.Name))
+			}
+		}
+	}
+	return res
+}
+
+// rollbackImages restores the images recorded before the deploy updated them
+func (p *DeployTaskPlugin) rollbackImages() {
+	var errs []string
+	for _, resource := range p.Task.ReplaceResources {
+		p.Log.Infof("roll back %s/%s/%s to %s", p.Task.Namespace, resource.Kind, resource.Name, resource.Origin)
+
+		var err error
+		switch resource.Kind {
+		case setting.Deployment:
+			err = updater.UpdateDeploymentImage(p.Task.Namespace, resource.Name, resource.Container, resource.Origin, p.kubeClient)
+		case setting.StatefulSet:
+			err = updater.UpdateStatefulSetImage(p.Task.Namespace, resource.Name, resource.Container, resource.Origin, p.kubeClient)
+		default:
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to roll back %s/%s: %s", resource.Kind, resource.Name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		p.Task.Error = strings.Join(append([]string{p.Task.Error}, errs...), "\n")
+		return
+	}
+	p.Task.RolledBack = true
+	p.Task.Error = strings.TrimPrefix(p.Task.Error+"\nrolled back to the previous images", "\n")
+}
+
+func (p *DeployTaskPlugin) shouldRollback() bool {
+	if !p.Task.RollbackOnFailure || len(p.Task.ReplaceResources) == 0 {
+		return false
+	}
+	return p.Task.TaskStatus == config.StatusFailed || p.Task.TaskStatus == config.StatusTimeout
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+)
+
+func podForRollbackTest(name, image, reason string) *corev1.Pod {
+	status := corev1.ContainerStatus{Name: "app", Image: image}
+	if reason != "" {
+		status.State.Waiting = &corev1.ContainerStateWaiting{Reason: reason}
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{status}},
+	}
+}
+
+func TestCrashLoopingContainers(t *testing.T) {
+	assert := assert.New(t)
+
+	pods := []*corev1.Pod{
+		podForRollbackTest("demo-1", "registry.io/demo:v2", crashLoopBackOff),
+		podForRollbackTest("demo-2", "registry.io/demo:v2", "ContainerCreating"),
+		podForRollbackTest("demo-3", "registry.io/demo:v1", crashLoopBackOff),
+	}
+
+	assert.Equal([]string{"demo-1/app"}, crashLoopingContainers(pods, "registry.io/demo:v2"))
+	assert.Empty(crashLoopingContainers(pods, "registry.io/demo:v3"))
+}
+
+func TestShouldRollback(t *testing.T) {
+	assert := assert.New(t)
+
+	p := &DeployTaskPlugin{Task: &task.Deploy{
+		TaskStatus:       config.StatusTimeout,
+		ReplaceResources: []task.Resource{{Name: "demo", Container: "app", Origin: "registry.io/demo:v1"}},
+	}}
+	assert.False(p.shouldRollback())
+
+	p.Task.RollbackOnFailure = true
+	assert.True(p.shouldRollback())
+
+	p.Task.TaskStatus = config.StatusPassed
+	assert.False(p.shouldRollback())
+}
+
+func TestLastDeployedRevision(t *testing.T) {
+	assert := assert.New(t)
+
+	rel := func(version int, status release.Status) *release.Release {
+		return &release.Release{Version: version, Info: &release.Info{Status: status}}
+	}
+
+	assert.Equal(0, lastDeployedRevision(nil))
+	assert.Equal(3, lastDeployedRevision([]*release.Release{rel(3, release.StatusDeployed), rel(2, release.StatusSuperseded)}))
+	assert.Equal(2, lastDeployedRevision([]*release.Release{rel(4, release.StatusFailed), rel(3, release.StatusFailed), rel(2, release.StatusDeployed)}))
+	assert.Equal(1, lastDeployedRevision([]*release.Release{rel(2, release.StatusFailed), rel(1, release.StatusSuperseded)}))
+	assert.Equal(0, lastDeployedRevision([]*release.Release{rel(1, release.StatusFailed)}))
+}
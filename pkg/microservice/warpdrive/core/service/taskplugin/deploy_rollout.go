@@ -0,0 +1,529 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	istionetworkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	versionedclient "istio.io/client-go/pkg/clientset/versioned"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/kube/wrapper"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	zadigtypes "github.com/koderover/zadig/pkg/types"
+)
+
+const (
+	rolloutTrackCanary = "canary"
+	rolloutTrackGreen  = "green"
+
+	rolloutPhaseProgressing = "progressing"
+	rolloutPhaseVerifying   = "verifying"
+	rolloutPhasePromoting   = "promoting"
+	rolloutPhasePromoted    = "promoted"
+	rolloutPhaseRolledBack  = "rolled_back"
+
+	rolloutCheckInterval = 2 * time.Second
+)
+
+func rolloutTrack(strategy *task.DeployStrategy) string {
+	if strategy.Type == setting.DeployStrategyBlueGreen {
+		return rolloutTrackGreen
+	}
+	return rolloutTrackCanary
+}
+
+func rolloutWorkloadName(name, track string) string {
+	return fmt.Sprintf("%s-%s", name, track)
+}
+
+func withRolloutTrack(set map[string]string, track string) map[string]string {
+	res := make(map[string]string, len(set)+1)
+	for k, v := range set {
+		res[k] = v
+	}
+	res[zadigtypes.ZadigLabelKeyRolloutTrack] = track
+	return res
+}
+
+// workloadLabels mark the deployment as the workload of a service in the env
+var workloadLabels = []string{setting.ProductLabel, setting.ServiceLabel}
+
+// rolloutLabels returns the labels of the copy of the deployment, the workload labels are dropped
+// so that the copy and its pods are neither listed in the env nor selected as pods of the service
+func rolloutLabels(set map[string]string, track string) map[string]string {
+	res := withRolloutTrack(set, track)
+	for _, key := range workloadLabels {
+		delete(res, key)
+	}
+	return res
+}
+
+// rolloutDeployment returns the canary or green copy of the deployment running the new image,
+// its pods keep the other labels of the deployment so that they are selected by the same Services
+func rolloutDeployment(d *appsv1.Deployment, strategy *task.DeployStrategy, container, image string) *appsv1.Deployment {
+	track := rolloutTrack(strategy)
+
+	var replicas int32 = 1
+	switch {
+	case strategy.Type == setting.DeployStrategyBlueGreen && d.Spec.Replicas != nil:
+		replicas = *d.Spec.Replicas
+	case strategy.Type == setting.DeployStrategyCanary && strategy.CanaryReplicas > 0:
+		replicas = int32(strategy.CanaryReplicas)
+	}
+
+	spec := d.Spec.DeepCopy()
+	spec.Replicas = &replicas
+	if spec.Selector == nil {
+		spec.Selector = &metav1.LabelSelector{}
+	}
+	spec.Selector.MatchLabels = rolloutLabels(spec.Selector.MatchLabels, track)
+	spec.Template.Labels = rolloutLabels(spec.Template.Labels, track)
+	for i := range spec.Template.Spec.Containers {
+		if spec.Template.Spec.Containers[i].Name == container {
+			spec.Template.Spec.Containers[i].Image = image
+		}
+	}
+
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       setting.Deployment,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rolloutWorkloadName(d.Name, track),
+			Namespace: d.Namespace,
+			Labels:    rolloutLabels(d.Labels, track),
+		},
+		Spec: *spec,
+	}
+}
+
+// deploymentRolledOut reports whether all replicas of the latest spec are available
+func deploymentRolledOut(d *appsv1.Deployment) bool {
+	if d.Status.ObservedGeneration < d.Generation {
+		return false
+	}
+	if d.Spec.Replicas != nil && d.Status.UpdatedReplicas < *d.Spec.Replicas {
+		return false
+	}
+	return wrapper.Deployment(d).Ready()
+}
+
+// updateDeploymentImage updates the image in place, or starts a rollout of the image if the task has a strategy
+func (p *DeployTaskPlugin) updateDeploymentImage(ctx context.Context, d *appsv1.Deployment, container string) error {
+	if p.Task.Strategy == nil || p.Task.Strategy.Type == "" {
+		return updater.UpdateDeploymentImage(d.Namespace, d.Name, container, p.Task.Image, p.kubeClient)
+	}
+	return p.startRollout(ctx, d, container)
+}
+
+// startRollout creates the canary or green copy of the deployment, the deployment itself keeps
+// the old image until the copy is verified in Wait
+func (p *DeployTaskPlugin) startRollout(ctx context.Context, d *appsv1.Deployment, container string) error {
+	strategy := p.Task.Strategy
+	workload := rolloutDeployment(d, strategy, container, p.Task.Image)
+	if err := updater.CreateOrPatchDeployment(workload, p.kubeClient); err != nil {
+		return fmt.Errorf("failed to create %s deployment %s: %s", rolloutTrack(strategy), workload.Name, err)
+	}
+
+	svcs, err := p.servicesOf(d)
+	if err != nil {
+		return fmt.Errorf("failed to list services of %s: %s", d.Name, err)
+	}
+
+	p.Task.Rollout = &task.RolloutStatus{
+		Strategy: strategy.Type,
+		Phase:    rolloutPhaseProgressing,
+		Workload: workload.Name,
+	}
+	for _, svc := range svcs {
+		p.Task.Rollout.Services = append(p.Task.Rollout.Services, &task.RolloutService{
+			Name:     svc.Name,
+			Selector: withoutRolloutTrack(svc.Spec.Selector),
+		})
+	}
+
+	if strategy.Type == setting.DeployStrategyCanary && strategy.TrafficWeight > 0 {
+		if err := p.ensureCanaryRoutes(ctx, d.Namespace); err != nil {
+			p.cleanupRollout(ctx, d)
+			return fmt.Errorf("failed to route traffic to canary %s: %s", workload.Name, err)
+		}
+	}
+
+	p.Log.Infof("%s rollout of %s/%s started with %s", strategy.Type, d.Namespace, d.Name, workload.Name)
+	return nil
+}
+
+func withoutRolloutTrack(set map[string]string) map[string]string {
+	res := make(map[string]string, len(set))
+	for k, v := range set {
+		if k != zadigtypes.ZadigLabelKeyRolloutTrack {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// servicesOf returns the Services which select the pods of the deployment, the track in the selector
+// is ignored in case a Service is still switched by a rollout which was interrupted
+func (p *DeployTaskPlugin) servicesOf(d *appsv1.Deployment) ([]*corev1.Service, error) {
+	svcs, err := getter.ListServices(d.Namespace, labels.Everything(), p.kubeClient)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*corev1.Service, 0)
+	for _, svc := range svcs {
+		if len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		selector := withoutRolloutTrack(svc.Spec.Selector)
+		if len(selector) > 0 && labels.SelectorFromSet(selector).Matches(labels.Set(d.Spec.Template.Labels)) {
+			res = append(res, svc)
+		}
+	}
+	return res, nil
+}
+
+func canaryServiceName(svcName string) string {
+	return rolloutWorkloadName(svcName, rolloutTrackCanary)
+}
+
+// canaryVirtualService splits the traffic of the Service between the Service and the canary Service by weight,
+// the Service itself still selects the canary pods unless it selects by the workload labels,
+// so the canary may receive slightly more than weight percent
+func canaryVirtualService(svc *corev1.Service, weight int32) *istionetworkingv1alpha3.VirtualService {
+	host := func(name string) string {
+		return fmt.Sprintf("%s.%s.svc.cluster.local", name, svc.Namespace)
+	}
+
+	return &istionetworkingv1alpha3.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      canaryServiceName(svc.Name),
+			Namespace: svc.Namespace,
+			Labels: map[string]string{
+				zadigtypes.ZadigLabelKeyGlobalOwner:  zadigtypes.Zadig,
+				zadigtypes.ZadigLabelKeyRolloutTrack: rolloutTrackCanary,
+			},
+		},
+		Spec: networkingv1alpha3.VirtualService{
+			Hosts: []string{svc.Name},
+			Http: []*networkingv1alpha3.HTTPRoute{{
+				Route: []*networkingv1alpha3.HTTPRouteDestination{
+					{Destination: &networkingv1alpha3.Destination{Host: host(svc.Name)}, Weight: 100 - weight},
+					{Destination: &networkingv1alpha3.Destination{Host: host(canaryServiceName(svc.Name))}, Weight: weight},
+				},
+			}},
+		},
+	}
+}
+
+func (p *DeployTaskPlugin) ensureCanaryRoutes(ctx context.Context, namespace string) error {
+	istioClient, err := versionedclient.NewForConfig(p.restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to new istio client: %s", err)
+	}
+
+	for _, rs := range p.Task.Rollout.Services {
+		svc, found, err := getter.GetService(namespace, rs.Name, p.kubeClient)
+		if err != nil || !found {
+			return fmt.Errorf("failed to get service %s: %v", rs.Name, err)
+		}
+		canarySvc := &corev1.Service{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      canaryServiceName(svc.Name),
+				Namespace: svc.Namespace,
+				Labels:    withRolloutTrack(map[string]string{zadigtypes.ZadigLabelKeyGlobalOwner: zadigtypes.Zadig}, rolloutTrackCanary),
+			},
+			Spec: corev1.ServiceSpec{
+				Ports:    svc.Spec.Ports,
+				Selector: rolloutLabels(rs.Selector, rolloutTrackCanary),
+			},
+		}
+		for i := range canarySvc.Spec.Ports {
+			canarySvc.Spec.Ports[i].NodePort = 0
+		}
+		if err := updater.CreateOrPatchService(canarySvc, p.kubeClient); err != nil {
+			return err
+		}
+
+		vs := canaryVirtualService(svc, int32(p.Task.Strategy.TrafficWeight))
+		vsClient := istioClient.NetworkingV1alpha3().VirtualServices(svc.Namespace)
+		current, err := vsClient.Get(ctx, vs.Name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			vs.ResourceVersion = current.ResourceVersion
+			_, err = vsClient.Update(ctx, vs, metav1.UpdateOptions{})
+		case apierrors.IsNotFound(err):
+			_, err = vsClient.Create(ctx, vs, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// switchSelectorPatch returns the patch which points the selector to the green pods,
+// or restores the selector recorded before the rollout if green is false
+func switchSelectorPatch(selector map[string]string, green bool) ([]byte, error) {
+	patch := make(map[string]interface{}, len(selector)+1)
+	if green {
+		for k, v := range rolloutLabels(selector, rolloutTrackGreen) {
+			patch[k] = v
+		}
+		for _, key := range workloadLabels {
+			if _, ok := selector[key]; ok {
+				patch[key] = nil
+			}
+		}
+	} else {
+		for k, v := range selector {
+			patch[k] = v
+		}
+		patch[zadigtypes.ZadigLabelKeyRolloutTrack] = nil
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"selector": patch},
+	})
+}
+
+// switchServices points the Services recorded by the rollout to the green pods, or back to all pods of the deployment if green is false
+func (p *DeployTaskPlugin) switchServices(namespace string, green bool) error {
+	for _, svc := range p.Task.Rollout.Services {
+		patch, err := switchSelectorPatch(svc.Selector, green)
+		if err != nil {
+			return err
+		}
+		if err := updater.PatchService(namespace, svc.Name, patch, p.kubeClient); err != nil {
+			return fmt.Errorf("failed to switch service %s: %s", svc.Name, err)
+		}
+	}
+	return nil
+}
+
+// cleanupRollout deletes the copy of the deployment and the canary routes
+func (p *DeployTaskPlugin) cleanupRollout(ctx context.Context, d *appsv1.Deployment) {
+	rollout := p.Task.Rollout
+	if err := updater.DeleteDeployment(d.Namespace, rollout.Workload, p.kubeClient); err != nil && !apierrors.IsNotFound(err) {
+		p.Log.Errorf("failed to delete deployment %s/%s: %s", d.Namespace, rollout.Workload, err)
+	}
+
+	if p.Task.Strategy.Type != setting.DeployStrategyCanary || p.Task.Strategy.TrafficWeight == 0 {
+		return
+	}
+
+	istioClient, err := versionedclient.NewForConfig(p.restConfig)
+	if err != nil {
+		p.Log.Errorf("failed to new istio client: %s", err)
+		return
+	}
+	for _, svc := range rollout.Services {
+		name := canaryServiceName(svc.Name)
+		if err := istioClient.NetworkingV1alpha3().VirtualServices(d.Namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			p.Log.Errorf("failed to delete VirtualService %s/%s: %s", d.Namespace, name, err)
+		}
+		if err := updater.DeleteService(d.Namespace, name, p.kubeClient); err != nil && !apierrors.IsNotFound(err) {
+			p.Log.Errorf("failed to delete service %s/%s: %s", d.Namespace, name, err)
+		}
+	}
+}
+
+func (p *DeployTaskPlugin) setRolloutPhase(phase, message string) {
+	p.Task.Rollout.Phase = phase
+	p.Task.Rollout.Message = message
+	if p.ack != nil {
+		p.ack()
+	}
+}
+
+// waitDeploymentRolledOut waits until all replicas of the deployment run its latest spec
+func (p *DeployTaskPlugin) waitDeploymentRolledOut(ctx context.Context, timeout <-chan time.Time, name string) config.Status {
+	for {
+		select {
+		case <-ctx.Done():
+			return config.StatusCancelled
+		case <-timeout:
+			return config.StatusTimeout
+		case <-time.After(rolloutCheckInterval):
+			d, found, err := getter.GetDeployment(p.Task.Namespace, name, p.kubeClient)
+			if err != nil || !found {
+				p.Log.Errorf("failed to check deployment ready status %s/%s - %v", p.Task.Namespace, name, err)
+				continue
+			}
+			if deploymentRolledOut(d) {
+				return config.StatusPassed
+			}
+		}
+	}
+}
+
+func podRestarts(pods []*corev1.Pod) int32 {
+	var restarts int32
+	for _, pod := range pods {
+		for _, cs := range pod.Status.ContainerStatuses {
+			restarts += cs.RestartCount
+		}
+	}
+	return restarts
+}
+
+// verifyRollout watches the copy of the deployment during the verification window,
+// the copy fails the verification if it becomes unavailable or its containers restart
+func (p *DeployTaskPlugin) verifyRollout(ctx context.Context, timeout <-chan time.Time, selector labels.Selector) (config.Status, string) {
+	pods, err := getter.ListPods(p.Task.Namespace, selector, p.kubeClient)
+	if err != nil {
+		return config.StatusFailed, fmt.Sprintf("failed to list pods of %s: %s", p.Task.Rollout.Workload, err)
+	}
+	baseline := podRestarts(pods)
+
+	window := time.After(time.Duration(p.Task.Strategy.VerifySeconds) * time.Second)
+	for {
+		select {
+		case <-ctx.Done():
+			return config.StatusCancelled, "rollout is cancelled"
+		case <-timeout:
+			return config.StatusTimeout, "rollout timed out during verification"
+		case <-window:
+			return config.StatusPassed, ""
+		case <-time.After(rolloutCheckInterval):
+			d, found, err := getter.GetDeployment(p.Task.Namespace, p.Task.Rollout.Workload, p.kubeClient)
+			if err != nil || !found {
+				return config.StatusFailed, fmt.Sprintf("failed to get deployment %s: %v", p.Task.Rollout.Workload, err)
+			}
+			if !wrapper.Deployment(d).Ready() {
+				return config.StatusFailed, fmt.Sprintf("%s became unavailable during verification", d.Name)
+			}
+
+			pods, err := getter.ListPods(p.Task.Namespace, selector, p.kubeClient)
+			if err != nil {
+				continue
+			}
+			if restarts := podRestarts(pods); restarts > baseline {
+				return config.StatusFailed, fmt.Sprintf("containers of %s restarted %d times during verification", d.Name, restarts-baseline)
+			}
+		}
+	}
+}
+
+// waitRollout verifies the copy of the deployment and promotes the new image to the deployment,
+// the deployment is rolled back to its old image if any step fails
+func (p *DeployTaskPlugin) waitRollout(ctx context.Context) {
+	var stable *task.Resource
+	for i := range p.Task.ReplaceResources {
+		if p.Task.ReplaceResources[i].Kind == setting.Deployment {
+			stable = &p.Task.ReplaceResources[i]
+			break
+		}
+	}
+	if stable == nil {
+		p.Task.TaskStatus = config.StatusFailed
+		p.Task.Error = "no deployment is found for the rollout"
+		return
+	}
+
+	d, found, err := getter.GetDeployment(p.Task.Namespace, stable.Name, p.kubeClient)
+	if err != nil || !found {
+		p.Task.TaskStatus = config.StatusFailed
+		p.Task.Error = fmt.Sprintf("failed to get deployment %s: %v", stable.Name, err)
+		return
+	}
+
+	timeout := time.After(time.Duration(p.TaskTimeout()) * time.Second)
+	status, msg := p.promoteRollout(ctx, timeout, d, stable)
+	if status == config.StatusPassed {
+		p.cleanupRollout(context.Background(), d)
+		p.setRolloutPhase(rolloutPhasePromoted, "")
+		p.Task.TaskStatus = config.StatusPassed
+		return
+	}
+
+	// The context may be cancelled already, the rollback must still finish
+	p.rollback(context.Background(), d, stable)
+	p.Task.RolledBack = true
+	p.setRolloutPhase(rolloutPhaseRolledBack, msg)
+	p.Task.TaskStatus = status
+	p.Task.Error = msg
+}
+
+func (p *DeployTaskPlugin) promoteRollout(ctx context.Context, timeout <-chan time.Time, d *appsv1.Deployment, stable *task.Resource) (config.Status, string) {
+	rollout := p.Task.Rollout
+	if status := p.waitDeploymentRolledOut(ctx, timeout, rollout.Workload); status != config.StatusPassed {
+		return status, fmt.Sprintf("%s is not ready: %s", rollout.Workload, status)
+	}
+
+	p.setRolloutPhase(rolloutPhaseVerifying, "")
+	selector := labels.SelectorFromSet(rolloutLabels(d.Spec.Selector.MatchLabels, rolloutTrack(p.Task.Strategy)))
+	if status, msg := p.verifyRollout(ctx, timeout, selector); status != config.StatusPassed {
+		return status, msg
+	}
+
+	p.setRolloutPhase(rolloutPhasePromoting, "")
+	if p.Task.Strategy.Type == setting.DeployStrategyBlueGreen {
+		if err := p.switchServices(d.Namespace, true); err != nil {
+			return config.StatusFailed, err.Error()
+		}
+	}
+
+	if err := updater.UpdateDeploymentImage(d.Namespace, d.Name, stable.Container, p.Task.Image, p.kubeClient); err != nil {
+		return config.StatusFailed, fmt.Sprintf("failed to promote %s: %s", d.Name, err)
+	}
+	if status := p.waitDeploymentRolledOut(ctx, timeout, d.Name); status != config.StatusPassed {
+		return status, fmt.Sprintf("%s is not ready after promotion: %s", d.Name, status)
+	}
+
+	if p.Task.Strategy.Type == setting.DeployStrategyBlueGreen {
+		if err := p.switchServices(d.Namespace, false); err != nil {
+			return config.StatusFailed, err.Error()
+		}
+	}
+	return config.StatusPassed, ""
+}
+
+// rollback restores the old image and the Services, and deletes the copy of the deployment
+func (p *DeployTaskPlugin) rollback(ctx context.Context, d *appsv1.Deployment, stable *task.Resource) {
+	p.Log.Infof("roll back %s/%s to %s", d.Namespace, d.Name, stable.Origin)
+
+	if err := updater.UpdateDeploymentImage(d.Namespace, d.Name, stable.Container, stable.Origin, p.kubeClient); err != nil {
+		p.Log.Errorf("failed to restore image of %s/%s: %s", d.Namespace, d.Name, err)
+	}
+	if p.Task.Strategy.Type == setting.DeployStrategyBlueGreen {
+		if err := p.switchServices(d.Namespace, false); err != nil {
+			p.Log.Error(err)
+		}
+	}
+	p.cleanupRollout(ctx, d)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"context"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	zadigtypes "github.com/koderover/zadig/pkg/types"
+)
+
+func deploymentForRolloutTest() *appsv1.Deployment {
+	replicas := int32(4)
+	podLabels := map[string]string{"app": "demo", setting.ServiceLabel: "demo"}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "dev", Labels: map[string]string{setting.ProductLabel: "demo", setting.ServiceLabel: "demo"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: podLabels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "demo", Image: "koderover/demo:1"},
+					{Name: "sidecar", Image: "koderover/sidecar:1"},
+				}},
+			},
+		},
+	}
+}
+
+func TestRolloutDeployment(t *testing.T) {
+	assert := assert.New(t)
+
+	d := deploymentForRolloutTest()
+	canary := rolloutDeployment(d, &task.DeployStrategy{Type: setting.DeployStrategyCanary, CanaryReplicas: 2}, "demo", "koderover/demo:2")
+	assert.Equal("demo-canary", canary.Name)
+	assert.Equal("dev", canary.Namespace)
+	assert.Equal(int32(2), *canary.Spec.Replicas)
+	assert.Equal("koderover/demo:2", canary.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal("koderover/sidecar:1", canary.Spec.Template.Spec.Containers[1].Image)
+	assert.Equal(map[string]string{"app": "demo", zadigtypes.ZadigLabelKeyRolloutTrack: "canary"}, canary.Spec.Selector.MatchLabels)
+	assert.Equal(canary.Spec.Selector.MatchLabels, canary.Spec.Template.Labels)
+	assert.Equal(map[string]string{zadigtypes.ZadigLabelKeyRolloutTrack: "canary"}, canary.Labels)
+
+	// The deployment itself is not changed
+	assert.Equal(map[string]string{"app": "demo", setting.ServiceLabel: "demo"}, d.Spec.Template.Labels)
+	assert.Equal("koderover/demo:1", d.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(int32(4), *d.Spec.Replicas)
+
+	green := rolloutDeployment(d, &task.DeployStrategy{Type: setting.DeployStrategyBlueGreen}, "demo", "koderover/demo:2")
+	assert.Equal("demo-green", green.Name)
+	assert.Equal(int32(4), *green.Spec.Replicas)
+	assert.Equal("green", green.Spec.Template.Labels[zadigtypes.ZadigLabelKeyRolloutTrack])
+}
+
+func TestDeploymentRolledOut(t *testing.T) {
+	assert := assert.New(t)
+
+	d := deploymentForRolloutTest()
+	d.Generation = 2
+	d.Status = appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 4, UpdatedReplicas: 4, AvailableReplicas: 4}
+	assert.False(deploymentRolledOut(d))
+
+	d.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 2, AvailableReplicas: 4}
+	assert.False(deploymentRolledOut(d))
+
+	d.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 4, AvailableReplicas: 3}
+	assert.False(deploymentRolledOut(d))
+
+	d.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 4, AvailableReplicas: 4}
+	assert.True(deploymentRolledOut(d))
+}
+
+func TestCanaryVirtualService(t *testing.T) {
+	assert := assert.New(t)
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "dev"}}
+	vs := canaryVirtualService(svc, 20)
+	assert.Equal("demo-canary", vs.Name)
+	assert.Equal([]string{"demo"}, vs.Spec.Hosts)
+
+	routes := vs.Spec.Http[0].Route
+	assert.Len(routes, 2)
+	assert.Equal("demo.dev.svc.cluster.local", routes[0].Destination.Host)
+	assert.Equal(int32(80), routes[0].Weight)
+	assert.Equal("demo-canary.dev.svc.cluster.local", routes[1].Destination.Host)
+	assert.Equal(int32(20), routes[1].Weight)
+}
+
+func serviceForRolloutTest(name string, selector map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev"},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}
+}
+
+func TestBlueGreenRolloutServices(t *testing.T) {
+	assert := assert.New(t)
+
+	d := deploymentForRolloutTest()
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		d,
+		serviceForRolloutTest("demo", map[string]string{"app": "demo"}),
+		serviceForRolloutTest("demo-svc", map[string]string{"app": "demo", setting.ServiceLabel: "demo"}),
+		serviceForRolloutTest("other", map[string]string{"app": "other"}),
+	).Build()
+	p := &DeployTaskPlugin{
+		kubeClient: cl,
+		Log:        zap.NewNop().Sugar(),
+		Task: &task.Deploy{
+			Namespace: "dev",
+			Image:     "koderover/demo:2",
+			Strategy:  &task.DeployStrategy{Type: setting.DeployStrategyBlueGreen},
+		},
+	}
+	selectorOf := func(name string) map[string]string {
+		svc, found, err := getter.GetService("dev", name, cl)
+		assert.NoError(err)
+		assert.True(found)
+		return svc.Spec.Selector
+	}
+
+	assert.NoError(p.startRollout(context.Background(), d, "demo"))
+	green, found, err := getter.GetDeployment("dev", "demo-green", cl)
+	assert.NoError(err)
+	assert.True(found)
+	assert.Equal(map[string]string{zadigtypes.ZadigLabelKeyRolloutTrack: "green"}, green.Labels)
+	assert.Equal(map[string]string{"app": "demo", zadigtypes.ZadigLabelKeyRolloutTrack: "green"}, green.Spec.Template.Labels)
+	assert.Len(p.Task.Rollout.Services, 2)
+
+	// switch
+	assert.NoError(p.switchServices("dev", true))
+	assert.Equal(map[string]string{"app": "demo", zadigtypes.ZadigLabelKeyRolloutTrack: "green"}, selectorOf("demo"))
+	assert.Equal(map[string]string{"app": "demo", zadigtypes.ZadigLabelKeyRolloutTrack: "green"}, selectorOf("demo-svc"))
+	assert.Equal(map[string]string{"app": "other"}, selectorOf("other"))
+
+	// promote, the switched Services no longer match the deployment but are still restored
+	assert.NoError(p.switchServices("dev", false))
+	assert.Equal(map[string]string{"app": "demo"}, selectorOf("demo"))
+	assert.Equal(map[string]string{"app": "demo", setting.ServiceLabel: "demo"}, selectorOf("demo-svc"))
+
+	// cleanup
+	p.cleanupRollout(context.Background(), d)
+	_, found, err = getter.GetDeployment("dev", "demo-green", cl)
+	assert.NoError(err)
+	assert.False(found)
+	_, found, err = getter.GetDeployment("dev", "demo", cl)
+	assert.NoError(err)
+	assert.True(found)
+}
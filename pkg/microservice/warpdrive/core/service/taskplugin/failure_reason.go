@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// Failure reasons of sub tasks, they are read from the exact state of the pods or of the build steps
+// so that a retry policy can tell transient failures from the others
+const (
+	FailureReasonImagePull = "image_pull"
+	FailureReasonEvicted   = "evicted"
+	FailureReasonRegistry  = types.BuildStepFailureRegistry
+)
+
+const podReasonEvicted = "Evicted"
+
+var imagePullReasons = sets.NewString("ErrImagePull", "ImagePullBackOff")
+
+// podsFailureReason returns the failure reason of the pods, or an empty string if it is not a known one
+func podsFailureReason(pods []*corev1.Pod) string {
+	for _, pod := range pods {
+		if pod.Status.Reason == podReasonEvicted {
+			return FailureReasonEvicted
+		}
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if cs.State.Waiting != nil && imagePullReasons.Has(cs.State.Waiting.Reason) {
+				return FailureReasonImagePull
+			}
+		}
+	}
+	return ""
+}
+
+// jobFailureReason returns the failure reason of the pods of the job
+func jobFailureReason(namespace, jobName string, kubeClient client.Client) string {
+	pods, err := getter.ListPods(namespace, labels.Set{"job-name": jobName}.AsSelector(), kubeClient)
+	if err != nil {
+		return ""
+	}
+	return podsFailureReason(pods)
+}
+
+// stepsFailureReason returns the failure reason reaper reported for the failed build step
+func stepsFailureReason(steps []*types.BuildStep) string {
+	for _, step := range steps {
+		if step.Status == types.BuildStepFailed && step.FailureReason != "" {
+			return step.FailureReason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/koderover/zadig/pkg/types"
+)
+
+func TestPodsFailureReason(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(FailureReasonImagePull, podsFailureReason([]*corev1.Pod{podForRollbackTest("job-1", "reaper", "ImagePullBackOff")}))
+	assert.Equal(FailureReasonEvicted, podsFailureReason([]*corev1.Pod{{Status: corev1.PodStatus{Reason: podReasonEvicted}}}))
+	// the message of the pod is never matched, only the exact reasons
+	assert.Empty(podsFailureReason([]*corev1.Pod{{Status: corev1.PodStatus{Message: "Evicted ImagePullBackOff"}}}))
+	assert.Empty(podsFailureReason([]*corev1.Pod{podForRollbackTest("job-1", "reaper", crashLoopBackOff)}))
+}
+
+func TestStepsFailureReason(t *testing.T) {
+	assert := assert.New(t)
+
+	steps := []*types.BuildStep{
+		{Name: "git", Status: types.BuildStepPassed},
+		{Name: "docker_build", Status: types.BuildStepFailed, FailureReason: types.BuildStepFailureRegistry},
+	}
+	assert.Equal(FailureReasonRegistry, stepsFailureReason(steps))
+	assert.Empty(stepsFailureReason([]*types.BuildStep{{Name: "script", Status: types.BuildStepFailed, ExitCode: 1}}))
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+	commontypes "github.com/koderover/zadig/pkg/types"
+)
+
+const (
+	imageBuilderContainer = "image-builder"
+	imageBuildVolume      = "image-build"
+
+	// the debug image is used since the kaniko container waits for the build in a shell
+	kanikoImage   = "gcr.io/kaniko-project/executor:v1.23.2-debug"
+	buildKitImage = "moby/buildkit:v0.13.2-rootless"
+	// rootlessUID is the user of the rootless buildkit image
+	rootlessUID = 1000
+)
+
+// daemonlessImageBuild reports whether the image of the build is built without a docker daemon
+func daemonlessImageBuild(ctx *task.DockerBuildCtx) bool {
+	if ctx == nil {
+		return false
+	}
+	return ctx.Builder == setting.ImageBuilderBuildKit || ctx.Builder == setting.ImageBuilderKaniko
+}
+
+// addImageBuilderContainer runs the daemonless image builder in its own container of the job from the upstream image,
+// so it is neither mixed with the tools in the reaper image nor granted more than it needs.
+// The job container shares commontypes.ImageBuildDir with it.
+func addImageBuilderContainer(job *batchv1.Job, ctx *task.DockerBuildCtx) {
+	if !daemonlessImageBuild(ctx) {
+		return
+	}
+
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         imageBuildVolume,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	mount := corev1.VolumeMount{Name: imageBuildVolume, MountPath: commontypes.ImageBuildDir}
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, mount)
+
+	container := corev1.Container{
+		Name:            imageBuilderContainer,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		VolumeMounts:    []corev1.VolumeMount{mount},
+		// the image is built here, the job container only waits for it
+		Resources: podSpec.Containers[0].Resources,
+
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+	}
+	switch ctx.Builder {
+	case setting.ImageBuilderKaniko:
+		container.Image = kanikoImage
+		container.Command = []string{"/busybox/sh", "-c", commontypes.KanikoContainerScript(commontypes.ImageBuildDir)}
+	case setting.ImageBuilderBuildKit:
+		container.Image = buildKitImage
+		// buildkitd only listens on the loopback address of the pod
+		container.Args = []string{"--addr", commontypes.BuildKitdAddr, "--oci-worker-no-process-sandbox"}
+		// rootless buildkitd creates user namespaces, which the default seccomp and apparmor profiles forbid
+		container.SecurityContext = &corev1.SecurityContext{
+			RunAsUser:      int64Ptr(rootlessUID),
+			RunAsGroup:     int64Ptr(rootlessUID),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+		}
+		if job.Spec.Template.Annotations == nil {
+			job.Spec.Template.Annotations = make(map[string]string)
+		}
+		job.Spec.Template.Annotations[corev1.AppArmorBetaContainerAnnotationKeyPrefix+imageBuilderContainer] = corev1.AppArmorBetaProfileNameUnconfined
+	}
+	podSpec.Containers = append(podSpec.Containers, container)
+}
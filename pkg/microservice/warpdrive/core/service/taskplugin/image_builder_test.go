@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+	commontypes "github.com/koderover/zadig/pkg/types"
+)
+
+func TestDaemonlessImageBuild(t *testing.T) {
+	assert.False(t, daemonlessImageBuild(nil))
+	assert.False(t, daemonlessImageBuild(&task.DockerBuildCtx{}))
+	assert.False(t, daemonlessImageBuild(&task.DockerBuildCtx{Builder: setting.ImageBuilderDocker}))
+	assert.True(t, daemonlessImageBuild(&task.DockerBuildCtx{Builder: setting.ImageBuilderBuildKit}))
+	assert.True(t, daemonlessImageBuild(&task.DockerBuildCtx{Builder: setting.ImageBuilderKaniko}))
+}
+
+func TestAddImageBuilderContainer(t *testing.T) {
+	newJob := func() *batchv1.Job {
+		job := &batchv1.Job{}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "buildv2"}}
+		return job
+	}
+	mount := corev1.VolumeMount{Name: imageBuildVolume, MountPath: commontypes.ImageBuildDir}
+
+	job := newJob()
+	addImageBuilderContainer(job, &task.DockerBuildCtx{Builder: setting.ImageBuilderDocker})
+	assert.Len(t, job.Spec.Template.Spec.Containers, 1)
+	assert.Empty(t, job.Spec.Template.Spec.Volumes)
+
+	job = newJob()
+	addImageBuilderContainer(job, &task.DockerBuildCtx{Builder: setting.ImageBuilderKaniko})
+	containers := job.Spec.Template.Spec.Containers
+	assert.Len(t, containers, 2)
+	assert.Equal(t, "buildv2", containers[0].Name)
+	assert.Equal(t, []corev1.VolumeMount{mount}, containers[0].VolumeMounts)
+	assert.Equal(t, kanikoImage, containers[1].Image)
+	assert.Equal(t, []corev1.VolumeMount{mount}, containers[1].VolumeMounts)
+	assert.Equal(t, commontypes.KanikoContainerScript(commontypes.ImageBuildDir), containers[1].Command[2])
+	assert.Nil(t, containers[1].SecurityContext)
+	assert.Equal(t, imageBuildVolume, job.Spec.Template.Spec.Volumes[0].Name)
+
+	job = newJob()
+	addImageBuilderContainer(job, &task.DockerBuildCtx{Builder: setting.ImageBuilderBuildKit})
+	builder := job.Spec.Template.Spec.Containers[1]
+	assert.Equal(t, buildKitImage, builder.Image)
+	assert.Contains(t, builder.Args, commontypes.BuildKitdAddr)
+	assert.Equal(t, int64(rootlessUID), *builder.SecurityContext.RunAsUser)
+	assert.Nil(t, builder.SecurityContext.Privileged)
+	assert.Equal(t, corev1.SeccompProfileTypeUnconfined, builder.SecurityContext.SeccompProfile.Type)
+	assert.Equal(t, corev1.AppArmorBetaProfileNameUnconfined, job.Spec.Template.Annotations[corev1.AppArmorBetaContainerAnnotationKeyPrefix+imageBuilderContainer])
+}
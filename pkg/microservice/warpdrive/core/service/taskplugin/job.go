@@ -48,6 +48,8 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
 	"github.com/koderover/zadig/pkg/shared/kube/wrapper"
+	"github.com/koderover/zadig/pkg/tool/cosign"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
 	"github.com/koderover/zadig/pkg/tool/kube/containerlog"
 	"github.com/koderover/zadig/pkg/tool/kube/getter"
 	"github.com/koderover/zadig/pkg/tool/kube/podexec"
@@ -87,18 +89,28 @@ func saveFile(src io.Reader, localFile string) error {
 }
 
 func saveContainerLog(pipelineTask *task.Task, namespace, clusterID, fileName string, jobLabel *JobLabel, kubeClient client.Client) error {
+	buf, err := getContainerLog(pipelineTask, namespace, clusterID, jobLabel, kubeClient)
+	if err != nil {
+		return err
+	}
+
+	return uploadContainerLog(pipelineTask, fileName, buf)
+}
+
+// getContainerLog returns the log of the job container
+func getContainerLog(pipelineTask *task.Task, namespace, clusterID string, jobLabel *JobLabel, kubeClient client.Client) (*bytes.Buffer, error) {
 	selector := labels.Set(getJobLabels(jobLabel)).AsSelector()
 	pods, err := getter.ListPods(namespace, selector, kubeClient)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(pods) < 1 {
-		return fmt.Errorf("no pod found with selector: %s", selector)
+		return nil, fmt.Errorf("no pod found with selector: %s", selector)
 	}
 
 	if len(pods[0].Status.ContainerStatuses) < 1 {
-		return fmt.Errorf("no cotainer statuses : %s", selector)
+		return nil, fmt.Errorf("no cotainer statuses : %s", selector)
 	}
 
 	buf := new(bytes.Buffer)
@@ -110,13 +122,18 @@ func saveContainerLog(pipelineTask *task.Task, namespace, clusterID, fileName st
 	clientSet, err := kubeclient.GetClientset(pipelineTask.ConfigPayload.HubServerAddr, clusterID)
 	if err != nil {
 		log.Errorf("saveContainerLog, get client set error: %s", err)
-		return err
+		return nil, err
 	}
 
 	if err := containerlog.GetContainerLogs(namespace, pods[0].Name, pods[0].Spec.Containers[0].Name, false, int64(0), buf, clientSet); err != nil {
-		return fmt.Errorf("failed to get container logs: %s", err)
+		return nil, fmt.Errorf("failed to get container logs: %s", err)
 	}
 
+	return buf, nil
+}
+
+// uploadContainerLog uploads the log to the storage of the pipeline task
+func uploadContainerLog(pipelineTask *task.Task, fileName string, buf *bytes.Buffer) error {
 	if tempFileName, err := util.GenerateTmpFile(); err == nil {
 		defer func() {
 			_ = os.Remove(tempFileName)
@@ -248,6 +265,7 @@ func (b *JobCtxBuilder) BuildReaperContext(pipelineTask *task.Task, serviceName
 		ctx.Cache = b.PipelineCtx.Cache
 		ctx.CacheDirType = b.PipelineCtx.CacheDirType
 		ctx.CacheUserDir = b.PipelineCtx.CacheUserDir
+		ctx.CacheDefaultBranch = b.PipelineCtx.CacheDefaultBranch
 	}
 
 	for _, install := range b.Installs {
@@ -283,6 +301,7 @@ func (b *JobCtxBuilder) BuildReaperContext(pipelineTask *task.Task, serviceName
 			User:         build.Username,
 			Password:     build.Password,
 			EnableProxy:  build.EnableProxy,
+			IsPrimary:    build.IsPrimary,
 		}
 		ctx.Repos = append(ctx.Repos, repo)
 	}
@@ -342,6 +361,9 @@ func (b *JobCtxBuilder) BuildReaperContext(pipelineTask *task.Task, serviceName
 			ImageName:             b.JobCtx.DockerBuildCtx.ImageName,
 			BuildArgs:             b.JobCtx.DockerBuildCtx.BuildArgs,
 			DockerTemplateContent: b.JobCtx.DockerBuildCtx.DockerTemplateContent,
+			Builder:               b.JobCtx.DockerBuildCtx.Builder,
+			Platforms:             b.JobCtx.DockerBuildCtx.Platforms,
+			Provenance:            b.JobCtx.DockerBuildCtx.Provenance,
 		}
 	}
 
@@ -355,6 +377,12 @@ func (b *JobCtxBuilder) BuildReaperContext(pipelineTask *task.Task, serviceName
 		ResultPath:     b.JobCtx.TestResultPath,
 		TestReportPath: b.JobCtx.TestReportPath,
 		ArtifactPaths:  b.JobCtx.ArtifactPaths,
+		ReportFormat:   b.JobCtx.TestReportFormat,
+		ResultGlob:     b.JobCtx.TestResultGlob,
+	}
+	if b.JobCtx.Coverage != nil {
+		ctx.GinkgoTest.CoverageFormat = b.JobCtx.Coverage.Format
+		ctx.GinkgoTest.CoveragePath = b.JobCtx.Coverage.Path
 	}
 
 	ctx.StorageEndpoint = pipelineTask.ConfigPayload.S3Storage.Endpoint
@@ -529,19 +557,9 @@ func buildJobWithLinkedNs(taskType config.TaskType, jobImage, jobName, serviceNa
 							ImagePullPolicy: corev1.PullAlways,
 							Name:            labels["s-type"],
 							Image:           jobImage,
-							Env: []corev1.EnvVar{
-								{
-									Name:  "JOB_CONFIG_FILE",
-									Value: path.Join(ctx.ConfigMapMountDir, "job-config.xml"),
-								},
-								// 连接对应wd上的dockerdeamon
-								{
-									Name:  "DOCKER_HOST",
-									Value: ctx.DockerHost,
-								},
-							},
-							VolumeMounts: getVolumeMounts(ctx),
-							Resources:    getResourceRequirements(resReq, resReqSpec),
+							Env:             jobEnvs(ctx),
+							VolumeMounts:    getVolumeMounts(ctx),
+							Resources:       getResourceRequirements(resReq, resReqSpec),
 
 							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 						},
@@ -681,6 +699,20 @@ func createOrUpdateRegistrySecrets(namespace, registryID string, registries []*t
 	return nil
 }
 
+// signImage signs the image pushed by a job in aslan after the job is done, the signing key never leaves aslan
+// so that it is never exposed to the jobs which run user scripts
+func signImage(httpClient *httpclient.Client, provenance *commontypes.ImageProvenance) error {
+	if provenance.Digest == "" {
+		return fmt.Errorf("digest of image %s is not reported", provenance.Image)
+	}
+	if _, err := httpClient.Post("/api/system/imageSigning/internal/sign", httpclient.SetBody(provenance)); err != nil {
+		return fmt.Errorf("failed to sign image %s: %s", provenance.Image, err)
+	}
+	provenance.Signed = true
+	provenance.SignatureRef = cosign.ImageRepo(provenance.Image) + ":" + cosign.SignatureTag(provenance.Digest)
+	return nil
+}
+
 func Min(x, y int) int {
 	if x < y {
 		return x
@@ -702,6 +734,23 @@ func lookupKubeDNSServerHost() (addresses []string, err error) {
 	return
 }
 
+func jobEnvs(ctx *task.PipelineCtx) []corev1.EnvVar {
+	envs := []corev1.EnvVar{
+		{
+			Name:  "JOB_CONFIG_FILE",
+			Value: path.Join(ctx.ConfigMapMountDir, "job-config.xml"),
+		},
+	}
+	// 连接对应wd上的dockerdeamon, daemonless构建时不需要
+	if ctx.DockerHost != "" {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "DOCKER_HOST",
+			Value: ctx.DockerHost,
+		})
+	}
+	return envs
+}
+
 func getVolumeMounts(ctx *task.PipelineCtx) []corev1.VolumeMount {
 	resp := make([]corev1.VolumeMount, 0)
 
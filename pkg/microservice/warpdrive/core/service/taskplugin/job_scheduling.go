@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	commontypes "github.com/koderover/zadig/pkg/types"
+)
+
+// jobScheduling returns the scheduling of a build or test job running in the cluster,
+// the settings of the template take precedence over the defaults of the cluster
+func jobScheduling(clusterID string, scheduling *commontypes.JobScheduling, K8SClusters []*task.K8SCluster) *commontypes.JobScheduling {
+	var clusterScheduling *commontypes.JobScheduling
+	if clusterConfig := findClusterConfig(clusterID, K8SClusters); clusterConfig != nil {
+		clusterScheduling = clusterConfig.Scheduling
+	}
+	return commontypes.MergeJobScheduling(clusterScheduling, scheduling)
+}
+
+// applyJobScheduling sets the tolerations, node selector, priority class, service account,
+// extra volumes and annotations to the pod of the job
+func applyJobScheduling(job *batchv1.Job, scheduling *commontypes.JobScheduling) {
+	if scheduling == nil {
+		return
+	}
+
+	podSpec := &job.Spec.Template.Spec
+	for _, t := range scheduling.Tolerations {
+		podSpec.Tolerations = append(podSpec.Tolerations, corev1.Toleration{
+			Key:               t.Key,
+			Operator:          corev1.TolerationOperator(t.Operator),
+			Value:             t.Value,
+			Effect:            corev1.TaintEffect(t.Effect),
+			TolerationSeconds: t.TolerationSeconds,
+		})
+	}
+
+	if len(scheduling.NodeSelector) > 0 {
+		if podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = make(map[string]string, len(scheduling.NodeSelector))
+		}
+		for k, v := range scheduling.NodeSelector {
+			podSpec.NodeSelector[k] = v
+		}
+	}
+
+	if scheduling.PriorityClassName != "" {
+		podSpec.PriorityClassName = scheduling.PriorityClassName
+	}
+	if scheduling.ServiceAccountName != "" {
+		podSpec.ServiceAccountName = scheduling.ServiceAccountName
+	}
+
+	if len(scheduling.Annotations) > 0 {
+		if job.Spec.Template.Annotations == nil {
+			job.Spec.Template.Annotations = make(map[string]string, len(scheduling.Annotations))
+		}
+		for k, v := range scheduling.Annotations {
+			job.Spec.Template.Annotations[k] = v
+		}
+	}
+
+	for _, v := range scheduling.Volumes {
+		volume := corev1.Volume{Name: v.Name}
+		switch v.Type {
+		case commontypes.HostPathJobVolume:
+			volume.HostPath = &corev1.HostPathVolumeSource{Path: v.Source}
+		case commontypes.PVCJobVolume:
+			volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: v.Source, ReadOnly: v.ReadOnly}
+		case commontypes.ConfigMapJobVolume:
+			volume.ConfigMap = &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: v.Source}}
+		case commontypes.SecretJobVolume:
+			volume.Secret = &corev1.SecretVolumeSource{SecretName: v.Source}
+		case commontypes.EmptyDirJobVolume:
+			volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		default:
+			continue
+		}
+
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+		for i := range podSpec.Containers {
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      v.Name,
+				MountPath: v.MountPath,
+				ReadOnly:  v.ReadOnly,
+			})
+		}
+	}
+}
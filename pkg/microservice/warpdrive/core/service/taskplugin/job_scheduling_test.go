@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	commontypes "github.com/koderover/zadig/pkg/types"
+)
+
+func TestJobScheduling(t *testing.T) {
+	assert := assert.New(t)
+
+	clusters := []*task.K8SCluster{{
+		ID: "c1",
+		AdvancedConfig: &task.AdvancedConfig{
+			Scheduling: &commontypes.JobScheduling{
+				Tolerations:        []*commontypes.Toleration{{Key: "build", Operator: "Exists", Effect: "NoSchedule"}},
+				NodeSelector:       map[string]string{"pool": "build", "arch": "amd64"},
+				PriorityClassName:  "build-low",
+				ServiceAccountName: "builder",
+			},
+		},
+	}}
+
+	assert.Nil(jobScheduling("c2", nil, clusters))
+
+	scheduling := jobScheduling("c1", &commontypes.JobScheduling{
+		NodeSelector:      map[string]string{"arch": "arm64"},
+		PriorityClassName: "build-high",
+	}, clusters)
+	assert.Equal(map[string]string{"pool": "build", "arch": "arm64"}, scheduling.NodeSelector)
+	assert.Equal("build-high", scheduling.PriorityClassName)
+	assert.Equal("builder", scheduling.ServiceAccountName)
+	assert.Len(scheduling.Tolerations, 1)
+
+	// the service account and the host path and secret volumes of a template are ignored
+	template := &commontypes.JobScheduling{
+		ServiceAccountName: "admin",
+		Volumes: []*commontypes.JobVolume{
+			{Name: "root", Type: commontypes.HostPathJobVolume, Source: "/", MountPath: "/host"},
+			{Name: "key", Type: commontypes.SecretJobVolume, Source: "key", MountPath: "/key"},
+			{Name: "m2", Type: commontypes.PVCJobVolume, Source: "maven", MountPath: "/root/.m2"},
+		},
+	}
+	assert.NotNil(template.ValidateTemplate())
+	for _, clusterID := range []string{"c1", "c2"} {
+		scheduling = jobScheduling(clusterID, template, clusters)
+		assert.NotEqual("admin", scheduling.ServiceAccountName)
+		assert.Len(scheduling.Volumes, 1)
+		assert.Equal("m2", scheduling.Volumes[0].Name)
+	}
+}
+
+func TestApplyJobScheduling(t *testing.T) {
+	assert := assert.New(t)
+
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+	applyJobScheduling(job, &commontypes.JobScheduling{
+		Tolerations:        []*commontypes.Toleration{{Key: "build", Operator: "Equal", Value: "true", Effect: "NoSchedule"}},
+		NodeSelector:       map[string]string{"pool": "build"},
+		PriorityClassName:  "build-low",
+		ServiceAccountName: "builder",
+		Volumes:            []*commontypes.JobVolume{{Name: "m2", Type: commontypes.PVCJobVolume, Source: "maven", MountPath: "/root/.m2"}},
+		Annotations:        map[string]string{"eks.amazonaws.com/role-arn": "arn"},
+	})
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(corev1.TaintEffectNoSchedule, podSpec.Tolerations[0].Effect)
+	assert.Equal("build", podSpec.NodeSelector["pool"])
+	assert.Equal("build-low", podSpec.PriorityClassName)
+	assert.Equal("builder", podSpec.ServiceAccountName)
+	assert.Equal("maven", podSpec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal("/root/.m2", podSpec.Containers[0].VolumeMounts[0].MountPath)
+	assert.Equal("arn", job.Spec.Template.Annotations["eks.amazonaws.com/role-arn"])
+}
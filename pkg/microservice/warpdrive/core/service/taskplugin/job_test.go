@@ -172,6 +172,16 @@ func TestGetVolumes(t *testing.T) {
 	assert.Equal(t, "job-config", vols[0].Name)
 }
 
+func TestJobEnvs(t *testing.T) {
+	envs := jobEnvs(&task.PipelineCtx{ConfigMapMountDir: "/cfgmnt", DockerHost: "tcp://dind-0.dind:2375"})
+	assert.Len(t, envs, 2)
+	assert.Equal(t, corev1.EnvVar{Name: "DOCKER_HOST", Value: "tcp://dind-0.dind:2375"}, envs[1])
+
+	envs = jobEnvs(&task.PipelineCtx{ConfigMapMountDir: "/cfgmnt"})
+	assert.Equal(t, []corev1.EnvVar{{Name: "JOB_CONFIG_FILE", Value: "/cfgmnt/job-config.xml"}}, envs)
+}
+
+
 func TestEnsureDeleteJob(t *testing.T) {
 
 	assert := assert.New(t)
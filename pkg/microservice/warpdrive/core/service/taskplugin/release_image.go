@@ -49,6 +49,7 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	commontypes "github.com/koderover/zadig/pkg/types"
 )
 
 // InitializeReleaseImagePlugin ...
@@ -78,6 +79,8 @@ type ReleaseImagePlugin struct {
 	Log           *zap.SugaredLogger
 	httpClient    *httpclient.Client
 	StorageURI    string
+	// VerifyImageSignature refuses to deploy the released images which are not signed
+	VerifyImageSignature bool
 }
 
 func (p *ReleaseImagePlugin) SetAckFunc(func()) {
@@ -135,6 +138,7 @@ func (p *ReleaseImagePlugin) Run(ctx context.Context, pipelineTask *task.Task, p
 	p.KubeNamespace = pipelineTask.ConfigPayload.Build.KubeNamespace
 	p.HubServerAddr = pipelineTask.ConfigPayload.HubServerAddr
 	p.StorageURI = pipelineTask.StorageURI
+	p.VerifyImageSignature = pipelineTask.ConfigPayload.VerifyImageSignature
 	// 设置本次运行需要配置
 	//t.Workspace = fmt.Sprintf("%s/%s", pipelineTask.ConfigPayload.NFS.Path, pipelineTask.PipelineName)
 	releases := make([]task.RepoImage, 0)
@@ -174,8 +178,10 @@ func (p *ReleaseImagePlugin) Run(ctx context.Context, pipelineTask *task.Task, p
 			Password: pipelineTask.ConfigPayload.Registry.SecretKey,
 		},
 
-		ReleaseImages:  releases,
-		DistributeInfo: distributes,
+		ReleaseImages:    releases,
+		DistributeInfo:   distributes,
+		CopyAllPlatforms: len(p.Task.Platforms) > 0,
+		Provenance:       p.Task.Provenance,
 	}
 
 	jobCtxBytes, err := yaml.Marshal(jobCtx)
@@ -234,6 +240,7 @@ func (p *ReleaseImagePlugin) Run(ctx context.Context, pipelineTask *task.Task, p
 	}
 
 	job.Namespace = p.KubeNamespace
+
 	startTime := time.Now().Unix()
 	for _, distribute := range p.Task.DistributeInfo {
 		distribute.DistributeStartTime = startTime
@@ -299,6 +306,14 @@ DistributeLoop:
 				continue
 			}
 		}
+		if p.VerifyImageSignature {
+			if err = verifyImageSignature(p.httpClient, distribute.Image); err != nil {
+				err = errors.WithMessagef(err, "image %s failed signature verification", distribute.Image)
+				distribute.DeployStatus = string(config.StatusFailed)
+				distribute.DeployEndTime = time.Now().Unix()
+				continue
+			}
+		}
 		// k8s deploy type service goes here
 		if distribute.DeployServiceType != setting.HelmDeployType {
 			replaced := false
@@ -782,12 +797,30 @@ func (p *ReleaseImagePlugin) Complete(ctx context.Context, pipelineTask *task.Ta
 	}()
 
 	// 保存实时日志到s3
-	err := saveContainerLog(pipelineTask, p.KubeNamespace, "", p.FileName, jobLabel, p.kubeClient)
+	buf, err := getContainerLog(pipelineTask, p.KubeNamespace, "", jobLabel, p.kubeClient)
 	if err != nil {
 		p.Log.Error(err)
 		p.Task.Error = err.Error()
 		return
 	}
+	p.Task.Provenances = commontypes.ParseImageProvenances(buf.String())
+	// 镜像推送之后由 aslan 签名，签名私钥不会进入运行用户脚本的任务
+	if p.Task.Provenance != nil && p.Task.Provenance.Sign && p.Task.TaskStatus == config.StatusPassed {
+		for _, provenance := range p.Task.Provenances {
+			if err := signImage(p.httpClient, provenance); err != nil {
+				p.Log.Error(err)
+				p.Task.TaskStatus = config.StatusFailed
+				p.Task.Error = err.Error()
+				break
+			}
+		}
+	}
+
+	if err := uploadContainerLog(pipelineTask, p.FileName, buf); err != nil {
+		p.Log.Error(err)
+		p.Task.Error = err.Error()
+		return
+	}
 
 	p.Task.LogFile = p.JobName
 }
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type clairScanner struct {
+	dockerHost string
+	namespace  string
+}
+
+// clairResult is the result of clair, every vulnerability of every feature is a record in the message
+type clairResult struct {
+	Result  string `json:"result"`
+	Message []struct {
+		ImageID       string `json:"imageId"`
+		Vulnerability struct {
+			Name          string `json:"name"`
+			NamespaceName string `json:"namespaceName"`
+			Description   string `json:"description"`
+			Link          string `json:"link"`
+			Severity      string `json:"severity"`
+			FixedBy       string `json:"fixedBy"`
+		} `json:"vulnerability"`
+		Feature struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"feature"`
+	} `json:"message"`
+}
+
+func (s *clairScanner) Name() string {
+	return ClairScanner
+}
+
+func (s *clairScanner) Scan(ctx context.Context, imageName string) (*Report, error) {
+	url := fmt.Sprintf("%s/analyzeLocalImage", configbase.ClairServiceAddress())
+	qs := map[string]string{
+		"imageName":  imageName,
+		"dockerHost": s.dockerHost,
+		"namespace":  s.namespace,
+	}
+
+	res, err := httpclient.Get(url, httpclient.SetQueryParams(qs), httpclient.ForceContentType("application/json"))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseClairResult(imageName, res.Body())
+}
+
+func parseClairResult(imageName string, body []byte) (*Report, error) {
+	result := &clairResult{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	if result.Result != "success" {
+		return nil, fmt.Errorf("failed to analysis %s", imageName)
+	}
+
+	report := &Report{}
+	for _, m := range result.Message {
+		report.ImageID = m.ImageID
+		report.Vulnerabilities = append(report.Vulnerabilities, &Vulnerability{
+			ID:               m.Vulnerability.Name,
+			Package:          m.Feature.Name,
+			InstalledVersion: m.Feature.Version,
+			FixedVersion:     m.Vulnerability.FixedBy,
+			Severity:         NormalizeSeverity(m.Vulnerability.Severity),
+			Description:      m.Vulnerability.Description,
+			Link:             m.Vulnerability.Link,
+			Target:           m.Vulnerability.NamespaceName,
+		})
+	}
+	return report, nil
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"context"
+)
+
+// MockScanner returns the report or the error it is given, it is used in tests
+type MockScanner struct {
+	Report *Report
+	Err    error
+	// Scanned are the images which have been scanned
+	Scanned []string
+}
+
+func (s *MockScanner) Name() string {
+	return "mock"
+}
+
+func (s *MockScanner) Scan(ctx context.Context, imageName string) (*Report, error) {
+	s.Scanned = append(s.Scanned, imageName)
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return s.Report, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	ClairScanner = "clair"
+	TrivyScanner = "trivy"
+)
+
+// Severities of vulnerabilities, they are named as clair does so that the existing statistics keep working
+const (
+	SeverityUnknown    = "Unknown"
+	SeverityNegligible = "Negligible"
+	SeverityLow        = "Low"
+	SeverityMedium     = "Medium"
+	SeverityHigh       = "High"
+	SeverityCritical   = "Critical"
+)
+
+var severityLevels = map[string]int{
+	SeverityUnknown:    0,
+	SeverityNegligible: 1,
+	SeverityLow:        2,
+	SeverityMedium:     3,
+	SeverityHigh:       4,
+	SeverityCritical:   5,
+}
+
+// Scanner scans an image for known vulnerabilities
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, imageName string) (*Report, error)
+}
+
+// Options are used to create a scanner
+type Options struct {
+	// DockerHost is the docker daemon the image was built in
+	DockerHost string
+	// Namespace is where the clair service runs
+	Namespace string
+	// TrivyCacheDir is the cache dir of trivy with an offline vulnerability db in it
+	TrivyCacheDir string
+}
+
+// Report is the result of scanning an image
+type Report struct {
+	ImageID         string
+	Vulnerabilities []*Vulnerability
+}
+
+// Vulnerability is a CVE found in a package of an image
+type Vulnerability struct {
+	ID               string
+	Package          string
+	InstalledVersion string
+	FixedVersion     string
+	Severity         string
+	Description      string
+	Link             string
+	// Target is the os or the lock file the package is found in
+	Target string
+}
+
+// New returns the scanner with the name, clair is returned if the name is empty
+func New(name string, opt *Options) (Scanner, error) {
+	switch name {
+	case "", ClairScanner:
+		return &clairScanner{dockerHost: opt.DockerHost, namespace: opt.Namespace}, nil
+	case TrivyScanner:
+		return &trivyScanner{dockerHost: opt.DockerHost, cacheDir: opt.TrivyCacheDir}, nil
+	default:
+		return nil, fmt.Errorf("unknown scanner %s", name)
+	}
+}
+
+// NormalizeSeverity maps the severities of all scanners to the ones defined above
+func NormalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "defcon1":
+		return SeverityCritical
+	case "high":
+		return SeverityHigh
+	case "medium":
+		return SeverityMedium
+	case "low":
+		return SeverityLow
+	case "negligible":
+		return SeverityNegligible
+	default:
+		return SeverityUnknown
+	}
+}
+
+// ValidSeverity reports whether the severity can be used as a threshold
+func ValidSeverity(severity string) bool {
+	_, ok := severityLevels[severity]
+	return ok
+}
+
+// Summarize counts the vulnerabilities by severity, the total count is kept under Total
+func Summarize(vulnerabilities []*Vulnerability) map[string]int {
+	summary := make(map[string]int, len(severityLevels)+1)
+	for severity := range severityLevels {
+		summary[severity] = 0
+	}
+	for _, v := range vulnerabilities {
+		summary[v.Severity]++
+	}
+	summary["Total"] = len(vulnerabilities)
+	return summary
+}
+
+// AtOrAbove returns the vulnerabilities with a severity at or above the threshold,
+// nothing is returned if there is no threshold
+func AtOrAbove(vulnerabilities []*Vulnerability, threshold string) []*Vulnerability {
+	if threshold == "" {
+		return nil
+	}
+	level := severityLevels[NormalizeSeverity(threshold)]
+
+	var res []*Vulnerability
+	for _, v := range vulnerabilities {
+		if severityLevels[v.Severity] >= level {
+			res = append(res, v)
+		}
+	}
+	return res
+}
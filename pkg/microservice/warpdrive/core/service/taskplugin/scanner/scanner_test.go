@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestParseTrivyResult(t *testing.T) {
+	assert := assert.New(t)
+	body := `{
+  "SchemaVersion": 2,
+  "ArtifactName": "koderover/demo:latest",
+  "Metadata": {"ImageID": "sha256:abc"},
+  "Results": [
+    {
+      "Target": "koderover/demo:latest (alpine 3.14.2)",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2021-3711", "PkgName": "libssl1.1", "InstalledVersion": "1.1.1k-r0", "FixedVersion": "1.1.1l-r0", "Severity": "CRITICAL", "Title": "openssl: SM2 Decryption Buffer Overflow", "PrimaryURL": "https://avd.aquasec.com/nvd/cve-2021-3711"}
+      ]
+    },
+    {"Target": "app/go.sum"}
+  ]
+}`
+
+	report, err := parseTrivyResult([]byte(body))
+	assert.NoError(err)
+	assert.Equal("sha256:abc", report.ImageID)
+	assert.Len(report.Vulnerabilities, 1)
+	assert.Equal(&Vulnerability{
+		ID:               "CVE-2021-3711",
+		Package:          "libssl1.1",
+		InstalledVersion: "1.1.1k-r0",
+		FixedVersion:     "1.1.1l-r0",
+		Severity:         SeverityCritical,
+		Description:      "openssl: SM2 Decryption Buffer Overflow",
+		Link:             "https://avd.aquasec.com/nvd/cve-2021-3711",
+		Target:           "koderover/demo:latest (alpine 3.14.2)",
+	}, report.Vulnerabilities[0])
+}
+
+func TestParseClairResult(t *testing.T) {
+	assert := assert.New(t)
+	body := `{"result": "success", "message": [{"imageId": "abc", "imageName": "koderover/demo:latest", "vulnerability": {"name": "CVE-2019-1543", "namespaceName": "debian:9", "severity": "Defcon1", "fixedBy": "1.1.0k-1"}, "feature": {"name": "openssl", "version": "1.1.0j-1"}}]}`
+
+	report, err := parseClairResult("koderover/demo:latest", []byte(body))
+	assert.NoError(err)
+	assert.Equal("abc", report.ImageID)
+	assert.Equal(SeverityCritical, report.Vulnerabilities[0].Severity)
+	assert.Equal("openssl", report.Vulnerabilities[0].Package)
+	assert.Equal("1.1.0k-1", report.Vulnerabilities[0].FixedVersion)
+
+	_, err = parseClairResult("koderover/demo:latest", []byte(`{"result": "failed"}`))
+	assert.Error(err)
+}
+
+func TestAtOrAbove(t *testing.T) {
+	assert := assert.New(t)
+	vulnerabilities := []*Vulnerability{
+		{ID: "a", Severity: SeverityCritical},
+		{ID: "b", Severity: SeverityMedium},
+		{ID: "c", Severity: SeverityUnknown},
+	}
+
+	assert.Empty(AtOrAbove(vulnerabilities, ""))
+	assert.Len(AtOrAbove(vulnerabilities, SeverityHigh), 1)
+	assert.Len(AtOrAbove(vulnerabilities, "medium"), 2)
+
+	summary := Summarize(vulnerabilities)
+	assert.Equal(1, summary[SeverityCritical])
+	assert.Equal(0, summary[SeverityHigh])
+	assert.Equal(3, summary["Total"])
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// trivyScanner runs the trivy cli with a vulnerability db prepared in the cache dir,
+// it never downloads the db so that it works in an offline cluster
+type trivyScanner struct {
+	dockerHost string
+	cacheDir   string
+}
+
+// trivyResult is the json output of trivy with schema version 2
+type trivyResult struct {
+	ArtifactName string `json:"ArtifactName"`
+	Metadata     struct {
+		ImageID string `json:"ImageID"`
+	} `json:"Metadata"`
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+			Description      string `json:"Description"`
+			PrimaryURL       string `json:"PrimaryURL"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+func (s *trivyScanner) Name() string {
+	return TrivyScanner
+}
+
+func (s *trivyScanner) Scan(ctx context.Context, imageName string) (*Report, error) {
+	args := []string{"image", "--quiet", "--format", "json", "--skip-db-update", "--offline-scan"}
+	if s.cacheDir != "" {
+		args = append(args, "--cache-dir", s.cacheDir)
+	}
+	args = append(args, imageName)
+
+	cmd := exec.CommandContext(ctx, "trivy", args...)
+	cmd.Env = os.Environ()
+	if s.dockerHost != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("DOCKER_HOST=%s", s.dockerHost))
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to scan %s with trivy: %s, %s", imageName, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseTrivyResult(stdout.Bytes())
+}
+
+func parseTrivyResult(body []byte) (*Report, error) {
+	result := &trivyResult{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("failed to parse the result of trivy: %s", err)
+	}
+
+	report := &Report{ImageID: result.Metadata.ImageID}
+	for _, r := range result.Results {
+		for _, v := range r.Vulnerabilities {
+			description := v.Title
+			if description == "" {
+				description = v.Description
+			}
+			report.Vulnerabilities = append(report.Vulnerabilities, &Vulnerability{
+				ID:               v.VulnerabilityID,
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Severity:         NormalizeSeverity(v.Severity),
+				Description:      description,
+				Link:             v.PrimaryURL,
+				Target:           r.Target,
+			})
+		}
+	}
+	return report, nil
+}
@@ -27,6 +27,7 @@ import (
 
 	configbase "github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin/scanner"
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types"
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
 	"github.com/koderover/zadig/pkg/tool/httpclient"
@@ -40,11 +41,14 @@ func InitializeSecurityPlugin(taskType config.TaskType) TaskPlugin {
 		httpClient: httpclient.New(
 			httpclient.SetHostURL(configbase.AslanServiceAddress()),
 		),
+		newScanner: scanner.New,
 	}
 }
 
 const (
 	SecurityTaskTimeout = 60 * 3 // 3 minutes
+	// maxListedVulnerabilities is how many vulnerabilities are listed in the error when the policy fails the task
+	maxListedVulnerabilities = 10
 )
 
 // SecurityPlugin Plugin name should be compatible with task type
@@ -59,10 +63,32 @@ type SecurityPlugin struct {
 	errorChan     chan error
 
 	httpClient *httpclient.Client
+	newScanner func(name string, opt *scanner.Options) (scanner.Scanner, error)
 }
 
+// deliverySecurityInfo is the vulnerabilities of an image kept in aslan, one record for each vulnerability of each package
 type deliverySecurityInfo struct {
-	Result string `json:"result"`
+	Result  string              `json:"result"`
+	Message []*deliverySecurity `json:"message"`
+}
+
+type deliverySecurity struct {
+	ImageID       string `json:"imageId"`
+	ImageName     string `json:"imageName"`
+	Severity      string `json:"severity"`
+	Scanner       string `json:"scanner"`
+	Vulnerability struct {
+		Name          string `json:"name"`
+		NamespaceName string `json:"namespaceName,omitempty"`
+		Description   string `json:"description,omitempty"`
+		Link          string `json:"link,omitempty"`
+		Severity      string `json:"severity"`
+		FixedBy       string `json:"fixedBy,omitempty"`
+	} `json:"vulnerability"`
+	Feature struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"feature"`
 }
 
 func (p *SecurityPlugin) SetAckFunc(func()) {
@@ -112,78 +138,84 @@ func (p *SecurityPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipel
 
 	imageName := p.Task.ImageName
 	go func() {
-		// send request to clair client to analysis image
-		body, err := p.analysis(ctx, imageName, pipelineCtx.DockerHost, namespace)
+		s, err := p.newScanner(p.Task.Scanner, &scanner.Options{
+			DockerHost:    pipelineCtx.DockerHost,
+			Namespace:     namespace,
+			TrivyCacheDir: config.TrivyCacheDir(),
+		})
 		if err != nil {
-			p.Log.Errorf("analysis err:%+v", err)
 			p.errorChan <- err
 			return
 		}
 
-		var imageID string
-		// send analysis result to aslan to store
-		if imageID, err = p.report(ctx, imageName, body); err != nil {
+		// scan the image with the scanner and keep the vulnerabilities in aslan
+		report, err := s.Scan(ctx, imageName)
+		if err != nil {
+			p.Log.Errorf("scan err:%+v", err)
+			p.errorChan <- err
+			return
+		}
+		if err = p.report(imageName, s.Name(), report); err != nil {
 			p.Log.Errorf("report err:%+v", err)
 			p.errorChan <- err
 			return
 		}
-		p.Task.ImageID = imageID
 
-		// get analysis summary and save to task
-		summary, err := p.getSummary(ctx, imageID)
-		if err != nil {
-			p.Log.Errorf("getSummary err:%+v", err)
+		if err = p.applyPolicy(report); err != nil {
 			p.errorChan <- err
 			return
 		}
-		p.Task.Summary = summary
 		p.Task.TaskStatus = config.StatusPassed
 	}()
 }
 
-func (p *SecurityPlugin) getSummary(ctx context.Context, imageID string) (map[string]int, error) {
-	url := "/api/delivery/security/stats"
+// applyPolicy saves the summary of the report and fails the task if there are vulnerabilities above the threshold
+func (p *SecurityPlugin) applyPolicy(report *scanner.Report) error {
+	p.Task.ImageID = report.ImageID
+	p.Task.Summary = scanner.Summarize(report.Vulnerabilities)
 
-	summary := map[string]int{}
-	_, err := p.httpClient.Get(url, httpclient.SetResult(&summary), httpclient.SetQueryParam("imageId", imageID))
-	if err != nil {
-		return nil, err
+	blocking := scanner.AtOrAbove(report.Vulnerabilities, p.Task.SeverityThreshold)
+	if len(blocking) == 0 {
+		return nil
 	}
-	return summary, nil
-}
-
-func (p *SecurityPlugin) report(ctx context.Context, imageName string, body []byte) (string, error) {
-	url := "/api/delivery/security"
-
-	res, err := p.httpClient.Post(url, httpclient.SetBody(body))
-	if err != nil {
-		return "", err
+	ids := make([]string, 0, maxListedVulnerabilities)
+	for i, v := range blocking {
+		if i == maxListedVulnerabilities {
+			ids = append(ids, "...")
+			break
+		}
+		ids = append(ids, v.ID)
 	}
-	p.Log.Info("security scan success !!! imageName :", imageName)
-	p.Task.ImageID = res.String()
-
-	return p.Task.ImageID, nil
+	return fmt.Errorf("%d vulnerabilities at or above %s severity: %s", len(blocking), p.Task.SeverityThreshold, strings.Join(ids, ", "))
 }
 
-func (p *SecurityPlugin) analysis(ctx context.Context, imageName, dockerHost, namespace string) ([]byte, error) {
-	url := fmt.Sprintf("%s/analyzeLocalImage", configbase.ClairServiceAddress())
-	qs := map[string]string{
-		"imageName":  imageName,
-		"dockerHost": dockerHost,
-		"namespace":  namespace,
-	}
+func (p *SecurityPlugin) report(imageName, scannerName string, report *scanner.Report) error {
+	url := "/api/delivery/security"
 
-	si := &deliverySecurityInfo{}
-	res, err := httpclient.Get(url, httpclient.SetQueryParams(qs), httpclient.SetResult(si), httpclient.ForceContentType("application/json"))
-	if err != nil {
-		return nil, err
+	body := &deliverySecurityInfo{Result: "success"}
+	for _, v := range report.Vulnerabilities {
+		record := &deliverySecurity{
+			ImageID:   report.ImageID,
+			ImageName: imageName,
+			Severity:  v.Severity,
+			Scanner:   scannerName,
+		}
+		record.Vulnerability.Name = v.ID
+		record.Vulnerability.NamespaceName = v.Target
+		record.Vulnerability.Description = v.Description
+		record.Vulnerability.Link = v.Link
+		record.Vulnerability.Severity = v.Severity
+		record.Vulnerability.FixedBy = v.FixedVersion
+		record.Feature.Name = v.Package
+		record.Feature.Version = v.InstalledVersion
+		body.Message = append(body.Message, record)
 	}
 
-	if si.Result == "success" {
-		return res.Body(), nil
+	if _, err := p.httpClient.Post(url, httpclient.SetBody(body)); err != nil {
+		return err
 	}
-
-	return nil, fmt.Errorf("failed to analysis %s", imageName)
+	p.Log.Info("security scan success !!! imageName :", imageName)
+	return nil
 }
 
 func (p *SecurityPlugin) Wait(ctx context.Context) {
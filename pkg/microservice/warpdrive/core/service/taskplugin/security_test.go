@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package taskplugin
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin/scanner"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+func securityPluginForTest(threshold string, s scanner.Scanner, aslanURL string) *SecurityPlugin {
+	plugin := InitializeSecurityPlugin(config.TaskSecurity).(*SecurityPlugin)
+	plugin.Task = &task.Security{
+		TaskType:          config.TaskSecurity,
+		Enabled:           true,
+		TaskStatus:        config.StatusRunning,
+		ImageName:         "koderover/demo:latest",
+		SeverityThreshold: threshold,
+	}
+	plugin.Log = zap.NewNop().Sugar()
+	plugin.httpClient = httpclient.New(httpclient.SetHostURL(aslanURL))
+	plugin.newScanner = func(name string, opt *scanner.Options) (scanner.Scanner, error) {
+		return s, nil
+	}
+	return plugin
+}
+
+func runSecurityPlugin(plugin *SecurityPlugin) {
+	pipelineTask := &task.Task{ConfigPayload: &task.ConfigPayload{}}
+	plugin.Run(context.Background(), pipelineTask, &task.PipelineCtx{}, "demo")
+	plugin.Wait(context.Background())
+}
+
+func TestSecurityPlugin_Run(t *testing.T) {
+	assert := assert.New(t)
+
+	var reported deliverySecurityInfo
+	aslan := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&reported)
+	}))
+	defer aslan.Close()
+
+	mock := &scanner.MockScanner{Report: &scanner.Report{
+		ImageID: "sha256:abc",
+		Vulnerabilities: []*scanner.Vulnerability{
+			{ID: "CVE-2021-0001", Package: "openssl", InstalledVersion: "1.1.1", FixedVersion: "1.1.2", Severity: scanner.SeverityHigh},
+			{ID: "CVE-2021-0002", Package: "zlib", InstalledVersion: "1.2", Severity: scanner.SeverityLow},
+		},
+	}}
+
+	plugin := securityPluginForTest(scanner.SeverityCritical, mock, aslan.URL)
+	runSecurityPlugin(plugin)
+	assert.Equal(config.StatusPassed, plugin.Task.TaskStatus)
+	assert.Equal([]string{"koderover/demo:latest"}, mock.Scanned)
+	assert.Equal("sha256:abc", plugin.Task.ImageID)
+	assert.Equal(1, plugin.Task.Summary[scanner.SeverityHigh])
+	assert.Equal(2, plugin.Task.Summary["Total"])
+	assert.Len(reported.Message, 2)
+	assert.Equal("openssl", reported.Message[0].Feature.Name)
+	assert.Equal("1.1.2", reported.Message[0].Vulnerability.FixedBy)
+
+	plugin = securityPluginForTest(scanner.SeverityHigh, mock, aslan.URL)
+	runSecurityPlugin(plugin)
+	assert.Equal(config.StatusFailed, plugin.Task.TaskStatus)
+	assert.Contains(plugin.Task.Error, "CVE-2021-0001")
+}
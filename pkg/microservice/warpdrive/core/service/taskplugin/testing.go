@@ -269,6 +269,7 @@ func (p *TestPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineC
 		p.Task.Error = msg
 		return
 	}
+	applyJobScheduling(job, jobScheduling(p.Task.ClusterID, p.Task.Scheduling, pipelineTask.ConfigPayload.K8SClusters))
 
 	if err := ensureDeleteJob(p.KubeNamespace, jobLabel, p.kubeClient); err != nil {
 		msg := fmt.Sprintf("delete testing job error: %v", err)
@@ -296,6 +297,9 @@ func (p *TestPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineC
 func (p *TestPlugin) Wait(ctx context.Context) {
 	status := waitJobEndWithFile(ctx, p.TaskTimeout(), p.KubeNamespace, p.JobName, true, p.kubeClient, p.clientset, p.restConfig, p.Log)
 	p.SetStatus(status)
+	if status == config.StatusFailed || status == config.StatusTimeout {
+		p.Task.FailureReason = jobFailureReason(p.KubeNamespace, p.JobName, p.kubeClient)
+	}
 }
 
 func (p *TestPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serviceName string) {
@@ -415,14 +419,29 @@ func (p *TestPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serv
 			return
 		}
 		p.Task.ReportReady = true
+		failures := testReport.FunctionTestSuite.Errors + testReport.FunctionTestSuite.Failures
+		if len(p.Task.JobCtx.QuarantinedCases) > 0 {
+			p.Task.QuarantinedFailures = quarantinedFailures(testReport.FunctionTestSuite.TestCases, p.Task.JobCtx.QuarantinedCases)
+			failures -= len(p.Task.QuarantinedFailures)
+			if len(p.Task.QuarantinedFailures) > 0 {
+				p.Log.Infof("%d failure case(s) in quarantine are ignored: %v", len(p.Task.QuarantinedFailures), p.Task.QuarantinedFailures)
+			}
+		}
 		testReport.FunctionTestSuite.TestCases = []types.TestCase{}
 		//测试报告
 		pipelineTask.TestReports[serviceName] = testReport
 
-		if testReport.FunctionTestSuite.Errors > 0 || testReport.FunctionTestSuite.Failures > 0 {
+		if p.Task.JobCtx.Coverage != nil {
+			p.Task.Coverage, err = downloadCoverage(s3client, store, fileName)
+			if err != nil {
+				p.Log.Warnf("failed to download coverage report: %v", err)
+			}
+		}
+
+		if failures > 0 {
 			msg := fmt.Sprintf(
 				"%d failure case(s) found",
-				testReport.FunctionTestSuite.Errors+testReport.FunctionTestSuite.Failures,
+				failures,
 			)
 			p.Log.Error(msg)
 			p.Task.Error = msg
@@ -430,6 +449,13 @@ func (p *TestPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serv
 			return
 		}
 
+		if msg := checkCoverage(p.Task.JobCtx.Coverage, p.Task.JobCtx.CoverageBaseline, p.Task.Coverage); msg != "" {
+			p.Log.Error(msg)
+			p.Task.Error = msg
+			p.Task.TaskStatus = config.StatusFailed
+			return
+		}
+
 	} else if p.Task.JobCtx.TestType == setting.PerformanceTest {
 		csvFile, err := os.Open(tmpFilename)
 		if err != nil {
@@ -539,6 +565,7 @@ func (p *TestPlugin) IsTaskEnabled() bool {
 
 func (p *TestPlugin) ResetError() {
 	p.Task.Error = ""
+	p.Task.FailureReason = ""
 }
 
 // Note: Since there are few environment variables and few variables to be replaced,
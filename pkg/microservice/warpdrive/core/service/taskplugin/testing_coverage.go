@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin/s3"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/types"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+// downloadCoverage downloads the coverage report uploaded by reaper next to the test result file
+func downloadCoverage(client *s3tool.Client, store *s3.S3, testResultFile string) (*types.CoverageReport, error) {
+	tmpFilename, err := util.GenerateTmpFile()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.Remove(tmpFilename)
+	}()
+
+	objectKey := store.GetObjectPath(types.CoverageReportFile(testResultFile))
+	if err := client.Download(store.Bucket, objectKey, tmpFilename); err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(tmpFilename)
+	if err != nil {
+		return nil, err
+	}
+	report := &types.CoverageReport{}
+	if err := json.Unmarshal(b, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// checkCoverage returns why the test fails because of its coverage, an empty string means the coverage is fine.
+// A missing report fails the test if a coverage gate is configured, otherwise the gate could be skipped by breaking the report.
+func checkCoverage(coverage *types.CoverageConfig, baseline *types.CoverageSummary, report *types.CoverageReport) string {
+	if coverage == nil {
+		return ""
+	}
+	if report == nil {
+		if coverage.Threshold > 0 || coverage.FailOnRegression {
+			return "coverage report not found, the coverage can not be checked"
+		}
+		return ""
+	}
+
+	if coverage.Threshold > 0 && report.LineRate < coverage.Threshold {
+		return fmt.Sprintf("line coverage %.2f%% is below the threshold %.2f%%", report.LineRate, coverage.Threshold)
+	}
+	if coverage.FailOnRegression && baseline != nil && report.LineRate < baseline.LineRate {
+		return fmt.Sprintf("line coverage %.2f%% regressed from %.2f%% of the base branch", report.LineRate, baseline.LineRate)
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/koderover/zadig/pkg/types"
+)
+
+func TestCheckCoverage(t *testing.T) {
+	report := &types.CoverageReport{CoverageSummary: types.CoverageSummary{LineRate: 75}}
+
+	assert.Empty(t, checkCoverage(nil, nil, report))
+	assert.Empty(t, checkCoverage(&types.CoverageConfig{Threshold: 70}, nil, report))
+	assert.NotEmpty(t, checkCoverage(&types.CoverageConfig{Threshold: 80}, nil, report))
+
+	baseline := &types.CoverageSummary{LineRate: 76}
+	assert.Empty(t, checkCoverage(&types.CoverageConfig{}, baseline, report))
+	assert.NotEmpty(t, checkCoverage(&types.CoverageConfig{FailOnRegression: true}, baseline, report))
+	assert.Empty(t, checkCoverage(&types.CoverageConfig{FailOnRegression: true}, nil, report))
+
+	assert.Empty(t, checkCoverage(&types.CoverageConfig{}, baseline, nil))
+	assert.NotEmpty(t, checkCoverage(&types.CoverageConfig{Threshold: 70}, nil, nil))
+	assert.NotEmpty(t, checkCoverage(&types.CoverageConfig{FailOnRegression: true}, baseline, nil))
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types"
+	commontypes "github.com/koderover/zadig/pkg/types"
+)
+
+// quarantinedFailures returns the names of the failed test cases which are in quarantine
+func quarantinedFailures(testCases []types.TestCase, quarantinedCases []string) []string {
+	quarantined := sets.NewString(quarantinedCases...)
+
+	var resp []string
+	for _, tc := range testCases {
+		if tc.Failure == nil && tc.Error == nil {
+			continue
+		}
+		if name := commontypes.TestCaseName(tc.ClassName, tc.Name); quarantined.Has(name) {
+			resp = append(resp, name)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types"
+)
+
+func TestQuarantinedFailures(t *testing.T) {
+	testCases := []types.TestCase{
+		{ClassName: "suite", Name: "passes"},
+		{ClassName: "suite", Name: "flaky", Failure: &types.Failure{Message: "timeout"}},
+		{ClassName: "suite", Name: "broken", Error: &types.Error{Message: "panic"}},
+		{Name: "standalone", Failure: &types.Failure{Message: "failed"}},
+	}
+
+	assert.Empty(t, quarantinedFailures(testCases, nil))
+	assert.Equal(t, []string{"suite.flaky"}, quarantinedFailures(testCases, []string{"suite.flaky", "suite.passes"}))
+	assert.Equal(t, []string{"suite.broken", "standalone"}, quarantinedFailures(testCases, []string{"standalone", "suite.broken"}))
+}
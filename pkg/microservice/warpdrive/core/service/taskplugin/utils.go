@@ -39,6 +39,8 @@ import (
 
 func int32Ptr(i int32) *int32 { return &i }
 
+func int64Ptr(i int64) *int64 { return &i }
+
 func uploadFileToS3(access, secret, bucket, remote, local string) error {
 	s3Cli, err := kodo.NewUploadClient(access, secret, bucket)
 	if err != nil {
@@ -224,6 +226,14 @@ func ToExtensionTask(sb map[string]interface{}) (*task.Extension, error) {
 	return extension, nil
 }
 
+func ToApprovalTask(sb map[string]interface{}) (*task.Approval, error) {
+	var approval *task.Approval
+	if err := task.IToi(sb, &approval); err != nil {
+		return nil, fmt.Errorf("convert interface to approvalTask error: %s", err)
+	}
+	return approval, nil
+}
+
 func GetK8sClients(hubServerAddr, clusterID string) (crClient.Client, kubernetes.Interface, *rest.Config, error) {
 	controllerRuntimeClient, err := kubeclient.GetKubeClient(hubServerAddr, clusterID)
 	if err != nil {
@@ -16,7 +16,10 @@ limitations under the License.
 
 package types
 
-import "github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+import (
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/types"
+)
 
 type PredatorContext struct {
 	JobType        string                 `yaml:"job_type"`
@@ -25,4 +28,8 @@ type PredatorContext struct {
 	OnSetup        string                 `yaml:"setup,omitempty"`
 	ReleaseImages  []task.RepoImage       `yaml:"release_images"`
 	DistributeInfo []*task.DistributeInfo `yaml:"distribute_info"`
+	// CopyAllPlatforms copies the whole manifest list of a multi-arch image when releasing it
+	CopyAllPlatforms bool `yaml:"copy_all_platforms"`
+	// Provenance are the options of the SBOM and the signature generated for the released images
+	Provenance *types.ProvenanceOptions `yaml:"provenance,omitempty"`
 }
@@ -123,6 +123,8 @@ type Context struct {
 	Cache        types.Cache        `yaml:"cache"`
 	CacheDirType types.CacheDirType `yaml:"cache_dir_type"`
 	CacheUserDir string             `yaml:"cache_user_dir"`
+	// CacheDefaultBranch is the branch whose cache is restored when the branch being built has no cache yet
+	CacheDefaultBranch string `yaml:"cache_default_branch"`
 
 	// Upload To S3 related context
 	UploadEnabled     bool                             `yaml:"upload_enabled"`
@@ -225,6 +227,7 @@ type Repo struct {
 	Password     string `yaml:"password"`
 	CheckoutRef  string `yaml:"checkout_ref"`
 	EnableProxy  bool   `yaml:"enable_proxy"`
+	IsPrimary    bool   `yaml:"is_primary"`
 }
 
 // PRRef returns refs format
@@ -281,6 +284,10 @@ type GinkgoTest struct {
 	ResultPath     string   `yaml:"result_path"`
 	TestReportPath string   `yaml:"test_report_path"`
 	ArtifactPaths  []string `yaml:"artifact_paths"`
+	ReportFormat   string   `yaml:"report_format,omitempty"`
+	ResultGlob     string   `yaml:"result_glob,omitempty"`
+	CoverageFormat string   `yaml:"coverage_format,omitempty"`
+	CoveragePath   string   `yaml:"coverage_path,omitempty"`
 }
 
 // DockerRegistry 推送镜像到 docker registry 配置
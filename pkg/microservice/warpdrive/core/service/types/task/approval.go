@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package task
+
+import (
+	"fmt"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+)
+
+type Approval struct {
+	TaskType        config.TaskType  `bson:"type"                       json:"type"`
+	Enabled         bool             `bson:"enabled"                    json:"enabled"`
+	TaskStatus      config.Status    `bson:"status"                     json:"status"`
+	Approvers       []string         `bson:"approvers"                  json:"approvers"`
+	NeededApprovers int              `bson:"needed_approvers"           json:"needed_approvers"`
+	Description     string           `bson:"description,omitempty"      json:"description,omitempty"`
+	ApproveResults  []*ApproveResult `bson:"approve_results,omitempty"  json:"approve_results,omitempty"`
+	Timeout         int              `bson:"timeout"                    json:"timeout,omitempty"`
+	IsRestart       bool             `bson:"is_restart"                 json:"is_restart"`
+	Error           string           `bson:"error,omitempty"            json:"error,omitempty"`
+	StartTime       int64            `bson:"start_time"                 json:"start_time,omitempty"`
+	EndTime         int64            `bson:"end_time"                   json:"end_time,omitempty"`
+}
+
+// ApproveResult records who approved or rejected the task and when
+type ApproveResult struct {
+	UserName      string `bson:"user_name"                  json:"user_name"`
+	Approved      bool   `bson:"approved"                   json:"approved"`
+	Comment       string `bson:"comment,omitempty"          json:"comment,omitempty"`
+	OperationTime int64  `bson:"operation_time"             json:"operation_time"`
+}
+
+// ToSubTask ...
+func (t *Approval) ToSubTask() (map[string]interface{}, error) {
+	var task map[string]interface{}
+	if err := IToi(t, &task); err != nil {
+		return nil, fmt.Errorf("convert approval to interface error: %s", err)
+	}
+	return task, nil
+}
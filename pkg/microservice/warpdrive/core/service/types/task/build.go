@@ -67,6 +67,35 @@ type Build struct {
 	CacheEnable  bool               `bson:"cache_enable"        json:"cache_enable"`
 	CacheDirType types.CacheDirType `bson:"cache_dir_type"      json:"cache_dir_type"`
 	CacheUserDir string             `bson:"cache_user_dir"      json:"cache_user_dir"`
+	// CacheDefaultBranch is the branch whose cache is restored when the branch being built has no cache yet,
+	// CacheResult is the cache hit or miss reported by reaper
+	CacheDefaultBranch string            `bson:"cache_default_branch,omitempty" json:"cache_default_branch,omitempty"`
+	CacheResult        *types.BuildCache `bson:"cache_result,omitempty"         json:"cache_result,omitempty"`
+
+	// Scheduling is the scheduling of the build template, it is merged with the defaults of the cluster
+	Scheduling *types.JobScheduling `bson:"scheduling,omitempty"            json:"scheduling,omitempty"`
+
+	// Provenance is the SBOM and the signature of the pushed image reported by reaper
+	Provenance *types.ImageProvenance `bson:"provenance,omitempty"            json:"provenance,omitempty"`
+
+	// ReuseResult allows the build to be skipped when a previous build has the same ResultKey,
+	// BuildRevision is the update time of the build template which is part of the key
+	ReuseResult   bool               `bson:"reuse_result,omitempty"          json:"reuse_result,omitempty"`
+	BuildRevision int64              `bson:"build_revision,omitempty"        json:"build_revision,omitempty"`
+	ResultKey     string             `bson:"result_key,omitempty"            json:"result_key,omitempty"`
+	ReusedResult  *ReusedBuildResult `bson:"reused_result,omitempty"         json:"reused_result,omitempty"`
+
+	// Steps are the phases reaper went through with their duration and result
+	Steps []*types.BuildStep `bson:"steps,omitempty"                 json:"steps,omitempty"`
+	// FailureReason is the exact reason of the failure if it is known, e.g. the image of the job can not be pulled
+	FailureReason string `bson:"failure_reason,omitempty"        json:"failure_reason,omitempty"`
+}
+
+// ReusedBuildResult is the previous build whose image is reused instead of building again
+type ReusedBuildResult struct {
+	PipelineName string `bson:"pipeline_name"       json:"pipeline_name"`
+	TaskID       int64  `bson:"task_id"             json:"task_id"`
+	Image        string `bson:"image"               json:"image"`
 }
 
 type ArtifactInfo struct {
@@ -178,6 +207,14 @@ type JobCtx struct {
 	TestResultPath string `bson:"test_result_path,omitempty"     json:"test_result_path,omitempty"`
 	TestReportPath string `bson:"test_report_path,omitempty"     json:"test_report_path,omitempty"`
 	TestJobName    string `bson:"test_job_name,omitempty"        json:"test_job_name,omitempty"`
+	// TestReportFormat and TestResultGlob select the result files and how they are parsed
+	TestReportFormat string `bson:"test_report_format,omitempty" json:"test_report_format,omitempty"`
+	TestResultGlob   string `bson:"test_result_glob,omitempty"   json:"test_result_glob,omitempty"`
+	// Coverage is collected after the test, CoverageBaseline is the latest coverage of the base branch
+	Coverage         *types.CoverageConfig  `bson:"coverage,omitempty"          json:"coverage,omitempty"`
+	CoverageBaseline *types.CoverageSummary `bson:"coverage_baseline,omitempty" json:"coverage_baseline,omitempty"`
+	// QuarantinedCases are the test cases whose failures do not fail the test
+	QuarantinedCases []string `bson:"quarantined_cases,omitempty" json:"quarantined_cases,omitempty"`
 	// DockerBuildCtx
 	DockerBuildCtx *DockerBuildCtx `bson:"docker_build_ctx,omitempty" json:"docker_build_ctx,omitempty"`
 	FileArchiveCtx *FileArchiveCtx `bson:"file_archive_ctx,omitempty" json:"file_archive_ctx,omitempty"`
@@ -222,13 +259,17 @@ type SSH struct {
 // DockerFile: dockerfile名称, 默认为Dockerfile
 // ImageBuild: build image镜像全称, e.g. xxx.com/release-candidates/image:tag
 type DockerBuildCtx struct {
-	WorkDir               string `yaml:"work_dir" bson:"work_dir" json:"work_dir"`
-	DockerFile            string `yaml:"docker_file" bson:"docker_file" json:"docker_file"`
-	ImageName             string `yaml:"image_name" bson:"image_name" json:"image_name"`
-	BuildArgs             string `yaml:"build_args" bson:"build_args" json:"build_args"`
-	ImageReleaseTag       string `yaml:"image_release_tag,omitempty" bson:"image_release_tag,omitempty" json:"image_release_tag"`
-	Source                string `yaml:"source" bson:"source" json:"source"`
-	DockerTemplateContent string `yaml:"docker_template_content" bson:"docker_template_content" json:"docker_template_content"`
+	WorkDir               string   `yaml:"work_dir" bson:"work_dir" json:"work_dir"`
+	DockerFile            string   `yaml:"docker_file" bson:"docker_file" json:"docker_file"`
+	ImageName             string   `yaml:"image_name" bson:"image_name" json:"image_name"`
+	BuildArgs             string   `yaml:"build_args" bson:"build_args" json:"build_args"`
+	ImageReleaseTag       string   `yaml:"image_release_tag,omitempty" bson:"image_release_tag,omitempty" json:"image_release_tag"`
+	Source                string   `yaml:"source" bson:"source" json:"source"`
+	DockerTemplateContent string   `yaml:"docker_template_content" bson:"docker_template_content" json:"docker_template_content"`
+	Builder               string   `yaml:"builder,omitempty" bson:"builder,omitempty" json:"builder,omitempty"`
+	Platforms             []string `yaml:"platforms,omitempty" bson:"platforms,omitempty" json:"platforms,omitempty"`
+	// Provenance are the options of the SBOM and the signature generated for the pushed image
+	Provenance *types.ProvenanceOptions `yaml:"provenance,omitempty" bson:"provenance,omitempty" json:"provenance,omitempty"`
 }
 
 type FileArchiveCtx struct {
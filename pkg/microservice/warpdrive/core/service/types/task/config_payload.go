@@ -20,6 +20,8 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+
+	"github.com/koderover/zadig/pkg/types"
 )
 
 type ConfigPayload struct {
@@ -58,6 +60,9 @@ type ConfigPayload struct {
 
 	// build concurrency settings
 	BuildConcurrency int64 `json:"build_concurrency"`
+
+	// VerifyImageSignature refuses to deploy unsigned images, the signing key itself is passed to the jobs in a secret
+	VerifyImageSignature bool `json:"verify_image_signature"`
 }
 
 func (cp *ConfigPayload) GetGitKnownHost() string {
@@ -170,6 +175,8 @@ type K8SCluster struct {
 type AdvancedConfig struct {
 	Strategy   string                     `json:"strategy,omitempty"      bson:"strategy,omitempty"`
 	NodeLabels []*NodeSelectorRequirement `json:"node_labels,omitempty"   bson:"node_labels,omitempty"`
+	// Scheduling is the default scheduling of the build and test jobs running in the cluster
+	Scheduling *types.JobScheduling `json:"scheduling,omitempty"    bson:"scheduling,omitempty"`
 }
 
 type NodeSelectorRequirement struct {
@@ -54,6 +54,45 @@ type Deploy struct {
 	IsRestart        bool                         `bson:"is_restart"                    json:"is_restart"`
 	ResetImage       bool                         `bson:"reset_image"                   json:"reset_image"`
 	ResetImagePolicy setting.ResetImagePolicyType `bson:"reset_image_policy"            json:"reset_image_policy"`
+	// Strategy is nil for a rolling update
+	Strategy *DeployStrategy `bson:"strategy,omitempty"            json:"strategy,omitempty"`
+	Rollout  *RolloutStatus  `bson:"rollout,omitempty"             json:"rollout,omitempty"`
+	// RollbackOnFailure restores the images recorded in ReplaceResources, or the previous helm revision,
+	// if the deploy fails, RolledBack is set when it happens
+	RollbackOnFailure bool `bson:"rollback_on_failure,omitempty" json:"rollback_on_failure,omitempty"`
+	RolledBack        bool `bson:"rolled_back,omitempty"         json:"rolled_back,omitempty"`
+	// FailureReason is the exact reason of the failure if it is known, e.g. the new image can not be pulled
+	FailureReason string `bson:"failure_reason,omitempty"       json:"failure_reason,omitempty"`
+}
+
+// RolloutStatus records the progress of a canary or blue-green deploy
+type RolloutStatus struct {
+	Strategy string `bson:"strategy"                json:"strategy"`
+	Phase    string `bson:"phase"                   json:"phase"`
+	// Workload is the canary or green copy of the deployment
+	Workload string `bson:"workload"                json:"workload"`
+	Message  string `bson:"message,omitempty"       json:"message,omitempty"`
+	// Services are the Services of the deployment found when the rollout starts, they are switched,
+	// restored and cleaned up by name since their selectors no longer match the deployment once switched
+	Services []*RolloutService `bson:"services,omitempty"      json:"services,omitempty"`
+}
+
+// RolloutService is a Service of the deployment and its selector before the rollout
+type RolloutService struct {
+	Name     string            `bson:"name"                    json:"name"`
+	Selector map[string]string `bson:"selector"                json:"selector"`
+}
+
+// DeployStrategy rolls out the new image with a canary or blue-green deploy instead of a rolling update,
+// only deployments are supported, statefulsets are still updated in place
+type DeployStrategy struct {
+	Type string `bson:"type"                          json:"type"`
+	// CanaryReplicas is the replicas of the canary, the traffic is split by the replica ratio if TrafficWeight is 0
+	CanaryReplicas int `bson:"canary_replicas,omitempty"     json:"canary_replicas,omitempty"`
+	// TrafficWeight is the percentage of traffic istio routes to the canary
+	TrafficWeight int `bson:"traffic_weight,omitempty"      json:"traffic_weight,omitempty"`
+	// VerifySeconds is how long the new version must stay healthy before it is promoted
+	VerifySeconds int `bson:"verify_seconds,omitempty"      json:"verify_seconds,omitempty"`
 }
 
 // SetNamespace ...
@@ -84,6 +84,16 @@ type Task struct {
 	IsRestart        bool                         `bson:"is_restart"                  json:"is_restart"`
 	StorageEndpoint  string                       `bson:"storage_endpoint"            json:"storage_endpoint"`
 	ArtifactInfo     *ArtifactInfo                `bson:"artifact_info"               json:"artifact_info"`
+	// DAG 自定义工作流中子任务之间的依赖关系, 为空时按顺序执行
+	DAG []*DAGNode `bson:"dag,omitempty"               json:"dag,omitempty"`
+	// StagePolicies 控制各个stage的执行条件和失败策略
+	StagePolicies []*common.StagePolicy `bson:"stage_policies,omitempty"    json:"stage_policies,omitempty"`
+}
+
+// DAGNode names a sub task of a custom workflow and lists the sub tasks it depends on
+type DAGNode struct {
+	Name      string   `bson:"name"                 json:"name"`
+	DependsOn []string `bson:"depends_on,omitempty" json:"depends_on,omitempty"`
 }
 
 type RenderInfo struct {
@@ -149,6 +159,8 @@ type WorkflowTaskArgs struct {
 	IgnoreCache bool `json:"ignore_cache" bson:"ignore_cache"`
 	// Ignore workspace cache and reset volume
 	ResetCache bool `json:"reset_cache" bson:"reset_cache"`
+	// Build again even if a previous build result could be reused
+	ForceRebuild bool `json:"force_rebuild" bson:"force_rebuild"`
 
 	// NotificationId is the id of scmnotify.Notification
 	NotificationID string `bson:"notification_id" json:"notification_id"`
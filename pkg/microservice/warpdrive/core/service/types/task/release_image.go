@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/types"
 )
 
 type ReleaseImageItem struct {
@@ -48,6 +49,13 @@ type ReleaseImage struct {
 	ProductName    string            `bson:"product_name"    json:"product_name"`
 	SourceImage    string            `bson:"source_image"    json:"source_image"`
 	DistributeInfo []*DistributeInfo `bson:"distribute_info" json:"distribute_info"`
+
+	// Provenance are the options of the SBOM and the signature generated for the released images,
+	// Provenances are the results reported by predator
+	Provenance  *types.ProvenanceOptions `bson:"provenance,omitempty"  json:"provenance,omitempty"`
+	Provenances []*types.ImageProvenance `bson:"provenances,omitempty" json:"provenances,omitempty"`
+	// Platforms are the platforms of the image given in its build, the image is copied with all of them if it is not empty
+	Platforms []string `bson:"platforms,omitempty" json:"platforms,omitempty"`
 }
 
 // DistributeInfo will be convert into yaml, adding yaml
@@ -33,6 +33,8 @@ type PipelineCtx struct {
 	CacheEnable  bool
 	CacheDirType types.CacheDirType
 	CacheUserDir string
+	// CacheDefaultBranch is only set for build jobs
+	CacheDefaultBranch string
 }
 
 type JobCtxBuilder struct {
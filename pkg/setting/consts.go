@@ -91,6 +91,7 @@ const (
 	DefaultRegistryAddr = "DEFAULT_REG_ADDRESS"
 	DefaultRegistryAK   = "DEFAULT_REG_ACCESS_KEY"
 	DefaultRegistrySK   = "DEFAULT_REG_SECRET_KEY"
+	TrivyCacheDir       = "TRIVY_CACHE_DIR"
 
 	// reaper
 	Home          = "HOME"
@@ -226,6 +227,11 @@ const (
 	// PMDeployType physical machine deploy method
 	PMDeployType = "pm"
 
+	// DeployStrategyCanary runs a canary copy of the workload with the new image and promotes it after verification
+	DeployStrategyCanary = "canary"
+	// DeployStrategyBlueGreen switches the Service to a copy of the workload with the new image
+	DeployStrategyBlueGreen = "blue_green"
+
 	// Infrastructure k8s type
 	BasicFacilityK8S = "kubernetes"
 	// Infrastructure Cloud Hosting
@@ -460,6 +466,22 @@ const (
 	PerformanceTest = "performance"
 )
 
+// Formats of function test results, the results are parsed as ginkgo junit reports if the format is empty
+const (
+	TestReportFormatGinkgo = "ginkgo"
+	TestReportFormatJUnit  = "junit"
+	TestReportFormatGoTest = "go-test-json"
+	TestReportFormatTAP    = "tap"
+)
+
+// Formats of coverage files collected by test jobs
+const (
+	CoverageFormatCobertura = "cobertura"
+	CoverageFormatLCOV      = "lcov"
+	CoverageFormatJaCoCo    = "jacoco"
+	CoverageFormatGo        = "go"
+)
+
 const (
 	// UbuntuPrecis ...
 	UbuntuPrecis = "precise"
@@ -479,6 +501,8 @@ const (
 	EnvRecyclePolicyAlways     = "always"
 	EnvRecyclePolicyTaskStatus = "success"
 	EnvRecyclePolicyNever      = "never"
+	// EnvRecyclePolicyPRClosed keeps the environment until the pull request is closed or merged
+	EnvRecyclePolicyPRClosed = "pr_closed"
 )
 
 const (
@@ -539,6 +563,13 @@ const (
 	ZadigDockerfilePath = "zadig-dockerfile"
 )
 
+// Image builders used by reaper, docker needs a docker daemon while buildkit and kaniko are daemonless
+const (
+	ImageBuilderDocker   = "docker"
+	ImageBuilderBuildKit = "buildkit"
+	ImageBuilderKaniko   = "kaniko"
+)
+
 // Yaml template constant
 const (
 	RegExpParameter = `{{.(\w)+}}`
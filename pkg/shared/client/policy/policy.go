@@ -115,7 +115,7 @@ type ResourcePermissionReq struct {
 func (c *Client) GetResourcePermission(req *ResourcePermissionReq) (map[string][]string, error) {
 	url := fmt.Sprintf("/permission/resources")
 	result := make(map[string][]string)
-	_, err := c.Post(url, httpclient.SetResult(req), httpclient.SetResult(&result))
+	_, err := c.Post(url, httpclient.SetBody(req), httpclient.SetResult(&result))
 	if err != nil {
 		log.Errorf("Failed to get resourcePermission,err: %s", err)
 		return nil, err
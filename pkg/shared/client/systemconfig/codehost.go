@@ -59,7 +59,7 @@ func GetCodeHostInfo(opt *Option) (*CodeHost, error) {
 }
 
 func (c *Client) GetCodeHost(id int) (*CodeHost, error) {
-	url := fmt.Sprintf("/codehosts/%d", id)
+	url := fmt.Sprintf("/codehosts/internal/%d", id)
 
 	res := &CodeHost{}
 	_, err := c.Get(url, httpclient.SetResult(res))
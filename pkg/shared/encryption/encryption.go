@@ -0,0 +1,323 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+// SecretField is a field of a collection which holds a credential
+type SecretField struct {
+	Collection string
+	// Field is the path of the field, the fields of embedded documents are separated by dots
+	Field string
+	// LegacyDecrypt decrypts the values stored before envelope encryption, they are plain text if it is nil
+	LegacyDecrypt func(string) (string, error)
+	// LegacyEncrypt encrypts the values back to the format before envelope encryption, it is used by rollback
+	LegacyEncrypt func(string) (string, error)
+}
+
+// SecretFields are all credentials which are encrypted at rest
+var SecretFields = []*SecretField{
+	{Collection: "private_key", Field: "private_key"},
+	{Collection: "registry_namespace", Field: "access_key"},
+	{Collection: "registry_namespace", Field: "secret_key"},
+	{Collection: "s3storage", Field: "ak"},
+	{Collection: "s3storage", Field: "encryptedSk", LegacyDecrypt: DecryptLegacy, LegacyEncrypt: crypto.AesEncrypt},
+	{Collection: "jenkins_integration", Field: "password"},
+	{Collection: "code_host", Field: "access_token"},
+	{Collection: "code_host", Field: "refresh_token"},
+	{Collection: "code_host", Field: "password"},
+	{Collection: "code_host", Field: "client_secret"},
+	{Collection: "env_snapshot", Field: "secret_yamls"},
+	{Collection: "system_setting", Field: "image_signing.private_key"},
+	{Collection: "system_setting", Field: "image_signing.password"},
+}
+
+var (
+	mu       sync.Mutex
+	envelope *crypto.Envelope
+)
+
+// getEnvelope loads the data keys from mongodb, the first data key is created if there is none
+func getEnvelope(reload bool) (*crypto.Envelope, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if envelope != nil && !reload {
+		return envelope, nil
+	}
+
+	coll := NewDataKeyColl()
+	keys, err := coll.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data keys: %s", err)
+	}
+	if len(keys) == 0 {
+		if err := coll.CreateFirst(); err != nil {
+			return nil, fmt.Errorf("failed to create data key: %s", err)
+		}
+		if keys, err = coll.List(); err != nil {
+			return nil, fmt.Errorf("failed to list data keys: %s", err)
+		}
+	}
+
+	var activeID string
+	dataKeys := make([]*crypto.DataKey, 0, len(keys))
+	for _, key := range keys {
+		plain, err := crypto.AesDecrypt(key.WrappedKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap data key %s: %s", key.ID.Hex(), err)
+		}
+		dataKeys = append(dataKeys, &crypto.DataKey{ID: key.ID.Hex(), Key: plain})
+		// keys are sorted from the oldest, the newest active one wins
+		if key.Active {
+			activeID = key.ID.Hex()
+		}
+	}
+
+	env, err := crypto.NewEnvelope(activeID, dataKeys)
+	if err != nil {
+		return nil, err
+	}
+	envelope = env
+	return envelope, nil
+}
+
+// Encrypt seals the credential with the active data key before it is stored
+func Encrypt(secret string) (string, error) {
+	if secret == "" || crypto.IsSealed(secret) {
+		return secret, nil
+	}
+
+	env, err := getEnvelope(false)
+	if err != nil {
+		return "", err
+	}
+	return env.Seal(secret)
+}
+
+// Decrypt opens a stored credential, credentials stored before encryption was enabled are returned as is
+func Decrypt(value string) (string, error) {
+	if !crypto.IsSealed(value) {
+		return value, nil
+	}
+
+	env, err := getEnvelope(false)
+	if err != nil {
+		return "", err
+	}
+	plain, err := env.Open(value)
+	if errors.Is(err, crypto.ErrUnknownDataKey) {
+		// the key may be rotated by another service
+		if env, err = getEnvelope(true); err != nil {
+			return "", err
+		}
+		return env.Open(value)
+	}
+	return plain, err
+}
+
+// DecryptLegacy opens a credential which is sealed by an envelope or encrypted by the master AES key only
+func DecryptLegacy(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if crypto.IsSealed(value) {
+		return Decrypt(value)
+	}
+	return crypto.AesDecrypt(value)
+}
+
+// KeepStored keeps the stored credential if value is empty. Credentials are never returned to the client
+// in plain text, so an empty credential in an update request means it is not changed. The stored credential
+// is only loaded if it is needed.
+func KeepStored(value *string, load func() (string, error)) error {
+	if *value != "" {
+		return nil
+	}
+	stored, err := load()
+	if err != nil {
+		return err
+	}
+	*value = stored
+	return nil
+}
+
+// RotateKey creates a new active data key, credentials sealed by the old data keys can still be decrypted
+// until EncryptSecrets re-encrypts them with the new one
+func RotateKey() (*DataKey, error) {
+	key, err := NewDataKeyColl().Create()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := getEnvelope(true); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EncryptSecrets seals all credentials which are plain text or sealed by an inactive data key with the active one,
+// it returns the number of updated documents
+func EncryptSecrets() (int, error) {
+	env, err := getEnvelope(true)
+	if err != nil {
+		return 0, err
+	}
+
+	return updateSecrets(func(f *SecretField, value string) (string, bool, error) {
+		if !crypto.IsSealed(value) && f.LegacyDecrypt != nil {
+			if value, err = f.LegacyDecrypt(value); err != nil {
+				return "", false, err
+			}
+		}
+		return env.Reseal(value)
+	})
+}
+
+// DecryptSecrets restores all sealed credentials to the format before envelope encryption,
+// it is only used to roll back to a version without envelope encryption
+func DecryptSecrets() (int, error) {
+	env, err := getEnvelope(true)
+	if err != nil {
+		return 0, err
+	}
+
+	return updateSecrets(func(f *SecretField, value string) (string, bool, error) {
+		if !crypto.IsSealed(value) {
+			return value, false, nil
+		}
+		plain, err := env.Open(value)
+		if err != nil {
+			return "", false, err
+		}
+		if f.LegacyEncrypt != nil {
+			if plain, err = f.LegacyEncrypt(plain); err != nil {
+				return "", false, err
+			}
+		}
+		return plain, true, nil
+	})
+}
+
+// convertFunc converts a stored credential, the bool result reports whether the value is changed
+type convertFunc func(f *SecretField, value string) (string, bool, error)
+
+func updateSecrets(convert convertFunc) (int, error) {
+	var collections []string
+	fields := make(map[string][]*SecretField)
+	for _, f := range SecretFields {
+		if _, ok := fields[f.Collection]; !ok {
+			collections = append(collections, f.Collection)
+		}
+		fields[f.Collection] = append(fields[f.Collection], f)
+	}
+
+	var updated int
+	for _, collection := range collections {
+		count, err := updateCollection(collection, fields[collection], convert)
+		if err != nil {
+			return updated, fmt.Errorf("failed to update secrets in %s: %s", collection, err)
+		}
+		updated += count
+	}
+	return updated, nil
+}
+
+func updateCollection(collection string, fields []*SecretField, convert convertFunc) (int, error) {
+	coll := mongotool.Database(config.MongoDatabase()).Collection(collection)
+
+	projection := bson.M{"_id": 1}
+	for _, f := range fields {
+		projection[f.Field] = 1
+	}
+	cursor, err := coll.Find(context.TODO(), bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return 0, err
+	}
+
+	var docs []bson.M
+	if err := cursor.All(context.TODO(), &docs); err != nil {
+		return 0, err
+	}
+
+	var ms []mongo.WriteModel
+	for _, doc := range docs {
+		// the stored values are part of the filter, so a document changed by a concurrent save
+		// since it was read is left alone instead of being overwritten with the stale secret
+		filter := bson.M{"_id": doc["_id"]}
+		change := bson.M{}
+		for _, f := range fields {
+			value, ok := lookupField(doc, f.Field)
+			if !ok || value == "" {
+				continue
+			}
+			converted, changed, err := convert(f, value)
+			if err != nil {
+				return 0, err
+			}
+			if changed {
+				filter[f.Field] = value
+				change[f.Field] = converted
+			}
+		}
+		if len(change) > 0 {
+			ms = append(ms, mongo.NewUpdateOneModel().
+				SetFilter(filter).
+				SetUpdate(bson.M{"$set": change}),
+			)
+		}
+	}
+
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	res, err := coll.BulkWrite(context.TODO(), ms, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+// lookupField gets the string value of a field, the fields of embedded documents are separated by dots
+func lookupField(doc bson.M, field string) (string, bool) {
+	parts := strings.Split(field, ".")
+	for _, part := range parts[:len(parts)-1] {
+		switch embedded := doc[part].(type) {
+		case bson.M:
+			doc = embedded
+		case bson.D:
+			doc = embedded.Map()
+		default:
+			return "", false
+		}
+	}
+	value, ok := doc[parts[len(parts)-1]].(string)
+	return value, ok
+}
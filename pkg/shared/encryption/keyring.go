@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+// DataKey is a data key wrapped by the master AES key, the plain key is never stored
+type DataKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WrappedKey string             `bson:"wrapped_key"   json:"-"`
+	Active     bool               `bson:"active"        json:"active"`
+	CreateTime int64              `bson:"create_time"   json:"create_time"`
+}
+
+func (DataKey) TableName() string {
+	return "encryption_key"
+}
+
+type DataKeyColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewDataKeyColl() *DataKeyColl {
+	name := DataKey{}.TableName()
+	return &DataKeyColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *DataKeyColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *DataKeyColl) EnsureIndex(ctx context.Context) error {
+	return nil
+}
+
+// List lists all data keys from the oldest to the newest
+func (c *DataKeyColl) List() ([]*DataKey, error) {
+	resp := make([]*DataKey, 0)
+	opts := options.Find().SetSort(bson.D{{"_id", 1}})
+	cursor, err := c.Collection.Find(context.TODO(), bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+// firstDataKeyID is the id of the first data key, services starting at the same time
+// can not create more than one first key since the id is unique
+var firstDataKeyID = primitive.ObjectID{11: 1}
+
+// CreateFirst creates the first data key, it does nothing if the key is created by another service
+func (c *DataKeyColl) CreateFirst() error {
+	dataKey, err := newDataKey(firstDataKeyID)
+	if err != nil {
+		return err
+	}
+	_, err = c.InsertOne(context.TODO(), dataKey)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// Create generates a new data key and makes it the only active one
+func (c *DataKeyColl) Create() (*DataKey, error) {
+	dataKey, err := newDataKey(primitive.NewObjectID())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.InsertOne(context.TODO(), dataKey); err != nil {
+		return nil, err
+	}
+
+	// only the older keys are deactivated, the newest key stays active if keys are rotated concurrently
+	query := bson.M{"_id": bson.M{"$lt": dataKey.ID}, "active": true}
+	change := bson.M{"$set": bson.M{"active": false}}
+	_, err = c.UpdateMany(context.TODO(), query, change)
+	return dataKey, err
+}
+
+func newDataKey(id primitive.ObjectID) (*DataKey, error) {
+	key, err := crypto.GenerateDataKey()
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := crypto.AesEncrypt(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataKey{
+		ID:         id,
+		WrappedKey: wrapped,
+		Active:     true,
+		CreateTime: time.Now().Unix(),
+	}, nil
+}
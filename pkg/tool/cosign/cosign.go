@@ -0,0 +1,180 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosign
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/types"
+)
+
+const (
+	cosignExe = "cosign"
+	syftExe   = "syft"
+
+	// privateKeyEnv and passwordEnv hold the signing key in the environment of the cosign process only
+	privateKeyEnv = "COSIGN_PRIVATE_KEY"
+	passwordEnv   = "COSIGN_PASSWORD"
+)
+
+// AttestOptions are the options of generating the provenance of an image
+type AttestOptions struct {
+	*types.ProvenanceOptions
+	// Envs are added to the environments of the commands, the registry is accessed with the docker config in them
+	Envs   []string
+	Output io.Writer
+}
+
+// Attest resolves the digest of a pushed image and attaches its SBOM, the SBOM is stored in the registry along with
+// the image in the cosign layout. The image is never signed here since the jobs run user scripts, it is signed by
+// Sign with the key which never leaves aslan
+func Attest(image string, opts *AttestOptions) (*types.ImageProvenance, error) {
+	sigRef, err := opts.run(cosignExe, "triangulate", image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the digest of %s: %s", image, err)
+	}
+	digest, err := DigestFromRef(sigRef)
+	if err != nil {
+		return nil, err
+	}
+	ref := ImageRepo(image) + "@" + digest
+	p := &types.ImageProvenance{Image: image, Digest: digest}
+
+	if opts.SBOMFormat != "" {
+		sbom, err := opts.generateSBOM(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the sbom of %s: %s", image, err)
+		}
+		defer os.Remove(sbom)
+
+		_, err = opts.run(cosignExe, "attach", "sbom", "--sbom", sbom, "--type", sbomType(opts.SBOMFormat), ref)
+		if err == nil {
+			p.SBOMRef, err = opts.run(cosignExe, "triangulate", "--type", "sbom", ref)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach the sbom to %s: %s", image, err)
+		}
+		p.SBOMFormat = opts.SBOMFormat
+	}
+
+	return p, nil
+}
+
+// SignOptions are the options of signing an image
+type SignOptions struct {
+	// PrivateKey and Password are the cosign key pair the image is signed with
+	PrivateKey string
+	Password   string
+	// DockerConfig is the directory of the docker config the registry is accessed with
+	DockerConfig string
+	Output       io.Writer
+}
+
+// Sign signs the image of the provenance by its digest, and the SBOM attached to it if there is one,
+// the signatures are stored in the registry along with the image in the cosign layout
+func Sign(p *types.ImageProvenance, opts *SignOptions) error {
+	o := &AttestOptions{
+		Envs: []string{
+			privateKeyEnv + "=" + opts.PrivateKey,
+			passwordEnv + "=" + opts.Password,
+			"DOCKER_CONFIG=" + opts.DockerConfig,
+		},
+		Output: opts.Output,
+	}
+
+	refs := []string{ImageRepo(p.Image) + "@" + p.Digest}
+	if p.SBOMRef != "" {
+		refs = append(refs, p.SBOMRef)
+	}
+	for _, ref := range refs {
+		if _, err := o.run(cosignExe, "sign", "--yes", "--tlog-upload=false", "--key", "env://"+privateKeyEnv, ref); err != nil {
+			return fmt.Errorf("failed to sign %s: %s", ref, err)
+		}
+	}
+	return nil
+}
+
+func (o *AttestOptions) generateSBOM(ref string) (string, error) {
+	f, err := ioutil.TempFile("", "sbom-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	cmd := o.command(syftExe, "registry:"+ref, "-o", string(o.SBOMFormat))
+	cmd.Stdout = f
+	if err := cmd.Run(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// run runs the command and returns its trimmed stdout
+func (o *AttestOptions) run(name string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := o.command(name, args...)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+func (o *AttestOptions) command(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), o.Envs...)
+	cmd.Stderr = o.Output
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	return cmd
+}
+
+// DigestFromRef returns the digest of the image from the reference of its signature,
+// e.g. sha256:abc from registry/app:sha256-abc.sig
+func DigestFromRef(ref string) (string, error) {
+	tag := ref[strings.LastIndex(ref, ":")+1:]
+	if !strings.HasPrefix(tag, "sha256-") || strings.Count(tag, ".") != 1 {
+		return "", fmt.Errorf("invalid signature reference: %s", ref)
+	}
+	return strings.Replace(tag[:strings.Index(tag, ".")], "-", ":", 1), nil
+}
+
+// ImageRepo returns the image without its tag or digest
+func ImageRepo(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i]
+	}
+	if strings.LastIndex(image, ":") > strings.LastIndex(image, "/") {
+		return image[:strings.LastIndex(image, ":")]
+	}
+	return image
+}
+
+func sbomType(format types.SBOMFormat) string {
+	if format == types.SBOMFormatCycloneDX {
+		return "cyclonedx"
+	}
+	return "spdx"
+}
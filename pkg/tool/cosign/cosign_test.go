@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:0a1b2c3d4e5f"
+
+func newTestKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.Nil(t, err)
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, digest string) *Signature {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"registry/app"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, digest))
+	hash := sha256.Sum256(payload)
+	raw, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	require.Nil(t, err)
+	return &Signature{Payload: payload, Signature: base64.StdEncoding.EncodeToString(raw)}
+}
+
+func TestVerify(t *testing.T) {
+	ast := require.New(t)
+
+	key, pub := newTestKey(t)
+	other, _ := newTestKey(t)
+
+	ast.Nil(Verify(pub, testDigest, []*Signature{sign(t, key, testDigest)}))
+	ast.Nil(Verify(pub, testDigest, []*Signature{sign(t, other, testDigest), sign(t, key, testDigest)}))
+	ast.Equal(ErrNoSignature, Verify(pub, testDigest, nil))
+	ast.NotNil(Verify(pub, testDigest, []*Signature{sign(t, other, testDigest)}))
+	ast.NotNil(Verify(pub, testDigest, []*Signature{sign(t, key, "sha256:ffff")}))
+
+	tampered := sign(t, key, testDigest)
+	tampered.Payload = []byte(fmt.Sprintf(`{"critical":{"image":{"docker-manifest-digest":%q}}}`, testDigest))
+	ast.NotNil(Verify(pub, testDigest, []*Signature{tampered}))
+
+	ast.NotNil(Verify("not a key", testDigest, []*Signature{sign(t, key, testDigest)}))
+}
+
+func TestDigestFromRef(t *testing.T) {
+	ast := require.New(t)
+
+	digest, err := DigestFromRef("registry:5000/ns/app:sha256-0a1b2c.sig")
+	ast.Nil(err)
+	ast.Equal("sha256:0a1b2c", digest)
+	ast.Equal("sha256-0a1b2c.sig", SignatureTag(digest))
+
+	_, err = DigestFromRef("registry/ns/app:v1")
+	ast.NotNil(err)
+}
+
+func TestImageRepo(t *testing.T) {
+	ast := require.New(t)
+
+	ast.Equal("registry:5000/ns/app", ImageRepo("registry:5000/ns/app:v1"))
+	ast.Equal("registry:5000/ns/app", ImageRepo("registry:5000/ns/app"))
+	ast.Equal("registry/ns/app", ImageRepo("registry/ns/app@sha256:0a1b2c"))
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SignatureAnnotation is the annotation of a layer in the signature manifest which holds the signature of the layer
+const SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// ErrNoSignature is returned if the image has no signature at all
+var ErrNoSignature = errors.New("the image is not signed")
+
+// Signature is a cosign signature of an image, Payload is the simple signing payload which is signed
+type Signature struct {
+	Payload []byte
+	// Signature is base64 encoded
+	Signature string
+}
+
+type simpleSigning struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// SignatureTag returns the tag the signatures of the image with the digest are stored at
+func SignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// ParsePublicKey parses a PEM encoded public key generated by `cosign generate-key-pair`
+func ParsePublicKey(key string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, errors.New("invalid public key: no PEM block found")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %s", err)
+	}
+	switch pub.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", pub)
+	}
+}
+
+// Verify checks that at least one of the signatures is made with the key for the image with the digest
+func Verify(publicKey, digest string, signatures []*Signature) error {
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if len(signatures) == 0 {
+		return ErrNoSignature
+	}
+
+	var errs []string
+	for _, sig := range signatures {
+		err := verifySignature(pub, digest, sig)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return fmt.Errorf("no valid signature found: %s", strings.Join(errs, "; "))
+}
+
+func verifySignature(pub crypto.PublicKey, digest string, sig *Signature) error {
+	raw, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+
+	hash := sha256.Sum256(sig.Payload)
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, hash[:], raw) {
+			return errors.New("signature mismatch")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], raw); err != nil {
+			return errors.New("signature mismatch")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, sig.Payload, raw) {
+			return errors.New("signature mismatch")
+		}
+	}
+
+	// the payload is only trusted after the signature is verified
+	payload := &simpleSigning{}
+	if err := json.Unmarshal(sig.Payload, payload); err != nil {
+		return fmt.Errorf("invalid signature payload: %s", err)
+	}
+	if payload.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("signature is made for %s", payload.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EnvelopePrefix marks a value sealed by an envelope, values without it are plain text
+const EnvelopePrefix = "enc:v1:"
+
+// ErrUnknownDataKey is returned if a value is sealed by a data key the envelope does not hold
+var ErrUnknownDataKey = errors.New("unknown data key")
+
+// DataKey is the key values are encrypted with, it is only stored wrapped by the master key
+type DataKey struct {
+	ID  string
+	Key string
+}
+
+// Envelope seals values with its active data key and opens them with the data key they are sealed by,
+// so that data keys can be rotated without re-encrypting all values at once
+type Envelope struct {
+	activeID string
+	ciphers  map[string]*Aes
+}
+
+func NewEnvelope(activeID string, keys []*DataKey) (*Envelope, error) {
+	e := &Envelope{
+		activeID: activeID,
+		ciphers:  make(map[string]*Aes, len(keys)),
+	}
+	for _, key := range keys {
+		if strings.Contains(key.ID, ":") {
+			return nil, fmt.Errorf("invalid data key id %s", key.ID)
+		}
+		c, err := NewAes(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data key %s: %s", key.ID, err)
+		}
+		e.ciphers[key.ID] = c
+	}
+	if _, ok := e.ciphers[activeID]; !ok {
+		return nil, fmt.Errorf("active data key %s is not found", activeID)
+	}
+
+	return e, nil
+}
+
+// ActiveKeyID returns the id of the data key new values are sealed with
+func (e *Envelope) ActiveKeyID() string {
+	return e.activeID
+}
+
+// Seal encrypts the value with the active data key, empty and sealed values are returned as is
+func (e *Envelope) Seal(value string) (string, error) {
+	if value == "" || IsSealed(value) {
+		return value, nil
+	}
+
+	encrypted, err := e.ciphers[e.activeID].Encrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return EnvelopePrefix + e.activeID + ":" + encrypted, nil
+}
+
+// Open decrypts a sealed value, values which are not sealed are returned as is
+func (e *Envelope) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+
+	keyID, encrypted, err := parseSealed(value)
+	if err != nil {
+		return "", err
+	}
+	c, ok := e.ciphers[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownDataKey, keyID)
+	}
+	return c.Decrypt(encrypted)
+}
+
+// Reseal seals the value with the active data key if it is plain text or sealed by another data key,
+// the second return value reports whether the value is changed
+func (e *Envelope) Reseal(value string) (string, bool, error) {
+	if value == "" || SealedBy(value) == e.activeID {
+		return value, false, nil
+	}
+
+	plain, err := e.Open(value)
+	if err != nil {
+		return "", false, err
+	}
+	sealed, err := e.Seal(plain)
+	if err != nil {
+		return "", false, err
+	}
+	return sealed, true, nil
+}
+
+// IsSealed reports whether the value is sealed by an envelope
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, EnvelopePrefix)
+}
+
+// SealedBy returns the id of the data key the value is sealed by, or an empty string if it is not sealed
+func SealedBy(value string) string {
+	keyID, _, err := parseSealed(value)
+	if err != nil {
+		return ""
+	}
+	return keyID
+}
+
+// GenerateDataKey generates a random data key for AES-256
+func GenerateDataKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func parseSealed(value string) (string, string, error) {
+	if !IsSealed(value) {
+		return "", "", errors.New("value is not sealed")
+	}
+	parts := strings.SplitN(strings.TrimPrefix(value, EnvelopePrefix), ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", errors.New("malformed sealed value")
+	}
+	return parts[0], parts[1], nil
+}
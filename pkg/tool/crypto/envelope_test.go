@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelope(t *testing.T) {
+	ast := require.New(t)
+
+	oldKey, err := GenerateDataKey()
+	ast.Nil(err)
+	newKey, err := GenerateDataKey()
+	ast.Nil(err)
+
+	old, err := NewEnvelope("k1", []*DataKey{{ID: "k1", Key: oldKey}})
+	ast.Nil(err)
+
+	sealed, err := old.Seal("secret")
+	ast.Nil(err)
+	ast.True(IsSealed(sealed))
+	ast.Equal("k1", SealedBy(sealed))
+
+	resealed, err := old.Seal(sealed)
+	ast.Nil(err)
+	ast.Equal(sealed, resealed)
+
+	plain, err := old.Open(sealed)
+	ast.Nil(err)
+	ast.Equal("secret", plain)
+
+	plain, err = old.Open("legacy")
+	ast.Nil(err)
+	ast.Equal("legacy", plain)
+
+	rotated, err := NewEnvelope("k2", []*DataKey{{ID: "k1", Key: oldKey}, {ID: "k2", Key: newKey}})
+	ast.Nil(err)
+
+	resealed, changed, err := rotated.Reseal(sealed)
+	ast.Nil(err)
+	ast.True(changed)
+	ast.Equal("k2", SealedBy(resealed))
+
+	plain, err = rotated.Open(resealed)
+	ast.Nil(err)
+	ast.Equal("secret", plain)
+
+	_, changed, err = rotated.Reseal(resealed)
+	ast.Nil(err)
+	ast.False(changed)
+
+	_, err = old.Open(resealed)
+	ast.True(errors.Is(err, ErrUnknownDataKey))
+
+	_, err = NewEnvelope("k3", []*DataKey{{ID: "k1", Key: oldKey}})
+	ast.NotNil(err)
+}
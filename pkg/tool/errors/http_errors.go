@@ -199,6 +199,10 @@ var (
 	ErrUpdateResource = NewHTTPError(6095, "更新对象资源失败")
 	//ErrDeleteResource ...
 	ErrDeleteResource = NewHTTPError(6096, "删除对象资源失败")
+	// ErrDetectEnvDrift ...
+	ErrDetectEnvDrift = NewHTTPError(6097, "检测环境配置漂移失败")
+	// ErrReconcileEnvDrift ...
+	ErrReconcileEnvDrift = NewHTTPError(6098, "修复环境配置漂移失败")
 	// ErrLoginPm ...
 	ErrLoginPm = NewHTTPError(6099, "登陆主机失败")
 
@@ -283,6 +287,16 @@ var (
 	ErrCountTasks = NewHTTPError(6167, "工作流计数失败")
 
 	ErrCreateTaskFailed = NewHTTPError(6168, "创建工作流任务失败")
+	// ErrUpdateTaskPriority ...
+	ErrUpdateTaskPriority = NewHTTPError(6169, "调整工作流任务优先级失败")
+	// ErrApproveTask ...
+	ErrApproveTask = NewHTTPError(6170, "审批工作流任务失败")
+	// ErrNotifyApprovers ...
+	ErrNotifyApprovers = NewHTTPError(6171, "通知工作流审批人失败")
+	// ErrFindBuildResultCache ...
+	ErrFindBuildResultCache = NewHTTPError(6172, "获取构建结果缓存失败")
+	// ErrCreateBuildResultCache ...
+	ErrCreateBuildResultCache = NewHTTPError(6173, "保存构建结果缓存失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// Keystore APIs Range: 6180 - 6189
@@ -381,6 +395,10 @@ var (
 	// ErrListImages ...
 	ErrListImages   = NewHTTPError(6280, "列出镜像失败")
 	ErrFindRegistry = NewHTTPError(6281, "找不到指定的镜像仓库")
+	// ErrVerifyImageSignature ...
+	ErrVerifyImageSignature = NewHTTPError(6282, "镜像签名校验失败")
+	// ErrSignImage ...
+	ErrSignImage = NewHTTPError(6283, "镜像签名失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// Insghts APIs Range: 6300 - 6399
@@ -465,6 +483,10 @@ var (
 	ErrDeleteTestModule = NewHTTPError(6533, "删除测试模块失败")
 	// ErrGetTestReport ...
 	ErrGetTestReport = NewHTTPError(6534, "获取html测试报告失败")
+	// ErrListFlakyTestCases ...
+	ErrListFlakyTestCases = NewHTTPError(6535, "列出不稳定测试用例失败")
+	// ErrUpdateTestQuarantine ...
+	ErrUpdateTestQuarantine = NewHTTPError(6536, "更新测试用例隔离列表失败")
 
 	// Workflow APIs Range: 6540 - 6550
 	//-----------------------------------------------------------------------------------------------
@@ -696,4 +718,28 @@ var (
 	//-----------------------------------------------------------------------------------------------
 	ErrListHelmReleases = NewHTTPError(6850, "获取release失败")
 	ErrGetHelmCharts    = NewHTTPError(6851, "获取chart信息失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// credential encryption Error Range: 6870 - 6879
+	//-----------------------------------------------------------------------------------------------
+	ErrListEncryptionKeys  = NewHTTPError(6870, "获取加密密钥列表失败")
+	ErrRotateEncryptionKey = NewHTTPError(6871, "轮换加密密钥失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// environment comparison and snapshot Error Range: 6880 - 6899
+	//-----------------------------------------------------------------------------------------------
+	ErrCompareEnvs        = NewHTTPError(6880, "对比环境失败")
+	ErrCreateEnvSnapshot  = NewHTTPError(6881, "创建环境快照失败")
+	ErrListEnvSnapshots   = NewHTTPError(6882, "获取环境快照列表失败")
+	ErrGetEnvSnapshot     = NewHTTPError(6883, "获取环境快照失败")
+	ErrDeleteEnvSnapshot  = NewHTTPError(6884, "删除环境快照失败")
+	ErrRestoreEnvSnapshot = NewHTTPError(6885, "恢复环境快照失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// environment lifecycle Error Range: 6900 - 6909
+	//-----------------------------------------------------------------------------------------------
+	ErrGetEnvLifecycle    = NewHTTPError(6900, "获取环境生命周期配置失败")
+	ErrUpdateEnvLifecycle = NewHTTPError(6901, "更新环境生命周期配置失败")
+	ErrSleepEnv           = NewHTTPError(6902, "休眠环境失败")
+	ErrWakeEnv            = NewHTTPError(6903, "唤醒环境失败")
 )
@@ -91,3 +91,18 @@ func (c *Client) ListFiles(ctx context.Context, owner string, repo string, numbe
 
 	return res, err
 }
+
+// CreatePullRequestComment comments on the conversation of a pull request, which is an issue comment in GitHub
+func (c *Client) CreatePullRequestComment(ctx context.Context, owner string, repo string, number int, body string) (*github.IssueComment, error) {
+	created, err := wrap(c.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: &body}))
+	if comment, ok := created.(*github.IssueComment); ok {
+		return comment, err
+	}
+
+	return nil, err
+}
+
+func (c *Client) EditPullRequestComment(ctx context.Context, owner string, repo string, commentID int64, body string) error {
+	_, err := wrap(c.Issues.EditComment(ctx, owner, repo, commentID, &github.IssueComment{Body: &body}))
+	return err
+}
@@ -56,3 +56,17 @@ func (c *Client) ListFiles(ctx context.Context, owner string, repo string, numbe
 	}
 	return is, err
 }
+
+func (c *Client) CreatePullRequestComment(ctx context.Context, owner string, repo string, number int, body string) (gitee.PullRequestComments, error) {
+	comment, _, err := c.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(ctx, owner, repo, int32(number), gitee.PullRequestCommentPostParam{Body: body})
+	if err != nil {
+		return gitee.PullRequestComments{}, err
+	}
+
+	return comment, err
+}
+
+func (c *Client) UpdatePullRequestComment(ctx context.Context, owner string, repo string, id int, body string) error {
+	_, _, err := c.PullRequestsApi.PatchV5ReposOwnerRepoPullsCommentsId(ctx, owner, repo, int32(id), gitee.PullRequestCommentPatchParam{Body: body})
+	return err
+}
@@ -31,6 +31,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	cm "github.com/chartmuseum/helm-push/pkg/chartmuseum"
 	hc "github.com/mittwald/go-helm-client"
@@ -543,6 +544,15 @@ func (hClient *HelmClient) InstallOrUpgradeChart(ctx context.Context, spec *hc.C
 	}
 }
 
+// RollbackToRevision works like executing `helm rollback --wait` to the given revision of the release
+func (hClient *HelmClient) RollbackToRevision(releaseName string, revision int, timeout time.Duration) error {
+	rollback := action.NewRollback(hClient.ActionConfig)
+	rollback.Version = revision
+	rollback.Wait = true
+	rollback.Timeout = timeout
+	return rollback.Run(releaseName)
+}
+
 // UpdateChartRepo works like executing `helm repo update`
 // environment `HELM_REPO_USERNAME` and `HELM_REPO_PASSWORD` are only required for ali acr repos
 func (hClient *HelmClient) UpdateChartRepo(repoEntry *repo.Entry) (string, error) {
@@ -0,0 +1,273 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type ChangeType string
+
+const (
+	// ChangeModified means the field has different values
+	ChangeModified ChangeType = "modified"
+	// ChangeAdded means the field only exists in the target object
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved means the field only exists in the source object
+	ChangeRemoved ChangeType = "removed"
+)
+
+// Change is the difference of a single field between two objects.
+// Path is like "spec.template.spec.containers[name=app].image", elements of lists are addressed
+// by their names if all of them have unique names, otherwise by their indexes.
+type Change struct {
+	Path string      `bson:"path"           json:"path"`
+	Type ChangeType  `bson:"type"           json:"type"`
+	From interface{} `bson:"from,omitempty" json:"from,omitempty"`
+	To   interface{} `bson:"to,omitempty"   json:"to,omitempty"`
+}
+
+// FieldDrift is a field of the live object which deviates from the expected one.
+type FieldDrift struct {
+	Path     string      `bson:"path"               json:"path"`
+	Expected interface{} `bson:"expected,omitempty" json:"expected,omitempty"`
+	Live     interface{} `bson:"live,omitempty"     json:"live,omitempty"`
+	// OutOfBand is true if the live value has been changed since it was last applied, e.g. by kubectl,
+	// otherwise the live value is still the applied one and the expected value is changed but not applied yet
+	OutOfBand bool `bson:"out_of_band" json:"out_of_band"`
+}
+
+// Compare returns all changes which turn object from into object to.
+func Compare(from, to map[string]interface{}) []*Change {
+	var changes []*Change
+	w := &walker{report: func(path string, t ChangeType, from, to, _ interface{}) {
+		changes = append(changes, &Change{Path: path, Type: t, From: from, To: to})
+	}}
+	w.walk("", from, to, nil)
+	return changes
+}
+
+// Drift does a three-way diff among the expected object, the last applied configuration and the live object.
+// Only the fields set in the expected object are compared, fields which are only set in the live object are
+// ignored since most of them are defaulted by the api server or injected by zadig.
+// lastApplied tells whether a deviation is made out of band, it can be nil if it is unknown.
+func Drift(expected, lastApplied, live map[string]interface{}) []*FieldDrift {
+	var drifts []*FieldDrift
+	w := &walker{subset: true, report: func(path string, _ ChangeType, from, to, base interface{}) {
+		drifts = append(drifts, &FieldDrift{
+			Path:      path,
+			Expected:  from,
+			Live:      to,
+			OutOfBand: lastApplied == nil || !valueEqual(path, base, to),
+		})
+	}}
+	w.walk("", expected, live, lastApplied)
+	return drifts
+}
+
+type walker struct {
+	// subset only compares the fields set in the source object
+	subset bool
+	// report is called for every change, base is the value at the same path of the base object if there is one
+	report func(path string, t ChangeType, from, to, base interface{})
+}
+
+func (w *walker) walk(path string, from, to, base interface{}) {
+	switch f := from.(type) {
+	case map[string]interface{}:
+		t, ok := to.(map[string]interface{})
+		if !ok {
+			w.report(path, ChangeModified, from, to, base)
+			return
+		}
+		b, _ := base.(map[string]interface{})
+		w.walkMap(path, f, t, b)
+	case []interface{}:
+		t, ok := to.([]interface{})
+		if !ok {
+			w.report(path, ChangeModified, from, to, base)
+			return
+		}
+		b, _ := base.([]interface{})
+		w.walkList(path, f, t, b)
+	default:
+		if !valueEqual(path, from, to) {
+			w.report(path, ChangeModified, from, to, base)
+		}
+	}
+}
+
+func (w *walker) walkMap(path string, from, to, base map[string]interface{}) {
+	for _, key := range sortedKeys(from, to, w.subset) {
+		fv, inFrom := from[key]
+		tv, inTo := to[key]
+		p := join(path, key)
+		switch {
+		case !inTo:
+			w.report(p, ChangeRemoved, fv, nil, base[key])
+		case !inFrom:
+			w.report(p, ChangeAdded, nil, tv, base[key])
+		default:
+			w.walk(p, fv, tv, base[key])
+		}
+	}
+}
+
+func (w *walker) walkList(path string, from, to, base []interface{}) {
+	fromByName, fromNames := byName(from)
+	toByName, toNames := byName(to)
+	if fromByName != nil && toByName != nil {
+		baseByName, _ := byName(base)
+		for _, name := range mergeNames(fromNames, toNames, w.subset) {
+			fv, inFrom := fromByName[name]
+			tv, inTo := toByName[name]
+			p := fmt.Sprintf("%s[name=%s]", path, name)
+			switch {
+			case !inTo:
+				w.report(p, ChangeRemoved, fv, nil, baseByName[name])
+			case !inFrom:
+				w.report(p, ChangeAdded, nil, tv, baseByName[name])
+			default:
+				w.walk(p, fv, tv, baseByName[name])
+			}
+		}
+		return
+	}
+
+	if len(from) != len(to) {
+		w.report(path, ChangeModified, from, to, base)
+		return
+	}
+	for i := range from {
+		var b interface{}
+		if i < len(base) {
+			b = base[i]
+		}
+		w.walk(fmt.Sprintf("%s[%d]", path, i), from[i], to[i], b)
+	}
+}
+
+// byName indexes list elements by their names, it returns nil if any element has no name or the names are not unique
+func byName(list []interface{}) (map[string]interface{}, []string) {
+	if len(list) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	res := make(map[string]interface{}, len(list))
+	names := make([]string, 0, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		name, ok := m["name"].(string)
+		if !ok || name == "" {
+			return nil, nil
+		}
+		if _, ok := res[name]; ok {
+			return nil, nil
+		}
+		res[name] = item
+		names = append(names, name)
+	}
+	return res, names
+}
+
+func mergeNames(from, to []string, subset bool) []string {
+	if subset {
+		return from
+	}
+
+	seen := make(map[string]bool, len(from))
+	res := append([]string{}, from...)
+	for _, name := range from {
+		seen[name] = true
+	}
+	for _, name := range to {
+		if !seen[name] {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+func sortedKeys(from, to map[string]interface{}, subset bool) []string {
+	keys := make([]string, 0, len(from)+len(to))
+	for k := range from {
+		keys = append(keys, k)
+	}
+	if !subset {
+		for k := range to {
+			if _, ok := from[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// valueEqual compares two scalar values, numbers are compared by value since yaml and json decoders
+// may produce different types, and resource quantities are compared semantically, e.g. "1000m" equals 1
+func valueEqual(path string, a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA && okB {
+		return fa == fb
+	}
+
+	if strings.Contains(path, "resources.") {
+		qa, errA := resource.ParseQuantity(fmt.Sprint(a))
+		qb, errB := resource.ParseQuantity(fmt.Sprint(b))
+		if errA == nil && errB == nil {
+			return qa.Cmp(qb) == 0
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func deployment(image string, replicas int64, cpu interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"kind": "Deployment",
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":      "app",
+							"image":     image,
+							"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": cpu}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	ast := require.New(t)
+
+	from := deployment("app:v1", 1, "1")
+	to := deployment("app:v2", 1, "1000m")
+	to["metadata"] = map[string]interface{}{"name": "app"}
+
+	changes := Compare(from, to)
+	ast.Len(changes, 2)
+	ast.Equal(&Change{Path: "metadata", Type: ChangeAdded, To: map[string]interface{}{"name": "app"}}, changes[0])
+	ast.Equal(&Change{Path: "spec.template.spec.containers[name=app].image", Type: ChangeModified, From: "app:v1", To: "app:v2"}, changes[1])
+
+	ast.Empty(Compare(from, deployment("app:v1", 1, "1")))
+}
+
+func TestDrift(t *testing.T) {
+	ast := require.New(t)
+
+	expected := deployment("app:v2", 2, int64(1))
+	lastApplied := deployment("app:v1", 2, int64(1))
+	live := deployment("app:v1", 3, "1")
+	// fields which are not set in the expected object are ignored
+	live["status"] = map[string]interface{}{"replicas": int64(3)}
+
+	drifts := Drift(expected, lastApplied, live)
+	ast.Len(drifts, 2)
+	ast.Equal(&FieldDrift{Path: "spec.replicas", Expected: int64(2), Live: int64(3), OutOfBand: true}, drifts[0])
+	ast.Equal(&FieldDrift{Path: "spec.template.spec.containers[name=app].image", Expected: "app:v2", Live: "app:v1"}, drifts[1])
+
+	drifts = Drift(expected, nil, live)
+	ast.Len(drifts, 2)
+	ast.True(drifts[1].OutOfBand)
+
+	ast.Empty(Drift(expected, expected, deployment("app:v2", 2, "1000m")))
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// BuildCacheMarker prefixes the lines reaper prints after restoring and saving the build cache,
+// the rest of the line is the json of the BuildCache
+const BuildCacheMarker = "##[zadig-cache]"
+
+// BuildCache is the result of restoring and saving the chunked build cache of a job
+type BuildCache struct {
+	// Key is the cache key the job saves its cache to, it is scoped by the branch being built
+	Key string `bson:"key"                      json:"key"`
+	// Hit is true if a cache was restored, RestoredFrom is the key it was restored from,
+	// which is the key of the default branch if the branch itself has no cache yet
+	Hit           bool   `bson:"hit"                      json:"hit"`
+	RestoredFrom  string `bson:"restored_from,omitempty"  json:"restored_from,omitempty"`
+	RestoredBytes int64  `bson:"restored_bytes,omitempty" json:"restored_bytes,omitempty"`
+	// TotalChunks is the number of chunks of the saved cache, only the chunks which
+	// were not in the storage yet are uploaded
+	TotalChunks    int   `bson:"total_chunks,omitempty"    json:"total_chunks,omitempty"`
+	UploadedChunks int   `bson:"uploaded_chunks,omitempty" json:"uploaded_chunks,omitempty"`
+	UploadedBytes  int64 `bson:"uploaded_bytes,omitempty"  json:"uploaded_bytes,omitempty"`
+}
+
+// Marker returns the line which records the cache result in the log
+func (c *BuildCache) Marker() string {
+	b, _ := json.Marshal(c)
+	return BuildCacheMarker + " " + string(b)
+}
+
+// ParseBuildCache returns the last cache result recorded in the log of a job,
+// or nil if the job did not use the chunked cache
+func ParseBuildCache(log string) *BuildCache {
+	var cache *BuildCache
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, BuildCacheMarker) {
+			continue
+		}
+
+		c := &BuildCache{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, BuildCacheMarker)), c); err != nil || c.Key == "" {
+			continue
+		}
+		cache = c
+	}
+	return cache
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// BuildStepMarker prefixes the lines reaper prints at the start and the end of every build step,
+// the rest of the line is the json of the BuildStep
+const BuildStepMarker = "##[zadig-step]"
+
+type BuildStepStatus string
+
+const (
+	BuildStepRunning BuildStepStatus = "running"
+	BuildStepPassed  BuildStepStatus = "passed"
+	BuildStepFailed  BuildStepStatus = "failed"
+)
+
+// BuildStepFailureRegistry is the failure reason of a step which failed to push the image to the registry
+const BuildStepFailureRegistry = "registry"
+
+// BuildStep is a phase of a build job, e.g. cloning the repositories or running the docker build
+type BuildStep struct {
+	Name      string          `bson:"name"                json:"name"`
+	Status    BuildStepStatus `bson:"status"              json:"status"`
+	StartTime int64           `bson:"start_time"          json:"start_time"`
+	EndTime   int64           `bson:"end_time,omitempty"  json:"end_time,omitempty"`
+	ExitCode  int             `bson:"exit_code,omitempty" json:"exit_code,omitempty"`
+	Error     string          `bson:"error,omitempty"     json:"error,omitempty"`
+	// FailureReason is set if the exact reason of the failure is known, e.g. BuildStepFailureRegistry
+	FailureReason string `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+}
+
+// Marker returns the line which records the step in the log
+func (s *BuildStep) Marker() string {
+	b, _ := json.Marshal(s)
+	return BuildStepMarker + " " + string(b)
+}
+
+// ParseBuildStepMarker returns the step recorded in the line, or false if the line is not a marker
+func ParseBuildStepMarker(line string) (*BuildStep, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, BuildStepMarker) {
+		return nil, false
+	}
+
+	step := &BuildStep{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, BuildStepMarker)), step); err != nil || step.Name == "" {
+		return nil, false
+	}
+	return step, true
+}
+
+// ParseBuildSteps returns the steps recorded in the log of a build job in the order they were started,
+// a step without an end marker is still running or was killed
+func ParseBuildSteps(log string) []*BuildStep {
+	var steps []*BuildStep
+	index := make(map[string]int)
+	for _, line := range strings.Split(log, "\n") {
+		step, ok := ParseBuildStepMarker(line)
+		if !ok {
+			continue
+		}
+		if i, ok := index[step.Name]; ok {
+			steps[i] = step
+			continue
+		}
+		index[step.Name] = len(steps)
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// BuildStepLog returns the lines printed between the start and the end marker of the step,
+// or false if the step can not be found in the log
+func BuildStepLog(log, name string) (string, bool) {
+	var (
+		lines  []string
+		found  bool
+		inStep bool
+	)
+	for _, line := range strings.Split(log, "\n") {
+		step, ok := ParseBuildStepMarker(line)
+		if !ok {
+			if inStep {
+				lines = append(lines, line)
+			}
+			continue
+		}
+		if step.Name != name {
+			continue
+		}
+		found = true
+		inStep = step.Status == BuildStepRunning
+	}
+	if !found {
+		return "", false
+	}
+	return strings.Join(lines, "\n"), true
+}
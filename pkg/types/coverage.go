@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"math"
+	"sort"
+)
+
+// CoverageConfig tells reaper where to find the coverage files of a test and how the result is checked
+type CoverageConfig struct {
+	Format string `bson:"format"                       json:"format"`
+	// Path is the glob of the coverage files relative to the workspace
+	Path string `bson:"path"                         json:"path"`
+	// Threshold is the minimum line coverage in percent, 0 means no threshold
+	Threshold float64 `bson:"threshold,omitempty"          json:"threshold,omitempty"`
+	// FailOnRegression fails the test if the line coverage is lower than the latest one of the base branch
+	FailOnRegression bool `bson:"fail_on_regression,omitempty" json:"fail_on_regression,omitempty"`
+}
+
+// CoverageSummary is the coverage of a set of source files, the rates are in percent
+type CoverageSummary struct {
+	LinesCovered    int     `bson:"lines_covered"    json:"lines_covered"`
+	LinesValid      int     `bson:"lines_valid"      json:"lines_valid"`
+	BranchesCovered int     `bson:"branches_covered" json:"branches_covered"`
+	BranchesValid   int     `bson:"branches_valid"   json:"branches_valid"`
+	LineRate        float64 `bson:"line_rate"        json:"line_rate"`
+	BranchRate      float64 `bson:"branch_rate"      json:"branch_rate"`
+}
+
+// Add adds the counters of other and recalculates the rates
+func (s *CoverageSummary) Add(other CoverageSummary) {
+	s.LinesCovered += other.LinesCovered
+	s.LinesValid += other.LinesValid
+	s.BranchesCovered += other.BranchesCovered
+	s.BranchesValid += other.BranchesValid
+	s.Calculate()
+}
+
+// Calculate updates the rates from the counters
+func (s *CoverageSummary) Calculate() {
+	s.LineRate = coverageRate(s.LinesCovered, s.LinesValid)
+	s.BranchRate = coverageRate(s.BranchesCovered, s.BranchesValid)
+}
+
+func coverageRate(covered, valid int) float64 {
+	if valid == 0 {
+		return 0
+	}
+	return math.Round(float64(covered)*10000/float64(valid)) / 100
+}
+
+// PackageCoverage is the coverage of a package, a directory or a module depending on the format
+type PackageCoverage struct {
+	Name            string `bson:"name"   json:"name"`
+	CoverageSummary `bson:",inline"`
+}
+
+// CoverageReport is the normalized coverage of a test, no matter which format it was collected in
+type CoverageReport struct {
+	Format          string `bson:"format"   json:"format"`
+	CoverageSummary `bson:",inline"`
+	Packages        []*PackageCoverage `bson:"packages" json:"packages"`
+}
+
+// NewCoverageReport sums up the packages into a report, packages with the same name are merged
+func NewCoverageReport(format string, packages []*PackageCoverage) *CoverageReport {
+	report := &CoverageReport{Format: format, Packages: []*PackageCoverage{}}
+	merged := make(map[string]*PackageCoverage)
+	for _, pkg := range packages {
+		if existed, ok := merged[pkg.Name]; ok {
+			existed.Add(pkg.CoverageSummary)
+			continue
+		}
+		p := &PackageCoverage{Name: pkg.Name, CoverageSummary: pkg.CoverageSummary}
+		p.Calculate()
+		merged[pkg.Name] = p
+		report.Packages = append(report.Packages, p)
+	}
+
+	sort.Slice(report.Packages, func(i, j int) bool {
+		return report.Packages[i].Name < report.Packages[j].Name
+	})
+	for _, pkg := range report.Packages {
+		report.Add(pkg.CoverageSummary)
+	}
+	report.Calculate()
+	return report
+}
+
+// CoverageReportFile is the name of the normalized coverage report uploaded next to the test result file
+func CoverageReportFile(testResultFile string) string {
+	return testResultFile + "-coverage.json"
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "fmt"
+
+// ImageBuildDir is the directory shared by the job container and the image builder container of a daemonless build
+const ImageBuildDir = "/zadig/image-build"
+
+const (
+	// BuildKitdAddr is the address which the rootless buildkitd in the image builder container listens on
+	BuildKitdAddr = "tcp://127.0.0.1:1234"
+
+	// KanikoBuildScript is written into ImageBuildDir by reaper when the context and the dockerfile are ready,
+	// the kaniko container runs it and records its output and exit code beside it
+	KanikoBuildScript   = "build.sh"
+	KanikoBuildLog      = "build.log"
+	KanikoBuildExitCode = "exitcode"
+)
+
+// KanikoContainerScript returns the command of the kaniko container, it waits for the build script in dir,
+// runs it and writes the exit code atomically after the output is flushed
+func KanikoContainerScript(dir string) string {
+	return fmt.Sprintf(`while [ ! -f %[1]s/%[2]s ]; do sleep 1; done
+sh %[1]s/%[2]s > %[1]s/%[3]s 2>&1
+echo $? > %[1]s/%[4]s.tmp && mv %[1]s/%[4]s.tmp %[1]s/%[4]s`, dir, KanikoBuildScript, KanikoBuildLog, KanikoBuildExitCode)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ImageProvenanceMarker prefixes the lines reaper and predator print after generating the SBOM of an image
+// and signing it, the rest of the line is the json of the ImageProvenance
+const ImageProvenanceMarker = "##[zadig-provenance]"
+
+type SBOMFormat string
+
+const (
+	SBOMFormatSPDX      SBOMFormat = "spdx-json"
+	SBOMFormatCycloneDX SBOMFormat = "cyclonedx-json"
+)
+
+// ProvenanceOptions are the options of the provenance generated for the images pushed by a job
+type ProvenanceOptions struct {
+	// SBOMFormat is the format of the SBOM attached to the image, no SBOM is generated if it is empty
+	SBOMFormat SBOMFormat `bson:"sbom_format,omitempty" json:"sbom_format,omitempty" yaml:"sbom_format,omitempty"`
+	// Sign signs the image, and the SBOM if there is one, with the signing key in the system settings
+	Sign bool `bson:"sign" json:"sign" yaml:"sign"`
+}
+
+// Enabled reports whether any provenance is generated
+func (o *ProvenanceOptions) Enabled() bool {
+	return o != nil && (o.SBOMFormat != "" || o.Sign)
+}
+
+func (o *ProvenanceOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	switch o.SBOMFormat {
+	case "", SBOMFormatSPDX, SBOMFormatCycloneDX:
+	default:
+		return fmt.Errorf("unsupported sbom format: %s", o.SBOMFormat)
+	}
+	return nil
+}
+
+// ImageProvenance is the provenance attached to an image in the registry
+type ImageProvenance struct {
+	Image  string `bson:"image"  json:"image"`
+	Digest string `bson:"digest" json:"digest"`
+	// SBOMRef is the reference of the SBOM attached to the image, it is signed along with the image
+	SBOMFormat SBOMFormat `bson:"sbom_format,omitempty" json:"sbom_format,omitempty"`
+	SBOMRef    string     `bson:"sbom_ref,omitempty"    json:"sbom_ref,omitempty"`
+	// SignatureRef is the reference of the cosign signature stored along with the image
+	Signed       bool   `bson:"signed"                  json:"signed"`
+	SignatureRef string `bson:"signature_ref,omitempty" json:"signature_ref,omitempty"`
+}
+
+// Marker returns the line which records the provenance in the log
+func (p *ImageProvenance) Marker() string {
+	b, _ := json.Marshal(p)
+	return ImageProvenanceMarker + " " + string(b)
+}
+
+// ParseImageProvenances returns the provenances recorded in the log of a job,
+// the last one is kept if an image is recorded more than once
+func ParseImageProvenances(log string) []*ImageProvenance {
+	var provenances []*ImageProvenance
+	index := make(map[string]int)
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, ImageProvenanceMarker) {
+			continue
+		}
+
+		p := &ImageProvenance{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, ImageProvenanceMarker)), p); err != nil || p.Image == "" {
+			continue
+		}
+		if i, ok := index[p.Image]; ok {
+			provenances[i] = p
+			continue
+		}
+		index[p.Image] = len(provenances)
+		provenances = append(provenances, p)
+	}
+	return provenances
+}
+
+// FindImageProvenance returns the provenance of the image, or nil if there is none
+func FindImageProvenance(provenances []*ImageProvenance, image string) *ImageProvenance {
+	for _, p := range provenances {
+		if p.Image == image {
+			return p
+		}
+	}
+	return nil
+}
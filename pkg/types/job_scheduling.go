@@ -0,0 +1,227 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+type JobVolumeType string
+
+const (
+	HostPathJobVolume  JobVolumeType = "host_path"
+	PVCJobVolume       JobVolumeType = "pvc"
+	ConfigMapJobVolume JobVolumeType = "config_map"
+	SecretJobVolume    JobVolumeType = "secret"
+	EmptyDirJobVolume  JobVolumeType = "empty_dir"
+)
+
+var (
+	jobVolumeNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	// volumes which are always mounted into the job pod
+	reservedJobVolumes = map[string]bool{"job-config": true, "build-cache": true, "image-build": true}
+)
+
+// Toleration lets the job pod be scheduled onto nodes with matching taints
+type Toleration struct {
+	Key      string `bson:"key"      json:"key"`
+	Operator string `bson:"operator" json:"operator"`
+	Value    string `bson:"value"    json:"value"`
+	Effect   string `bson:"effect"   json:"effect"`
+	// TolerationSeconds is only used with the NoExecute effect
+	TolerationSeconds *int64 `bson:"toleration_seconds,omitempty" json:"toleration_seconds,omitempty"`
+}
+
+// JobVolume is an extra volume mounted into the job container, Source is the host path,
+// the claim name, the configmap name or the secret name depending on the type
+type JobVolume struct {
+	Name      string        `bson:"name"       json:"name"`
+	Type      JobVolumeType `bson:"type"       json:"type"`
+	Source    string        `bson:"source"     json:"source"`
+	MountPath string        `bson:"mount_path" json:"mount_path"`
+	ReadOnly  bool          `bson:"read_only"  json:"read_only"`
+}
+
+// JobScheduling controls where and how the pod of a build or test job is scheduled.
+// It can be set on a cluster as the default of all jobs running in it and on a build or test template.
+type JobScheduling struct {
+	Tolerations        []*Toleration     `bson:"tolerations,omitempty"          json:"tolerations,omitempty"`
+	NodeSelector       map[string]string `bson:"node_selector,omitempty"        json:"node_selector,omitempty"`
+	PriorityClassName  string            `bson:"priority_class_name,omitempty"  json:"priority_class_name,omitempty"`
+	ServiceAccountName string            `bson:"service_account_name,omitempty" json:"service_account_name,omitempty"`
+	Volumes            []*JobVolume      `bson:"volumes,omitempty"              json:"volumes,omitempty"`
+	Annotations        map[string]string `bson:"annotations,omitempty"          json:"annotations,omitempty"`
+}
+
+// Validate checks the settings which would otherwise only fail when the job is created
+func (s *JobScheduling) Validate() error {
+	if s == nil {
+		return nil
+	}
+
+	for _, t := range s.Tolerations {
+		switch t.Operator {
+		case "", "Equal":
+		case "Exists":
+			if t.Value != "" {
+				return fmt.Errorf("toleration %s: value must be empty when the operator is Exists", t.Key)
+			}
+		default:
+			return fmt.Errorf("toleration %s: unsupported operator %s", t.Key, t.Operator)
+		}
+		switch t.Effect {
+		case "", "NoSchedule", "PreferNoSchedule", "NoExecute":
+		default:
+			return fmt.Errorf("toleration %s: unsupported effect %s", t.Key, t.Effect)
+		}
+		if t.Key == "" && t.Operator != "Exists" {
+			return fmt.Errorf("toleration without a key must use the Exists operator")
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, v := range s.Volumes {
+		if !jobVolumeNameRegex.MatchString(v.Name) || len(v.Name) > 63 {
+			return fmt.Errorf("volume %q: invalid name", v.Name)
+		}
+		if reservedJobVolumes[v.Name] || names[v.Name] {
+			return fmt.Errorf("volume %s: name is already used", v.Name)
+		}
+		names[v.Name] = true
+
+		switch v.Type {
+		case HostPathJobVolume, PVCJobVolume, ConfigMapJobVolume, SecretJobVolume:
+			if v.Source == "" {
+				return fmt.Errorf("volume %s: source is required", v.Name)
+			}
+		case EmptyDirJobVolume:
+		default:
+			return fmt.Errorf("volume %s: unsupported type %s", v.Name, v.Type)
+		}
+		if !filepath.IsAbs(v.MountPath) {
+			return fmt.Errorf("volume %s: mount path must be absolute", v.Name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTemplate checks the scheduling of a build or test template, the service account and the host path and
+// secret volumes give the job access to the cluster and the node, so they can only be set on a cluster by admins
+func (s *JobScheduling) ValidateTemplate() error {
+	if s == nil {
+		return nil
+	}
+	if s.ServiceAccountName != "" {
+		return fmt.Errorf("service account can only be set in the scheduling of a cluster")
+	}
+	for _, v := range s.Volumes {
+		if !templateJobVolume(v) {
+			return fmt.Errorf("volume %s: %s volumes can only be set in the scheduling of a cluster", v.Name, v.Type)
+		}
+	}
+	return s.Validate()
+}
+
+func templateJobVolume(v *JobVolume) bool {
+	return v.Type != HostPathJobVolume && v.Type != SecretJobVolume
+}
+
+// templateScheduling drops the settings only admins can set from the scheduling of a template,
+// templates saved before they are restricted may still carry them
+func templateScheduling(template *JobScheduling) *JobScheduling {
+	if template == nil {
+		return nil
+	}
+	ret := *template
+	ret.ServiceAccountName = ""
+	ret.Volumes = nil
+	for _, v := range template.Volumes {
+		if templateJobVolume(v) {
+			ret.Volumes = append(ret.Volumes, v)
+		}
+	}
+	return &ret
+}
+
+// MergeJobScheduling returns the scheduling of a job running in a cluster, the settings of the template
+// take precedence over the defaults of the cluster except the ones only admins can set
+func MergeJobScheduling(cluster, template *JobScheduling) *JobScheduling {
+	template = templateScheduling(template)
+	if cluster == nil {
+		return template
+	}
+	if template == nil {
+		return cluster
+	}
+
+	merged := &JobScheduling{
+		PriorityClassName:  cluster.PriorityClassName,
+		ServiceAccountName: cluster.ServiceAccountName,
+		NodeSelector:       mergeStringMap(cluster.NodeSelector, template.NodeSelector),
+		Annotations:        mergeStringMap(cluster.Annotations, template.Annotations),
+	}
+	if template.PriorityClassName != "" {
+		merged.PriorityClassName = template.PriorityClassName
+	}
+
+	merged.Tolerations = append(merged.Tolerations, template.Tolerations...)
+	for _, t := range cluster.Tolerations {
+		overridden := false
+		for _, tt := range template.Tolerations {
+			if tt.Key == t.Key && tt.Effect == t.Effect {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged.Tolerations = append(merged.Tolerations, t)
+		}
+	}
+
+	merged.Volumes = append(merged.Volumes, template.Volumes...)
+	for _, v := range cluster.Volumes {
+		overridden := false
+		for _, tv := range template.Volumes {
+			if tv.Name == v.Name {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged.Volumes = append(merged.Volumes, v)
+		}
+	}
+
+	return merged
+}
+
+func mergeStringMap(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
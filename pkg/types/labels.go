@@ -23,5 +23,8 @@ const Zadig = "zadig"
 
 var ZadigLabelKeyGlobalOwner = fmt.Sprintf("%s/owner", ZadigDomain)
 
+// ZadigLabelKeyRolloutTrack marks the canary or green copy of a workload during a rollout
+var ZadigLabelKeyRolloutTrack = fmt.Sprintf("%s/rollout-track", ZadigDomain)
+
 const IstioLabelKeyInjection = "istio-injection"
 const IstioLabelValueInjection = "enabled"